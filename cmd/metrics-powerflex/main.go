@@ -18,75 +18,240 @@ package main
 
 import (
 	"context"
+	"errors"
+	"flag"
 	"fmt"
+	"io"
+	"maps"
 	"math"
+	"net/http"
 	"os"
-	"strconv"
+	"path/filepath"
+	"slices"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/dell/goscaleio"
+	"github.com/dell/karavi-metrics-powerflex/internal/domain"
 	"github.com/dell/karavi-metrics-powerflex/internal/entrypoint"
 	"github.com/dell/karavi-metrics-powerflex/internal/k8s"
 	"github.com/dell/karavi-metrics-powerflex/internal/service"
+	"github.com/dell/karavi-metrics-powerflex/internal/settings"
 	otlexporters "github.com/dell/karavi-metrics-powerflex/opentelemetry/exporters"
 	"github.com/fsnotify/fsnotify"
 	"github.com/sirupsen/logrus"
 	"github.com/spf13/viper"
 	"go.opentelemetry.io/otel"
+	"golang.org/x/time/rate"
+	"sigs.k8s.io/yaml"
 )
 
 const (
-	defaultTickInterval            = 5 * time.Second
 	defaultConfigFile              = "/etc/config/karavi-metrics-powerflex.yaml"
 	defaultStorageSystemConfigFile = "/vxflexos-config/config"
+	redactedValue                  = "********"
 )
 
 var (
 	logger          *logrus.Logger
 	goscaleioClient = goscaleio.NewClientWithArgs
+	printConfig     bool
+
+	// certificateMonitor exports the certificate expiry of the gateways of the storage systems
+	certificateMonitor = &service.CertificateMonitor{Meter: otel.Meter("powerflex/certificates")}
+
+	// inlineCADir is the private directory the inline CA bundles are written to, created on first use
+	inlineCADir   string
+	inlineCADirMu sync.Mutex
+
+	// powerflexClients are the clients of the last applied configuration, for the goroutines that run outside of a reload
+	powerflexClients   map[string]service.PowerFlexClient
+	powerflexClientsMu sync.RWMutex
 )
 
 func main() {
+	if len(os.Args) > 1 && os.Args[1] == validateCommand {
+		os.Exit(runValidate(os.Args[2:], os.Stdout, os.Stderr))
+	}
+	if err := parseFlags(os.Args[1:]); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			os.Exit(0)
+		}
+		os.Exit(2)
+	}
+	if printConfig {
+		loadConfig(nil)
+		os.Exit(printEffectiveConfig(os.Stdout, os.Stderr, defaultStorageSystemConfigFile))
+	}
 	config, exporter, powerflexSvc := configure()
 	if err := entrypoint.Run(context.Background(), config, exporter, powerflexSvc); err != nil {
 		logger.WithError(err).Fatal("running service")
 	}
 }
 
+// parseFlags applies the command line flags. --kubeconfig and --context let the service run
+// outside of the cluster it monitors, e.g. from a workstation or a central management cluster.
+// --print-config prints the effective configuration and exits.
+func parseFlags(args []string) error {
+	flags := flag.NewFlagSet("metrics-powerflex", flag.ContinueOnError)
+	flags.StringVar(&k8s.ClientConfig.Kubeconfig, "kubeconfig", "", "path to a kubeconfig file, defaults to $KUBECONFIG or the in-cluster configuration")
+	flags.StringVar(&k8s.ClientConfig.Context, "context", "", "kubeconfig context to use instead of the current context")
+	flags.BoolVar(&printConfig, "print-config", false, "print the effective configuration, with credentials redacted, and exit")
+	return flags.Parse(args)
+}
+
+// printEffectiveConfig writes the effective configuration as YAML for support cases. Defaults are filled in
+// and storage system passwords are redacted. Problems are written to stderr, and the exit code is 1 if there are any.
+func printEffectiveConfig(stdout io.Writer, stderr io.Writer, storageSystemConfigFile string) int {
+	s, settingsErr := settings.Load(viper.GetViper(), os.Getenv)
+	configReader := service.ConfigurationReader{}
+	storageSystems, storageSystemsErr := configReader.GetStorageSystemConfiguration(storageSystemConfigFile)
+	if storageSystemsErr != nil {
+		storageSystemsErr = fmt.Errorf("reading storage system configuration: %w", storageSystemsErr)
+	}
+
+	file, env := s.Values()
+	effective := struct {
+		Configuration  map[string]string            `json:"configuration"`
+		Environment    map[string]string            `json:"environment"`
+		StorageSystems []domain.ArrayConnectionData `json:"storageSystems"`
+	}{file, env, redactStorageSystems(storageSystems)}
+
+	out, err := yaml.Marshal(effective)
+	if err != nil {
+		fmt.Fprintf(stderr, "printing configuration: %v\n", err)
+		return 1
+	}
+	_, _ = stdout.Write(out)
+
+	if err := errors.Join(settingsErr, storageSystemsErr); err != nil {
+		fmt.Fprintf(stderr, "invalid configuration:\n%v\n", err)
+		return 1
+	}
+	return 0
+}
+
+// redactStorageSystems returns a copy of the storage systems without their passwords
+func redactStorageSystems(storageSystems []domain.ArrayConnectionData) []domain.ArrayConnectionData {
+	redacted := make([]domain.ArrayConnectionData, len(storageSystems))
+	for i, storageSystem := range storageSystems {
+		if storageSystem.Password != "" {
+			storageSystem.Password = redactedValue
+		}
+		redacted[i] = storageSystem
+	}
+	return redacted
+}
+
 func configure() (*entrypoint.Config, otlexporters.Otlexporter, *service.PowerFlexService) {
 	logger := setupLogger()
+	certificateMonitor.Logger = logger
 	configFileListener := setupConfigFileListener()
-	sdcFinder, storageClassFinder, leaderElectorGetter, volumeFinder, nodeFinder, exporter := initializeComponents(logger)
+	kubeAPI := &k8s.API{}
+	sdcFinder, storageClassFinder, leaderElectorGetter, volumeFinder, nodeFinder, exporter := initializeComponents(logger, kubeAPI)
 	config := setupConfig(sdcFinder, storageClassFinder, leaderElectorGetter, volumeFinder, nodeFinder, logger)
 	powerflexSvc := setupPowerFlexService(logger, volumeFinder)
-	onChangeUpdate(powerflexSvc, config, sdcFinder, exporter, storageClassFinder, volumeFinder, logger)
-	updatePowerFlexConnection(defaultStorageSystemConfigFile, config, sdcFinder, storageClassFinder, volumeFinder, logger)
-	setupConfigWatchers(configFileListener, powerflexSvc, config, sdcFinder, storageClassFinder, volumeFinder, exporter, logger)
+	s := onChangeUpdate(powerflexSvc, config, sdcFinder, exporter, storageClassFinder, volumeFinder, logger)
+	setupLeaderElection(leaderElectorGetter, config, powerflexSvc, s, logger)
+	setupCollectionMode(config, kubeAPI, sdcFinder, leaderElectorGetter, powerflexSvc, s, logger)
+	serveHealthProbes(s.HealthProbeAddress, kubeAPI, logger)
+	startKubernetesInformers(kubeAPI, logger)
+	storageSystems := setupStorageSystemSource(kubeAPI, s, logger)
+	updatePowerFlexConnection(storageSystems, config, sdcFinder, storageClassFinder, volumeFinder, s, logger)
+	powerflexSvc.MissingSDCs.SetStorageSystems(slices.Collect(maps.Keys(config.PowerFlexClient)))
+	setupConfigWatchers(configFileListener, storageSystems, powerflexSvc, config, sdcFinder, storageClassFinder, volumeFinder, exporter, logger)
 	return config, exporter, powerflexSvc
 }
 
-func initializeComponents(logger *logrus.Logger) (*k8s.SDCFinder, *k8s.StorageClassFinder, *k8s.LeaderElector, *k8s.VolumeFinder, *k8s.NodeFinder, *otlexporters.OtlCollectorExporter) {
+// initializeComponents creates the kubernetes finders. They share kubeAPI so that one set of informers backs all of them.
+func initializeComponents(logger *logrus.Logger, kubeAPI *k8s.API) (*k8s.SDCFinder, *k8s.StorageClassFinder, *k8s.LeaderElector, *k8s.VolumeFinder, *k8s.NodeFinder, *otlexporters.OtlCollectorExporter) {
+	// the persistent volumes and storage classes left out by the volume filter are counted together
+	filteredObjects := &k8s.FilteredObjects{
+		Meter:  otel.Meter("powerflex/volume_filter"),
+		Logger: logger,
+	}
 	sdcFinder := &k8s.SDCFinder{
-		API: &k8s.API{},
+		API: kubeAPI,
 	}
 	storageClassFinder := &k8s.StorageClassFinder{
-		API: &k8s.API{},
+		API:             kubeAPI,
+		FilteredObjects: filteredObjects,
 	}
 	leaderElectorGetter := &k8s.LeaderElector{
 		API: &k8s.LeaderElector{},
 	}
 	volumeFinder := &k8s.VolumeFinder{
-		API:    &k8s.API{},
-		Logger: logger,
+		API:             kubeAPI,
+		Metadata:        kubeAPI,
+		Pods:            kubeAPI,
+		Logger:          logger,
+		FilteredObjects: filteredObjects,
 	}
 	nodeFinder := &k8s.NodeFinder{
-		API: &k8s.API{},
+		API: kubeAPI,
 	}
 	exporter := &otlexporters.OtlCollectorExporter{}
 	return sdcFinder, storageClassFinder, leaderElectorGetter, volumeFinder, nodeFinder, exporter
 }
 
+// startKubernetesInformers starts the informers behind the kubernetes finders and blocks until their
+// caches are filled, so that metrics are not collected from a partial view of the cluster
+func startKubernetesInformers(kubeAPI *k8s.API, logger *logrus.Logger) {
+	if err := kubeAPI.Start(context.Background()); err != nil {
+		logger.WithError(err).Fatal("starting kubernetes informers")
+	}
+	// the caches fill in the background, the readiness probe fails until they are synced
+	go waitForKubernetesInformers(context.Background(), kubeAPI, logger)
+}
+
+// waitForKubernetesInformers returns true once the started informer caches synced, warning every CacheSyncTimeout until they are
+func waitForKubernetesInformers(ctx context.Context, kubeAPI *k8s.API, logger *logrus.Logger) bool {
+	for {
+		waitCtx, cancel := context.WithTimeout(ctx, k8s.CacheSyncTimeout)
+		synced := kubeAPI.WaitForCacheSync(waitCtx)
+		cancel()
+		if synced {
+			logger.Info("kubernetes informer caches synced")
+			return true
+		}
+		if ctx.Err() != nil {
+			return false
+		}
+		logger.Warn("kubernetes informer caches have not synced yet, still waiting")
+	}
+}
+
+// serveHealthProbes serves /healthz, which succeeds while the process runs, and /readyz, which fails until the
+// kubernetes informer caches synced
+func serveHealthProbes(address string, kubeAPI *k8s.API, logger *logrus.Logger) {
+	server := &http.Server{
+		Addr:              address,
+		Handler:           healthProbeHandler(kubeAPI.HasSynced),
+		ReadHeaderTimeout: 5 * time.Second,
+	}
+	go func() {
+		if err := server.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			logger.WithError(err).WithField("address", address).Error("serving health probes")
+		}
+	}()
+}
+
+func healthProbeHandler(ready func() bool) http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/healthz", func(w http.ResponseWriter, _ *http.Request) {
+		fmt.Fprintln(w, "ok")
+	})
+	mux.HandleFunc("/readyz", func(w http.ResponseWriter, _ *http.Request) {
+		if !ready() {
+			http.Error(w, "kubernetes informer caches have not synced", http.StatusServiceUnavailable)
+			return
+		}
+		fmt.Fprintln(w, "ok")
+	})
+	return mux
+}
+
 func setupLogger() *logrus.Logger {
 	logger := logrus.New()
 	loadConfig(logger)
@@ -109,14 +274,13 @@ func setupConfigFileListener() *viper.Viper {
 	return configFileListener
 }
 
-// getCollectorCertPath retrieves the certificate path for the OpenTelemetry collector.
-func getCollectorCertPath() string {
-	if tls := os.Getenv("TLS_ENABLED"); tls == "true" {
-		if certPath := strings.TrimSpace(os.Getenv("COLLECTOR_CERT_PATH")); certPath != "" {
-			return certPath
-		}
+// loadSettings reads the typed settings. If any value is invalid, it exits and reports every problem at once.
+func loadSettings(logger *logrus.Logger) *settings.Settings {
+	s, err := settings.Load(viper.GetViper(), os.Getenv)
+	if err != nil {
+		logger.WithError(err).Fatal("invalid configuration")
 	}
-	return otlexporters.DefaultCollectorCertPath
+	return s
 }
 
 // setupConfig creates the main configuration structure.
@@ -134,18 +298,116 @@ func setupConfig(
 		LeaderElector:      leaderElectorGetter,
 		VolumeFinder:       volumeFinder,
 		NodeFinder:         nodeFinder,
-		CollectorCertPath:  getCollectorCertPath(),
 		Logger:             logger,
 	}
 }
 
+// setupLeaderElection applies the leader election settings. The new leader warms the inventory cache
+// so its first polls don't all rediscover the arrays at once, and a replica that stops leading clears it.
+// With sharding, every replica collects the arrays it owns instead of one leader collecting all of them.
+func setupLeaderElection(leaderElector *k8s.LeaderElector, config *entrypoint.Config, powerflexSvc *service.PowerFlexService, s *settings.Settings, logger *logrus.Logger) {
+	config.MetricsEndpoint = s.MetricsEndpoint
+	config.MetricsNamespace = s.MetricsNamespace
+	leaderElector.Disabled = !s.LeaderElectionEnabled
+	leaderElector.Sharding = s.ShardingEnabled
+	leaderElector.LeaseDuration = s.LeaseDuration
+	leaderElector.RenewDeadline = s.RenewDeadline
+	leaderElector.RetryPeriod = s.RetryPeriod
+
+	sdcFinder, _ := config.SDCFinder.(*k8s.SDCFinder)
+	leaderElector.StorageSystemIDs = func() []string {
+		if sdcFinder == nil {
+			return nil
+		}
+		ids := make([]string, 0, len(sdcFinder.StorageSystemID))
+		for _, storageSystem := range sdcFinder.StorageSystemID {
+			ids = append(ids, storageSystem.ID)
+		}
+		return ids
+	}
+
+	leaderElector.Logger = logger
+	leaderElector.Meter = otel.Meter("powerflex/leader_election")
+	leaderElector.OnStartedLeading = func(ctx context.Context) {
+		warmInventoryCache(ctx, currentPowerFlexClients(), config, powerflexSvc, logger)
+	}
+	leaderElector.OnStoppedLeading = func() {
+		powerflexSvc.InventoryCache.Invalidate()
+	}
+}
+
+// setupCollectionMode applies POWERFLEX_COLLECTION_MODE. In the default "cluster" mode one replica collects
+// every SDC. In "node" mode the service runs as a DaemonSet: each pod collects only the SDC of the node it runs on,
+// named by NODE_NAME from the downward API, along with the volumes mapped to it. The pods don't need a leader,
+// and storage pool and topology metrics are left to a cluster mode deployment. Each pod only watches its own node,
+// CSINode and pods, so it must run before the informers are started.
+func setupCollectionMode(config *entrypoint.Config, kubeAPI *k8s.API, sdcFinder *k8s.SDCFinder, leaderElector *k8s.LeaderElector, powerflexSvc *service.PowerFlexService, s *settings.Settings, logger *logrus.Logger) {
+	if !s.NodeLocal() {
+		return
+	}
+
+	config.NodeName = s.NodeName
+	kubeAPI.NodeName = s.NodeName
+	sdcFinder.NodeName = s.NodeName
+	sdcFinder.SDCGUID = s.SDCGUID
+	leaderElector.Disabled = true
+	powerflexSvc.LocalSDC = &service.LocalSDC{}
+	if metricsWrapper, ok := powerflexSvc.MetricsWrapper.(*service.MetricsWrapper); ok {
+		metricsWrapper.NodeName = s.NodeName
+	}
+	logger.WithField("node_name", s.NodeName).Info("collecting metrics for the local SDC only")
+}
+
+// warmInventoryCache discovers the SDCs and storage pools of every available array this replica collects, if the inventory is cached
+func warmInventoryCache(ctx context.Context, clients map[string]service.PowerFlexClient, config *entrypoint.Config, powerflexSvc *service.PowerFlexService, logger *logrus.Logger) {
+	if !powerflexSvc.InventoryCache.Enabled() {
+		return
+	}
+	for storageSystemID, client := range clients {
+		if !service.ClientAvailable(client) || !service.OwnsStorageSystem(config.LeaderElector, storageSystemID) {
+			continue
+		}
+		entry := logger.WithField("storage_system_id", storageSystemID)
+		if config.SDCFinder != nil {
+			if _, err := powerflexSvc.GetSDCs(ctx, client, config.SDCFinder); err != nil {
+				entry.WithError(err).Warn("warming sdc inventory")
+			}
+		}
+		if config.StorageClassFinder != nil {
+			if _, err := powerflexSvc.GetStorageClasses(ctx, client, config.StorageClassFinder); err != nil {
+				entry.WithError(err).Warn("warming storage class inventory")
+			}
+		}
+	}
+	logger.Debug("warmed inventory cache")
+}
+
+func setPowerFlexClients(clients map[string]service.PowerFlexClient) {
+	powerflexClientsMu.Lock()
+	defer powerflexClientsMu.Unlock()
+	powerflexClients = clients
+}
+
+// currentPowerFlexClients returns a copy of the clients of the last applied configuration
+func currentPowerFlexClients() map[string]service.PowerFlexClient {
+	powerflexClientsMu.RLock()
+	defer powerflexClientsMu.RUnlock()
+	return maps.Clone(powerflexClients)
+}
+
 func setupPowerFlexService(logger *logrus.Logger, volumeFinder *k8s.VolumeFinder) *service.PowerFlexService {
 	return &service.PowerFlexService{
 		MetricsWrapper: &service.MetricsWrapper{
 			Meter: otel.Meter("powerflex/sdc"),
 		},
-		Logger:       logger,
-		VolumeFinder: volumeFinder,
+		Logger:         logger,
+		VolumeFinder:   volumeFinder,
+		InventoryCache: service.NewInventoryCache(service.DefaultInventoryRefreshInterval),
+		UnmappedSDCs: &service.UnmappedSDCs{
+			Meter:  otel.Meter("powerflex/sdc"),
+			Logger: logger,
+		},
+		MissingSDCs: &service.MissingSDCs{},
 	}
 }
 
@@ -157,23 +419,29 @@ func onChangeUpdate(
 	storageClassFinder *k8s.StorageClassFinder,
 	volumeFinder *k8s.VolumeFinder,
 	logger *logrus.Logger,
-) {
-	updateCollectorAddress(config, exporter, logger)
-	updateProvisionerNames(sdcFinder, storageClassFinder, volumeFinder, logger)
-	updateMetricsEnabled(config)
-	updateTickIntervals(config, logger)
-	updateService(powerflexSvc, logger)
+) *settings.Settings {
+	s := loadSettings(logger)
+	updateCollectorAddress(config, exporter, s)
+	updateProvisionerNames(sdcFinder, storageClassFinder, volumeFinder, s)
+	updateVolumeFilter(storageClassFinder, volumeFinder, s)
+	updateLabelAllowlist(powerflexSvc, storageClassFinder, volumeFinder, s, logger)
+	updateVolumeConsumers(volumeFinder, s)
+	updateMetricsEnabled(config, s)
+	updateTickIntervals(config, s, logger)
+	updateStorageSystems(config, s)
+	updateService(powerflexSvc, s, logger)
+	return s
 }
 
 func updateLoggingSettings(logger *logrus.Logger) {
-	logFormat := viper.GetString("LOG_FORMAT")
+	logFormat := viper.GetString(settings.LogFormatKey)
 	if strings.EqualFold(logFormat, "json") {
 		logger.SetFormatter(&logrus.JSONFormatter{})
 	} else {
 		logger.SetFormatter(&logrus.TextFormatter{})
 	}
 
-	logLevel := viper.GetString("LOG_LEVEL")
+	logLevel := viper.GetString(settings.LogLevelKey)
 	level, err := logrus.ParseLevel(logLevel)
 	if err != nil {
 		logger.WithError(err).Info("invalid log level, setting the log level as INFO")
@@ -182,30 +450,85 @@ func updateLoggingSettings(logger *logrus.Logger) {
 	logger.SetLevel(level)
 }
 
-// setupConfigWatchers sets up dynamic updates when config files change.
-func setupConfigWatchers(configFileListener *viper.Viper, powerflexSvc *service.PowerFlexService, config *entrypoint.Config, sdcFinder *k8s.SDCFinder, storageClassFinder *k8s.StorageClassFinder, volumeFinder *k8s.VolumeFinder, exporter *otlexporters.OtlCollectorExporter, logger *logrus.Logger) {
+// setupConfigWatchers sets up dynamic updates when config files or the storage system secret change.
+func setupConfigWatchers(configFileListener *viper.Viper, storageSystems storageSystemSource, powerflexSvc *service.PowerFlexService, config *entrypoint.Config, sdcFinder *k8s.SDCFinder, storageClassFinder *k8s.StorageClassFinder, volumeFinder *k8s.VolumeFinder, exporter *otlexporters.OtlCollectorExporter, logger *logrus.Logger) {
 	viper.WatchConfig()
 	viper.OnConfigChange(func(_ fsnotify.Event) {
 		updateLoggingSettings(logger)
 	})
 
+	reload := func() {
+		s := onChangeUpdate(powerflexSvc, config, sdcFinder, exporter, storageClassFinder, volumeFinder, logger)
+		updatePowerFlexConnection(storageSystems, config, sdcFinder, storageClassFinder, volumeFinder, s, logger)
+		// the clients were replaced, so nothing cached for the old ones will be used again
+		powerflexSvc.InventoryCache.Invalidate()
+		powerflexSvc.MissingSDCs.SetStorageSystems(slices.Collect(maps.Keys(config.PowerFlexClient)))
+	}
+
+	if storageSystems.secret != nil {
+		if err := storageSystems.secret.Watch(func([]byte) { reload() }); err != nil {
+			logger.WithError(err).Fatal("watching storage system secret")
+		}
+		return
+	}
 	configFileListener.WatchConfig()
 	configFileListener.OnConfigChange(func(_ fsnotify.Event) {
-		onChangeUpdate(powerflexSvc, config, sdcFinder, exporter, storageClassFinder, volumeFinder, logger)
-		updatePowerFlexConnection(defaultStorageSystemConfigFile, config, sdcFinder, storageClassFinder, volumeFinder, logger)
+		reload()
 	})
 }
 
+// storageSystemSource reads the storage systems from the mounted vxflexos-config file, or through the kubernetes API if secret is set
+type storageSystemSource struct {
+	file   string
+	secret *k8s.SecretWatcher
+}
+
+func (source storageSystemSource) read() ([]domain.ArrayConnectionData, error) {
+	configReader := service.ConfigurationReader{}
+	if source.secret == nil {
+		return configReader.GetStorageSystemConfiguration(source.file)
+	}
+	content, err := source.secret.Content()
+	if err != nil {
+		return nil, err
+	}
+	return configReader.ParseStorageSystemConfiguration(content)
+}
+
+// setupStorageSystemSource starts watching the storage system secret when the credentials are read through the kubernetes API
+func setupStorageSystemSource(kubeAPI *k8s.API, s *settings.Settings, logger *logrus.Logger) storageSystemSource {
+	source := storageSystemSource{file: defaultStorageSystemConfigFile}
+	if !s.CredentialsFromSecret() {
+		return source
+	}
+
+	source.secret = &k8s.SecretWatcher{
+		Client:    kubeAPI.Client,
+		Namespace: s.CredentialsSecretNamespace,
+		Name:      s.CredentialsSecretName,
+		Key:       s.CredentialsSecretKey,
+		Logger:    logger,
+	}
+	if err := source.secret.Start(context.Background()); err != nil {
+		logger.WithError(err).Fatal("watching storage system secret")
+	}
+	logger.WithFields(logrus.Fields{
+		"secret": s.CredentialsSecretNamespace + "/" + s.CredentialsSecretName,
+		"key":    s.CredentialsSecretKey,
+	}).Info("reading storage systems through the kubernetes API")
+	return source
+}
+
 func updatePowerFlexConnection(
-	storageSystemConfigFile string,
+	storageSystems storageSystemSource,
 	config *entrypoint.Config,
 	sdcFinder *k8s.SDCFinder,
 	storageClassFinder *k8s.StorageClassFinder,
 	volumeFinder *k8s.VolumeFinder,
+	s *settings.Settings,
 	logger *logrus.Logger,
 ) {
-	configReader := service.ConfigurationReader{}
-	storageSystemArray, err := configReader.GetStorageSystemConfiguration(storageSystemConfigFile)
+	storageSystemArray, err := storageSystems.read()
 	if err != nil {
 		logger.WithError(err).Fatal("getting storage system configuration")
 	}
@@ -214,8 +537,28 @@ func updatePowerFlexConnection(
 	sdcFinder.StorageSystemID = make([]k8s.StorageSystemID, len(storageSystemArray))
 	storageClassFinder.StorageSystemID = make([]k8s.StorageSystemID, len(storageSystemArray))
 
-	config.PowerFlexClient = make(map[string]service.PowerFlexClient)
-	config.PowerFlexConfig = make(map[string]goscaleio.ConfigConnect)
+	// a rateLimit in the storage system secret takes precedence over the one in the overrides
+	for i, storageSystem := range storageSystemArray {
+		if storageSystem.RateLimit == nil {
+			storageSystemArray[i].RateLimit = s.StorageSystem(storageSystem.SystemID).RateLimit
+		}
+	}
+
+	// arrays behind the same gateway share one limiter
+	gatewayLimiters := make(map[string]*rate.Limiter)
+	for endpoint, limit := range getGatewayRateLimits(storageSystemArray, s.RateLimit) {
+		logger.WithFields(logrus.Fields{
+			"gateway":             endpoint,
+			"requests_per_second": limit.RequestsPerSecond,
+			"burst":               limit.Burst,
+		}).Debug("setting gateway rate limit")
+		gatewayLimiters[endpoint] = service.NewRateLimiter(limit)
+	}
+
+	// the maps are filled before they replace the ones in use, which the collection goroutines may be reading
+	clients := make(map[string]service.PowerFlexClient, len(storageSystemArray))
+	clientConfigs := make(map[string]goscaleio.ConfigConnect, len(storageSystemArray))
+	gateways := make([]service.GatewayTLS, 0, len(storageSystemArray))
 	for i, storageSystem := range storageSystemArray {
 		powerFlexEndpoint := storageSystem.Endpoint
 		powerFlexGatewayUser := storageSystem.Username
@@ -230,9 +573,17 @@ func updatePowerFlexConnection(
 		storageClassFinder.StorageSystemID[i] = storageID
 		volumeFinder.StorageSystemID[i] = storageID
 
-		// backwards compatible with previous 'Insecure' flag
-		insecure := storageSystem.Insecure || storageSystem.SkipCertificateValidation
-		client, err := goscaleioClient(powerFlexEndpoint, "", math.MaxInt64, insecure, true, "")
+		tlsConfig, err := service.GatewayTLSConfig(storageSystem)
+		if err != nil {
+			logger.WithError(err).Fatalf("configuring TLS for powerflex %s", powerFlexSystemID)
+		}
+		gateways = append(gateways, service.GatewayTLS{StorageSystemID: powerFlexSystemID, Endpoint: powerFlexEndpoint, Config: tlsConfig})
+
+		insecure, caFilePath, err := gatewayClientTLS(storageSystem)
+		if err != nil {
+			logger.WithError(err).Fatalf("configuring the client TLS for powerflex %s", powerFlexSystemID)
+		}
+		client, err := goscaleioClient(powerFlexEndpoint, "", math.MaxInt64, insecure, true, caFilePath)
 		if err != nil {
 			logger.WithError(err).Fatal("creating powerflex client")
 		}
@@ -242,159 +593,222 @@ func updatePowerFlexConnection(
 			logger.WithError(err).Fatalf("authenticating to powerflex %s", powerFlexSystemID)
 		}
 
-		config.PowerFlexClient[powerFlexSystemID] = client
-		config.PowerFlexConfig[powerFlexSystemID] = goscaleio.ConfigConnect{Username: powerFlexGatewayUser, Password: powerFlexGatewayPassword}
+		clients[powerFlexSystemID] = &service.CircuitBreakerClient{
+			PowerFlexClient: &service.SessionClient{
+				PowerFlexClient: &service.RateLimitedClient{
+					PowerFlexClient: client,
+					Limiter:         gatewayLimiters[powerFlexEndpoint],
+					StorageSystemID: powerFlexSystemID,
+					Gateway:         powerFlexEndpoint,
+					Logger:          logger,
+					Meter:           otel.Meter("powerflex/rate_limiter"),
+				},
+				ConfigConnect:   &goscaleio.ConfigConnect{Username: powerFlexGatewayUser, Password: powerFlexGatewayPassword},
+				StorageSystemID: powerFlexSystemID,
+				Logger:          logger,
+				Meter:           otel.Meter("powerflex/session"),
+			},
+			StorageSystemID:  powerFlexSystemID,
+			FailureThreshold: s.CircuitBreakerFailureThreshold,
+			Logger:           logger,
+			Meter:            otel.Meter("powerflex/circuit_breaker"),
+		}
+		clientConfigs[powerFlexSystemID] = goscaleio.ConfigConnect{Username: powerFlexGatewayUser, Password: powerFlexGatewayPassword}
 		logger.WithField("storage_system_id", powerFlexSystemID).Info("set powerflex system ID")
 	}
+	config.PowerFlexClient = clients
+	config.PowerFlexConfig = clientConfigs
+	setPowerFlexClients(clients)
+	certificateMonitor.SetGateways(gateways)
 
 	// we need to add DriverNames explicitly here because if onConfigChange is called DriverNames would be empty
-	updateProvisionerNames(sdcFinder, storageClassFinder, volumeFinder, logger)
+	updateProvisionerNames(sdcFinder, storageClassFinder, volumeFinder, s)
+}
+
+// gatewayClientTLS returns the insecure flag and CA file for the goscaleio client of a storage system.
+// goscaleio only verifies against a CA file, so an inline CA bundle is written to a private directory.
+// goscaleio creates its own HTTP transport, so a certificate fingerprint can't be pinned on the connections
+// it makes and a storage system that configures one is refused.
+func gatewayClientTLS(storageSystem domain.ArrayConnectionData) (bool, string, error) {
+	if len(storageSystem.CertificateFingerprints) > 0 {
+		return false, "", fmt.Errorf("certificate fingerprints of %s are not supported, configure a CA certificate instead", storageSystem.SystemID)
+	}
+	// backwards compatible with previous 'Insecure' flag
+	insecure := storageSystem.Insecure || storageSystem.SkipCertificateValidation
+
+	caFilePath := storageSystem.CACertificateFile
+	if storageSystem.CACertificate != "" {
+		var err error
+		caFilePath, err = writeInlineCA(storageSystem.SystemID, storageSystem.CACertificate)
+		if err != nil {
+			return false, "", err
+		}
+	}
+	return insecure, caFilePath, nil
+}
+
+// writeInlineCA writes the inline CA bundle of a storage system to a file in inlineCADir, one file per storage
+// system that is overwritten when the configuration changes, and returns the path of the file
+func writeInlineCA(storageSystemID string, bundle string) (string, error) {
+	inlineCADirMu.Lock()
+	defer inlineCADirMu.Unlock()
+
+	if inlineCADir == "" {
+		dir, err := os.MkdirTemp("", "powerflex-ca-")
+		if err != nil {
+			return "", fmt.Errorf("creating CA certificate directory: %w", err)
+		}
+		inlineCADir = dir
+	}
+	caFilePath := filepath.Join(inlineCADir, filepath.Base(storageSystemID)+".pem")
+	if err := os.WriteFile(caFilePath, []byte(bundle), 0o600); err != nil {
+		return "", fmt.Errorf("writing CA certificate: %w", err)
+	}
+	return caFilePath, nil
+}
+
+// getGatewayRateLimits returns the rate limit for each gateway endpoint. Each array is limited by its rateLimit,
+// or by the default if it has none, and a gateway shared by several arrays gets the lowest requests per second
+// and, separately, the lowest burst of any of them.
+func getGatewayRateLimits(storageSystems []domain.ArrayConnectionData, defaultLimit domain.RateLimit) map[string]domain.RateLimit {
+	limits := make(map[string]domain.RateLimit)
+	for _, storageSystem := range storageSystems {
+		limit := defaultLimit
+		if storageSystem.RateLimit != nil {
+			limit = *storageSystem.RateLimit
+		}
+		if current, ok := limits[storageSystem.Endpoint]; ok {
+			limit = lowerRateLimit(current, limit)
+		}
+		limits[storageSystem.Endpoint] = limit
+	}
+	return limits
+}
+
+// lowerRateLimit returns the lower requests per second and the lower burst of two limits. As in NewRateLimiter,
+// a rate of zero is unlimited and a burst of zero is one second's worth of requests.
+func lowerRateLimit(a, b domain.RateLimit) domain.RateLimit {
+	if a.RequestsPerSecond <= 0 {
+		return b
+	}
+	if b.RequestsPerSecond <= 0 {
+		return a
+	}
+	burst := func(limit domain.RateLimit) int {
+		if limit.Burst <= 0 {
+			return int(math.Ceil(limit.RequestsPerSecond))
+		}
+		return limit.Burst
+	}
+	return domain.RateLimit{
+		RequestsPerSecond: math.Min(a.RequestsPerSecond, b.RequestsPerSecond),
+		Burst:             min(burst(a), burst(b)),
+	}
 }
 
 func updateCollectorAddress(
 	config *entrypoint.Config,
 	exporter *otlexporters.OtlCollectorExporter,
-	logger *logrus.Logger,
+	s *settings.Settings,
 ) {
-	collectorAddress := viper.GetString("COLLECTOR_ADDR")
-	if collectorAddress == "" {
-		logger.Fatal("COLLECTOR_ADDR is required")
-	}
-	config.CollectorAddress = collectorAddress
-	exporter.CollectorAddr = collectorAddress
+	config.CollectorAddress = s.CollectorAddress
+	config.CollectorCertPath = s.CollectorCertPath
+	exporter.CollectorAddr = s.CollectorAddress
 }
 
 func updateProvisionerNames(
 	sdcFinder *k8s.SDCFinder,
 	storageClassFinder *k8s.StorageClassFinder,
 	volumeFinder *k8s.VolumeFinder,
-	logger *logrus.Logger,
+	s *settings.Settings,
 ) {
-	provisionerNamesValue := viper.GetString("provisioner_names")
-	if provisionerNamesValue == "" {
-		logger.Fatal("PROVISIONER_NAMES is required")
-	}
-	provisionerNames := strings.Split(provisionerNamesValue, ",")
 	for i := range sdcFinder.StorageSystemID {
-		sdcFinder.StorageSystemID[i].DriverNames = provisionerNames
+		sdcFinder.StorageSystemID[i].DriverNames = s.ProvisionerNames
 	}
 	for i := range storageClassFinder.StorageSystemID {
-		storageClassFinder.StorageSystemID[i].DriverNames = provisionerNames
+		storageClassFinder.StorageSystemID[i].DriverNames = s.ProvisionerNames
 	}
 	for i := range volumeFinder.StorageSystemID {
-		volumeFinder.StorageSystemID[i].DriverNames = provisionerNames
+		volumeFinder.StorageSystemID[i].DriverNames = s.ProvisionerNames
 	}
 }
 
-func updateMetricsEnabled(config *entrypoint.Config) {
-	powerflexSdcMetricsEnabled := true
-	powerflexSdcMetricsEnabledValue := viper.GetString("POWERFLEX_SDC_METRICS_ENABLED")
-	if powerflexSdcMetricsEnabledValue == "false" {
-		powerflexSdcMetricsEnabled = false
-	}
-	if powerflexSdcMetricsEnabledValue != "true" && powerflexSdcMetricsEnabledValue != "false" {
-		logger.Fatal("POWERFLEX_SDC_METRICS_ENABLED value is invalid. valid values are true or false")
-	}
-
-	powerflexVolumeMetricsEnabled := true
-	powerflexVolumeMetricsEnabledValue := viper.GetString("POWERFLEX_VOLUME_METRICS_ENABLED")
-	if powerflexVolumeMetricsEnabledValue == "false" {
-		powerflexVolumeMetricsEnabled = false
-	}
-	if powerflexVolumeMetricsEnabledValue != "true" && powerflexVolumeMetricsEnabledValue != "false" {
-		logger.Fatal("POWERFLEX_VOLUME_METRICS_ENABLED value is invalid. valid values are true or false")
-	}
+// updateVolumeFilter applies the volume filter to the finders, so that volume I/O, topology and capacity
+// metrics are exported for the same persistent volumes and storage classes
+func updateVolumeFilter(storageClassFinder *k8s.StorageClassFinder, volumeFinder *k8s.VolumeFinder, s *settings.Settings) {
+	filter := s.VolumeFilter
+	storageClassFinder.Filter = &filter
+	volumeFinder.Filter = &filter
+}
 
-	storagePoolMetricsEnabled := true
-	storagePoolMetricsEnabledValue := viper.GetString("POWERFLEX_STORAGE_POOL_METRICS_ENABLED")
-	if storagePoolMetricsEnabledValue == "false" {
-		storagePoolMetricsEnabled = false
+// updateLabelAllowlist exports the allowed kubernetes labels as attributes of the volume, topology, capacity and SDC series.
+// The allowlist is only replaced when it changed, so that the values counted against MaxLabelValues are kept
+// until they age out.
+func updateLabelAllowlist(powerflexSvc *service.PowerFlexService, storageClassFinder *k8s.StorageClassFinder, volumeFinder *k8s.VolumeFinder, s *settings.Settings, logger *logrus.Logger) {
+	if current := volumeFinder.Labels; current != nil && current.Keys.Equal(s.LabelKeys) && current.MaxValues == s.MaxLabelValues {
+		return
 	}
-	if storagePoolMetricsEnabledValue != "true" && storagePoolMetricsEnabledValue != "false" {
-		logger.Fatal("POWERFLEX_STORAGE_POOL_METRICS_ENABLED value is invalid. valid values are true or false")
+	allowlist := &k8s.LabelAllowlist{
+		Keys:      s.LabelKeys,
+		MaxValues: s.MaxLabelValues,
+		Logger:    logger,
 	}
-	config.SDCMetricsEnabled = powerflexSdcMetricsEnabled
-	config.VolumeMetricsEnabled = powerflexVolumeMetricsEnabled
-	config.StoragePoolMetricsEnabled = storagePoolMetricsEnabled
-
-	powerflexTopologyMetricsEnabled := true
-	powerflexTopologyMetricsEnabledValue := viper.GetString("POWERFLEX_TOPOLOGY_METRICS_ENABLED")
-	if powerflexTopologyMetricsEnabledValue == "false" {
-		powerflexTopologyMetricsEnabled = false
-	}
-
-	config.TopologyMetricsEnabled = powerflexTopologyMetricsEnabled
+	storageClassFinder.Labels = allowlist
+	volumeFinder.Labels = allowlist
+	powerflexSvc.NodeLabels = allowlist
 }
 
-func updateTickIntervals(config *entrypoint.Config, logger *logrus.Logger) {
-	sdcTickInterval := defaultTickInterval
-	sdcIoPollFrequencySeconds := viper.GetString("POWERFLEX_SDC_IO_POLL_FREQUENCY")
-	if sdcIoPollFrequencySeconds != "" {
-		numSeconds, err := strconv.Atoi(sdcIoPollFrequencySeconds)
-		if err != nil {
-			logger.WithError(err).Fatal("POWERFLEX_SDC_IO_POLL_FREQUENCY was not set to a valid number")
-		}
-		if numSeconds <= 0 {
-			logger.Fatal("POWERFLEX_SDC_IO_POLL_FREQUENCY value was invalid (<= 0)")
-		}
-		sdcTickInterval = time.Duration(numSeconds) * time.Second
-	}
+// updateVolumeConsumers resolves the pods and workloads that mount each volume for the topology metrics.
+// Pods are only watched once it is enabled.
+func updateVolumeConsumers(volumeFinder *k8s.VolumeFinder, s *settings.Settings) {
+	volumeFinder.ResolveConsumers = s.VolumeConsumersEnabled
+}
 
-	volumeTickInterval := defaultTickInterval
-	volIoPollFrequencySeconds := viper.GetString("POWERFLEX_VOLUME_IO_POLL_FREQUENCY")
-	if volIoPollFrequencySeconds != "" {
-		numSeconds, err := strconv.Atoi(volIoPollFrequencySeconds)
-		if err != nil {
-			logger.WithError(err).Fatal("POWERFLEX_VOLUME_IO_POLL_FREQUENCY was not set to a valid number")
-		}
-		if numSeconds <= 0 {
-			logger.Fatal("POWERFLEX_VOLUME_IO_POLL_FREQUENCY value was invalid (<= 0)")
-		}
-		volumeTickInterval = time.Duration(numSeconds) * time.Second
-	}
+func updateMetricsEnabled(config *entrypoint.Config, s *settings.Settings) {
+	config.SDCMetricsEnabled = s.SDCMetricsEnabled
+	config.VolumeMetricsEnabled = s.VolumeMetricsEnabled
+	config.StoragePoolMetricsEnabled = s.StoragePoolMetricsEnabled
+	config.TopologyMetricsEnabled = s.TopologyMetricsEnabled
 
-	storagePoolTickInterval := defaultTickInterval
-	storagePoolPollFrequencySeconds := viper.GetString("POWERFLEX_STORAGE_POOL_POLL_FREQUENCY")
-	if storagePoolPollFrequencySeconds != "" {
-		numSeconds, err := strconv.Atoi(storagePoolPollFrequencySeconds)
-		if err != nil {
-			logger.WithError(err).Fatal("POWERFLEX_STORAGE_POOL_POLL_FREQUENCY was not set to a valid number")
-		}
-		if numSeconds <= 0 {
-			logger.Fatal("POWERFLEX_STORAGE_POOL_POLL_FREQUENCY value was invalid (<= 0)")
-		}
-		storagePoolTickInterval = time.Duration(numSeconds) * time.Second
+	if s.NodeLocal() {
+		// storage pools and topology aren't tied to a node, every pod would export the same series
+		config.StoragePoolMetricsEnabled = false
+		config.TopologyMetricsEnabled = false
 	}
+}
 
-	config.SDCTickInterval = sdcTickInterval
-	config.VolumeTickInterval = volumeTickInterval
-	config.StoragePoolTickInterval = storagePoolTickInterval
+func updateTickIntervals(config *entrypoint.Config, s *settings.Settings, logger *logrus.Logger) {
+	config.SDCTickInterval = s.SDCPollFrequency
+	config.VolumeTickInterval = s.VolumePollFrequency
+	config.StoragePoolTickInterval = s.StoragePoolPollFrequency
+	config.TopologyMetricsTickInterval = s.TopologyMetricsPollFrequency
+	logger.WithField("cluster_performance_tick_interval", fmt.Sprintf("%v", s.TopologyMetricsPollFrequency)).Debug("setting cluster performance tick interval")
+}
 
-	topologyMetricsTickInterval := defaultTickInterval
-	topologyMetricsPollFrequencySeconds := viper.GetString("POWERFLEX_TOPOLOGY_METRICS_POLL_FREQUENCY")
-	if topologyMetricsPollFrequencySeconds != "" {
-		numSeconds, err := strconv.Atoi(topologyMetricsPollFrequencySeconds)
-		if err != nil {
-			logger.WithError(err).Fatal("POWERFLEX_TOPOLOGY_METRICS_POLL_FREQUENCY was not set to a valid number")
+// updateStorageSystems applies the settings of the storage systems that have overrides.
+// Like the shared setting, storage pool metrics stay disabled in node collection mode.
+func updateStorageSystems(config *entrypoint.Config, s *settings.Settings) {
+	storageSystems := make(map[string]entrypoint.StorageSystemConfig, len(s.StorageSystems))
+	for storageSystemID, storageSystem := range s.StorageSystems {
+		storageSystems[storageSystemID] = entrypoint.StorageSystemConfig{
+			SDCTickInterval:           storageSystem.SDCPollFrequency,
+			VolumeTickInterval:        storageSystem.VolumePollFrequency,
+			StoragePoolTickInterval:   storageSystem.StoragePoolPollFrequency,
+			SDCMetricsEnabled:         storageSystem.SDCMetricsEnabled,
+			VolumeMetricsEnabled:      storageSystem.VolumeMetricsEnabled,
+			StoragePoolMetricsEnabled: storageSystem.StoragePoolMetricsEnabled && !s.NodeLocal(),
+			MaxPowerFlexConnections:   storageSystem.MaxConcurrentQueries,
 		}
-
-		topologyMetricsTickInterval = time.Duration(numSeconds) * time.Second
 	}
-	config.TopologyMetricsTickInterval = topologyMetricsTickInterval
-	logger.WithField("cluster_performance_tick_interval", fmt.Sprintf("%v", topologyMetricsTickInterval)).Debug("setting cluster performance tick interval")
+	config.StorageSystems = storageSystems
 }
 
-func updateService(powerflexSvc *service.PowerFlexService, logger *logrus.Logger) {
-	maxPowerFlexConcurrentRequests := service.DefaultMaxPowerFlexConnections
-	maxPowerFlexConcurrentRequestsVar := viper.GetString("POWERFLEX_MAX_CONCURRENT_QUERIES")
-	if maxPowerFlexConcurrentRequestsVar != "" {
-		maxPowerFlexConcurrentRequests, err := strconv.Atoi(maxPowerFlexConcurrentRequestsVar)
-		if err != nil {
-			logger.WithError(err).Fatal("POWERFLEX_MAX_CONCURRENT_QUERIES was not set to a valid number")
-		}
-		if maxPowerFlexConcurrentRequests <= 0 {
-			logger.WithError(err).Fatal("POWERFLEX_MAX_CONCURRENT_QUERIES value was invalid (<= 0)")
-		}
-	}
-	powerflexSvc.MaxPowerFlexConnections = maxPowerFlexConcurrentRequests
+func updateService(powerflexSvc *service.PowerFlexService, s *settings.Settings, logger *logrus.Logger) {
+	powerflexSvc.MaxPowerFlexConnections = s.MaxConcurrentQueries
+	powerflexSvc.MetricsBatchSize = s.MetricsBatchSize
+	powerflexSvc.MetricCatalog = s.MetricCatalog
+	powerflexSvc.VolumeAggregation = s.VolumeAggregation
+	powerflexSvc.NodeConditions = s.NodeConditionsEnabled
+	powerflexSvc.InventoryCache.SetRefreshInterval(s.InventoryRefreshInterval)
+	logger.WithField("inventory_refresh_interval", s.InventoryRefreshInterval.String()).Debug("setting inventory refresh interval")
 }
@@ -17,22 +17,36 @@
 package main
 
 import (
+	"bytes"
+	"context"
+	"errors"
 	"fmt"
 	"math"
 	"net/http"
 	"net/http/httptest"
 	"os"
+	"path/filepath"
+	"strings"
 	"testing"
 	"time"
 
 	"github.com/dell/goscaleio"
+	"github.com/dell/karavi-metrics-powerflex/internal/domain"
 	"github.com/dell/karavi-metrics-powerflex/internal/entrypoint"
 	"github.com/dell/karavi-metrics-powerflex/internal/k8s"
 	"github.com/dell/karavi-metrics-powerflex/internal/service"
+	"github.com/dell/karavi-metrics-powerflex/internal/settings"
 	otlexporters "github.com/dell/karavi-metrics-powerflex/opentelemetry/exporters"
 	"github.com/sirupsen/logrus"
+	logrustest "github.com/sirupsen/logrus/hooks/test"
 	"github.com/spf13/viper"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/fake"
+	k8stesting "k8s.io/client-go/testing"
 )
 
 func TestInitializeComponents(t *testing.T) {
@@ -57,7 +71,7 @@ func TestInitializeComponents(t *testing.T) {
 			viper.Reset()
 			viper.Set("provisioner_names", tt.provisioners)
 			logger := logrus.New()
-			sdcFinder, storageClassFinder, _, volumeFinder, _, _ := initializeComponents(logger)
+			sdcFinder, storageClassFinder, _, volumeFinder, _, _ := initializeComponents(logger, &k8s.API{})
 			// assert.NotPanics(t, func() { updateProvisionerNames(sdcFinder, storageClassFinder, volumeFinder, logger) })
 			for _, StorageSystemID := range sdcFinder.StorageSystemID {
 				assert.Equal(t, tt.expected, StorageSystemID.DriverNames)
@@ -139,32 +153,32 @@ func TestSetupConfigFileListener(t *testing.T) {
 	}
 }
 
-func TestGetCollectorCertPath(t *testing.T) {
-	t.Run("Valid Cert Path", func(t *testing.T) {
-		_ = os.Setenv("TLS_ENABLED", "true")
-		_ = os.Setenv("COLLECTOR_CERT_PATH", "/path/to/cert")
-		path := getCollectorCertPath()
-		assert.Equal(t, "/path/to/cert", path)
-	})
+// setRequiredConfig sets the configuration keys that have no default
+func setRequiredConfig() {
+	viper.Set("COLLECTOR_ADDR", "localhost:8080")
+	viper.Set("provisioner_names", "csi-vxflexos.dellemc.com")
+}
 
-	t.Run("TLS Enabled But No Cert Path", func(t *testing.T) {
-		_ = os.Setenv("TLS_ENABLED", "true")
-		_ = os.Setenv("COLLECTOR_CERT_PATH", "") // Explicitly setting it to empty
-		path := getCollectorCertPath()
-		assert.Equal(t, otlexporters.DefaultCollectorCertPath, path)
-	})
+func TestLoadSettings(t *testing.T) {
+	t.Run("Valid Settings", func(t *testing.T) {
+		viper.Reset()
+		setRequiredConfig()
+		logger := logrus.New()
+		logger.ExitFunc = func(int) { panic("fatal") }
 
-	t.Run("TLS Disabled", func(t *testing.T) {
-		_ = os.Setenv("TLS_ENABLED", "false")
-		path := getCollectorCertPath()
-		assert.Equal(t, otlexporters.DefaultCollectorCertPath, path)
+		s := loadSettings(logger)
+		assert.Equal(t, "localhost:8080", s.CollectorAddress)
+		assert.Equal(t, otlexporters.DefaultCollectorCertPath, s.CollectorCertPath)
 	})
 
-	t.Run("TLS Not Set", func(t *testing.T) {
-		_ = os.Unsetenv("TLS_ENABLED")
-		_ = os.Unsetenv("COLLECTOR_CERT_PATH")
-		path := getCollectorCertPath()
-		assert.Equal(t, otlexporters.DefaultCollectorCertPath, path)
+	t.Run("Invalid Settings", func(t *testing.T) {
+		viper.Reset()
+		setRequiredConfig()
+		viper.Set("POWERFLEX_TOPOLOGY_METRICS_ENABLED", "test")
+		logger := logrus.New()
+		logger.ExitFunc = func(int) { panic("fatal") }
+
+		assert.Panics(t, func() { loadSettings(logger) })
 	})
 }
 
@@ -197,6 +211,8 @@ func TestSetupPowerFlexService(t *testing.T) {
 	assert.NotNil(t, config, "Expected valid config")
 	assert.NotNil(t, exporter, "Expected valid exporter")
 	assert.NotNil(t, powerflexSvc, "Expected valid powerflex service")
+	assert.NotNil(t, powerflexSvc.UnmappedSDCs, "Expected unmapped SDCs to be counted")
+	assert.NotNil(t, powerflexSvc.MissingSDCs, "Expected missing SDCs to be reported")
 }
 
 func TestOnChangeUpdate(t *testing.T) {
@@ -289,6 +305,7 @@ func TestUpdateCollectorAddress(t *testing.T) {
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			viper.Reset()
+			setRequiredConfig()
 			viper.Set("COLLECTOR_ADDR", tt.addr)
 
 			logger := logrus.New()
@@ -297,11 +314,13 @@ func TestUpdateCollectorAddress(t *testing.T) {
 			exporter := &otlexporters.OtlCollectorExporter{}
 
 			if tt.expectPanic {
-				assert.Panics(t, func() { updateCollectorAddress(config, exporter, logger) })
+				assert.Panics(t, func() { loadSettings(logger) })
 			} else {
-				assert.NotPanics(t, func() { updateCollectorAddress(config, exporter, logger) })
+				s := loadSettings(logger)
+				assert.NotPanics(t, func() { updateCollectorAddress(config, exporter, s) })
 				assert.Equal(t, tt.addr, config.CollectorAddress)
 				assert.Equal(t, tt.addr, exporter.CollectorAddr)
+				assert.Equal(t, otlexporters.DefaultCollectorCertPath, config.CollectorCertPath)
 			}
 		})
 	}
@@ -380,6 +399,26 @@ func TestUpdateMetricsEnabled(t *testing.T) {
 			expectedStoragePoolMetricsEnabled:       true,
 			expectPanic:                             true,
 		},
+		{
+			name:                                    "topologyMetricsEnabled error",
+			sdcMetricsEnabled:                       "true",
+			volumeMetricsEnabled:                    "true",
+			storagePoolMetricsEnabled:               "true",
+			powerflexTopologyMetricsEnabled:         "test",
+			expectedPowerflexTopologyMetricsEnabled: true,
+			expectedSdcMetricsEnabled:               true,
+			expectedVolumeMetricsEnabled:            true,
+			expectedStoragePoolMetricsEnabled:       true,
+			expectPanic:                             true,
+		},
+		{
+			name:                                    "Defaults when empty",
+			expectedSdcMetricsEnabled:               true,
+			expectedVolumeMetricsEnabled:            true,
+			expectedStoragePoolMetricsEnabled:       true,
+			expectedPowerflexTopologyMetricsEnabled: true,
+			expectPanic:                             false,
+		},
 		{
 			name:                                    "Topology metrics disabled",
 			sdcMetricsEnabled:                       "true",
@@ -396,19 +435,23 @@ func TestUpdateMetricsEnabled(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
+			viper.Reset()
+			setRequiredConfig()
 			viper.Set("POWERFLEX_SDC_METRICS_ENABLED", tt.sdcMetricsEnabled)
 			viper.Set("POWERFLEX_VOLUME_METRICS_ENABLED", tt.volumeMetricsEnabled)
 			viper.Set("POWERFLEX_STORAGE_POOL_METRICS_ENABLED", tt.storagePoolMetricsEnabled)
 			viper.Set("POWERFLEX_TOPOLOGY_METRICS_ENABLED", tt.powerflexTopologyMetricsEnabled)
 			logger := logrus.New()
+			logger.ExitFunc = func(int) { panic("fatal") }
 			config := &entrypoint.Config{Logger: logger}
 			if tt.expectPanic {
-				assert.Panics(t, func() { updateMetricsEnabled(config) })
+				assert.Panics(t, func() { loadSettings(logger) })
 			} else {
-				assert.NotPanics(t, func() { updateMetricsEnabled(config) })
+				s := loadSettings(logger)
+				assert.NotPanics(t, func() { updateMetricsEnabled(config, s) })
 				assert.Equal(t, tt.expectedSdcMetricsEnabled, config.SDCMetricsEnabled, "SDC metrics enabled should be set correctly")
 				assert.Equal(t, tt.expectedVolumeMetricsEnabled, config.VolumeMetricsEnabled, "Volume metrics enabled should be set correctly")
-				assert.Equal(t, tt.expectedStoragePoolMetricsEnabled, config.SDCMetricsEnabled, "Storage metrics enabled should be set correctly")
+				assert.Equal(t, tt.expectedStoragePoolMetricsEnabled, config.StoragePoolMetricsEnabled, "Storage metrics enabled should be set correctly")
 				assert.Equal(t, tt.expectedPowerflexTopologyMetricsEnabled, config.TopologyMetricsEnabled, "Topology metrics enabled should be set correctly")
 			}
 		})
@@ -445,6 +488,7 @@ func TestUpdateProvisionerNames(t *testing.T) {
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			viper.Reset()
+			setRequiredConfig()
 			viper.Set("provisioner_names", tt.provisioners)
 
 			sdcFinder := &k8s.SDCFinder{
@@ -472,9 +516,10 @@ func TestUpdateProvisionerNames(t *testing.T) {
 			logger.ExitFunc = func(int) { panic("fatal") }
 
 			if tt.expectPanic {
-				assert.Panics(t, func() { updateProvisionerNames(sdcFinder, storageClassFinder, volumeFinder, logger) })
+				assert.Panics(t, func() { loadSettings(logger) })
 			} else {
-				assert.NotPanics(t, func() { updateProvisionerNames(sdcFinder, storageClassFinder, volumeFinder, logger) })
+				s := loadSettings(logger)
+				assert.NotPanics(t, func() { updateProvisionerNames(sdcFinder, storageClassFinder, volumeFinder, s) })
 				for _, StorageSystemID := range sdcFinder.StorageSystemID {
 					assert.Equal(t, tt.expected, StorageSystemID.DriverNames)
 				}
@@ -489,6 +534,66 @@ func TestUpdateProvisionerNames(t *testing.T) {
 	}
 }
 
+func TestUpdateVolumeFilter(t *testing.T) {
+	viper.Reset()
+	setRequiredConfig()
+	viper.Set(settings.VolumeIncludeNamespacesKey, "team-a")
+	viper.Set(settings.VolumeExcludeStorageClassesKey, "scratch")
+
+	storageClassFinder := &k8s.StorageClassFinder{}
+	volumeFinder := &k8s.VolumeFinder{}
+	updateVolumeFilter(storageClassFinder, volumeFinder, loadSettings(logrus.New()))
+	assert.Equal(t, []string{"team-a"}, volumeFinder.Filter.IncludeNamespaces)
+	assert.Equal(t, []string{"scratch"}, storageClassFinder.Filter.ExcludeStorageClasses)
+	assert.True(t, volumeFinder.Filtering())
+
+	// removing the keys stops filtering
+	viper.Reset()
+	setRequiredConfig()
+	updateVolumeFilter(storageClassFinder, volumeFinder, loadSettings(logrus.New()))
+	assert.False(t, volumeFinder.Filtering())
+	assert.True(t, storageClassFinder.Filter.IncludesStorageClass("scratch"))
+}
+
+func TestUpdateLabelAllowlist(t *testing.T) {
+	viper.Reset()
+	setRequiredConfig()
+	viper.Set(settings.PersistentVolumeClaimLabelsKey, "team")
+	viper.Set(settings.StorageClassLabelsKey, "tier")
+
+	storageClassFinder := &k8s.StorageClassFinder{}
+	volumeFinder := &k8s.VolumeFinder{}
+	svc := &service.PowerFlexService{}
+	updateLabelAllowlist(svc, storageClassFinder, volumeFinder, loadSettings(logrus.New()), logrus.New())
+	allowlist := volumeFinder.Labels
+	assert.Equal(t, []string{"team"}, allowlist.Keys.PersistentVolumeClaim)
+	assert.Equal(t, k8s.DefaultMaxLabelValues, allowlist.MaxValues)
+	assert.Same(t, allowlist, storageClassFinder.Labels)
+	assert.Same(t, allowlist, svc.NodeLabels)
+
+	// an unrelated change keeps the allowlist and the values it counted
+	viper.Set(settings.LogLevelKey, "debug")
+	updateLabelAllowlist(svc, storageClassFinder, volumeFinder, loadSettings(logrus.New()), logrus.New())
+	assert.Same(t, allowlist, volumeFinder.Labels)
+
+	viper.Set(settings.MaxLabelValuesKey, "10")
+	updateLabelAllowlist(svc, storageClassFinder, volumeFinder, loadSettings(logrus.New()), logrus.New())
+	assert.NotSame(t, allowlist, volumeFinder.Labels)
+	assert.Equal(t, 10, volumeFinder.Labels.MaxValues)
+}
+
+func TestUpdateVolumeConsumers(t *testing.T) {
+	viper.Reset()
+	setRequiredConfig()
+	volumeFinder := &k8s.VolumeFinder{}
+	updateVolumeConsumers(volumeFinder, loadSettings(logrus.New()))
+	assert.False(t, volumeFinder.ResolveConsumers)
+
+	viper.Set(settings.VolumeConsumersEnabledKey, "true")
+	updateVolumeConsumers(volumeFinder, loadSettings(logrus.New()))
+	assert.True(t, volumeFinder.ResolveConsumers)
+}
+
 func TestUpdateTickIntervals(t *testing.T) {
 	tests := []struct {
 		name                string
@@ -518,9 +623,9 @@ func TestUpdateTickIntervals(t *testing.T) {
 			volumeIOFreq:        "",
 			storagePoolFreq:     "",
 			topologyMetricFreq:  "",
-			expectedSdcIO:       defaultTickInterval,
-			expectedVolumeIO:    defaultTickInterval,
-			expectedStoragePool: defaultTickInterval,
+			expectedSdcIO:       settings.DefaultPollFrequency,
+			expectedVolumeIO:    settings.DefaultPollFrequency,
+			expectedStoragePool: settings.DefaultPollFrequency,
 			expectPanic:         false,
 		},
 		{
@@ -529,9 +634,9 @@ func TestUpdateTickIntervals(t *testing.T) {
 			volumeIOFreq:        "25",
 			storagePoolFreq:     "15",
 			topologyMetricFreq:  "invalid",
-			expectedSdcIO:       defaultTickInterval,
-			expectedVolumeIO:    defaultTickInterval,
-			expectedStoragePool: defaultTickInterval,
+			expectedSdcIO:       settings.DefaultPollFrequency,
+			expectedVolumeIO:    settings.DefaultPollFrequency,
+			expectedStoragePool: settings.DefaultPollFrequency,
 			expectPanic:         true,
 		},
 		{
@@ -540,9 +645,9 @@ func TestUpdateTickIntervals(t *testing.T) {
 			volumeIOFreq:        "invalid",
 			storagePoolFreq:     "15",
 			topologyMetricFreq:  "invalid",
-			expectedSdcIO:       defaultTickInterval,
-			expectedVolumeIO:    defaultTickInterval,
-			expectedStoragePool: defaultTickInterval,
+			expectedSdcIO:       settings.DefaultPollFrequency,
+			expectedVolumeIO:    settings.DefaultPollFrequency,
+			expectedStoragePool: settings.DefaultPollFrequency,
 			expectPanic:         true,
 		},
 		{
@@ -551,9 +656,9 @@ func TestUpdateTickIntervals(t *testing.T) {
 			volumeIOFreq:        "10",
 			storagePoolFreq:     "invalid",
 			topologyMetricFreq:  "invalid",
-			expectedSdcIO:       defaultTickInterval,
-			expectedVolumeIO:    defaultTickInterval,
-			expectedStoragePool: defaultTickInterval,
+			expectedSdcIO:       settings.DefaultPollFrequency,
+			expectedVolumeIO:    settings.DefaultPollFrequency,
+			expectedStoragePool: settings.DefaultPollFrequency,
 			expectPanic:         true,
 		},
 		{
@@ -562,9 +667,9 @@ func TestUpdateTickIntervals(t *testing.T) {
 			volumeIOFreq:        "25",
 			storagePoolFreq:     "15",
 			topologyMetricFreq:  "invalid",
-			expectedSdcIO:       defaultTickInterval,
-			expectedVolumeIO:    defaultTickInterval,
-			expectedStoragePool: defaultTickInterval,
+			expectedSdcIO:       settings.DefaultPollFrequency,
+			expectedVolumeIO:    settings.DefaultPollFrequency,
+			expectedStoragePool: settings.DefaultPollFrequency,
 			expectPanic:         true,
 		},
 		{
@@ -572,9 +677,9 @@ func TestUpdateTickIntervals(t *testing.T) {
 			sdcIOFreq:           "30",
 			volumeIOFreq:        "-1",
 			storagePoolFreq:     "15",
-			expectedSdcIO:       defaultTickInterval,
-			expectedVolumeIO:    defaultTickInterval,
-			expectedStoragePool: defaultTickInterval,
+			expectedSdcIO:       settings.DefaultPollFrequency,
+			expectedVolumeIO:    settings.DefaultPollFrequency,
+			expectedStoragePool: settings.DefaultPollFrequency,
 			expectPanic:         true,
 		},
 		{
@@ -582,9 +687,9 @@ func TestUpdateTickIntervals(t *testing.T) {
 			sdcIOFreq:           "30",
 			volumeIOFreq:        "25",
 			storagePoolFreq:     "-1",
-			expectedSdcIO:       defaultTickInterval,
-			expectedVolumeIO:    defaultTickInterval,
-			expectedStoragePool: defaultTickInterval,
+			expectedSdcIO:       settings.DefaultPollFrequency,
+			expectedVolumeIO:    settings.DefaultPollFrequency,
+			expectedStoragePool: settings.DefaultPollFrequency,
 			expectPanic:         true,
 		},
 	}
@@ -592,6 +697,7 @@ func TestUpdateTickIntervals(t *testing.T) {
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			viper.Reset()
+			setRequiredConfig()
 			viper.Set("POWERFLEX_SDC_IO_POLL_FREQUENCY", tt.sdcIOFreq)
 			viper.Set("POWERFLEX_VOLUME_IO_POLL_FREQUENCY", tt.volumeIOFreq)
 			viper.Set("POWERFLEX_STORAGE_POOL_POLL_FREQUENCY", tt.storagePoolFreq)
@@ -602,9 +708,10 @@ func TestUpdateTickIntervals(t *testing.T) {
 			logger.ExitFunc = func(int) { panic("fatal") }
 
 			if tt.expectPanic {
-				assert.Panics(t, func() { updateTickIntervals(config, logger) })
+				assert.Panics(t, func() { loadSettings(logger) })
 			} else {
-				assert.NotPanics(t, func() { updateTickIntervals(config, logger) })
+				s := loadSettings(logger)
+				assert.NotPanics(t, func() { updateTickIntervals(config, s, logger) })
 				assert.Equal(t, tt.expectedSdcIO, config.SDCTickInterval)
 				assert.Equal(t, tt.expectedVolumeIO, config.VolumeTickInterval)
 				assert.Equal(t, tt.expectedStoragePool, config.StoragePoolTickInterval)
@@ -643,21 +750,65 @@ func TestUpdateService(t *testing.T) {
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			viper.Reset()
+			setRequiredConfig()
 			viper.Set("POWERFLEX_MAX_CONCURRENT_QUERIES", tt.maxConcurrent)
 
-			svc := &service.PowerFlexService{}
+			svc := &service.PowerFlexService{InventoryCache: service.NewInventoryCache(service.DefaultInventoryRefreshInterval)}
 			logger := logrus.New()
 			logger.ExitFunc = func(int) { panic("fatal") }
 			if tt.expectPanic {
-				assert.Panics(t, func() { updateService(svc, logger) })
+				assert.Panics(t, func() { loadSettings(logger) })
 			} else {
-				assert.NotPanics(t, func() { updateService(svc, logger) })
+				s := loadSettings(logger)
+				assert.NotPanics(t, func() { updateService(svc, s, logger) })
 				assert.Equal(t, tt.expected, svc.MaxPowerFlexConnections)
 			}
 		})
 	}
 }
 
+func TestUpdateStorageSystems(t *testing.T) {
+	tests := []struct {
+		name                      string
+		env                       map[string]string
+		storagePoolMetricsEnabled bool
+	}{
+		{
+			name:                      "Cluster Mode",
+			storagePoolMetricsEnabled: true,
+		},
+		{
+			name:                      "Node Mode Disables Storage Pool Metrics",
+			env:                       map[string]string{"POWERFLEX_COLLECTION_MODE": "node", "NODE_NAME": "worker-1"},
+			storagePoolMetricsEnabled: false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for key, value := range tt.env {
+				t.Setenv(key, value)
+			}
+			viper.Reset()
+			setRequiredConfig()
+			viper.Set("storage_system_overrides.lab.POWERFLEX_VOLUME_IO_POLL_FREQUENCY", "60")
+			viper.Set("storage_system_overrides.lab.POWERFLEX_MAX_CONCURRENT_QUERIES", "2")
+
+			logger := logrus.New()
+			logger.ExitFunc = func(int) { panic("fatal") }
+			config := &entrypoint.Config{}
+			updateStorageSystems(config, loadSettings(logger))
+
+			lab := config.StorageSystem("LAB")
+			assert.Equal(t, time.Minute, lab.VolumeTickInterval)
+			assert.Equal(t, settings.DefaultPollFrequency, lab.SDCTickInterval)
+			assert.Equal(t, 2, lab.MaxPowerFlexConnections)
+			assert.True(t, lab.VolumeMetricsEnabled)
+			assert.Equal(t, tt.storagePoolMetricsEnabled, lab.StoragePoolMetricsEnabled)
+		})
+	}
+}
+
 func Test_updateLoggingSettings(t *testing.T) {
 	tests := []struct {
 		name          string
@@ -723,7 +874,7 @@ func TestSetupConfigWatchers(t *testing.T) {
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			assert.NotPanics(t, func() {
-				setupConfigWatchers(configFileListener, powerflexSvc, config, sdcFinder, storageClassFinder, volumeFinder, exporter, logger)
+				setupConfigWatchers(configFileListener, storageSystemSource{file: defaultStorageSystemConfigFile}, powerflexSvc, config, sdcFinder, storageClassFinder, volumeFinder, exporter, logger)
 			}, "Expected setupConfigWatchers to not panic")
 		})
 	}
@@ -801,11 +952,12 @@ func TestUpdatePowerFlexConnection(t *testing.T) {
 			if tt.expectPanic {
 				assert.Panics(t, func() {
 					updatePowerFlexConnection(
-						tt.configContentFile,
+						storageSystemSource{file: tt.configContentFile},
 						config,
 						sdcFinder,
 						storageClassFinder,
 						volumeFinder,
+						settings.Defaults(),
 						logger,
 					)
 				})
@@ -859,11 +1011,12 @@ func TestUpdatePowerFlexConnectionSuccess(t *testing.T) {
 
 	assert.NotPanics(t, func() {
 		updatePowerFlexConnection(
-			tmpFile.Name(),
+			storageSystemSource{file: tmpFile.Name()},
 			config,
 			sdcFinder,
 			storageClassFinder,
 			volumeFinder,
+			settings.Defaults(),
 			lgr,
 		)
 	})
@@ -872,6 +1025,137 @@ func TestUpdatePowerFlexConnectionSuccess(t *testing.T) {
 	assert.Contains(t, config.PowerFlexConfig, "test-system")
 	assert.Equal(t, 1, len(sdcFinder.StorageSystemID))
 	assert.Equal(t, "test-system", sdcFinder.StorageSystemID[0].ID)
+
+	// the leader callbacks get a copy, which a later reload can't change under them
+	clients := currentPowerFlexClients()
+	assert.Equal(t, config.PowerFlexClient, clients)
+	delete(config.PowerFlexClient, "test-system")
+	assert.Contains(t, clients, "test-system")
+}
+
+func TestUpdatePowerFlexConnectionFromSecret(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/api/login":
+			_, _ = fmt.Fprintf(w, `"fake-token"`)
+		case "/api/version":
+			_, _ = fmt.Fprintf(w, `"4.0"`)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	// the secret of the CSI driver holds JSON or YAML, like the mounted file
+	configContent := fmt.Sprintf(`[{"username": "admin", "password": "password", "systemID": "secret-system", "endpoint": "%s", "insecure": true}]`, server.URL)
+	client := fake.NewClientset(&corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: "vxflexos-config", Namespace: "vxflexos"},
+		Data:       map[string][]byte{"config": []byte(configContent)},
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	watcher := &k8s.SecretWatcher{Client: client, Namespace: "vxflexos", Name: "vxflexos-config", Key: "config"}
+	assert.NoError(t, watcher.Start(ctx))
+
+	viper.Reset()
+	viper.Set("provisioner_names", "csi-vxflexos.dellemc.com")
+
+	config := &entrypoint.Config{}
+	sdcFinder := &k8s.SDCFinder{}
+	lgr := logrus.New()
+	lgr.ExitFunc = func(int) { panic("fatal") }
+
+	assert.NotPanics(t, func() {
+		updatePowerFlexConnection(storageSystemSource{secret: watcher}, config, sdcFinder, &k8s.StorageClassFinder{}, &k8s.VolumeFinder{}, settings.Defaults(), lgr)
+	})
+	assert.Contains(t, config.PowerFlexClient, "secret-system")
+	assert.Equal(t, "secret-system", sdcFinder.StorageSystemID[0].ID)
+}
+
+func TestSetupStorageSystemSource(t *testing.T) {
+	t.Run("Mounted File", func(t *testing.T) {
+		source := setupStorageSystemSource(&k8s.API{}, settings.Defaults(), logrus.New())
+		assert.Equal(t, storageSystemSource{file: defaultStorageSystemConfigFile}, source)
+	})
+
+	t.Run("Secret API", func(t *testing.T) {
+		s := settings.Defaults()
+		s.CredentialsSource = settings.CredentialsSourceSecret
+		s.CredentialsSecretNamespace = "vxflexos"
+		client := fake.NewClientset(&corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{Name: settings.DefaultCredentialsSecretName, Namespace: "vxflexos"},
+			Data:       map[string][]byte{settings.DefaultCredentialsSecretKey: []byte("- systemID: ID1\n")},
+		})
+
+		source := setupStorageSystemSource(&k8s.API{Client: client}, s, logrus.New())
+		assert.NotNil(t, source.secret)
+		content, err := source.secret.Content()
+		assert.NoError(t, err)
+		assert.Equal(t, "- systemID: ID1\n", string(content))
+	})
+}
+
+func TestGatewayClientTLS(t *testing.T) {
+	caCertificate := "-----BEGIN CERTIFICATE-----\nMIIB\n-----END CERTIFICATE-----\n"
+
+	tests := []struct {
+		name           string
+		storageSystem  domain.ArrayConnectionData
+		expectInsecure bool
+		expectCAFile   string
+		expectInlineCA bool
+		expectError    bool
+	}{
+		{
+			name:           "Insecure Flag",
+			storageSystem:  domain.ArrayConnectionData{SystemID: "ID1", Insecure: true},
+			expectInsecure: true,
+		},
+		{
+			name:          "CA Certificate File",
+			storageSystem: domain.ArrayConnectionData{SystemID: "ID1", CACertificateFile: "/etc/powerflex/ca.pem"},
+			expectCAFile:  "/etc/powerflex/ca.pem",
+		},
+		{
+			name:           "Inline CA Certificate",
+			storageSystem:  domain.ArrayConnectionData{SystemID: "ID1", CACertificate: caCertificate},
+			expectInlineCA: true,
+		},
+		{
+			name:          "Certificate Fingerprints",
+			storageSystem: domain.ArrayConnectionData{SystemID: "ID1", CACertificateFile: "/etc/powerflex/ca.pem", CertificateFingerprints: []string{strings.Repeat("ab", 32)}},
+			expectError:   true,
+		},
+	}
+
+	t.Cleanup(func() {
+		_ = os.RemoveAll(inlineCADir)
+		inlineCADir = ""
+	})
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			insecure, caFilePath, err := gatewayClientTLS(tt.storageSystem)
+			if tt.expectError {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.expectInsecure, insecure)
+			if tt.expectInlineCA {
+				content, err := os.ReadFile(caFilePath)
+				assert.NoError(t, err)
+				assert.Equal(t, caCertificate, string(content))
+				// the bundle is written to a directory only this process can use
+				dir, err := os.Stat(filepath.Dir(caFilePath))
+				assert.NoError(t, err)
+				assert.Equal(t, os.FileMode(0o700), dir.Mode().Perm())
+				return
+			}
+			assert.Equal(t, tt.expectCAFile, caFilePath)
+		})
+	}
 }
 
 func TestUpdatePowerFlexConnectionClientError(t *testing.T) {
@@ -904,24 +1188,72 @@ func TestUpdatePowerFlexConnectionClientError(t *testing.T) {
 
 	assert.Panics(t, func() {
 		updatePowerFlexConnection(
-			tmpFile.Name(),
+			storageSystemSource{file: tmpFile.Name()},
 			config,
 			sdcFinder,
 			storageClassFinder,
 			volumeFinder,
+			settings.Defaults(),
 			lgr,
 		)
 	})
 }
 
+func TestUpdateServiceVolumeAggregation(t *testing.T) {
+	viper.Reset()
+	setRequiredConfig()
+	viper.Set(settings.VolumeAggregateByKey, "namespace")
+	viper.Set(settings.VolumeAggregatesOnlyKey, "true")
+
+	svc := &service.PowerFlexService{InventoryCache: service.NewInventoryCache(service.DefaultInventoryRefreshInterval)}
+	updateService(svc, loadSettings(logrus.New()), logrus.New())
+	assert.Equal(t, service.VolumeAggregation{ByNamespace: true, AggregatesOnly: true}, svc.VolumeAggregation)
+}
+
+func TestUpdateServiceNodeConditions(t *testing.T) {
+	viper.Reset()
+	setRequiredConfig()
+	svc := &service.PowerFlexService{InventoryCache: service.NewInventoryCache(service.DefaultInventoryRefreshInterval)}
+	updateService(svc, loadSettings(logrus.New()), logrus.New())
+	assert.False(t, svc.NodeConditions)
+
+	viper.Set(settings.NodeConditionsEnabledKey, "true")
+	updateService(svc, loadSettings(logrus.New()), logrus.New())
+	assert.True(t, svc.NodeConditions)
+}
+
 func TestUpdateServiceDefault(t *testing.T) {
 	viper.Reset()
 	// Don't set POWERFLEX_MAX_CONCURRENT_QUERIES so the default is used
-	svc := &service.PowerFlexService{}
+	svc := &service.PowerFlexService{InventoryCache: service.NewInventoryCache(time.Hour)}
 	lgr := logrus.New()
 
-	assert.NotPanics(t, func() { updateService(svc, lgr) })
+	assert.NotPanics(t, func() { updateService(svc, settings.Defaults(), lgr) })
 	assert.Equal(t, service.DefaultMaxPowerFlexConnections, svc.MaxPowerFlexConnections)
+	assert.Equal(t, service.DefaultMetricsBatchSize, svc.MetricsBatchSize)
+}
+
+func TestUpdateServiceMetricsBatchSize(t *testing.T) {
+	viper.Reset()
+	setRequiredConfig()
+	viper.Set(settings.MetricsBatchSizeKey, "20")
+	svc := &service.PowerFlexService{InventoryCache: service.NewInventoryCache(service.DefaultInventoryRefreshInterval)}
+	updateService(svc, loadSettings(logrus.New()), logrus.New())
+	assert.Equal(t, 20, svc.MetricsBatchSize)
+}
+
+func TestUpdateServiceMetricCatalog(t *testing.T) {
+	viper.Reset()
+	setRequiredConfig()
+	svc := &service.PowerFlexService{InventoryCache: service.NewInventoryCache(service.DefaultInventoryRefreshInterval)}
+	updateService(svc, loadSettings(logrus.New()), logrus.New())
+	assert.Nil(t, svc.MetricCatalog)
+
+	viper.Set(settings.MetricCatalogFileKey, "../../internal/service/testdata/metric-catalog.yaml")
+	updateService(svc, loadSettings(logrus.New()), logrus.New())
+	if assert.NotNil(t, svc.MetricCatalog) {
+		assert.Len(t, svc.MetricCatalog.Metrics, 23)
+	}
 }
 
 func TestUpdatePowerFlexConnectionInsecureFlags(t *testing.T) {
@@ -967,14 +1299,352 @@ func TestUpdatePowerFlexConnectionInsecureFlags(t *testing.T) {
 
 	assert.NotPanics(t, func() {
 		updatePowerFlexConnection(
-			tmpFile.Name(),
+			storageSystemSource{file: tmpFile.Name()},
 			config,
 			sdcFinder,
 			storageClassFinder,
 			volumeFinder,
+			settings.Defaults(),
 			lgr,
 		)
 	})
 
 	assert.Contains(t, config.PowerFlexClient, "test-system")
 }
+
+func TestGetGatewayRateLimits(t *testing.T) {
+	defaultLimit := domain.RateLimit{RequestsPerSecond: 50, Burst: 100}
+	storageSystems := []domain.ArrayConnectionData{
+		{SystemID: "ID1", Endpoint: "https://gateway-1"},
+		{SystemID: "ID2", Endpoint: "https://gateway-2", RateLimit: &domain.RateLimit{RequestsPerSecond: 10}},
+		{SystemID: "ID3", Endpoint: "https://gateway-2", RateLimit: &domain.RateLimit{RequestsPerSecond: 5, Burst: 5}},
+		{SystemID: "ID4", Endpoint: "https://gateway-2"},
+		{SystemID: "ID5", Endpoint: "https://gateway-3", RateLimit: &domain.RateLimit{RequestsPerSecond: 100}},
+		// an array without an override keeps the default for the arrays that share its gateway
+		{SystemID: "ID6", Endpoint: "https://gateway-4"},
+		{SystemID: "ID7", Endpoint: "https://gateway-4", RateLimit: &domain.RateLimit{RequestsPerSecond: 80, Burst: 200}},
+		// the lowest rate and the lowest burst can come from different arrays
+		{SystemID: "ID8", Endpoint: "https://gateway-5", RateLimit: &domain.RateLimit{RequestsPerSecond: 20, Burst: 500}},
+		{SystemID: "ID9", Endpoint: "https://gateway-5"},
+		// an unlimited array doesn't lift the limit of the others
+		{SystemID: "ID10", Endpoint: "https://gateway-6", RateLimit: &domain.RateLimit{}},
+		{SystemID: "ID11", Endpoint: "https://gateway-6", RateLimit: &domain.RateLimit{RequestsPerSecond: 30}},
+	}
+
+	assert.Equal(t, map[string]domain.RateLimit{
+		"https://gateway-1": defaultLimit,
+		"https://gateway-2": {RequestsPerSecond: 5, Burst: 5},
+		"https://gateway-3": {RequestsPerSecond: 100},
+		"https://gateway-4": defaultLimit,
+		"https://gateway-5": {RequestsPerSecond: 20, Burst: 100},
+		"https://gateway-6": {RequestsPerSecond: 30},
+	}, getGatewayRateLimits(storageSystems, defaultLimit))
+}
+
+func TestUpdateServiceInventoryRefreshInterval(t *testing.T) {
+	tests := []struct {
+		name        string
+		value       string
+		expected    time.Duration
+		expectPanic bool
+	}{
+		{"default", "", service.DefaultInventoryRefreshInterval, false},
+		{"valid", "60", time.Minute, false},
+		{"disabled", "0", 0, false},
+		{"not a number", "soon", 0, true},
+		{"negative", "-1", 0, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			viper.Reset()
+			setRequiredConfig()
+			viper.Set("POWERFLEX_INVENTORY_REFRESH_INTERVAL", tt.value)
+			svc := &service.PowerFlexService{InventoryCache: service.NewInventoryCache(time.Hour)}
+			lgr := logrus.New()
+			lgr.ExitFunc = func(int) { panic("fatal") }
+			if tt.expectPanic {
+				assert.Panics(t, func() { loadSettings(lgr) })
+			} else {
+				s := loadSettings(lgr)
+				assert.NotPanics(t, func() { updateService(svc, s, lgr) })
+				assert.Equal(t, tt.expected, svc.InventoryCache.RefreshInterval)
+			}
+		})
+	}
+}
+
+func TestInitializeComponentsSharesKubernetesAPI(t *testing.T) {
+	kubeAPI := &k8s.API{}
+	sdcFinder, storageClassFinder, _, volumeFinder, nodeFinder, _ := initializeComponents(logrus.New(), kubeAPI)
+	assert.Same(t, kubeAPI, sdcFinder.API)
+	assert.Same(t, kubeAPI, storageClassFinder.API)
+	assert.Same(t, kubeAPI, volumeFinder.API)
+	assert.Same(t, kubeAPI, volumeFinder.Metadata)
+	assert.Same(t, kubeAPI, volumeFinder.Pods)
+	assert.Same(t, kubeAPI, nodeFinder.API)
+	assert.Same(t, volumeFinder.FilteredObjects, storageClassFinder.FilteredObjects)
+}
+
+func TestStartKubernetesInformers(t *testing.T) {
+	t.Run("caches sync in the background", func(t *testing.T) {
+		kubeAPI := &k8s.API{Client: fake.NewClientset()}
+		lgr := logrus.New()
+		lgr.ExitFunc = func(int) { panic("fatal") }
+		assert.NotPanics(t, func() { startKubernetesInformers(kubeAPI, lgr) })
+		assert.Eventually(t, kubeAPI.HasSynced, 5*time.Second, 10*time.Millisecond)
+	})
+
+	t.Run("keeps waiting when the caches don't sync in time", func(t *testing.T) {
+		oldCacheSyncTimeout := k8s.CacheSyncTimeout
+		defer func() { k8s.CacheSyncTimeout = oldCacheSyncTimeout }()
+		k8s.CacheSyncTimeout = 20 * time.Millisecond
+
+		client := fake.NewClientset()
+		client.PrependReactor("list", "*", func(k8stesting.Action) (bool, runtime.Object, error) {
+			return true, nil, errors.New("api server unavailable")
+		})
+		kubeAPI := &k8s.API{Client: client}
+		require.NoError(t, kubeAPI.Start(context.Background()))
+
+		lgr, hook := logrustest.NewNullLogger()
+		lgr.ExitFunc = func(int) { panic("fatal") }
+		ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+		defer cancel()
+		assert.False(t, waitForKubernetesInformers(ctx, kubeAPI, lgr))
+		require.NotEmpty(t, hook.AllEntries())
+		assert.Equal(t, logrus.WarnLevel, hook.LastEntry().Level)
+	})
+
+	t.Run("fatal when the kubernetes API is unreachable", func(t *testing.T) {
+		oldConnectFn := k8s.ConnectFn
+		defer func() { k8s.ConnectFn = oldConnectFn }()
+		k8s.ConnectFn = func(_ *k8s.API) error { return errors.New("no cluster") }
+
+		lgr := logrus.New()
+		lgr.ExitFunc = func(int) { panic("fatal") }
+		assert.Panics(t, func() { startKubernetesInformers(&k8s.API{}, lgr) })
+	})
+}
+
+func TestHealthProbeHandler(t *testing.T) {
+	ready := false
+	handler := healthProbeHandler(func() bool { return ready })
+	get := func(path string) int {
+		recorder := httptest.NewRecorder()
+		handler.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, path, nil))
+		return recorder.Code
+	}
+
+	assert.Equal(t, http.StatusOK, get("/healthz"))
+	assert.Equal(t, http.StatusServiceUnavailable, get("/readyz"))
+
+	ready = true
+	assert.Equal(t, http.StatusOK, get("/readyz"))
+}
+
+func TestParseFlags(t *testing.T) {
+	defer func() { k8s.ClientConfig = k8s.ClientConfigOptions{} }()
+
+	assert.NoError(t, parseFlags([]string{}))
+	assert.Equal(t, k8s.ClientConfigOptions{}, k8s.ClientConfig)
+
+	assert.NoError(t, parseFlags([]string{"--kubeconfig", "/tmp/kubeconfig", "--context", "kind-kind"}))
+	assert.Equal(t, k8s.ClientConfigOptions{Kubeconfig: "/tmp/kubeconfig", Context: "kind-kind"}, k8s.ClientConfig)
+
+	assert.NoError(t, parseFlags([]string{"--print-config"}))
+	assert.True(t, printConfig)
+	printConfig = false
+
+	assert.Error(t, parseFlags([]string{"--unknown"}))
+}
+
+func TestPrintEffectiveConfig(t *testing.T) {
+	t.Run("valid configuration", func(t *testing.T) {
+		viper.Reset()
+		setRequiredConfig()
+		var stdout, stderr bytes.Buffer
+
+		assert.Equal(t, 0, printEffectiveConfig(&stdout, &stderr, "testdata/config.yaml"))
+		assert.Empty(t, stderr.String())
+		assert.Contains(t, stdout.String(), "COLLECTOR_ADDR: localhost:8080")
+		assert.Contains(t, stdout.String(), "POWERFLEX_SDC_IO_POLL_FREQUENCY: \"5\"")
+		assert.Contains(t, stdout.String(), "password: '********'")
+		assert.NotContains(t, stdout.String(), "password: password")
+	})
+
+	t.Run("invalid configuration", func(t *testing.T) {
+		viper.Reset()
+		viper.Set("POWERFLEX_TOPOLOGY_METRICS_ENABLED", "test")
+		var stdout, stderr bytes.Buffer
+
+		assert.Equal(t, 1, printEffectiveConfig(&stdout, &stderr, "testdata/not-exist.yaml"))
+		assert.NotEmpty(t, stdout.String())
+		assert.Contains(t, stderr.String(), "COLLECTOR_ADDR is required")
+		assert.Contains(t, stderr.String(), "provisioner_names is required")
+		assert.Contains(t, stderr.String(), "POWERFLEX_TOPOLOGY_METRICS_ENABLED")
+		assert.Contains(t, stderr.String(), "reading storage system configuration")
+	})
+}
+
+func TestSetupLeaderElection(t *testing.T) {
+	tests := []struct {
+		name          string
+		values        map[string]string
+		disabled      bool
+		leaseDuration time.Duration
+		renewDeadline time.Duration
+		retryPeriod   time.Duration
+		expectPanic   bool
+	}{
+		{"defaults", map[string]string{}, false, k8s.DefaultLeaseDuration, k8s.DefaultRenewDeadline, k8s.DefaultRetryPeriod, false},
+		{"disabled", map[string]string{"POWERFLEX_LEADER_ELECTION_ENABLED": "false"}, true, k8s.DefaultLeaseDuration, k8s.DefaultRenewDeadline, k8s.DefaultRetryPeriod, false},
+		{"custom timings", map[string]string{
+			"POWERFLEX_LEADER_ELECTION_LEASE_DURATION": "60",
+			"POWERFLEX_LEADER_ELECTION_RENEW_DEADLINE": "30",
+			"POWERFLEX_LEADER_ELECTION_RETRY_PERIOD":   "5",
+		}, false, time.Minute, 30 * time.Second, 5 * time.Second, false},
+		{"invalid enabled", map[string]string{"POWERFLEX_LEADER_ELECTION_ENABLED": "maybe"}, false, 0, 0, 0, true},
+		{"invalid duration", map[string]string{"POWERFLEX_LEADER_ELECTION_LEASE_DURATION": "long"}, false, 0, 0, 0, true},
+		{"zero duration", map[string]string{"POWERFLEX_LEADER_ELECTION_RETRY_PERIOD": "0"}, false, 0, 0, 0, true},
+		{"renew deadline longer than lease", map[string]string{"POWERFLEX_LEADER_ELECTION_RENEW_DEADLINE": "20"}, false, 0, 0, 0, true},
+		{"retry period longer than renew deadline", map[string]string{"POWERFLEX_LEADER_ELECTION_RETRY_PERIOD": "12"}, false, 0, 0, 0, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			viper.Reset()
+			setRequiredConfig()
+			for key, value := range tt.values {
+				viper.Set(key, value)
+			}
+			lgr := logrus.New()
+			lgr.ExitFunc = func(int) { panic("fatal") }
+			leaderElector := &k8s.LeaderElector{}
+			svc := &service.PowerFlexService{InventoryCache: service.NewInventoryCache(time.Minute)}
+			config := &entrypoint.Config{}
+
+			if tt.expectPanic {
+				assert.Panics(t, func() { loadSettings(lgr) })
+				return
+			}
+			s := loadSettings(lgr)
+			assert.NotPanics(t, func() { setupLeaderElection(leaderElector, config, svc, s, lgr) })
+			assert.Equal(t, tt.disabled, leaderElector.Disabled)
+			assert.Equal(t, tt.leaseDuration, leaderElector.LeaseDuration)
+			assert.Equal(t, tt.renewDeadline, leaderElector.RenewDeadline)
+			assert.Equal(t, tt.retryPeriod, leaderElector.RetryPeriod)
+			assert.NotNil(t, leaderElector.OnStartedLeading)
+			assert.NotNil(t, leaderElector.OnStoppedLeading)
+
+			// no arrays are configured, so warming is a no-op
+			assert.NotPanics(t, func() { leaderElector.OnStartedLeading(context.Background()) })
+			assert.NotPanics(t, leaderElector.OnStoppedLeading)
+		})
+	}
+}
+
+func TestSetupLeaderElection_Sharding(t *testing.T) {
+	tests := []struct {
+		name        string
+		values      map[string]string
+		sharding    bool
+		expectPanic bool
+	}{
+		{"not set", map[string]string{}, false, false},
+		{"enabled", map[string]string{"POWERFLEX_SHARDING_ENABLED": "true"}, true, false},
+		{"invalid", map[string]string{"POWERFLEX_SHARDING_ENABLED": "yes"}, false, true},
+		{"leader election disabled", map[string]string{
+			"POWERFLEX_SHARDING_ENABLED":        "true",
+			"POWERFLEX_LEADER_ELECTION_ENABLED": "false",
+		}, false, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			viper.Reset()
+			setRequiredConfig()
+			for key, value := range tt.values {
+				viper.Set(key, value)
+			}
+			lgr := logrus.New()
+			lgr.ExitFunc = func(int) { panic("fatal") }
+			leaderElector := &k8s.LeaderElector{}
+			svc := &service.PowerFlexService{InventoryCache: service.NewInventoryCache(time.Minute)}
+			sdcFinder := &k8s.SDCFinder{StorageSystemID: []k8s.StorageSystemID{{ID: "system-1"}, {ID: "system-2"}}}
+			config := &entrypoint.Config{SDCFinder: sdcFinder}
+
+			if tt.expectPanic {
+				assert.Panics(t, func() { loadSettings(lgr) })
+				return
+			}
+			s := loadSettings(lgr)
+			assert.NotPanics(t, func() { setupLeaderElection(leaderElector, config, svc, s, lgr) })
+			assert.Equal(t, tt.sharding, leaderElector.Sharding)
+			assert.Equal(t, []string{"system-1", "system-2"}, leaderElector.StorageSystemIDs())
+		})
+	}
+}
+
+func TestSetupCollectionMode(t *testing.T) {
+	tests := []struct {
+		name        string
+		mode        string
+		nodeName    string
+		sdcGUID     string
+		sharding    bool
+		nodeLocal   bool
+		expectPanic bool
+	}{
+		{"default", "", "worker-1", "", false, false, false},
+		{"cluster", "cluster", "worker-1", "", false, false, false},
+		{"node", "node", "worker-1", "", false, true, false},
+		{"node with local sdc guid", "node", "worker-1", "guid-1", false, true, false},
+		{"node without node name", "node", "", "", false, false, true},
+		{"node with sharding", "node", "worker-1", "", true, false, true},
+		{"invalid", "daemonset", "worker-1", "", false, false, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("POWERFLEX_COLLECTION_MODE", tt.mode)
+			t.Setenv("NODE_NAME", tt.nodeName)
+			t.Setenv("POWERFLEX_SDC_GUID", tt.sdcGUID)
+			viper.Reset()
+			setRequiredConfig()
+			viper.Set("POWERFLEX_SHARDING_ENABLED", fmt.Sprint(tt.sharding))
+			lgr := logrus.New()
+			lgr.ExitFunc = func(int) { panic("fatal") }
+			config := &entrypoint.Config{}
+			kubeAPI := &k8s.API{}
+			sdcFinder := &k8s.SDCFinder{}
+			leaderElector := &k8s.LeaderElector{}
+			svc := &service.PowerFlexService{MetricsWrapper: &service.MetricsWrapper{}}
+
+			if tt.expectPanic {
+				assert.Panics(t, func() { loadSettings(lgr) })
+				return
+			}
+			s := loadSettings(lgr)
+			assert.NotPanics(t, func() { setupCollectionMode(config, kubeAPI, sdcFinder, leaderElector, svc, s, lgr) })
+			assert.Equal(t, tt.nodeLocal, leaderElector.Disabled)
+			if !tt.nodeLocal {
+				assert.Empty(t, config.NodeName)
+				assert.Empty(t, kubeAPI.NodeName)
+				assert.Empty(t, sdcFinder.NodeName)
+				return
+			}
+			assert.Equal(t, tt.nodeName, config.NodeName)
+			assert.Equal(t, tt.nodeName, kubeAPI.NodeName)
+			assert.Equal(t, tt.nodeName, sdcFinder.NodeName)
+			assert.Equal(t, tt.sdcGUID, sdcFinder.SDCGUID)
+			assert.Equal(t, tt.nodeName, svc.MetricsWrapper.(*service.MetricsWrapper).NodeName)
+
+			updateMetricsEnabled(config, s)
+			assert.True(t, config.SDCMetricsEnabled)
+			assert.True(t, config.VolumeMetricsEnabled)
+			assert.False(t, config.StoragePoolMetricsEnabled)
+			assert.False(t, config.TopologyMetricsEnabled)
+		})
+	}
+}
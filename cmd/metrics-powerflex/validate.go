@@ -0,0 +1,248 @@
+/*
+ Copyright (c) 2025 Dell Inc. or its subsidiaries. All Rights Reserved.
+
+ Licensed under the Apache License, Version 2.0 (the "License");
+ you may not use this file except in compliance with the License.
+ You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package main
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+
+	"github.com/dell/karavi-metrics-powerflex/internal/domain"
+	"github.com/dell/karavi-metrics-powerflex/internal/service"
+	"github.com/dell/karavi-metrics-powerflex/internal/settings"
+	"github.com/spf13/viper"
+	"sigs.k8s.io/yaml"
+)
+
+const (
+	validateCommand = "validate"
+
+	// keys of the files in the karavi-metrics-powerflex ConfigMap and the vxflexos-config Secret
+	configMapKey = "karavi-metrics-powerflex.yaml"
+	secretKey    = "config"
+)
+
+// validationResult is the JSON document written by the validate subcommand
+type validationResult struct {
+	Valid          bool       `json:"valid"`
+	Configuration  fileResult `json:"configuration"`
+	StorageSystems fileResult `json:"storageSystems"`
+}
+
+type fileResult struct {
+	File   string   `json:"file"`
+	Errors []string `json:"errors"`
+}
+
+// manifest is the part of a ConfigMap or Secret manifest that holds the configuration files
+type manifest struct {
+	Kind       string            `json:"kind"`
+	Data       map[string]string `json:"data"`
+	StringData map[string]string `json:"stringData"`
+}
+
+// runValidate lints the configuration and storage system files without contacting an array or the API server,
+// e.g. in a CI pipeline. Each file can be the mounted file or the ConfigMap or Secret manifest that holds it.
+// The result is written as JSON and the exit code is 1 if any file is invalid, or 2 for invalid arguments.
+func runValidate(args []string, stdout io.Writer, stderr io.Writer) int {
+	flags := flag.NewFlagSet("metrics-powerflex "+validateCommand, flag.ContinueOnError)
+	flags.SetOutput(stderr)
+	configFile := flags.String("config", defaultConfigFile, "path to karavi-metrics-powerflex.yaml or the ConfigMap manifest that contains it")
+	storageSystemConfigFile := flags.String("storage-systems", defaultStorageSystemConfigFile, "path to the vxflexos-config file or the Secret manifest that contains it")
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+
+	result := validationResult{
+		Configuration:  fileResult{File: *configFile, Errors: errorStrings(validateConfigurationFile(*configFile))},
+		StorageSystems: fileResult{File: *storageSystemConfigFile, Errors: errorStrings(validateStorageSystemFile(*storageSystemConfigFile))},
+	}
+	result.Valid = len(result.Configuration.Errors) == 0 && len(result.StorageSystems.Errors) == 0
+
+	out, err := json.MarshalIndent(result, "", "  ")
+	if err != nil {
+		fmt.Fprintf(stderr, "printing validation result: %v\n", err)
+		return 1
+	}
+	fmt.Fprintln(stdout, string(out))
+
+	if !result.Valid {
+		return 1
+	}
+	return 0
+}
+
+// validateConfigurationFile parses the file the same way the service does. Values from the environment of
+// the pod, such as POWERFLEX_COLLECTION_MODE, are read from the environment of the validate subcommand.
+func validateConfigurationFile(file string) error {
+	content, err := readConfigurationFile(file, "ConfigMap", configMapKey)
+	if err != nil {
+		return err
+	}
+
+	v := viper.New()
+	v.SetConfigType("yaml")
+	if err := v.ReadConfig(bytes.NewReader(content)); err != nil {
+		return fmt.Errorf("parsing %s: %w", file, err)
+	}
+	_, err = settings.Load(v, os.Getenv)
+	return err
+}
+
+func validateStorageSystemFile(file string) error {
+	content, err := readConfigurationFile(file, "Secret", secretKey)
+	if err != nil {
+		return err
+	}
+
+	configReader := service.ConfigurationReader{}
+	storageSystems, err := configReader.ParseStorageSystemConfiguration(content)
+	if err != nil {
+		return err
+	}
+	return validateAvailabilityZones(storageSystems)
+}
+
+// readConfigurationFile returns the content of file. If file is a manifest of the given kind,
+// the content of its key is returned instead.
+func readConfigurationFile(file string, kind string, key string) ([]byte, error) {
+	content, err := os.ReadFile(filepath.Clean(file))
+	if err != nil {
+		return nil, fmt.Errorf("reading %s: %w", file, err)
+	}
+
+	var m manifest
+	if err := yaml.Unmarshal(content, &m); err != nil || m.Kind == "" {
+		return content, nil
+	}
+	if m.Kind != kind {
+		return nil, fmt.Errorf("%s is a %s, expected the file or a %s", file, m.Kind, kind)
+	}
+	if value, ok := m.StringData[key]; ok {
+		return []byte(value), nil
+	}
+	if value, ok := m.Data[key]; ok {
+		if kind == "Secret" {
+			// the data of a Secret is base64 encoded
+			decoded, err := base64.StdEncoding.DecodeString(value)
+			if err != nil {
+				return nil, fmt.Errorf("decoding %s in %s: %w", key, file, err)
+			}
+			return decoded, nil
+		}
+		return []byte(value), nil
+	}
+	return nil, fmt.Errorf("%s %s has no %s key", kind, file, key)
+}
+
+// validateAvailabilityZones checks that the storage systems can be told apart and that their zones are complete
+func validateAvailabilityZones(storageSystems []domain.ArrayConnectionData) error {
+	var errs []error
+	systemIDs := make(map[string]bool)
+	zones := make(map[domain.ZoneName]string)
+	labelKeys := make(map[string]bool)
+	defaults := 0
+	withZone := 0
+
+	for i, storageSystem := range storageSystems {
+		if systemIDs[storageSystem.SystemID] {
+			errs = append(errs, fmt.Errorf("duplicate systemID %s at index %d", storageSystem.SystemID, i))
+		}
+		systemIDs[storageSystem.SystemID] = true
+		if storageSystem.IsDefault {
+			defaults++
+		}
+
+		zone := storageSystem.AvailabilityZone
+		if zone == nil {
+			continue
+		}
+		withZone++
+		if zone.Name == "" {
+			errs = append(errs, fmt.Errorf("zone of %s has no name", storageSystem.SystemID))
+		} else if other, ok := zones[zone.Name]; ok {
+			errs = append(errs, fmt.Errorf("zone %s is defined for both %s and %s", zone.Name, other, storageSystem.SystemID))
+		} else {
+			zones[zone.Name] = storageSystem.SystemID
+		}
+		if zone.LabelKey == "" {
+			errs = append(errs, fmt.Errorf("zone of %s has no labelKey", storageSystem.SystemID))
+		} else {
+			labelKeys[zone.LabelKey] = true
+		}
+		if len(zone.ProtectionDomains) == 0 {
+			errs = append(errs, fmt.Errorf("zone of %s has no protection domains", storageSystem.SystemID))
+		}
+		for _, protectionDomain := range zone.ProtectionDomains {
+			errs = append(errs, validateProtectionDomain(storageSystem.SystemID, protectionDomain)...)
+		}
+	}
+
+	if defaults > 1 {
+		errs = append(errs, fmt.Errorf("%d storage systems are marked isDefault, at most one is allowed", defaults))
+	}
+	if withZone > 0 && withZone < len(storageSystems) {
+		errs = append(errs, fmt.Errorf("zone is defined for %d of %d storage systems, it must be defined for all or none", withZone, len(storageSystems)))
+	}
+	if len(labelKeys) > 1 {
+		errs = append(errs, fmt.Errorf("zones use %d different labelKeys, they must all use the same one", len(labelKeys)))
+	}
+	return errors.Join(errs...)
+}
+
+func validateProtectionDomain(systemID string, protectionDomain domain.ProtectionDomain) []error {
+	var errs []error
+	if protectionDomain.Name == "" {
+		errs = append(errs, fmt.Errorf("zone of %s lists pools %v without a protection domain name", systemID, protectionDomain.Pools))
+	}
+	if len(protectionDomain.Pools) == 0 {
+		errs = append(errs, fmt.Errorf("protection domain %s of %s has no pools", protectionDomain.Name, systemID))
+	}
+	pools := make(map[domain.PoolName]bool)
+	for _, pool := range protectionDomain.Pools {
+		if pool == "" {
+			errs = append(errs, fmt.Errorf("protection domain %s of %s has an empty pool name", protectionDomain.Name, systemID))
+			continue
+		}
+		if pools[pool] {
+			errs = append(errs, fmt.Errorf("pool %s is listed more than once in protection domain %s of %s", pool, protectionDomain.Name, systemID))
+		}
+		pools[pool] = true
+	}
+	return errs
+}
+
+// errorStrings flattens the errors joined by errors.Join into one message each
+func errorStrings(err error) []string {
+	messages := []string{}
+	if err == nil {
+		return messages
+	}
+	if joined, ok := err.(interface{ Unwrap() []error }); ok {
+		for _, e := range joined.Unwrap() {
+			messages = append(messages, errorStrings(e)...)
+		}
+		return messages
+	}
+	return append(messages, err.Error())
+}
@@ -0,0 +1,225 @@
+/*
+ Copyright (c) 2025 Dell Inc. or its subsidiaries. All Rights Reserved.
+
+ Licensed under the Apache License, Version 2.0 (the "License");
+ you may not use this file except in compliance with the License.
+ You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package main
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/dell/karavi-metrics-powerflex/internal/domain"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	validConfiguration  = "COLLECTOR_ADDR: otel-collector:55680\nprovisioner_names: csi-vxflexos.dellemc.com\nPOWERFLEX_SDC_IO_POLL_FREQUENCY: 10\n"
+	validStorageSystems = `- username: admin
+  password: password
+  systemID: ID1
+  endpoint: https://127.0.0.1
+  zone:
+    name: zoneA
+    labelKey: topology.kubernetes.io/zone
+    protectionDomains:
+      - name: pd1
+        pools: [pool1, pool2]
+- username: admin
+  password: password
+  systemID: ID2
+  endpoint: https://127.0.0.2
+  zone:
+    name: zoneB
+    labelKey: topology.kubernetes.io/zone
+    protectionDomains:
+      - name: pd1
+        pools: [pool1]
+`
+)
+
+func writeFile(t *testing.T, name string, content string) string {
+	file := filepath.Join(t.TempDir(), name)
+	require.NoError(t, os.WriteFile(file, []byte(content), 0o600))
+	return file
+}
+
+func TestRunValidate(t *testing.T) {
+	configMap := "apiVersion: v1\nkind: ConfigMap\nmetadata:\n  name: karavi-metrics-powerflex-configmap\ndata:\n  karavi-metrics-powerflex.yaml: |\n" +
+		"    COLLECTOR_ADDR: otel-collector:55680\n    provisioner_names: csi-vxflexos.dellemc.com\n"
+	secret := "apiVersion: v1\nkind: Secret\nmetadata:\n  name: vxflexos-config\ndata:\n  config: " +
+		base64.StdEncoding.EncodeToString([]byte(validStorageSystems)) + "\n"
+	stringDataSecret := "apiVersion: v1\nkind: Secret\nmetadata:\n  name: vxflexos-config\nstringData:\n  config: |\n" +
+		"    - username: admin\n      password: password\n      systemID: ID1\n      endpoint: https://127.0.0.1\n"
+
+	tests := map[string]struct {
+		configuration  string
+		storageSystems string
+		exitCode       int
+		configErrors   []string
+		systemErrors   []string
+	}{
+		"valid files": {
+			configuration:  validConfiguration,
+			storageSystems: validStorageSystems,
+			exitCode:       0,
+		},
+		"valid manifests": {
+			configuration:  configMap,
+			storageSystems: secret,
+			exitCode:       0,
+		},
+		"secret with stringData": {
+			configuration:  validConfiguration,
+			storageSystems: stringDataSecret,
+			exitCode:       0,
+		},
+		"invalid configuration": {
+			configuration:  "provisioner_names: csi-vxflexos.dellemc.com\nPOWERFLEX_TOPOLOGY_METRICS_ENABLED: test\n",
+			storageSystems: validStorageSystems,
+			exitCode:       1,
+			configErrors: []string{
+				"COLLECTOR_ADDR is required",
+				`POWERFLEX_TOPOLOGY_METRICS_ENABLED value "test" is invalid, valid values are true or false`,
+			},
+		},
+		"invalid storage system": {
+			configuration:  validConfiguration,
+			storageSystems: "- username: admin\n  systemID: ID1\n  endpoint: https://127.0.0.1\n",
+			exitCode:       1,
+			systemErrors:   []string{"invalid value for Password at index 0"},
+		},
+		"manifests of the wrong kind": {
+			configuration:  secret,
+			storageSystems: configMap,
+			exitCode:       1,
+			configErrors:   []string{"is a Secret, expected the file or a ConfigMap"},
+			systemErrors:   []string{"is a ConfigMap, expected the file or a Secret"},
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			configFile := writeFile(t, "karavi-metrics-powerflex.yaml", tc.configuration)
+			storageSystemConfigFile := writeFile(t, "config", tc.storageSystems)
+			var stdout, stderr bytes.Buffer
+
+			exitCode := runValidate([]string{"--config", configFile, "--storage-systems", storageSystemConfigFile}, &stdout, &stderr)
+			assert.Equal(t, tc.exitCode, exitCode, stdout.String())
+
+			var result validationResult
+			require.NoError(t, json.Unmarshal(stdout.Bytes(), &result))
+			assert.Equal(t, tc.exitCode == 0, result.Valid)
+			assert.Equal(t, configFile, result.Configuration.File)
+			assert.Equal(t, storageSystemConfigFile, result.StorageSystems.File)
+			assert.Len(t, result.Configuration.Errors, len(tc.configErrors))
+			assert.Len(t, result.StorageSystems.Errors, len(tc.systemErrors))
+			for i, problem := range tc.configErrors {
+				assert.Contains(t, result.Configuration.Errors[i], problem)
+			}
+			for i, problem := range tc.systemErrors {
+				assert.Contains(t, result.StorageSystems.Errors[i], problem)
+			}
+		})
+	}
+
+	t.Run("missing files", func(t *testing.T) {
+		var stdout, stderr bytes.Buffer
+		assert.Equal(t, 1, runValidate([]string{"--config", "testdata/not-exist.yaml", "--storage-systems", "testdata/not-exist.yaml"}, &stdout, &stderr))
+		assert.Contains(t, stdout.String(), `"valid": false`)
+		assert.Contains(t, stdout.String(), "reading testdata/not-exist.yaml")
+	})
+
+	t.Run("invalid arguments", func(t *testing.T) {
+		var stdout, stderr bytes.Buffer
+		assert.Equal(t, 2, runValidate([]string{"--unknown"}, &stdout, &stderr))
+		assert.Empty(t, stdout.String())
+	})
+}
+
+func TestValidateAvailabilityZones(t *testing.T) {
+	zone := func(name domain.ZoneName, labelKey string, protectionDomains ...domain.ProtectionDomain) *domain.AvailabilityZone {
+		return &domain.AvailabilityZone{Name: name, LabelKey: labelKey, ProtectionDomains: protectionDomains}
+	}
+	pd := func(name domain.ProtectionDomainName, pools ...domain.PoolName) domain.ProtectionDomain {
+		return domain.ProtectionDomain{Name: name, Pools: pools}
+	}
+
+	tests := map[string]struct {
+		storageSystems []domain.ArrayConnectionData
+		problems       []string
+	}{
+		"no zones": {
+			storageSystems: []domain.ArrayConnectionData{{SystemID: "ID1", IsDefault: true}, {SystemID: "ID2"}},
+		},
+		"complete zones": {
+			storageSystems: []domain.ArrayConnectionData{
+				{SystemID: "ID1", AvailabilityZone: zone("zoneA", "zone", pd("pd1", "pool1"))},
+				{SystemID: "ID2", AvailabilityZone: zone("zoneB", "zone", pd("pd1", "pool1"), pd("pd2", "pool2"))},
+			},
+		},
+		"duplicate system IDs and defaults": {
+			storageSystems: []domain.ArrayConnectionData{{SystemID: "ID1", IsDefault: true}, {SystemID: "ID1", IsDefault: true}},
+			problems: []string{
+				"duplicate systemID ID1 at index 1",
+				"2 storage systems are marked isDefault, at most one is allowed",
+			},
+		},
+		"pools without a protection domain": {
+			storageSystems: []domain.ArrayConnectionData{
+				{SystemID: "ID1", AvailabilityZone: zone("zoneA", "zone", pd("", "pool1"), pd("pd2"))},
+			},
+			problems: []string{
+				"zone of ID1 lists pools [pool1] without a protection domain name",
+				"protection domain pd2 of ID1 has no pools",
+			},
+		},
+		"inconsistent zones": {
+			storageSystems: []domain.ArrayConnectionData{
+				{SystemID: "ID1", AvailabilityZone: zone("zoneA", "zone", pd("pd1", "pool1", "pool1"))},
+				{SystemID: "ID2", AvailabilityZone: zone("zoneA", "rack")},
+				{SystemID: "ID3", AvailabilityZone: zone("", "")},
+				{SystemID: "ID4"},
+			},
+			problems: []string{
+				"pool pool1 is listed more than once in protection domain pd1 of ID1",
+				"zone zoneA is defined for both ID1 and ID2",
+				"zone of ID2 has no protection domains",
+				"zone of ID3 has no name",
+				"zone of ID3 has no labelKey",
+				"zone of ID3 has no protection domains",
+				"zone is defined for 3 of 4 storage systems, it must be defined for all or none",
+				"zones use 2 different labelKeys, they must all use the same one",
+			},
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			assert.Equal(t, tc.problems, nilIfEmpty(errorStrings(validateAvailabilityZones(tc.storageSystems))))
+		})
+	}
+}
+
+func nilIfEmpty(messages []string) []string {
+	if len(messages) == 0 {
+		return nil
+	}
+	return messages
+}
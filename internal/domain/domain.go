@@ -16,6 +16,19 @@ type ArrayConnectionData struct {
 	IsDefault                 bool              `json:"isDefault,omitempty"`
 	SkipCertificateValidation bool              `json:"skipCertificateValidation,omitempty"`
 	AvailabilityZone          *AvailabilityZone `json:"zone,omitempty"`
+	RateLimit                 *RateLimit        `json:"rateLimit,omitempty"`
+	// CACertificate is a PEM encoded CA bundle that signs the gateway certificate
+	CACertificate string `json:"caCertificate,omitempty"`
+	// CACertificateFile is the path of a PEM encoded CA bundle, used instead of CACertificate
+	CACertificateFile string `json:"caCertificateFile,omitempty"`
+	// CertificateFingerprints is not supported and only kept so that a configuration using it is rejected
+	CertificateFingerprints []string `json:"certificateFingerprints,omitempty"`
+}
+
+// RateLimit overrides the client-side request rate towards the array's gateway
+type RateLimit struct {
+	RequestsPerSecond float64 `json:"requestsPerSecond"`
+	Burst             int     `json:"burst,omitempty"`
 }
 
 // Definitions to make AvailabilityZone decomposition easier to read.
@@ -18,11 +18,13 @@ package entrypoint
 
 import (
 	"context"
+	"errors"
 	"fmt"
-	"os"
 	"runtime"
+	"strings"
 	"time"
 
+	"github.com/dell/karavi-metrics-powerflex/internal/k8s"
 	pflexServices "github.com/dell/karavi-metrics-powerflex/internal/service"
 	otlexporters "github.com/dell/karavi-metrics-powerflex/opentelemetry/exporters"
 	"github.com/sirupsen/logrus"
@@ -47,7 +49,7 @@ const (
 	MinimumVolTickInterval = 5 * time.Second
 	// DefaultEndPoint for leader election path
 	DefaultEndPoint = "karavi-metrics-powerflex" // #nosec G101
-	// DefaultNameSpace for powerflex pod running metrics collection
+	// DefaultNameSpace for the leader election lease when the namespace can't be detected
 	DefaultNameSpace = "karavi"
 )
 
@@ -74,6 +76,94 @@ type Config struct {
 	CollectorCertPath           string
 	Logger                      *logrus.Logger
 	TopologyMetricsEnabled      bool
+	// NodeName is set when the service runs on every node and only collects the local SDC and its volumes
+	NodeName string
+	// MetricsEndpoint and MetricsNamespace name the Leases used for leader election and sharding
+	MetricsEndpoint  string
+	MetricsNamespace string
+	// StorageSystems overrides the collection settings of some storage systems, keyed by lower case systemID
+	StorageSystems map[string]StorageSystemConfig
+}
+
+// StorageSystemConfig holds the collection settings of one storage system
+type StorageSystemConfig struct {
+	SDCTickInterval           time.Duration
+	VolumeTickInterval        time.Duration
+	StoragePoolTickInterval   time.Duration
+	SDCMetricsEnabled         bool
+	VolumeMetricsEnabled      bool
+	StoragePoolMetricsEnabled bool
+	// MaxPowerFlexConnections is the number of queries made to the storage system at a time, or 0 for the service default
+	MaxPowerFlexConnections int
+}
+
+// StorageSystem returns the collection settings of a storage system, which are the shared settings unless it has overrides
+func (c *Config) StorageSystem(storageSystemID string) StorageSystemConfig {
+	if storageSystem, ok := c.StorageSystems[strings.ToLower(storageSystemID)]; ok {
+		return storageSystem
+	}
+	return c.shared()
+}
+
+// shared returns the collection settings of the storage systems without overrides
+func (c *Config) shared() StorageSystemConfig {
+	return StorageSystemConfig{
+		SDCTickInterval:           c.SDCTickInterval,
+		VolumeTickInterval:        c.VolumeTickInterval,
+		StoragePoolTickInterval:   c.StoragePoolTickInterval,
+		SDCMetricsEnabled:         c.SDCMetricsEnabled,
+		VolumeMetricsEnabled:      c.VolumeMetricsEnabled,
+		StoragePoolMetricsEnabled: c.StoragePoolMetricsEnabled,
+	}
+}
+
+// metricsGroup selects the settings of one group of metrics from the storage system settings
+type metricsGroup func(StorageSystemConfig) (enabled bool, tickInterval time.Duration)
+
+func sdcGroup(c StorageSystemConfig) (bool, time.Duration) {
+	return c.SDCMetricsEnabled, c.SDCTickInterval
+}
+
+func volumeGroup(c StorageSystemConfig) (bool, time.Duration) {
+	return c.VolumeMetricsEnabled, c.VolumeTickInterval
+}
+
+func storagePoolGroup(c StorageSystemConfig) (bool, time.Duration) {
+	return c.StoragePoolMetricsEnabled, c.StoragePoolTickInterval
+}
+
+// enabled returns true if the group is collected for any storage system
+func (c *Config) enabled(group metricsGroup) bool {
+	enabled, _ := group(c.shared())
+	for _, storageSystem := range c.StorageSystems {
+		overrideEnabled, _ := group(storageSystem)
+		enabled = enabled || overrideEnabled
+	}
+	return enabled
+}
+
+// tickInterval returns the shortest interval of the group, so every storage system is collected when it is due
+func (c *Config) tickInterval(group metricsGroup) time.Duration {
+	_, interval := group(c.shared())
+	for _, storageSystem := range c.StorageSystems {
+		if _, overrideInterval := group(storageSystem); overrideInterval < interval {
+			interval = overrideInterval
+		}
+	}
+	return interval
+}
+
+// collectionSchedule remembers when each storage system was last collected for one group of metrics,
+// so a storage system with a longer interval than the ticker of its group is skipped until it is due
+type collectionSchedule map[string]time.Time
+
+func (s collectionSchedule) due(storageSystemID string, interval time.Duration, tickInterval time.Duration, now time.Time) bool {
+	// half a tick of slack keeps a storage system from slipping to the next tick because of timer jitter
+	if last, ok := s[storageSystemID]; ok && now.Add(tickInterval/2).Before(last.Add(interval)) {
+		return false
+	}
+	s[storageSystemID] = now
+	return true
 }
 
 // Run is the entry point for starting the service
@@ -86,11 +176,14 @@ func Run(ctx context.Context, config *Config, exporter otlexporters.Otlexporter,
 
 	errCh := make(chan error, 1)
 	go func() {
-		powerflexEndpoint := os.Getenv("POWERFLEX_METRICS_ENDPOINT")
+		powerflexEndpoint := config.MetricsEndpoint
 		if powerflexEndpoint == "" {
 			powerflexEndpoint = DefaultEndPoint
 		}
-		powerflexNamespace := os.Getenv("POWERFLEX_METRICS_NAMESPACE")
+		powerflexNamespace := config.MetricsNamespace
+		if powerflexNamespace == "" {
+			powerflexNamespace = k8s.Namespace()
+		}
 		if powerflexNamespace == "" {
 			powerflexNamespace = DefaultNameSpace
 		}
@@ -124,9 +217,12 @@ func Run(ctx context.Context, config *Config, exporter otlexporters.Otlexporter,
 	runtime.GOMAXPROCS(runtime.NumCPU())
 
 	// set initial tick intervals
-	SDCTickInterval := config.SDCTickInterval
-	VolumeTickInterval := config.VolumeTickInterval
-	StoragePoolTickInterval := config.StoragePoolTickInterval
+	SDCTickInterval := config.tickInterval(sdcGroup)
+	VolumeTickInterval := config.tickInterval(volumeGroup)
+	StoragePoolTickInterval := config.tickInterval(storagePoolGroup)
+	sdcSchedule := collectionSchedule{}
+	volumeSchedule := collectionSchedule{}
+	storagePoolSchedule := collectionSchedule{}
 	sdcTicker := time.NewTicker(SDCTickInterval)
 	volumeTicker := time.NewTicker(VolumeTickInterval)
 	storagePoolTicker := time.NewTicker(StoragePoolTickInterval)
@@ -139,7 +235,7 @@ func Run(ctx context.Context, config *Config, exporter otlexporters.Otlexporter,
 				logger.Info("not leader pod to collect metrics")
 				continue
 			}
-			if !config.SDCMetricsEnabled {
+			if !config.enabled(sdcGroup) {
 				logger.Info("powerflex SDC metrics collection is disabled")
 				continue
 			}
@@ -148,13 +244,26 @@ func Run(ctx context.Context, config *Config, exporter otlexporters.Otlexporter,
 
 			for key, client := range config.PowerFlexClient {
 				logger.WithField("storage system id", key).Debug("storage system id")
+				if !pflexServices.OwnsStorageSystem(config.LeaderElector, key) {
+					logger.WithField("storage_system_id", key).Debug("storage system owned by another replica, skipping")
+					continue
+				}
+				if !pflexServices.ClientAvailable(client) {
+					logger.WithField("storage_system_id", key).Debug("storage system unavailable, skipping")
+					continue
+				}
+				storageSystem := config.StorageSystem(key)
+				if !storageSystem.SDCMetricsEnabled || !sdcSchedule.due(key, storageSystem.SDCTickInterval, SDCTickInterval, time.Now()) {
+					continue
+				}
+				collectCtx := pflexServices.WithMaxPowerFlexConnections(ctx, storageSystem.MaxPowerFlexConnections)
 				sioConfig, ok := config.PowerFlexConfig[key]
 				if !ok {
 					logger.WithField("storage_system_id", key).Error("no configuration found for storage_system_id")
 					continue
 				}
 
-				sdcs, err := pflexSvc.GetSDCs(ctx, client, config.SDCFinder)
+				sdcs, err := pflexSvc.GetSDCs(collectCtx, client, config.SDCFinder)
 				if err != nil {
 					logger.WithError(err).WithField("endpoint", sioConfig.Endpoint).Error("getting SDCs")
 					continue
@@ -166,7 +275,7 @@ func Run(ctx context.Context, config *Config, exporter otlexporters.Otlexporter,
 					continue
 				}
 
-				pflexSvc.GetSDCStatistics(ctx, nodes, sdcs)
+				pflexSvc.GetSDCStatistics(collectCtx, nodes, sdcs)
 			}
 
 		case <-volumeTicker.C:
@@ -174,7 +283,7 @@ func Run(ctx context.Context, config *Config, exporter otlexporters.Otlexporter,
 				logger.Info("not leader pod to collect metrics")
 				continue
 			}
-			if !config.VolumeMetricsEnabled {
+			if !config.enabled(volumeGroup) {
 				logger.Info("powerflex volume metrics collection is disabled")
 				continue
 			}
@@ -183,23 +292,36 @@ func Run(ctx context.Context, config *Config, exporter otlexporters.Otlexporter,
 
 			for key, client := range config.PowerFlexClient {
 				logger.WithField("storage system id", key).Debug("storage system id")
+				if !pflexServices.OwnsStorageSystem(config.LeaderElector, key) {
+					logger.WithField("storage_system_id", key).Debug("storage system owned by another replica, skipping")
+					continue
+				}
+				if !pflexServices.ClientAvailable(client) {
+					logger.WithField("storage_system_id", key).Debug("storage system unavailable, skipping")
+					continue
+				}
+				storageSystem := config.StorageSystem(key)
+				if !storageSystem.VolumeMetricsEnabled || !volumeSchedule.due(key, storageSystem.VolumeTickInterval, VolumeTickInterval, time.Now()) {
+					continue
+				}
+				collectCtx := pflexServices.WithMaxPowerFlexConnections(ctx, storageSystem.MaxPowerFlexConnections)
 				sioConfig, ok := config.PowerFlexConfig[key]
 				if !ok {
 					logger.WithField("storage_system_id", key).Error("no configuration found for storage_system_id")
 					continue
 				}
-				sdcs, err := pflexSvc.GetSDCs(ctx, client, config.SDCFinder)
+				sdcs, err := pflexSvc.GetSDCs(collectCtx, client, config.SDCFinder)
 				if err != nil {
 					logger.WithError(err).WithField("endpoint", sioConfig.Endpoint).Error("getting SDCs")
 					continue
 				}
 
-				volumes, err := pflexSvc.GetVolumes(ctx, client, sdcs)
+				volumes, err := pflexSvc.GetVolumes(collectCtx, client, sdcs)
 				if err != nil {
 					logger.WithError(err).Error("getting volumes")
 					continue
 				}
-				pflexSvc.ExportVolumeStatistics(ctx, volumes, config.VolumeFinder)
+				pflexSvc.ExportVolumeStatistics(collectCtx, volumes, config.VolumeFinder)
 			}
 
 		case <-storagePoolTicker.C:
@@ -207,7 +329,7 @@ func Run(ctx context.Context, config *Config, exporter otlexporters.Otlexporter,
 				logger.Info("not leader pod to collect metrics")
 				continue
 			}
-			if !config.StoragePoolMetricsEnabled {
+			if !config.enabled(storagePoolGroup) {
 				logger.Info("powerflex storage pool metrics collection is disabled")
 				continue
 			}
@@ -216,6 +338,19 @@ func Run(ctx context.Context, config *Config, exporter otlexporters.Otlexporter,
 
 			for key, client := range config.PowerFlexClient {
 				logger.WithField("storage system id", key).Debug("storage system id")
+				if !pflexServices.OwnsStorageSystem(config.LeaderElector, key) {
+					logger.WithField("storage_system_id", key).Debug("storage system owned by another replica, skipping")
+					continue
+				}
+				if !pflexServices.ClientAvailable(client) {
+					logger.WithField("storage_system_id", key).Debug("storage system unavailable, skipping")
+					continue
+				}
+				storageSystem := config.StorageSystem(key)
+				if !storageSystem.StoragePoolMetricsEnabled || !storagePoolSchedule.due(key, storageSystem.StoragePoolTickInterval, StoragePoolTickInterval, time.Now()) {
+					continue
+				}
+				collectCtx := pflexServices.WithMaxPowerFlexConnections(ctx, storageSystem.MaxPowerFlexConnections)
 
 				sioConfig, ok := config.PowerFlexConfig[key]
 				if !ok {
@@ -223,14 +358,14 @@ func Run(ctx context.Context, config *Config, exporter otlexporters.Otlexporter,
 					continue
 				}
 
-				storageClassMetas, err := pflexSvc.GetStorageClasses(ctx, client, config.StorageClassFinder)
+				storageClassMetas, err := pflexSvc.GetStorageClasses(collectCtx, client, config.StorageClassFinder)
 				if err != nil {
 					logger.WithError(err).WithField("endpoint", sioConfig.Endpoint).Error("getting storage class and storage pool information")
 					continue
 				}
 
 				logger.WithField("storageClassMetas", storageClassMetas).Debug("storageClassMetas")
-				pflexSvc.GetStoragePoolStatistics(ctx, storageClassMetas)
+				pflexSvc.GetStoragePoolStatistics(collectCtx, storageClassMetas)
 			}
 
 		case <-topologyMetricsTicker.C:
@@ -242,6 +377,10 @@ func Run(ctx context.Context, config *Config, exporter otlexporters.Otlexporter,
 				logger.Info("powerflex topology metrics collection is disabled")
 				continue
 			}
+			if !pflexServices.OwnsStorageSystem(config.LeaderElector, k8s.ClusterShardKey) {
+				logger.Debug("topology metrics owned by another replica, skipping")
+				continue
+			}
 			pflexSvc.ExportTopologyMetrics(ctx)
 
 		case err := <-errCh:
@@ -254,16 +393,16 @@ func Run(ctx context.Context, config *Config, exporter otlexporters.Otlexporter,
 		}
 
 		// check if tick interval config settings have changed
-		if SDCTickInterval != config.SDCTickInterval {
-			SDCTickInterval = config.SDCTickInterval
+		if SDCTickInterval != config.tickInterval(sdcGroup) {
+			SDCTickInterval = config.tickInterval(sdcGroup)
 			sdcTicker = time.NewTicker(SDCTickInterval)
 		}
-		if VolumeTickInterval != config.VolumeTickInterval {
-			VolumeTickInterval = config.VolumeTickInterval
+		if VolumeTickInterval != config.tickInterval(volumeGroup) {
+			VolumeTickInterval = config.tickInterval(volumeGroup)
 			volumeTicker = time.NewTicker(VolumeTickInterval)
 		}
-		if StoragePoolTickInterval != config.StoragePoolTickInterval {
-			StoragePoolTickInterval = config.StoragePoolTickInterval
+		if StoragePoolTickInterval != config.tickInterval(storagePoolGroup) {
+			StoragePoolTickInterval = config.tickInterval(storagePoolGroup)
 			storagePoolTicker = time.NewTicker(StoragePoolTickInterval)
 		}
 		if TopologyMetricsTickInterval != config.TopologyMetricsTickInterval {
@@ -273,34 +412,54 @@ func Run(ctx context.Context, config *Config, exporter otlexporters.Otlexporter,
 	}
 }
 
-// ValidateConfig will validate the configuration and return any errors
+// ValidateConfig will validate the configuration and return every problem found
 func ValidateConfig(config *Config) error {
 	if config == nil {
 		return fmt.Errorf("no config provided")
 	}
 
+	var errs []error
 	if config.PowerFlexClient == nil {
-		return fmt.Errorf("no PowerFlexClient provided in config")
+		errs = append(errs, fmt.Errorf("no PowerFlexClient provided in config"))
 	}
 
 	if config.SDCFinder == nil {
-		return fmt.Errorf("no SDCFinder provided in config")
+		errs = append(errs, fmt.Errorf("no SDCFinder provided in config"))
 	}
 
 	if config.NodeFinder == nil {
-		return fmt.Errorf("no NodeFinder provided in config")
+		errs = append(errs, fmt.Errorf("no NodeFinder provided in config"))
 	}
 
 	if config.SDCTickInterval > MaximumSDCTickInterval || config.SDCTickInterval < MinimumSDCTickInterval {
-		return fmt.Errorf("SDC polling frequency not within allowed range of %v and %v", MinimumSDCTickInterval.String(), MaximumSDCTickInterval.String())
+		errs = append(errs, fmt.Errorf("SDC polling frequency not within allowed range of %v and %v", MinimumSDCTickInterval.String(), MaximumSDCTickInterval.String()))
 	}
 
 	if config.VolumeTickInterval > MaximumVolTickInterval || config.VolumeTickInterval < MinimumVolTickInterval {
-		return fmt.Errorf("volume polling frequency not within allowed range of %v and %v", MinimumVolTickInterval.String(), MaximumVolTickInterval.String())
+		errs = append(errs, fmt.Errorf("volume polling frequency not within allowed range of %v and %v", MinimumVolTickInterval.String(), MaximumVolTickInterval.String()))
+	}
+
+	if config.StoragePoolTickInterval > MaximumTickInterval || config.StoragePoolTickInterval < MinimumTickInterval {
+		errs = append(errs, fmt.Errorf("storage pool polling frequency not within allowed range of %v and %v", MinimumTickInterval.String(), MaximumTickInterval.String()))
 	}
 
 	if config.TopologyMetricsTickInterval > MaximumTickInterval || config.TopologyMetricsTickInterval < MinimumTickInterval {
-		return fmt.Errorf("topology metrics polling frequency not within allowed range of %v and %v", MinimumTickInterval.String(), MaximumTickInterval.String())
+		errs = append(errs, fmt.Errorf("topology metrics polling frequency not within allowed range of %v and %v", MinimumTickInterval.String(), MaximumTickInterval.String()))
+	}
+
+	for storageSystemID, storageSystem := range config.StorageSystems {
+		for _, interval := range []struct {
+			name     string
+			interval time.Duration
+		}{
+			{"SDC", storageSystem.SDCTickInterval},
+			{"volume", storageSystem.VolumeTickInterval},
+			{"storage pool", storageSystem.StoragePoolTickInterval},
+		} {
+			if interval.interval > MaximumTickInterval || interval.interval < MinimumTickInterval {
+				errs = append(errs, fmt.Errorf("%s polling frequency of storage system %s not within allowed range of %v and %v", interval.name, storageSystemID, MinimumTickInterval.String(), MaximumTickInterval.String()))
+			}
+		}
 	}
-	return nil
+	return errors.Join(errs...)
 }
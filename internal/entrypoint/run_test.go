@@ -20,6 +20,7 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"strings"
 	"testing"
 	"time"
 
@@ -929,6 +930,7 @@ func Test_ValidateConfig_Success(t *testing.T) {
 	config := &entrypoint.Config{
 		SDCTickInterval:             entrypoint.MinimumSDCTickInterval,
 		VolumeTickInterval:          entrypoint.MinimumVolTickInterval,
+		StoragePoolTickInterval:     entrypoint.MinimumTickInterval,
 		TopologyMetricsTickInterval: entrypoint.MinimumTickInterval,
 		PowerFlexClient:             map[string]pflexServices.PowerFlexClient{"k": nil},
 		SDCFinder:                   metricsmocks.NewMockSDCFinder(ctrl),
@@ -941,6 +943,39 @@ func Test_ValidateConfig_Success(t *testing.T) {
 	}
 }
 
+func Test_ValidateConfig_ReportsEveryProblem(t *testing.T) {
+	config := &entrypoint.Config{
+		SDCTickInterval:             entrypoint.MinimumSDCTickInterval,
+		VolumeTickInterval:          entrypoint.MinimumVolTickInterval,
+		StoragePoolTickInterval:     entrypoint.MaximumTickInterval + time.Second,
+		TopologyMetricsTickInterval: entrypoint.MinimumTickInterval - time.Second,
+		StorageSystems: map[string]entrypoint.StorageSystemConfig{
+			"lab": {
+				SDCTickInterval:         entrypoint.MinimumSDCTickInterval,
+				VolumeTickInterval:      entrypoint.MinimumVolTickInterval - time.Second,
+				StoragePoolTickInterval: entrypoint.MinimumTickInterval,
+			},
+		},
+	}
+
+	err := entrypoint.ValidateConfig(config)
+	if err == nil {
+		t.Fatalf("expected error for invalid config, got nil")
+	}
+	for _, problem := range []string{
+		"no PowerFlexClient provided in config",
+		"no SDCFinder provided in config",
+		"no NodeFinder provided in config",
+		"storage pool polling frequency not within allowed range",
+		"topology metrics polling frequency not within allowed range",
+		"volume polling frequency of storage system lab not within allowed range",
+	} {
+		if !strings.Contains(err.Error(), problem) {
+			t.Errorf("expected error to contain %q, got %v", problem, err)
+		}
+	}
+}
+
 func Test_Run_TopologyDisabledWhenLeader(t *testing.T) {
 	ctrl := gomock.NewController(t)
 	defer ctrl.Finish()
@@ -1085,7 +1120,7 @@ func Test_Run_TickIntervalChange(t *testing.T) {
 	}
 }
 
-func Test_Run_EnvVarOverrides(t *testing.T) {
+func Test_Run_MetricsEndpointOverrides(t *testing.T) {
 	ctrl := gomock.NewController(t)
 	defer ctrl.Finish()
 
@@ -1114,14 +1149,71 @@ func Test_Run_EnvVarOverrides(t *testing.T) {
 		SDCFinder:                   metricsmocks.NewMockSDCFinder(ctrl),
 		NodeFinder:                  metricsmocks.NewMockNodeFinder(ctrl),
 		Logger:                      logrus.New(),
+		MetricsEndpoint:             "custom-endpoint",
+		MetricsNamespace:            "custom-namespace",
 	}
 
 	prev := entrypoint.ConfigValidatorFunc
 	entrypoint.ConfigValidatorFunc = noCheckConfig
 	defer func() { entrypoint.ConfigValidatorFunc = prev }()
 
-	t.Setenv("POWERFLEX_METRICS_ENDPOINT", "custom-endpoint")
-	t.Setenv("POWERFLEX_METRICS_NAMESPACE", "custom-namespace")
+	ctx, cancel := context.WithTimeout(context.Background(), 200*time.Millisecond)
+	defer cancel()
+
+	err := entrypoint.Run(ctx, config, exporter, svc)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+}
+
+func Test_Run_SkipsArrayWithOpenCircuit(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	pfClient := metricsmocks.NewMockPowerFlexClient(ctrl)
+	pfClient.EXPECT().GetInstance("").Return(nil, errors.New("connection refused")).Times(1)
+	breaker := &pflexServices.CircuitBreakerClient{
+		PowerFlexClient:  pfClient,
+		StorageSystemID:  "key",
+		FailureThreshold: 1,
+		InitialBackoff:   time.Hour,
+		Logger:           logrus.New(),
+	}
+	// trip the breaker so the array is skipped by every collection group
+	_, _ = breaker.GetInstance("")
+
+	leaderElector := metricsmocks.NewMockLeaderElector(ctrl)
+	leaderElector.EXPECT().InitLeaderElection(gomock.Any(), gomock.Any()).Return(nil)
+	leaderElector.EXPECT().IsLeader().AnyTimes().Return(true)
+
+	svc := metricsmocks.NewMockService(ctrl)
+	svc.EXPECT().GetSDCs(gomock.Any(), gomock.Any(), gomock.Any()).Times(0)
+	svc.EXPECT().GetStorageClasses(gomock.Any(), gomock.Any(), gomock.Any()).Times(0)
+
+	exporter := exportermocks.NewMockOtlexporter(ctrl)
+	exporter.EXPECT().InitExporter(gomock.Any(), gomock.Any()).Return(nil)
+	exporter.EXPECT().StopExporter().Return(nil)
+
+	config := &entrypoint.Config{
+		LeaderElector:               leaderElector,
+		SDCMetricsEnabled:           true,
+		VolumeMetricsEnabled:        true,
+		StoragePoolMetricsEnabled:   true,
+		TopologyMetricsEnabled:      false,
+		SDCTickInterval:             50 * time.Millisecond,
+		VolumeTickInterval:          50 * time.Millisecond,
+		StoragePoolTickInterval:     50 * time.Millisecond,
+		TopologyMetricsTickInterval: 100 * time.Millisecond,
+		PowerFlexClient:             map[string]pflexServices.PowerFlexClient{"key": breaker},
+		PowerFlexConfig:             map[string]sio.ConfigConnect{"key": {}},
+		SDCFinder:                   metricsmocks.NewMockSDCFinder(ctrl),
+		NodeFinder:                  metricsmocks.NewMockNodeFinder(ctrl),
+		Logger:                      logrus.New(),
+	}
+
+	prev := entrypoint.ConfigValidatorFunc
+	entrypoint.ConfigValidatorFunc = noCheckConfig
+	defer func() { entrypoint.ConfigValidatorFunc = prev }()
 
 	ctx, cancel := context.WithTimeout(context.Background(), 200*time.Millisecond)
 	defer cancel()
@@ -1130,4 +1222,116 @@ func Test_Run_EnvVarOverrides(t *testing.T) {
 	if err != nil {
 		t.Fatalf("expected no error, got %v", err)
 	}
+	if breaker.State() != pflexServices.CircuitOpen {
+		t.Fatalf("expected circuit to stay open, got %v", breaker.State())
+	}
+}
+
+func Test_Run_StorageSystemOverrides(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	production := metricsmocks.NewMockPowerFlexClient(ctrl)
+	lab := metricsmocks.NewMockPowerFlexClient(ctrl)
+
+	leaderElector := metricsmocks.NewMockLeaderElector(ctrl)
+	leaderElector.EXPECT().InitLeaderElection(gomock.Any(), gomock.Any()).Return(nil)
+	leaderElector.EXPECT().IsLeader().AnyTimes().Return(true)
+
+	nodeFinder := metricsmocks.NewMockNodeFinder(ctrl)
+	nodeFinder.EXPECT().GetNodes().AnyTimes().Return(nil, nil)
+
+	// the mocks are deeply equal, so match the clients by identity
+	is := func(client pflexServices.PowerFlexClient) gomock.Matcher {
+		return gomock.Cond(func(x pflexServices.PowerFlexClient) bool { return x == client })
+	}
+
+	svc := metricsmocks.NewMockService(ctrl)
+	// the production array is collected on every tick, for SDC and volume metrics
+	svc.EXPECT().GetSDCs(gomock.Any(), is(production), gomock.Any()).MinTimes(4).Return(nil, nil)
+	svc.EXPECT().GetVolumes(gomock.Any(), is(production), gomock.Any()).MinTimes(2).Return(nil, nil)
+	svc.EXPECT().ExportVolumeStatistics(gomock.Any(), gomock.Any(), gomock.Any()).MinTimes(2)
+	// the lab array only has SDC metrics, collected once a minute
+	svc.EXPECT().GetSDCs(gomock.Any(), is(lab), gomock.Any()).Times(1).Return(nil, nil)
+	svc.EXPECT().GetSDCStatistics(gomock.Any(), gomock.Any(), gomock.Any()).MinTimes(3)
+	svc.EXPECT().GetStorageClasses(gomock.Any(), gomock.Any(), gomock.Any()).Times(0)
+
+	exporter := exportermocks.NewMockOtlexporter(ctrl)
+	exporter.EXPECT().InitExporter(gomock.Any(), gomock.Any()).Return(nil)
+	exporter.EXPECT().StopExporter().Return(nil)
+
+	config := &entrypoint.Config{
+		LeaderElector:               leaderElector,
+		SDCMetricsEnabled:           false,
+		VolumeMetricsEnabled:        true,
+		StoragePoolMetricsEnabled:   false,
+		TopologyMetricsEnabled:      false,
+		SDCTickInterval:             time.Minute,
+		VolumeTickInterval:          time.Minute,
+		StoragePoolTickInterval:     50 * time.Millisecond,
+		TopologyMetricsTickInterval: time.Minute,
+		PowerFlexClient:             map[string]pflexServices.PowerFlexClient{"production": production, "lab": lab},
+		PowerFlexConfig:             map[string]sio.ConfigConnect{"production": {}, "lab": {}},
+		SDCFinder:                   metricsmocks.NewMockSDCFinder(ctrl),
+		NodeFinder:                  nodeFinder,
+		Logger:                      logrus.New(),
+		StorageSystems: map[string]entrypoint.StorageSystemConfig{
+			"production": {
+				SDCTickInterval:         50 * time.Millisecond,
+				VolumeTickInterval:      50 * time.Millisecond,
+				StoragePoolTickInterval: 50 * time.Millisecond,
+				SDCMetricsEnabled:       true,
+				VolumeMetricsEnabled:    true,
+				MaxPowerFlexConnections: 20,
+			},
+			"lab": {
+				SDCTickInterval:         time.Minute,
+				VolumeTickInterval:      time.Minute,
+				StoragePoolTickInterval: time.Minute,
+				SDCMetricsEnabled:       true,
+				MaxPowerFlexConnections: 1,
+			},
+		},
+	}
+
+	prev := entrypoint.ConfigValidatorFunc
+	entrypoint.ConfigValidatorFunc = noCheckConfig
+	defer func() { entrypoint.ConfigValidatorFunc = prev }()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 300*time.Millisecond)
+	defer cancel()
+
+	err := entrypoint.Run(ctx, config, exporter, svc)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+}
+
+func Test_Config_StorageSystem(t *testing.T) {
+	config := &entrypoint.Config{
+		SDCTickInterval:         10 * time.Second,
+		VolumeTickInterval:      20 * time.Second,
+		StoragePoolTickInterval: 30 * time.Second,
+		SDCMetricsEnabled:       true,
+		VolumeMetricsEnabled:    true,
+		StorageSystems: map[string]entrypoint.StorageSystemConfig{
+			"lab": {VolumeTickInterval: time.Minute, MaxPowerFlexConnections: 2},
+		},
+	}
+
+	lab := entrypoint.StorageSystemConfig{VolumeTickInterval: time.Minute, MaxPowerFlexConnections: 2}
+	if got := config.StorageSystem("LAB"); got != lab {
+		t.Errorf("expected the override %+v, got %+v", lab, got)
+	}
+
+	shared := entrypoint.StorageSystemConfig{
+		SDCTickInterval:         10 * time.Second,
+		VolumeTickInterval:      20 * time.Second,
+		StoragePoolTickInterval: 30 * time.Second,
+		SDCMetricsEnabled:       true,
+		VolumeMetricsEnabled:    true,
+	}
+	if got := config.StorageSystem("production"); got != shared {
+		t.Errorf("expected the shared settings %+v, got %+v", shared, got)
+	}
 }
@@ -0,0 +1,112 @@
+/*
+ Copyright (c) 2025 Dell Inc. or its subsidiaries. All Rights Reserved.
+
+ Licensed under the Apache License, Version 2.0 (the "License");
+ you may not use this file except in compliance with the License.
+ You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package k8s
+
+import (
+	"os"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/util/uuid"
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/tools/clientcmd"
+)
+
+// serviceAccountNamespaceFile holds the namespace of the pod when running in the cluster
+const serviceAccountNamespaceFile = "/var/run/secrets/kubernetes.io/serviceaccount/namespace"
+
+// ClientConfigOptions selects the cluster that kubernetes clients connect to
+type ClientConfigOptions struct {
+	// Kubeconfig is the path to a kubeconfig file. The KUBECONFIG environment variable is used when empty.
+	Kubeconfig string
+	// Context is the kubeconfig context to use instead of the current context
+	Context string
+}
+
+// ClientConfig is used by every kubernetes client built in this package, including the leader election client.
+// When neither it nor KUBECONFIG select a kubeconfig, the in-cluster configuration is used.
+var ClientConfig ClientConfigOptions
+
+// KubeconfigFn will return a configuration loaded from a kubeconfig file
+var KubeconfigFn = func(options ClientConfigOptions) (*rest.Config, error) {
+	return kubeconfigLoader(options).ClientConfig()
+}
+
+// UsesKubeconfig returns true if clients are configured from a kubeconfig rather than the in-cluster configuration
+func UsesKubeconfig() bool {
+	return ClientConfig.Kubeconfig != "" || ClientConfig.Context != "" || os.Getenv(clientcmd.RecommendedConfigPathEnvVar) != ""
+}
+
+// Namespace returns the namespace of the kubeconfig context in use, or of the pod when running in the cluster.
+// It returns an empty string if the namespace can't be determined.
+func Namespace() string {
+	if UsesKubeconfig() {
+		rawConfig, err := kubeconfigLoader(ClientConfig).RawConfig()
+		if err != nil {
+			return ""
+		}
+		contextName := rawConfig.CurrentContext
+		if ClientConfig.Context != "" {
+			contextName = ClientConfig.Context
+		}
+		if context, ok := rawConfig.Contexts[contextName]; ok {
+			return context.Namespace
+		}
+		return ""
+	}
+
+	namespace, err := os.ReadFile(serviceAccountNamespaceFile)
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(string(namespace))
+}
+
+// LeaderElectionIdentity returns the identity used to hold the leader election lease.
+// In a pod HOSTNAME is the unique pod name. Elsewhere the host name may be shared by several
+// processes, e.g. on a workstation, so a random suffix keeps the identity unique.
+func LeaderElectionIdentity() string {
+	if podName := os.Getenv("POD_NAME"); podName != "" {
+		return podName
+	}
+	if hostname := os.Getenv("HOSTNAME"); hostname != "" && !UsesKubeconfig() {
+		return hostname
+	}
+
+	hostname, err := os.Hostname()
+	if err != nil || hostname == "" {
+		hostname = "karavi-metrics-powerflex"
+	}
+	return hostname + "_" + string(uuid.NewUUID())
+}
+
+func getConfig() (*rest.Config, error) {
+	if UsesKubeconfig() {
+		return KubeconfigFn(ClientConfig)
+	}
+	config, err := InClusterConfigFn()
+	if err != nil {
+		return nil, err
+	}
+	return config, nil
+}
+
+func kubeconfigLoader(options ClientConfigOptions) clientcmd.ClientConfig {
+	rules := clientcmd.NewDefaultClientConfigLoadingRules()
+	rules.ExplicitPath = options.Kubeconfig
+	overrides := &clientcmd.ConfigOverrides{CurrentContext: options.Context}
+	return clientcmd.NewNonInteractiveDeferredLoadingClientConfig(rules, overrides)
+}
@@ -0,0 +1,172 @@
+/*
+ Copyright (c) 2025 Dell Inc. or its subsidiaries. All Rights Reserved.
+
+ Licensed under the Apache License, Version 2.0 (the "License");
+ you may not use this file except in compliance with the License.
+ You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package k8s_test
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/dell/karavi-metrics-powerflex/internal/k8s"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/rest"
+)
+
+const testKubeconfig = `apiVersion: v1
+kind: Config
+current-context: local
+clusters:
+- name: local
+  cluster:
+    server: https://127.0.0.1:6443
+- name: remote
+  cluster:
+    server: https://remote.example.com:6443
+contexts:
+- name: local
+  context:
+    cluster: local
+    user: admin
+- name: remote
+  context:
+    cluster: remote
+    user: admin
+    namespace: monitoring
+users:
+- name: admin
+  user:
+    token: secret
+`
+
+func writeTestKubeconfig(t *testing.T) string {
+	path := filepath.Join(t.TempDir(), "kubeconfig")
+	require.NoError(t, os.WriteFile(path, []byte(testKubeconfig), 0o600))
+	return path
+}
+
+func setClientConfig(t *testing.T, options k8s.ClientConfigOptions) {
+	old := k8s.ClientConfig
+	t.Cleanup(func() { k8s.ClientConfig = old })
+	k8s.ClientConfig = options
+}
+
+func Test_KubeconfigFn(t *testing.T) {
+	path := writeTestKubeconfig(t)
+
+	config, err := k8s.KubeconfigFn(k8s.ClientConfigOptions{Kubeconfig: path})
+	require.NoError(t, err)
+	assert.Equal(t, "https://127.0.0.1:6443", config.Host)
+
+	config, err = k8s.KubeconfigFn(k8s.ClientConfigOptions{Kubeconfig: path, Context: "remote"})
+	require.NoError(t, err)
+	assert.Equal(t, "https://remote.example.com:6443", config.Host)
+
+	_, err = k8s.KubeconfigFn(k8s.ClientConfigOptions{Kubeconfig: path, Context: "missing"})
+	assert.Error(t, err)
+}
+
+func Test_UsesKubeconfig(t *testing.T) {
+	t.Setenv("KUBECONFIG", "")
+	setClientConfig(t, k8s.ClientConfigOptions{})
+	assert.False(t, k8s.UsesKubeconfig())
+
+	t.Setenv("KUBECONFIG", "/tmp/kubeconfig")
+	assert.True(t, k8s.UsesKubeconfig())
+
+	t.Setenv("KUBECONFIG", "")
+	k8s.ClientConfig.Context = "remote"
+	assert.True(t, k8s.UsesKubeconfig())
+}
+
+func Test_Namespace(t *testing.T) {
+	t.Setenv("KUBECONFIG", "")
+	path := writeTestKubeconfig(t)
+
+	setClientConfig(t, k8s.ClientConfigOptions{Kubeconfig: path, Context: "remote"})
+	assert.Equal(t, "monitoring", k8s.Namespace())
+
+	k8s.ClientConfig.Context = ""
+	assert.Equal(t, "", k8s.Namespace(), "the current context has no namespace")
+
+	k8s.ClientConfig.Kubeconfig = filepath.Join(t.TempDir(), "missing")
+	assert.Equal(t, "", k8s.Namespace())
+}
+
+func Test_LeaderElectionIdentity(t *testing.T) {
+	t.Setenv("KUBECONFIG", "")
+	setClientConfig(t, k8s.ClientConfigOptions{})
+
+	t.Setenv("POD_NAME", "metrics-powerflex-abc")
+	t.Setenv("HOSTNAME", "ignored")
+	assert.Equal(t, "metrics-powerflex-abc", k8s.LeaderElectionIdentity())
+
+	t.Setenv("POD_NAME", "")
+	assert.Equal(t, "ignored", k8s.LeaderElectionIdentity())
+
+	// outside the cluster the host name isn't unique, so each process gets its own identity
+	k8s.ClientConfig.Kubeconfig = "/tmp/kubeconfig"
+	first := k8s.LeaderElectionIdentity()
+	second := k8s.LeaderElectionIdentity()
+	assert.NotEqual(t, first, second)
+	hostname, _ := os.Hostname()
+	assert.True(t, strings.HasPrefix(first, hostname+"_"))
+}
+
+func Test_ClientsUseKubeconfig(t *testing.T) {
+	t.Setenv("KUBECONFIG", "")
+	path := writeTestKubeconfig(t)
+	setClientConfig(t, k8s.ClientConfigOptions{Kubeconfig: path, Context: "remote"})
+
+	oldInClusterConfig := k8s.InClusterConfigFn
+	defer func() { k8s.InClusterConfigFn = oldInClusterConfig }()
+	k8s.InClusterConfigFn = func() (*rest.Config, error) {
+		t.Fatalf("in-cluster config should not be used with a kubeconfig")
+		return nil, nil
+	}
+
+	t.Run("api client", func(t *testing.T) {
+		oldNewConfigFn := k8s.NewConfigFn
+		defer func() { k8s.NewConfigFn = oldNewConfigFn }()
+		var host string
+		k8s.NewConfigFn = func(config *rest.Config) (*kubernetes.Clientset, error) {
+			host = config.Host
+			return nil, errors.New("stop")
+		}
+
+		_, err := (&k8s.API{}).GetNodes()
+		assert.Error(t, err)
+		assert.Equal(t, "https://remote.example.com:6443", host)
+	})
+
+	t.Run("leader election client", func(t *testing.T) {
+		oldNewForConfigFn := k8s.NewForConfigFn
+		defer func() { k8s.NewForConfigFn = oldNewForConfigFn }()
+		var host string
+		k8s.NewForConfigFn = func(config *rest.Config) (*kubernetes.Clientset, error) {
+			host = config.Host
+			return nil, errors.New("stop")
+		}
+
+		err := (&k8s.LeaderElector{}).InitLeaderElection("karavi-metrics-powerflex", "karavi")
+		assert.Error(t, err)
+		assert.Equal(t, "https://remote.example.com:6443", host)
+	})
+}
@@ -18,73 +18,406 @@ package k8s
 
 import (
 	"context"
+	"errors"
+	"sort"
 	"sync"
+	"time"
 
+	appsv1 "k8s.io/api/apps/v1"
 	corev1 "k8s.io/api/core/v1"
 	v1 "k8s.io/api/storage/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/fields"
+	"k8s.io/apimachinery/pkg/labels"
 
+	"k8s.io/client-go/informers"
+	coreinformers "k8s.io/client-go/informers/core/v1"
+	storageinformers "k8s.io/client-go/informers/storage/v1"
 	"k8s.io/client-go/kubernetes"
+	appslisters "k8s.io/client-go/listers/apps/v1"
+	corelisters "k8s.io/client-go/listers/core/v1"
+	storagelisters "k8s.io/client-go/listers/storage/v1"
 	"k8s.io/client-go/rest"
+	"k8s.io/client-go/tools/cache"
 )
 
-// API holds data used to access the K8S API
+// CacheSyncTimeout is how long a getter waits for the informer caches to fill when the informers were not started in advance
+var CacheSyncTimeout = 2 * time.Minute
+
+// API holds data used to access the K8S API.
+// CSI nodes, persistent volumes, storage classes and nodes are served from shared informer caches
+// that are kept in sync through watches, instead of listing them from the API server on every call.
 type API struct {
 	Client kubernetes.Interface
 	Lock   sync.Mutex
+	// ResyncPeriod is how often the informers replay their caches, zero disables resyncs
+	ResyncPeriod time.Duration
+	// NodeName scopes the nodes, CSINodes and pods to the one node, for pods that only collect their own node's SDC.
+	// It must be set before the informers are started.
+	NodeName string
+
+	started           bool
+	stop              <-chan struct{}
+	factory           informers.SharedInformerFactory
+	synced            []cache.InformerSynced
+	csiNodes          storagelisters.CSINodeLister
+	persistentVolumes corelisters.PersistentVolumeLister
+	storageClasses    storagelisters.StorageClassLister
+	nodes             corelisters.NodeLister
+
+	// namespaces and claims are only watched once they are needed, by the selectors of a VolumeFilter
+	namespaces       corelisters.NamespaceLister
+	namespacesSynced cache.InformerSynced
+	claims           corelisters.PersistentVolumeClaimLister
+	claimsSynced     cache.InformerSynced
+
+	// pods and replica sets are only watched once the consumers of the volumes are resolved
+	pods              corelisters.PodLister
+	podsSynced        cache.InformerSynced
+	replicaSets       appslisters.ReplicaSetLister
+	replicaSetsSynced cache.InformerSynced
 }
 
-// GetCSINodes will return a list of CSI nodes in the kubernetes cluster
-func (api *API) GetCSINodes() (*v1.CSINodeList, error) {
+// Start connects to the kubernetes API and starts the shared informers, which stop when ctx is done.
+// Calling Start again is a no-op.
+func (api *API) Start(ctx context.Context) error {
 	api.Lock.Lock()
 	defer api.Lock.Unlock()
+	return api.start(ctx)
+}
+
+func (api *API) start(ctx context.Context) error {
+	if api.started {
+		return nil
+	}
 	if api.Client == nil {
 		err := ConnectFn(api)
 		if err != nil {
-			return nil, err
+			return err
+		}
+	}
+
+	factory := informers.NewSharedInformerFactory(api.Client, api.ResyncPeriod)
+	if api.NodeName != "" {
+		api.scopeToNode(factory)
+	}
+	csiNodes := factory.Storage().V1().CSINodes()
+	persistentVolumes := factory.Core().V1().PersistentVolumes()
+	storageClasses := factory.Storage().V1().StorageClasses()
+	nodes := factory.Core().V1().Nodes()
+
+	api.csiNodes = csiNodes.Lister()
+	api.persistentVolumes = persistentVolumes.Lister()
+	api.storageClasses = storageClasses.Lister()
+	api.nodes = nodes.Lister()
+	api.synced = []cache.InformerSynced{
+		csiNodes.Informer().HasSynced,
+		persistentVolumes.Informer().HasSynced,
+		storageClasses.Informer().HasSynced,
+		nodes.Informer().HasSynced,
+	}
+
+	factory.Start(ctx.Done())
+	api.factory = factory
+	api.stop = ctx.Done()
+	api.started = true
+	return nil
+}
+
+// scopeToNode registers informers that only watch the node named NodeName, its CSINode and the pods running on it.
+// The factory hands them out in place of its cluster wide informers of the same types.
+func (api *API) scopeToNode(factory informers.SharedInformerFactory) {
+	indexers := cache.Indexers{cache.NamespaceIndex: cache.MetaNamespaceIndexFunc}
+	byName := func(options *metav1.ListOptions) {
+		options.FieldSelector = fields.OneTermEqualSelector("metadata.name", api.NodeName).String()
+	}
+	onNode := func(options *metav1.ListOptions) {
+		options.FieldSelector = fields.OneTermEqualSelector("spec.nodeName", api.NodeName).String()
+	}
+
+	factory.InformerFor(&corev1.Node{}, func(client kubernetes.Interface, resync time.Duration) cache.SharedIndexInformer {
+		return coreinformers.NewFilteredNodeInformer(client, resync, indexers, byName)
+	})
+	factory.InformerFor(&v1.CSINode{}, func(client kubernetes.Interface, resync time.Duration) cache.SharedIndexInformer {
+		return storageinformers.NewFilteredCSINodeInformer(client, resync, indexers, byName)
+	})
+	factory.InformerFor(&corev1.Pod{}, func(client kubernetes.Interface, resync time.Duration) cache.SharedIndexInformer {
+		return coreinformers.NewFilteredPodInformer(client, metav1.NamespaceAll, resync, indexers, onNode)
+	})
+}
+
+// HasSynced returns true once every informer has completed its initial list
+func (api *API) HasSynced() bool {
+	api.Lock.Lock()
+	synced := api.synced
+	api.Lock.Unlock()
+
+	if len(synced) == 0 {
+		return false
+	}
+	for _, hasSynced := range synced {
+		if !hasSynced() {
+			return false
 		}
 	}
-	return api.Client.StorageV1().CSINodes().List(context.Background(), metav1.ListOptions{})
+	return true
+}
+
+// WaitForCacheSync blocks until the informer caches are filled and returns false if ctx is done first
+func (api *API) WaitForCacheSync(ctx context.Context) bool {
+	api.Lock.Lock()
+	synced := api.synced
+	api.Lock.Unlock()
+
+	if len(synced) == 0 {
+		return false
+	}
+	return cache.WaitForCacheSync(ctx.Done(), synced...)
+}
+
+// ready starts the informers if needed and waits for their initial sync
+func (api *API) ready() error {
+	err := api.Start(context.Background())
+	if err != nil {
+		return err
+	}
+	if api.HasSynced() {
+		return nil
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), CacheSyncTimeout)
+	defer cancel()
+	if !api.WaitForCacheSync(ctx) {
+		return errors.New("timed out waiting for kubernetes informer caches to sync")
+	}
+	return nil
+}
+
+// GetCSINodes will return a list of CSI nodes in the kubernetes cluster
+func (api *API) GetCSINodes() (*v1.CSINodeList, error) {
+	if err := api.ready(); err != nil {
+		return nil, err
+	}
+	nodes, err := api.csiNodes.List(labels.Everything())
+	if err != nil {
+		return nil, err
+	}
+
+	list := &v1.CSINodeList{Items: make([]v1.CSINode, 0, len(nodes))}
+	for _, node := range nodes {
+		list.Items = append(list.Items, *node)
+	}
+	sort.Slice(list.Items, func(i, j int) bool { return list.Items[i].Name < list.Items[j].Name })
+	return list, nil
 }
 
 // GetPersistentVolumes will return a list of persistent volumes in the kubernetes cluster
 func (api *API) GetPersistentVolumes() (*corev1.PersistentVolumeList, error) {
+	if err := api.ready(); err != nil {
+		return nil, err
+	}
+	volumes, err := api.persistentVolumes.List(labels.Everything())
+	if err != nil {
+		return nil, err
+	}
+
+	list := &corev1.PersistentVolumeList{Items: make([]corev1.PersistentVolume, 0, len(volumes))}
+	for _, volume := range volumes {
+		list.Items = append(list.Items, *volume)
+	}
+	sort.Slice(list.Items, func(i, j int) bool { return list.Items[i].Name < list.Items[j].Name })
+	return list, nil
+}
+
+// GetNamespaces will return the list of namespaces in the kubernetes cluster.
+// The namespaces are watched from the first call on.
+func (api *API) GetNamespaces() (*corev1.NamespaceList, error) {
 	api.Lock.Lock()
-	defer api.Lock.Unlock()
-	if api.Client == nil {
-		err := ConnectFn(api)
-		if err != nil {
-			return nil, err
+	err := api.start(context.Background())
+	if err == nil && api.namespaces == nil {
+		namespaces := api.factory.Core().V1().Namespaces()
+		api.namespaces = namespaces.Lister()
+		api.namespacesSynced = namespaces.Informer().HasSynced
+		api.factory.Start(api.stop)
+	}
+	lister, synced := api.namespaces, api.namespacesSynced
+	api.Lock.Unlock()
+	if err != nil {
+		return nil, err
+	}
+	if err := waitForSync(synced); err != nil {
+		return nil, err
+	}
+
+	namespaces, err := lister.List(labels.Everything())
+	if err != nil {
+		return nil, err
+	}
+	list := &corev1.NamespaceList{Items: make([]corev1.Namespace, 0, len(namespaces))}
+	for _, namespace := range namespaces {
+		list.Items = append(list.Items, *namespace)
+	}
+	sort.Slice(list.Items, func(i, j int) bool { return list.Items[i].Name < list.Items[j].Name })
+	return list, nil
+}
+
+// GetPersistentVolumeClaims will return the list of persistent volume claims in the kubernetes cluster.
+// The claims are watched from the first call on.
+func (api *API) GetPersistentVolumeClaims() (*corev1.PersistentVolumeClaimList, error) {
+	api.Lock.Lock()
+	err := api.start(context.Background())
+	if err == nil && api.claims == nil {
+		claims := api.factory.Core().V1().PersistentVolumeClaims()
+		api.claims = claims.Lister()
+		api.claimsSynced = claims.Informer().HasSynced
+		api.factory.Start(api.stop)
+	}
+	lister, synced := api.claims, api.claimsSynced
+	api.Lock.Unlock()
+	if err != nil {
+		return nil, err
+	}
+	if err := waitForSync(synced); err != nil {
+		return nil, err
+	}
+
+	claims, err := lister.List(labels.Everything())
+	if err != nil {
+		return nil, err
+	}
+	list := &corev1.PersistentVolumeClaimList{Items: make([]corev1.PersistentVolumeClaim, 0, len(claims))}
+	for _, claim := range claims {
+		list.Items = append(list.Items, *claim)
+	}
+	sort.Slice(list.Items, func(i, j int) bool {
+		if list.Items[i].Namespace != list.Items[j].Namespace {
+			return list.Items[i].Namespace < list.Items[j].Namespace
 		}
+		return list.Items[i].Name < list.Items[j].Name
+	})
+	return list, nil
+}
+
+// GetPods will return the list of pods in the kubernetes cluster.
+// The pods are watched from the first call on.
+func (api *API) GetPods() (*corev1.PodList, error) {
+	api.Lock.Lock()
+	err := api.start(context.Background())
+	if err == nil && api.pods == nil {
+		pods := api.factory.Core().V1().Pods()
+		api.pods = pods.Lister()
+		api.podsSynced = pods.Informer().HasSynced
+		api.factory.Start(api.stop)
+	}
+	lister, synced := api.pods, api.podsSynced
+	api.Lock.Unlock()
+	if err != nil {
+		return nil, err
+	}
+	if err := waitForSync(synced); err != nil {
+		return nil, err
+	}
+
+	pods, err := lister.List(labels.Everything())
+	if err != nil {
+		return nil, err
+	}
+	list := &corev1.PodList{Items: make([]corev1.Pod, 0, len(pods))}
+	for _, pod := range pods {
+		list.Items = append(list.Items, *pod)
 	}
-	return api.Client.CoreV1().PersistentVolumes().List(context.Background(), metav1.ListOptions{})
+	sort.Slice(list.Items, func(i, j int) bool {
+		if list.Items[i].Namespace != list.Items[j].Namespace {
+			return list.Items[i].Namespace < list.Items[j].Namespace
+		}
+		return list.Items[i].Name < list.Items[j].Name
+	})
+	return list, nil
 }
 
-// GetStorageClasses will return a list of storage classes in the kubernetes clusteer
-func (api *API) GetStorageClasses() (*v1.StorageClassList, error) {
+// GetReplicaSets will return the list of replica sets in the kubernetes cluster.
+// The replica sets are watched from the first call on.
+func (api *API) GetReplicaSets() (*appsv1.ReplicaSetList, error) {
 	api.Lock.Lock()
-	defer api.Lock.Unlock()
-	if api.Client == nil {
-		err := ConnectFn(api)
-		if err != nil {
-			return nil, err
+	err := api.start(context.Background())
+	if err == nil && api.replicaSets == nil {
+		replicaSets := api.factory.Apps().V1().ReplicaSets()
+		api.replicaSets = replicaSets.Lister()
+		api.replicaSetsSynced = replicaSets.Informer().HasSynced
+		api.factory.Start(api.stop)
+	}
+	lister, synced := api.replicaSets, api.replicaSetsSynced
+	api.Lock.Unlock()
+	if err != nil {
+		return nil, err
+	}
+	if err := waitForSync(synced); err != nil {
+		return nil, err
+	}
+
+	replicaSets, err := lister.List(labels.Everything())
+	if err != nil {
+		return nil, err
+	}
+	list := &appsv1.ReplicaSetList{Items: make([]appsv1.ReplicaSet, 0, len(replicaSets))}
+	for _, replicaSet := range replicaSets {
+		list.Items = append(list.Items, *replicaSet)
+	}
+	sort.Slice(list.Items, func(i, j int) bool {
+		if list.Items[i].Namespace != list.Items[j].Namespace {
+			return list.Items[i].Namespace < list.Items[j].Namespace
 		}
+		return list.Items[i].Name < list.Items[j].Name
+	})
+	return list, nil
+}
+
+func waitForSync(synced cache.InformerSynced) error {
+	if synced() {
+		return nil
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), CacheSyncTimeout)
+	defer cancel()
+	if !cache.WaitForCacheSync(ctx.Done(), synced) {
+		return errors.New("timed out waiting for kubernetes informer caches to sync")
+	}
+	return nil
+}
+
+// GetStorageClasses will return a list of storage classes in the kubernetes clusteer
+func (api *API) GetStorageClasses() (*v1.StorageClassList, error) {
+	if err := api.ready(); err != nil {
+		return nil, err
+	}
+	classes, err := api.storageClasses.List(labels.Everything())
+	if err != nil {
+		return nil, err
+	}
+
+	list := &v1.StorageClassList{Items: make([]v1.StorageClass, 0, len(classes))}
+	for _, class := range classes {
+		list.Items = append(list.Items, *class)
 	}
-	return api.Client.StorageV1().StorageClasses().List(context.Background(), metav1.ListOptions{})
+	sort.Slice(list.Items, func(i, j int) bool { return list.Items[i].Name < list.Items[j].Name })
+	return list, nil
 }
 
 // GetNodes will return the list of nodes in the kubernetes cluster
 func (api *API) GetNodes() (*corev1.NodeList, error) {
-	api.Lock.Lock()
-	defer api.Lock.Unlock()
-	if api.Client == nil {
-		err := ConnectFn(api)
-		if err != nil {
-			return nil, err
-		}
+	if err := api.ready(); err != nil {
+		return nil, err
+	}
+	nodes, err := api.nodes.List(labels.Everything())
+	if err != nil {
+		return nil, err
 	}
 
-	return api.Client.CoreV1().Nodes().List(context.Background(), metav1.ListOptions{})
+	list := &corev1.NodeList{Items: make([]corev1.Node, 0, len(nodes))}
+	for _, node := range nodes {
+		list.Items = append(list.Items, *node)
+	}
+	sort.Slice(list.Items, func(i, j int) bool { return list.Items[i].Name < list.Items[j].Name })
+	return list, nil
 }
 
 // ConnectFn will connect the client to the k8s API
@@ -109,11 +442,3 @@ var InClusterConfigFn = func() (*rest.Config, error) {
 var NewConfigFn = func(config *rest.Config) (*kubernetes.Clientset, error) {
 	return kubernetes.NewForConfig(config)
 }
-
-func getConfig() (*rest.Config, error) {
-	config, err := InClusterConfigFn()
-	if err != nil {
-		return nil, err
-	}
-	return config, nil
-}
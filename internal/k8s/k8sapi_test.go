@@ -17,20 +17,25 @@
 package k8s_test
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"testing"
+	"time"
 
 	"github.com/dell/karavi-metrics-powerflex/internal/k8s"
 
 	"k8s.io/client-go/kubernetes"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	appsv1 "k8s.io/api/apps/v1"
 	corev1 "k8s.io/api/core/v1"
 	v1 "k8s.io/api/storage/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/client-go/kubernetes/fake"
 	"k8s.io/client-go/rest"
+	k8stesting "k8s.io/client-go/testing"
 )
 
 func Test_GetCSINodes(t *testing.T) {
@@ -78,9 +83,6 @@ func Test_GetCSINodes(t *testing.T) {
 			}
 
 			expectedNodes := &v1.CSINodeList{
-				ListMeta: metav1.ListMeta{
-					ResourceVersion: "2",
-				},
 				Items: []v1.CSINode{
 					{
 						ObjectMeta: metav1.ObjectMeta{
@@ -177,9 +179,6 @@ func Test_GetPersistentVolumes(t *testing.T) {
 			}
 
 			expectedVolumes := &corev1.PersistentVolumeList{
-				ListMeta: metav1.ListMeta{
-					ResourceVersion: "2",
-				},
 				Items: []corev1.PersistentVolume{
 					{
 						ObjectMeta: metav1.ObjectMeta{
@@ -271,9 +270,6 @@ func Test_GetStorageClasses(t *testing.T) {
 			}
 
 			expectedStorageClasses := &v1.StorageClassList{
-				ListMeta: metav1.ListMeta{
-					ResourceVersion: "2",
-				},
 				Items: []v1.StorageClass{
 					{
 						ObjectMeta: metav1.ObjectMeta{
@@ -365,9 +361,6 @@ func Test_GetNodes(t *testing.T) {
 			}
 
 			expectedNodes := &corev1.NodeList{
-				ListMeta: metav1.ListMeta{
-					ResourceVersion: "2",
-				},
 				Items: []corev1.Node{
 					{
 						ObjectMeta: metav1.ObjectMeta{
@@ -454,3 +447,149 @@ func Test_NewForConfigError(t *testing.T) {
 		assert.Equal(t, expected, err.Error())
 	}
 }
+
+func Test_API_Informers(t *testing.T) {
+	t.Run("not synced before start", func(t *testing.T) {
+		api := &k8s.API{Client: fake.NewClientset()}
+		assert.False(t, api.HasSynced())
+
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+		defer cancel()
+		assert.False(t, api.WaitForCacheSync(ctx))
+	})
+
+	t.Run("serves lists from the informer caches", func(t *testing.T) {
+		client := fake.NewClientset(&corev1.PersistentVolume{ObjectMeta: metav1.ObjectMeta{Name: "pv-1"}})
+		api := &k8s.API{Client: client}
+
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+		assert.NoError(t, api.Start(ctx))
+		assert.NoError(t, api.Start(ctx), "starting again is a no-op")
+		assert.True(t, api.WaitForCacheSync(ctx))
+		assert.True(t, api.HasSynced())
+
+		lists := 0
+		for _, action := range client.Actions() {
+			if action.GetVerb() == "list" {
+				lists++
+			}
+		}
+
+		for i := 0; i < 3; i++ {
+			volumes, err := api.GetPersistentVolumes()
+			assert.NoError(t, err)
+			assert.Len(t, volumes.Items, 1)
+		}
+
+		listsAfter := 0
+		for _, action := range client.Actions() {
+			if action.GetVerb() == "list" {
+				listsAfter++
+			}
+		}
+		assert.Equal(t, lists, listsAfter, "getters should not list from the API server")
+	})
+
+	t.Run("picks up changes through the watch", func(t *testing.T) {
+		client := fake.NewClientset()
+		api := &k8s.API{Client: client}
+
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+		assert.NoError(t, api.Start(ctx))
+		assert.True(t, api.WaitForCacheSync(ctx))
+
+		_, err := client.StorageV1().StorageClasses().Create(ctx, &v1.StorageClass{ObjectMeta: metav1.ObjectMeta{Name: "vxflexos"}}, metav1.CreateOptions{})
+		assert.NoError(t, err)
+
+		assert.Eventually(t, func() bool {
+			classes, err := api.GetStorageClasses()
+			return err == nil && len(classes.Items) == 1
+		}, 5*time.Second, 10*time.Millisecond)
+	})
+
+	t.Run("watches namespaces and claims once they are needed", func(t *testing.T) {
+		client := fake.NewClientset(
+			&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "team-b"}},
+			&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "team-a"}},
+			&corev1.PersistentVolumeClaim{ObjectMeta: metav1.ObjectMeta{Name: "data", Namespace: "team-b"}},
+			&corev1.PersistentVolumeClaim{ObjectMeta: metav1.ObjectMeta{Name: "logs", Namespace: "team-a"}},
+		)
+		api := &k8s.API{Client: client}
+
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+		assert.NoError(t, api.Start(ctx))
+		assert.True(t, api.WaitForCacheSync(ctx))
+		for _, action := range client.Actions() {
+			assert.NotContains(t, []string{"namespaces", "persistentvolumeclaims"}, action.GetResource().Resource)
+		}
+
+		namespaces, err := api.GetNamespaces()
+		assert.NoError(t, err)
+		require.Len(t, namespaces.Items, 2)
+		assert.Equal(t, "team-a", namespaces.Items[0].Name)
+
+		claims, err := api.GetPersistentVolumeClaims()
+		assert.NoError(t, err)
+		require.Len(t, claims.Items, 2)
+		assert.Equal(t, "team-a", claims.Items[0].Namespace)
+
+		_, err = client.CoreV1().Namespaces().Create(ctx, &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "team-c"}}, metav1.CreateOptions{})
+		assert.NoError(t, err)
+		assert.Eventually(t, func() bool {
+			namespaces, err := api.GetNamespaces()
+			return err == nil && len(namespaces.Items) == 3
+		}, 5*time.Second, 10*time.Millisecond)
+	})
+
+	t.Run("watches pods and replica sets once they are needed", func(t *testing.T) {
+		client := fake.NewClientset(
+			&corev1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "web-1", Namespace: "team-b"}},
+			&corev1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "web-2", Namespace: "team-a"}},
+			&appsv1.ReplicaSet{ObjectMeta: metav1.ObjectMeta{Name: "web", Namespace: "team-a"}},
+		)
+		api := &k8s.API{Client: client}
+
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+		assert.NoError(t, api.Start(ctx))
+		assert.True(t, api.WaitForCacheSync(ctx))
+		for _, action := range client.Actions() {
+			assert.NotContains(t, []string{"pods", "replicasets"}, action.GetResource().Resource)
+		}
+
+		pods, err := api.GetPods()
+		assert.NoError(t, err)
+		require.Len(t, pods.Items, 2)
+		assert.Equal(t, "team-a", pods.Items[0].Namespace)
+
+		replicaSets, err := api.GetReplicaSets()
+		assert.NoError(t, err)
+		require.Len(t, replicaSets.Items, 1)
+		assert.Equal(t, "web", replicaSets.Items[0].Name)
+	})
+	t.Run("scopes nodes, CSINodes and pods to NodeName", func(t *testing.T) {
+		client := fake.NewClientset()
+		api := &k8s.API{Client: client, NodeName: "worker-1"}
+
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+		assert.NoError(t, api.Start(ctx))
+		assert.True(t, api.WaitForCacheSync(ctx))
+		_, err := api.GetPods()
+		assert.NoError(t, err)
+
+		selectors := map[string]string{}
+		for _, action := range client.Actions() {
+			if list, ok := action.(k8stesting.ListAction); ok {
+				selectors[action.GetResource().Resource] = list.GetListRestrictions().Fields.String()
+			}
+		}
+		assert.Equal(t, "metadata.name=worker-1", selectors["nodes"])
+		assert.Equal(t, "metadata.name=worker-1", selectors["csinodes"])
+		assert.Equal(t, "spec.nodeName=worker-1", selectors["pods"])
+		assert.Empty(t, selectors["persistentvolumes"], "persistent volumes can't be selected by node")
+	})
+}
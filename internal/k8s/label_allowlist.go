@@ -0,0 +1,203 @@
+/*
+ Copyright (c) 2025 Dell Inc. or its subsidiaries. All Rights Reserved.
+
+ Licensed under the Apache License, Version 2.0 (the "License");
+ you may not use this file except in compliance with the License.
+ You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package k8s
+
+import (
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/dell/karavi-metrics-powerflex/internal/telemetry"
+	"github.com/sirupsen/logrus"
+	"k8s.io/apimachinery/pkg/labels"
+)
+
+const (
+	// DefaultMaxLabelValues is how many distinct values of each label attribute are exported by default
+	DefaultMaxLabelValues = 100
+	// OverflowLabelValue replaces the values of a label attribute once it reached its maximum number of values
+	OverflowLabelValue = "__overflow__"
+	// DefaultLabelValueTTL is how long a label value that is no longer exported counts towards the maximum by default
+	DefaultLabelValueTTL = time.Hour
+)
+
+// LabelKeys are the label keys of each kind of object that are exported as metric attributes
+type LabelKeys struct {
+	PersistentVolumeClaim []string
+	PersistentVolume      []string
+	Namespace             []string
+	StorageClass          []string
+	// Node labels are exported on the SDC series
+	Node []string
+}
+
+// Empty returns true if no label is exported
+func (k LabelKeys) Empty() bool {
+	return len(k.PersistentVolumeClaim) == 0 && len(k.PersistentVolume) == 0 && len(k.Namespace) == 0 && len(k.StorageClass) == 0 && len(k.Node) == 0
+}
+
+// Equal returns true if both export the same labels
+func (k LabelKeys) Equal(other LabelKeys) bool {
+	return slices.Equal(k.PersistentVolumeClaim, other.PersistentVolumeClaim) &&
+		slices.Equal(k.PersistentVolume, other.PersistentVolume) &&
+		slices.Equal(k.Namespace, other.Namespace) &&
+		slices.Equal(k.StorageClass, other.StorageClass) &&
+		slices.Equal(k.Node, other.Node)
+}
+
+// LabelAttribute returns the metric attribute of a label key, e.g. label_team for team or label_app_kubernetes_io_name for app.kubernetes.io/name
+func LabelAttribute(key string) string {
+	return "label_" + strings.Map(func(r rune) rune {
+		if (r >= 'a' && r <= 'z') || (r >= 'A' && r <= 'Z') || (r >= '0' && r <= '9') || r == '_' {
+			return r
+		}
+		return '_'
+	}, key)
+}
+
+// LabelAllowlist turns the allowed labels of kubernetes objects into metric attributes.
+// A volume gets the labels of its claim, its persistent volume and the namespace of its claim, and if the same
+// key is allowed on more than one of them, the claim's value wins over the volume's, which wins over the namespace's.
+// To bound the cardinality of the series, each attribute exports at most MaxValues distinct values, later values
+// are exported as OverflowLabelValue. A value that wasn't exported for ValueTTL, e.g. of a deleted claim or namespace,
+// no longer counts towards the maximum and makes room for a new value. A nil *LabelAllowlist exports no labels.
+type LabelAllowlist struct {
+	Keys LabelKeys
+	// MaxValues defaults to DefaultMaxLabelValues
+	MaxValues int
+	// ValueTTL defaults to DefaultLabelValueTTL
+	ValueTTL time.Duration
+	Logger   *logrus.Logger
+
+	mu sync.Mutex
+	// values holds when each value of each attribute was last exported
+	values     map[string]map[string]time.Time
+	overflowed map[string]bool
+}
+
+// Empty returns true if no label is exported
+func (a *LabelAllowlist) Empty() bool {
+	return a == nil || a.Keys.Empty()
+}
+
+// VolumeAttributes returns the attributes of a volume with the labels of its claim, its persistent volume and its namespace
+func (a *LabelAllowlist) VolumeAttributes(claimLabels labels.Set, volumeLabels labels.Set, namespaceLabels labels.Set) map[string]string {
+	if a.Empty() {
+		return nil
+	}
+	attributes := make(map[string]string)
+	a.add(attributes, a.Keys.Namespace, namespaceLabels)
+	a.add(attributes, a.Keys.PersistentVolume, volumeLabels)
+	a.add(attributes, a.Keys.PersistentVolumeClaim, claimLabels)
+	return a.limit(attributes)
+}
+
+// StorageClassAttributes returns the attributes of a storage class with its labels
+func (a *LabelAllowlist) StorageClassAttributes(classLabels labels.Set) map[string]string {
+	if a.Empty() || len(a.Keys.StorageClass) == 0 {
+		return nil
+	}
+	attributes := make(map[string]string)
+	a.add(attributes, a.Keys.StorageClass, classLabels)
+	return a.limit(attributes)
+}
+
+// NodeAttributes returns the attributes of the SDC on a node with the labels of the node
+func (a *LabelAllowlist) NodeAttributes(nodeLabels labels.Set) map[string]string {
+	if a.Empty() || len(a.Keys.Node) == 0 {
+		return nil
+	}
+	attributes := make(map[string]string)
+	a.add(attributes, a.Keys.Node, nodeLabels)
+	return a.limit(attributes)
+}
+
+func (a *LabelAllowlist) selectsNamespaceLabels() bool {
+	return a != nil && len(a.Keys.Namespace) > 0
+}
+
+func (a *LabelAllowlist) selectsClaimLabels() bool {
+	return a != nil && len(a.Keys.PersistentVolumeClaim) > 0
+}
+
+// add sets the attribute of every allowed key, to an empty value if the object doesn't have the label so that every series has the same attributes
+func (a *LabelAllowlist) add(attributes map[string]string, keys []string, objectLabels labels.Set) {
+	for _, key := range keys {
+		attribute := LabelAttribute(key)
+		if value, ok := objectLabels[key]; ok {
+			attributes[attribute] = value
+		} else if _, ok := attributes[attribute]; !ok {
+			attributes[attribute] = ""
+		}
+	}
+}
+
+func (a *LabelAllowlist) limit(attributes map[string]string) map[string]string {
+	maxValues := a.MaxValues
+	if maxValues <= 0 {
+		maxValues = DefaultMaxLabelValues
+	}
+	now := time.Now()
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if a.values == nil {
+		a.values = make(map[string]map[string]time.Time)
+		a.overflowed = make(map[string]bool)
+	}
+	for attribute, value := range attributes {
+		if value == "" {
+			continue
+		}
+		seen, ok := a.values[attribute]
+		if !ok {
+			seen = make(map[string]time.Time)
+			a.values[attribute] = seen
+		}
+		if _, ok := seen[value]; !ok && len(seen) >= maxValues {
+			a.expire(seen, now)
+		}
+		if _, ok := seen[value]; ok || len(seen) < maxValues {
+			if !ok {
+				a.overflowed[attribute] = false
+			}
+			seen[value] = now
+			continue
+		}
+		if !a.overflowed[attribute] {
+			telemetry.Logger(a.Logger).WithField("attribute", attribute).Warnf("label attribute reached %d values, further values are exported as %s", maxValues, OverflowLabelValue)
+			// remember the overflow so that the warning is logged once
+			a.overflowed[attribute] = true
+		}
+		attributes[attribute] = OverflowLabelValue
+	}
+	return attributes
+}
+
+// expire forgets the values that weren't exported for ValueTTL
+func (a *LabelAllowlist) expire(seen map[string]time.Time, now time.Time) {
+	ttl := a.ValueTTL
+	if ttl <= 0 {
+		ttl = DefaultLabelValueTTL
+	}
+	for value, exported := range seen {
+		if now.Sub(exported) > ttl {
+			delete(seen, value)
+		}
+	}
+}
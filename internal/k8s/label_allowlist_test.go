@@ -0,0 +1,110 @@
+/*
+ Copyright (c) 2025 Dell Inc. or its subsidiaries. All Rights Reserved.
+
+ Licensed under the Apache License, Version 2.0 (the "License");
+ you may not use this file except in compliance with the License.
+ You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package k8s_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/dell/karavi-metrics-powerflex/internal/k8s"
+	"github.com/stretchr/testify/assert"
+	"k8s.io/apimachinery/pkg/labels"
+)
+
+func Test_LabelAttribute(t *testing.T) {
+	assert.Equal(t, "label_team", k8s.LabelAttribute("team"))
+	assert.Equal(t, "label_cost_center", k8s.LabelAttribute("cost-center"))
+	assert.Equal(t, "label_app_kubernetes_io_name", k8s.LabelAttribute("app.kubernetes.io/name"))
+}
+
+func Test_LabelAllowlist(t *testing.T) {
+	var nilAllowlist *k8s.LabelAllowlist
+	assert.True(t, nilAllowlist.Empty())
+	assert.Nil(t, nilAllowlist.VolumeAttributes(labels.Set{"team": "a"}, nil, nil))
+	assert.Nil(t, nilAllowlist.StorageClassAttributes(labels.Set{"team": "a"}))
+
+	allowlist := &k8s.LabelAllowlist{
+		Keys: k8s.LabelKeys{
+			PersistentVolumeClaim: []string{"team", "app"},
+			PersistentVolume:      []string{"team"},
+			Namespace:             []string{"team", "cost-center"},
+		},
+	}
+	assert.False(t, allowlist.Empty())
+
+	t.Run("claim labels win over volume and namespace labels", func(t *testing.T) {
+		attributes := allowlist.VolumeAttributes(
+			labels.Set{"team": "claim", "other": "ignored"},
+			labels.Set{"team": "volume"},
+			labels.Set{"team": "namespace", "cost-center": "cc-1"},
+		)
+		assert.Equal(t, map[string]string{"label_team": "claim", "label_app": "", "label_cost_center": "cc-1"}, attributes)
+
+		attributes = allowlist.VolumeAttributes(nil, labels.Set{"team": "volume"}, labels.Set{"team": "namespace"})
+		assert.Equal(t, map[string]string{"label_team": "volume", "label_app": "", "label_cost_center": ""}, attributes)
+	})
+
+	t.Run("storage classes only export storage class labels", func(t *testing.T) {
+		assert.Nil(t, allowlist.StorageClassAttributes(labels.Set{"team": "a"}))
+
+		classAllowlist := &k8s.LabelAllowlist{Keys: k8s.LabelKeys{StorageClass: []string{"tier"}}}
+		assert.Equal(t, map[string]string{"label_tier": "gold"}, classAllowlist.StorageClassAttributes(labels.Set{"tier": "gold", "team": "a"}))
+		assert.Empty(t, classAllowlist.VolumeAttributes(labels.Set{"tier": "gold"}, nil, nil))
+	})
+
+	t.Run("nodes only export node labels", func(t *testing.T) {
+		assert.Nil(t, allowlist.NodeAttributes(labels.Set{"team": "a"}))
+
+		nodeAllowlist := &k8s.LabelAllowlist{Keys: k8s.LabelKeys{Node: []string{"topology.kubernetes.io/zone", "rack"}}}
+		assert.False(t, nodeAllowlist.Empty())
+		assert.Equal(t, map[string]string{"label_topology_kubernetes_io_zone": "zone-a", "label_rack": ""},
+			nodeAllowlist.NodeAttributes(labels.Set{"topology.kubernetes.io/zone": "zone-a", "team": "a"}))
+		assert.Empty(t, nodeAllowlist.VolumeAttributes(labels.Set{"rack": "r1"}, nil, nil))
+	})
+
+	t.Run("values beyond the maximum are exported as overflow", func(t *testing.T) {
+		limited := &k8s.LabelAllowlist{Keys: k8s.LabelKeys{PersistentVolumeClaim: []string{"team"}}, MaxValues: 2}
+		assert.Equal(t, "a", limited.VolumeAttributes(labels.Set{"team": "a"}, nil, nil)["label_team"])
+		assert.Equal(t, "b", limited.VolumeAttributes(labels.Set{"team": "b"}, nil, nil)["label_team"])
+		assert.Equal(t, k8s.OverflowLabelValue, limited.VolumeAttributes(labels.Set{"team": "c"}, nil, nil)["label_team"])
+		assert.Equal(t, k8s.OverflowLabelValue, limited.VolumeAttributes(labels.Set{"team": "d"}, nil, nil)["label_team"])
+		assert.Equal(t, "a", limited.VolumeAttributes(labels.Set{"team": "a"}, nil, nil)["label_team"], "known values are still exported")
+		assert.Equal(t, "", limited.VolumeAttributes(nil, nil, nil)["label_team"], "a missing label doesn't count as a value")
+	})
+
+	t.Run("values that are no longer exported make room for new values", func(t *testing.T) {
+		limited := &k8s.LabelAllowlist{Keys: k8s.LabelKeys{PersistentVolumeClaim: []string{"team"}}, MaxValues: 2, ValueTTL: 50 * time.Millisecond}
+		assert.Equal(t, "a", limited.VolumeAttributes(labels.Set{"team": "a"}, nil, nil)["label_team"])
+		assert.Equal(t, "b", limited.VolumeAttributes(labels.Set{"team": "b"}, nil, nil)["label_team"])
+		assert.Equal(t, k8s.OverflowLabelValue, limited.VolumeAttributes(labels.Set{"team": "c"}, nil, nil)["label_team"])
+
+		// the claims of team a were deleted, team b is still exported
+		time.Sleep(60 * time.Millisecond)
+		assert.Equal(t, "b", limited.VolumeAttributes(labels.Set{"team": "b"}, nil, nil)["label_team"])
+		assert.Equal(t, "c", limited.VolumeAttributes(labels.Set{"team": "c"}, nil, nil)["label_team"])
+		assert.Equal(t, k8s.OverflowLabelValue, limited.VolumeAttributes(labels.Set{"team": "a"}, nil, nil)["label_team"])
+	})
+}
+
+func Test_LabelKeys_Equal(t *testing.T) {
+	keys := k8s.LabelKeys{PersistentVolumeClaim: []string{"team"}, StorageClass: []string{"tier"}}
+	assert.True(t, keys.Equal(k8s.LabelKeys{PersistentVolumeClaim: []string{"team"}, StorageClass: []string{"tier"}}))
+	assert.False(t, keys.Equal(k8s.LabelKeys{PersistentVolumeClaim: []string{"team"}}))
+	assert.False(t, keys.Equal(k8s.LabelKeys{PersistentVolumeClaim: []string{"team"}, StorageClass: []string{"tier"}, Node: []string{"rack"}}))
+	assert.True(t, k8s.LabelKeys{}.Empty())
+	assert.False(t, keys.Empty())
+}
@@ -18,9 +18,13 @@ package k8s
 
 import (
 	"context"
-	"os"
+	"sync"
 	"time"
 
+	"github.com/dell/karavi-metrics-powerflex/internal/telemetry"
+	"github.com/sirupsen/logrus"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 
 	"k8s.io/client-go/kubernetes"
@@ -30,6 +34,15 @@ import (
 	"k8s.io/client-go/tools/leaderelection/resourcelock"
 )
 
+const (
+	// DefaultLeaseDuration is how long followers wait before trying to take over a lease that was not renewed
+	DefaultLeaseDuration = 15 * time.Second
+	// DefaultRenewDeadline is how long the leader keeps trying to renew the lease before giving it up
+	DefaultRenewDeadline = 10 * time.Second
+	// DefaultRetryPeriod is the wait between attempts to acquire or renew the lease
+	DefaultRetryPeriod = 2 * time.Second
+)
+
 // LeaderElectorGetter is an interface for initialize and check elected leader
 //
 //go:generate mockgen -destination=mocks/leader_elector_getter_mocks.go -package=mocks github.com/dell/karavi-metrics-powerflex/internal/k8s LeaderElectorGetter
@@ -42,11 +55,44 @@ type LeaderElectorGetter interface {
 type LeaderElector struct {
 	API     LeaderElectorGetter
 	Elector *leaderelection.LeaderElector
+	// Disabled makes this replica the leader without taking a Lease, for single-replica deployments
+	Disabled bool
+	// Sharding spreads the storage systems across all replicas instead of electing a single leader
+	Sharding bool
+	// StorageSystemIDs returns the storage systems to spread across replicas when Sharding is set
+	StorageSystemIDs func() []string
+	LeaseDuration    time.Duration
+	RenewDeadline    time.Duration
+	RetryPeriod      time.Duration
+	// OnStartedLeading is called when this replica becomes the leader
+	OnStartedLeading func(context.Context)
+	// OnStoppedLeading is called when this replica loses the lease
+	OnStoppedLeading func()
+	Logger           *logrus.Logger
+	Meter            metric.Meter
+
+	mu           sync.Mutex
+	coordinator  *ShardCoordinator
+	gauge        telemetry.Gauge
+	shardGauge   telemetry.Gauge
+	identityOnce sync.Once
+	identity     string
 }
 
-// InitLeaderElection will run algorithm for leader election, call during service initialzation process
+// InitLeaderElection will run algorithm for leader election, call during service initialzation process.
+// When the lease is lost the elector re-enters the election, so InitLeaderElection only returns on error.
 func (elect *LeaderElector) InitLeaderElection(endpoint string, namespace string) error {
-	k8sconfig, err := InClusterConfigFn()
+	elect.registerGauge()
+
+	if elect.Disabled {
+		telemetry.Logger(elect.Logger).Info("leader election is disabled, collecting metrics as the only replica")
+		if elect.OnStartedLeading != nil {
+			elect.OnStartedLeading(context.Background())
+		}
+		return nil
+	}
+
+	k8sconfig, err := getConfig()
 	if err != nil {
 		return err
 	}
@@ -56,14 +102,46 @@ func (elect *LeaderElector) InitLeaderElection(endpoint string, namespace string
 		return err
 	}
 
+	identity := elect.Identity()
+	if elect.Sharding {
+		coordinator := &ShardCoordinator{
+			Client:           k8sclient,
+			Namespace:        namespace,
+			Group:            endpoint,
+			Identity:         identity,
+			LeaseDuration:    durationOrDefault(elect.LeaseDuration, DefaultLeaseDuration),
+			RetryPeriod:      durationOrDefault(elect.RetryPeriod, DefaultRetryPeriod),
+			StorageSystemIDs: elect.StorageSystemIDs,
+			OnStartedLeading: elect.OnStartedLeading,
+			OnStoppedLeading: elect.OnStoppedLeading,
+			Logger:           telemetry.Logger(elect.Logger),
+		}
+		elect.setCoordinator(coordinator)
+		elect.registerShardGauge()
+		telemetry.Logger(elect.Logger).WithField("identity", identity).Info("sharding storage systems across replicas")
+		return coordinator.Run(context.Background())
+	}
+
 	leaderConfig := leaderelection.LeaderElectionConfig{
-		LeaseDuration: 15 * time.Second,
-		RenewDeadline: 10 * time.Second,
-		RetryPeriod:   2 * time.Second,
+		LeaseDuration: durationOrDefault(elect.LeaseDuration, DefaultLeaseDuration),
+		RenewDeadline: durationOrDefault(elect.RenewDeadline, DefaultRenewDeadline),
+		RetryPeriod:   durationOrDefault(elect.RetryPeriod, DefaultRetryPeriod),
 		Callbacks: leaderelection.LeaderCallbacks{
-			OnStartedLeading: func(context.Context) {},
-			OnStoppedLeading: func() {},
-			OnNewLeader:      func(_ string) {},
+			OnStartedLeading: func(ctx context.Context) {
+				telemetry.Logger(elect.Logger).WithField("identity", identity).Info("started leading")
+				if elect.OnStartedLeading != nil {
+					elect.OnStartedLeading(ctx)
+				}
+			},
+			OnStoppedLeading: func() {
+				telemetry.Logger(elect.Logger).WithField("identity", identity).Info("stopped leading")
+				if elect.OnStoppedLeading != nil {
+					elect.OnStoppedLeading()
+				}
+			},
+			OnNewLeader: func(leader string) {
+				telemetry.Logger(elect.Logger).WithField("leader", leader).Debug("new leader elected")
+			},
 		},
 		Lock: &resourcelock.LeaseLock{
 			LeaseMeta: metav1.ObjectMeta{
@@ -72,26 +150,130 @@ func (elect *LeaderElector) InitLeaderElection(endpoint string, namespace string
 			},
 			Client: k8sclient.CoordinationV1(),
 			LockConfig: resourcelock.ResourceLockConfig{
-				Identity: os.Getenv("HOSTNAME"),
+				Identity: identity,
 			},
 		},
 	}
 
-	elect.Elector, err = NewLeaderElectorFn(leaderConfig)
-	if err != nil {
-		return err
-	}
+	for {
+		elector, err := NewLeaderElectorFn(leaderConfig)
+		if err != nil {
+			return err
+		}
+		elect.setElector(elector)
 
-	elect.Elector.Run(context.Background())
-	return nil
+		// Run returns once the lease is lost
+		elector.Run(context.Background())
+		telemetry.Logger(elect.Logger).WithField("identity", identity).Warn("lost leader election lease, re-entering election")
+		time.Sleep(leaderConfig.RetryPeriod)
+	}
 }
 
-// IsLeader return true if the given client is leader at the moment
+// IsLeader return true if the given client collects metrics at the moment.
+// When sharding, every replica that joined the shard group collects metrics for the storage systems it owns,
+// use HoldsLease to tell the single leader apart.
 func (elect *LeaderElector) IsLeader() bool {
-	if elect.Elector == nil {
+	if elect.Disabled {
+		return true
+	}
+	elect.mu.Lock()
+	elector := elect.Elector
+	coordinator := elect.coordinator
+	elect.mu.Unlock()
+	if elect.Sharding {
+		return coordinator != nil
+	}
+	if elector == nil {
 		return false
 	}
-	return elect.Elector.IsLeader()
+	return elector.IsLeader()
+}
+
+// HoldsLease returns true if this replica holds the election lease. When sharding, that is the Lease of the
+// ClusterShardKey shard, which only one replica holds at a time. Without an election this replica is the only one.
+func (elect *LeaderElector) HoldsLease() bool {
+	if elect.Disabled {
+		return true
+	}
+	elect.mu.Lock()
+	elector := elect.Elector
+	coordinator := elect.coordinator
+	elect.mu.Unlock()
+	if elect.Sharding {
+		return coordinator != nil && coordinator.Owns(ClusterShardKey)
+	}
+	return elector != nil && elector.IsLeader()
+}
+
+// Owns returns true if this replica collects metrics for the storage system.
+// Without sharding the leader collects every storage system.
+func (elect *LeaderElector) Owns(storageSystemID string) bool {
+	if !elect.Sharding || elect.Disabled {
+		return true
+	}
+	elect.mu.Lock()
+	coordinator := elect.coordinator
+	elect.mu.Unlock()
+	return coordinator != nil && coordinator.Owns(storageSystemID)
+}
+
+// Identity returns the identity this replica uses to hold the lease
+func (elect *LeaderElector) Identity() string {
+	elect.identityOnce.Do(func() {
+		elect.identity = LeaderElectionIdentity()
+	})
+	return elect.identity
+}
+
+func (elect *LeaderElector) setCoordinator(coordinator *ShardCoordinator) {
+	elect.mu.Lock()
+	defer elect.mu.Unlock()
+	elect.coordinator = coordinator
+}
+
+func (elect *LeaderElector) setElector(elector *leaderelection.LeaderElector) {
+	elect.mu.Lock()
+	defer elect.mu.Unlock()
+	elect.Elector = elector
+}
+
+// registerGauge exports powerflex_is_leader, which is 1 while this replica holds the election lease
+func (elect *LeaderElector) registerGauge() {
+	elect.gauge.RegisterInt64(elect.Meter, elect.Logger, "powerflex_is_leader", func(_ context.Context, obs metric.Int64Observer) error {
+		obs.Observe(boolToInt64(elect.HoldsLease()), metric.WithAttributes(attribute.String("Identity", elect.Identity())))
+		return nil
+	})
+}
+
+// registerShardGauge exports powerflex_shard_owned, which is 1 for every shard this replica collects when sharding
+func (elect *LeaderElector) registerShardGauge() {
+	elect.shardGauge.RegisterInt64(elect.Meter, elect.Logger, "powerflex_shard_owned", func(_ context.Context, obs metric.Int64Observer) error {
+		var ids []string
+		if elect.StorageSystemIDs != nil {
+			ids = append(ids, elect.StorageSystemIDs()...)
+		}
+		for _, id := range append(ids, ClusterShardKey) {
+			obs.Observe(boolToInt64(elect.Owns(id)), metric.WithAttributes(
+				attribute.String("Identity", elect.Identity()),
+				attribute.String("StorageSystemID", id),
+			))
+		}
+		return nil
+	})
+}
+
+func boolToInt64(b bool) int64 {
+	if b {
+		return 1
+	}
+	return 0
+}
+
+func durationOrDefault(d time.Duration, defaultDuration time.Duration) time.Duration {
+	if d <= 0 {
+		return defaultDuration
+	}
+	return d
 }
 
 // NewForConfigFn creates a new Clientset for the given config. If config's RateLimiter is not set and QPS and Burst are acceptable, NewForConfigFn will generate a rate-limiter in configShallowCopy
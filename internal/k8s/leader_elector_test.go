@@ -17,12 +17,17 @@
 package k8s_test
 
 import (
+	"context"
 	"errors"
 	"testing"
+	"time"
 
 	"github.com/dell/karavi-metrics-powerflex/internal/k8s"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata"
 	"k8s.io/client-go/kubernetes"
 	"k8s.io/client-go/rest"
 	"k8s.io/client-go/tools/leaderelection"
@@ -136,3 +141,87 @@ func Test_NewLeaderElectorFn(t *testing.T) {
 		assert.Error(t, err)
 	})
 }
+
+func Test_InitLeaderElection_Config(t *testing.T) {
+	oldInClusterConfig := k8s.InClusterConfigFn
+	defer func() { k8s.InClusterConfigFn = oldInClusterConfig }()
+	k8s.InClusterConfigFn = func() (*rest.Config, error) {
+		return &rest.Config{}, nil
+	}
+	oldNewForConfigFn := k8s.NewForConfigFn
+	defer func() { k8s.NewForConfigFn = oldNewForConfigFn }()
+	k8s.NewForConfigFn = func(_ *rest.Config) (*kubernetes.Clientset, error) {
+		return &kubernetes.Clientset{}, nil
+	}
+
+	var captured leaderelection.LeaderElectionConfig
+	oldLeaderElection := k8s.NewLeaderElectorFn
+	defer func() { k8s.NewLeaderElectorFn = oldLeaderElection }()
+	k8s.NewLeaderElectorFn = func(lec leaderelection.LeaderElectionConfig) (*leaderelection.LeaderElector, error) {
+		captured = lec
+		return nil, errors.New("stop")
+	}
+
+	t.Run("default timings", func(t *testing.T) {
+		elector := &k8s.LeaderElector{}
+		assert.Error(t, elector.InitLeaderElection("karavi-metrics-powerflex", "karavi"))
+		assert.Equal(t, k8s.DefaultLeaseDuration, captured.LeaseDuration)
+		assert.Equal(t, k8s.DefaultRenewDeadline, captured.RenewDeadline)
+		assert.Equal(t, k8s.DefaultRetryPeriod, captured.RetryPeriod)
+	})
+
+	t.Run("configured timings and callbacks", func(t *testing.T) {
+		started, stopped := false, false
+		elector := &k8s.LeaderElector{
+			LeaseDuration:    60 * time.Second,
+			RenewDeadline:    40 * time.Second,
+			RetryPeriod:      5 * time.Second,
+			OnStartedLeading: func(context.Context) { started = true },
+			OnStoppedLeading: func() { stopped = true },
+		}
+		assert.Error(t, elector.InitLeaderElection("karavi-metrics-powerflex", "karavi"))
+		assert.Equal(t, 60*time.Second, captured.LeaseDuration)
+		assert.Equal(t, 40*time.Second, captured.RenewDeadline)
+		assert.Equal(t, 5*time.Second, captured.RetryPeriod)
+
+		captured.Callbacks.OnStartedLeading(context.Background())
+		captured.Callbacks.OnStoppedLeading()
+		captured.Callbacks.OnNewLeader("other")
+		assert.True(t, started)
+		assert.True(t, stopped)
+		assert.Equal(t, elector.Identity(), captured.Lock.Identity())
+	})
+}
+
+func Test_LeaderElector_Disabled(t *testing.T) {
+	reader := sdkmetric.NewManualReader()
+	provider := sdkmetric.NewMeterProvider(sdkmetric.WithReader(reader))
+
+	started := false
+	elector := &k8s.LeaderElector{
+		Disabled:         true,
+		OnStartedLeading: func(context.Context) { started = true },
+		Meter:            provider.Meter("test"),
+	}
+
+	oldNewForConfigFn := k8s.NewForConfigFn
+	defer func() { k8s.NewForConfigFn = oldNewForConfigFn }()
+	k8s.NewForConfigFn = func(_ *rest.Config) (*kubernetes.Clientset, error) {
+		t.Fatalf("no kubernetes client should be created when leader election is disabled")
+		return nil, nil
+	}
+
+	assert.NoError(t, elector.InitLeaderElection("karavi-metrics-powerflex", "karavi"))
+	assert.True(t, started)
+	assert.True(t, elector.IsLeader())
+	assert.True(t, elector.HoldsLease())
+
+	var rm metricdata.ResourceMetrics
+	assert.NoError(t, reader.Collect(context.Background(), &rm))
+	require.Len(t, rm.ScopeMetrics, 1)
+	require.Len(t, rm.ScopeMetrics[0].Metrics, 1)
+	assert.Equal(t, "powerflex_is_leader", rm.ScopeMetrics[0].Metrics[0].Name)
+	gauge := rm.ScopeMetrics[0].Metrics[0].Data.(metricdata.Gauge[int64])
+	require.Len(t, gauge.DataPoints, 1)
+	assert.Equal(t, int64(1), gauge.DataPoints[0].Value)
+}
@@ -0,0 +1,72 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: github.com/dell/karavi-metrics-powerflex/internal/k8s (interfaces: ConsumerGetter)
+//
+// Generated by this command:
+//
+//	mockgen -destination=mocks/consumer_getter_mocks.go -package=mocks github.com/dell/karavi-metrics-powerflex/internal/k8s ConsumerGetter
+//
+
+// Package mocks is a generated GoMock package.
+package mocks
+
+import (
+	reflect "reflect"
+
+	gomock "go.uber.org/mock/gomock"
+	v1 "k8s.io/api/apps/v1"
+	v10 "k8s.io/api/core/v1"
+)
+
+// MockConsumerGetter is a mock of ConsumerGetter interface.
+type MockConsumerGetter struct {
+	ctrl     *gomock.Controller
+	recorder *MockConsumerGetterMockRecorder
+	isgomock struct{}
+}
+
+// MockConsumerGetterMockRecorder is the mock recorder for MockConsumerGetter.
+type MockConsumerGetterMockRecorder struct {
+	mock *MockConsumerGetter
+}
+
+// NewMockConsumerGetter creates a new mock instance.
+func NewMockConsumerGetter(ctrl *gomock.Controller) *MockConsumerGetter {
+	mock := &MockConsumerGetter{ctrl: ctrl}
+	mock.recorder = &MockConsumerGetterMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockConsumerGetter) EXPECT() *MockConsumerGetterMockRecorder {
+	return m.recorder
+}
+
+// GetPods mocks base method.
+func (m *MockConsumerGetter) GetPods() (*v10.PodList, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetPods")
+	ret0, _ := ret[0].(*v10.PodList)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetPods indicates an expected call of GetPods.
+func (mr *MockConsumerGetterMockRecorder) GetPods() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetPods", reflect.TypeOf((*MockConsumerGetter)(nil).GetPods))
+}
+
+// GetReplicaSets mocks base method.
+func (m *MockConsumerGetter) GetReplicaSets() (*v1.ReplicaSetList, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetReplicaSets")
+	ret0, _ := ret[0].(*v1.ReplicaSetList)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetReplicaSets indicates an expected call of GetReplicaSets.
+func (mr *MockConsumerGetterMockRecorder) GetReplicaSets() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetReplicaSets", reflect.TypeOf((*MockConsumerGetter)(nil).GetReplicaSets))
+}
@@ -0,0 +1,71 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: github.com/dell/karavi-metrics-powerflex/internal/k8s (interfaces: VolumeMetadataGetter)
+//
+// Generated by this command:
+//
+//	mockgen -destination=mocks/volume_metadata_getter_mocks.go -package=mocks github.com/dell/karavi-metrics-powerflex/internal/k8s VolumeMetadataGetter
+//
+
+// Package mocks is a generated GoMock package.
+package mocks
+
+import (
+	reflect "reflect"
+
+	gomock "go.uber.org/mock/gomock"
+	v1 "k8s.io/api/core/v1"
+)
+
+// MockVolumeMetadataGetter is a mock of VolumeMetadataGetter interface.
+type MockVolumeMetadataGetter struct {
+	ctrl     *gomock.Controller
+	recorder *MockVolumeMetadataGetterMockRecorder
+	isgomock struct{}
+}
+
+// MockVolumeMetadataGetterMockRecorder is the mock recorder for MockVolumeMetadataGetter.
+type MockVolumeMetadataGetterMockRecorder struct {
+	mock *MockVolumeMetadataGetter
+}
+
+// NewMockVolumeMetadataGetter creates a new mock instance.
+func NewMockVolumeMetadataGetter(ctrl *gomock.Controller) *MockVolumeMetadataGetter {
+	mock := &MockVolumeMetadataGetter{ctrl: ctrl}
+	mock.recorder = &MockVolumeMetadataGetterMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockVolumeMetadataGetter) EXPECT() *MockVolumeMetadataGetterMockRecorder {
+	return m.recorder
+}
+
+// GetNamespaces mocks base method.
+func (m *MockVolumeMetadataGetter) GetNamespaces() (*v1.NamespaceList, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetNamespaces")
+	ret0, _ := ret[0].(*v1.NamespaceList)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetNamespaces indicates an expected call of GetNamespaces.
+func (mr *MockVolumeMetadataGetterMockRecorder) GetNamespaces() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetNamespaces", reflect.TypeOf((*MockVolumeMetadataGetter)(nil).GetNamespaces))
+}
+
+// GetPersistentVolumeClaims mocks base method.
+func (m *MockVolumeMetadataGetter) GetPersistentVolumeClaims() (*v1.PersistentVolumeClaimList, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetPersistentVolumeClaims")
+	ret0, _ := ret[0].(*v1.PersistentVolumeClaimList)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetPersistentVolumeClaims indicates an expected call of GetPersistentVolumeClaims.
+func (mr *MockVolumeMetadataGetterMockRecorder) GetPersistentVolumeClaims() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetPersistentVolumeClaims", reflect.TypeOf((*MockVolumeMetadataGetter)(nil).GetPersistentVolumeClaims))
+}
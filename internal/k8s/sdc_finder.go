@@ -33,25 +33,76 @@ type KubernetesAPI interface {
 type SDCFinder struct {
 	API             KubernetesAPI
 	StorageSystemID []StorageSystemID
+	// NodeName limits the lookup to the CSINode of one node, for pods that only collect their own node's SDC
+	NodeName string
+	// SDCGUID is the GUID of the local SDC. When set, it is returned without looking up CSINodes.
+	SDCGUID string
 }
 
 // GetSDCGuids will return a list of SDC GUIDs that match the given DriverName in Kubernetes
 func (f *SDCFinder) GetSDCGuids() ([]string, error) {
+	if f.SDCGUID != "" {
+		return []string{f.SDCGUID}, nil
+	}
+
+	sdcs, err := f.findSDCs()
+	if err != nil {
+		return nil, err
+	}
+
 	var sdcGUIDS []string
+	for _, sdc := range sdcs {
+		sdcGUIDS = append(sdcGUIDS, sdc.guid)
+	}
+	return sdcGUIDS, nil
+}
+
+// GetSDCNodes returns the name of the node of each SDC GUID. The name of a CSINode is the name of its node, so unlike
+// the addresses of the node, it identifies the node of an SDC that uses a storage network address.
+func (f *SDCFinder) GetSDCNodes() (map[string]string, error) {
+	if f.SDCGUID != "" {
+		if f.NodeName == "" {
+			return map[string]string{}, nil
+		}
+		return map[string]string{f.SDCGUID: f.NodeName}, nil
+	}
 
+	sdcs, err := f.findSDCs()
+	if err != nil {
+		return nil, err
+	}
+
+	sdcNodes := make(map[string]string, len(sdcs))
+	for _, sdc := range sdcs {
+		sdcNodes[sdc.guid] = sdc.node
+	}
+	return sdcNodes, nil
+}
+
+// csiNodeSDC is the GUID of an SDC and the node whose CSINode reported it
+type csiNodeSDC struct {
+	guid string
+	node string
+}
+
+func (f *SDCFinder) findSDCs() ([]csiNodeSDC, error) {
 	nodes, err := f.API.GetCSINodes()
 	if err != nil {
 		return nil, err
 	}
 
+	var sdcs []csiNodeSDC
 	for _, node := range nodes.Items {
+		if f.NodeName != "" && node.Name != f.NodeName {
+			continue
+		}
 		for _, driver := range node.Spec.Drivers {
 			if f.isMatch(driver) {
-				sdcGUIDS = append(sdcGUIDS, driver.NodeID)
+				sdcs = append(sdcs, csiNodeSDC{guid: driver.NodeID, node: node.Name})
 			}
 		}
 	}
-	return sdcGUIDS, nil
+	return sdcs, nil
 }
 
 func (f *SDCFinder) isMatch(driver v1.CSINodeDriver) bool {
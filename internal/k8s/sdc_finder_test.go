@@ -24,6 +24,7 @@ import (
 	"github.com/dell/karavi-metrics-powerflex/internal/k8s/mocks"
 
 	v1 "k8s.io/api/storage/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 
 	"github.com/stretchr/testify/assert"
 	"go.uber.org/mock/gomock"
@@ -111,6 +112,53 @@ func Test_K8sSDCFinder(t *testing.T) {
 			finder := k8s.SDCFinder{API: api, StorageSystemID: ids}
 			return finder, check(hasNoError, checkExpectedOutput([]string{"node-1", "node-3"})), ctrl
 		},
+		"node-local only returns the sdc of its node": func(*testing.T) (k8s.SDCFinder, []checkFn, *gomock.Controller) {
+			ctrl := gomock.NewController(t)
+			api := mocks.NewMockKubernetesAPI(ctrl)
+
+			nodes := &v1.CSINodeList{
+				Items: []v1.CSINode{
+					{
+						ObjectMeta: metav1.ObjectMeta{Name: "worker-1"},
+						Spec: v1.CSINodeSpec{
+							Drivers: []v1.CSINodeDriver{
+								{
+									Name:         "csi-vxflexos.dellemc.com",
+									NodeID:       "node-1",
+									TopologyKeys: []string{"csi-vxflexos.dellemc.com/storage-system-id-1"},
+								},
+							},
+						},
+					},
+					{
+						ObjectMeta: metav1.ObjectMeta{Name: "worker-2"},
+						Spec: v1.CSINodeSpec{
+							Drivers: []v1.CSINodeDriver{
+								{
+									Name:         "csi-vxflexos.dellemc.com",
+									NodeID:       "node-2",
+									TopologyKeys: []string{"csi-vxflexos.dellemc.com/storage-system-id-1"},
+								},
+							},
+						},
+					},
+				},
+			}
+			api.EXPECT().GetCSINodes().Times(1).Return(nodes, nil)
+
+			ids := []k8s.StorageSystemID{{ID: "storage-system-id-1", DriverNames: []string{"csi-vxflexos.dellemc.com"}}}
+
+			finder := k8s.SDCFinder{API: api, StorageSystemID: ids, NodeName: "worker-2"}
+			return finder, check(hasNoError, checkExpectedOutput([]string{"node-2"})), ctrl
+		},
+		"node-local with a local sdc guid": func(*testing.T) (k8s.SDCFinder, []checkFn, *gomock.Controller) {
+			ctrl := gomock.NewController(t)
+			api := mocks.NewMockKubernetesAPI(ctrl)
+			api.EXPECT().GetCSINodes().Times(0)
+
+			finder := k8s.SDCFinder{API: api, NodeName: "worker-1", SDCGUID: "local-guid"}
+			return finder, check(hasNoError, checkExpectedOutput([]string{"local-guid"})), ctrl
+		},
 		"success with multiple driver names": func(*testing.T) (k8s.SDCFinder, []checkFn, *gomock.Controller) {
 			ctrl := gomock.NewController(t)
 			api := mocks.NewMockKubernetesAPI(ctrl)
@@ -180,3 +228,64 @@ func Test_K8sSDCFinder(t *testing.T) {
 		})
 	}
 }
+
+func Test_K8sSDCFinder_GetSDCNodes(t *testing.T) {
+	ids := []k8s.StorageSystemID{{ID: "storage-system-id-1", DriverNames: []string{"csi-vxflexos.dellemc.com"}}}
+	csiNode := func(name string, guid string, topologyKey string) v1.CSINode {
+		return v1.CSINode{
+			ObjectMeta: metav1.ObjectMeta{Name: name},
+			Spec: v1.CSINodeSpec{
+				Drivers: []v1.CSINodeDriver{{Name: "csi-vxflexos.dellemc.com", NodeID: guid, TopologyKeys: []string{topologyKey}}},
+			},
+		}
+	}
+	nodes := &v1.CSINodeList{Items: []v1.CSINode{
+		csiNode("worker-1", "guid-1", "csi-vxflexos.dellemc.com/storage-system-id-1"),
+		csiNode("worker-2", "guid-2", "csi-vxflexos.dellemc.com/storage-system-id-1"),
+		csiNode("worker-3", "guid-3", "csi-vxflexos.dellemc.com/storage-system-id-2"),
+	}}
+
+	t.Run("maps the guid of each sdc to the node of its csinode", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		api := mocks.NewMockKubernetesAPI(ctrl)
+		api.EXPECT().GetCSINodes().Times(1).Return(nodes, nil)
+
+		finder := k8s.SDCFinder{API: api, StorageSystemID: ids}
+		sdcNodes, err := finder.GetSDCNodes()
+		assert.NoError(t, err)
+		assert.Equal(t, map[string]string{"guid-1": "worker-1", "guid-2": "worker-2"}, sdcNodes)
+	})
+
+	t.Run("only the node of this pod", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		api := mocks.NewMockKubernetesAPI(ctrl)
+		api.EXPECT().GetCSINodes().Times(1).Return(nodes, nil)
+
+		finder := k8s.SDCFinder{API: api, StorageSystemID: ids, NodeName: "worker-2"}
+		sdcNodes, err := finder.GetSDCNodes()
+		assert.NoError(t, err)
+		assert.Equal(t, map[string]string{"guid-2": "worker-2"}, sdcNodes)
+	})
+
+	t.Run("local sdc guid", func(t *testing.T) {
+		finder := k8s.SDCFinder{SDCGUID: "guid-local", NodeName: "worker-1"}
+		sdcNodes, err := finder.GetSDCNodes()
+		assert.NoError(t, err)
+		assert.Equal(t, map[string]string{"guid-local": "worker-1"}, sdcNodes)
+
+		finder = k8s.SDCFinder{SDCGUID: "guid-local"}
+		sdcNodes, err = finder.GetSDCNodes()
+		assert.NoError(t, err)
+		assert.Empty(t, sdcNodes)
+	})
+
+	t.Run("error calling k8s", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		api := mocks.NewMockKubernetesAPI(ctrl)
+		api.EXPECT().GetCSINodes().Times(1).Return(nil, errors.New("error"))
+
+		finder := k8s.SDCFinder{API: api, StorageSystemID: ids}
+		_, err := finder.GetSDCNodes()
+		assert.Error(t, err)
+	})
+}
@@ -0,0 +1,148 @@
+/*
+ Copyright (c) 2025 Dell Inc. or its subsidiaries. All Rights Reserved.
+
+ Licensed under the Apache License, Version 2.0 (the "License");
+ you may not use this file except in compliance with the License.
+ You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package k8s
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/dell/karavi-metrics-powerflex/internal/telemetry"
+	"github.com/sirupsen/logrus"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/fields"
+	"k8s.io/client-go/informers"
+	"k8s.io/client-go/kubernetes"
+	corelisters "k8s.io/client-go/listers/core/v1"
+	"k8s.io/client-go/tools/cache"
+)
+
+// SecretWatcher reads one key of a Secret through the kubernetes API and watches it. Unlike a mounted
+// Secret, which the kubelet updates with a delay and an atomic symlink swap that file watches can miss,
+// a change to the Secret is seen as soon as the API server sends it.
+type SecretWatcher struct {
+	Client    kubernetes.Interface
+	Namespace string
+	Name      string
+	Key       string
+	Logger    *logrus.Logger
+
+	mu       sync.Mutex
+	informer cache.SharedIndexInformer
+	secrets  corelisters.SecretLister
+	content  []byte
+}
+
+// Start watches the Secret until ctx is done and waits for the initial list, so that Content can be called when it returns.
+// Only the watched Secret is listed, which lets its RBAC role be restricted to that resource name.
+func (w *SecretWatcher) Start(ctx context.Context) error {
+	w.mu.Lock()
+	if w.informer != nil {
+		w.mu.Unlock()
+		return nil
+	}
+	factory := informers.NewSharedInformerFactoryWithOptions(w.Client, 0,
+		informers.WithNamespace(w.Namespace),
+		informers.WithTweakListOptions(func(options *metav1.ListOptions) {
+			options.FieldSelector = fields.OneTermEqualSelector("metadata.name", w.Name).String()
+		}),
+	)
+	secrets := factory.Core().V1().Secrets()
+	w.informer = secrets.Informer()
+	w.secrets = secrets.Lister()
+	factory.Start(ctx.Done())
+	informer := w.informer
+	w.mu.Unlock()
+
+	syncCtx, cancel := context.WithTimeout(ctx, CacheSyncTimeout)
+	defer cancel()
+	if !cache.WaitForCacheSync(syncCtx.Done(), informer.HasSynced) {
+		return fmt.Errorf("timed out waiting for secret %s/%s", w.Namespace, w.Name)
+	}
+	return nil
+}
+
+// Content returns the content of the watched key
+func (w *SecretWatcher) Content() ([]byte, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.secrets == nil {
+		return nil, errors.New("secret watcher is not started")
+	}
+
+	secret, err := w.secrets.Secrets(w.Namespace).Get(w.Name)
+	if apierrors.IsNotFound(err) {
+		return nil, fmt.Errorf("secret %s/%s does not exist", w.Namespace, w.Name)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("getting secret %s/%s: %w", w.Namespace, w.Name, err)
+	}
+	content, ok := secret.Data[w.Key]
+	if !ok {
+		return nil, fmt.Errorf("secret %s/%s has no %s key", w.Namespace, w.Name, w.Key)
+	}
+	w.content = content
+	return content, nil
+}
+
+// Watch calls onChange with the content of the key whenever it differs from the content last returned by
+// Content or passed to onChange. A change made between reading the Content and calling Watch is reported too.
+func (w *SecretWatcher) Watch(onChange func(content []byte)) error {
+	w.mu.Lock()
+	informer := w.informer
+	w.mu.Unlock()
+	if informer == nil {
+		return errors.New("secret watcher is not started")
+	}
+
+	update := func(obj interface{}) {
+		secret, ok := obj.(*corev1.Secret)
+		if !ok || secret.Name != w.Name {
+			return
+		}
+		content, ok := secret.Data[w.Key]
+		if !ok {
+			telemetry.Logger(w.Logger).WithField("secret", w.Namespace+"/"+w.Name).Warnf("storage system secret has no %s key, keeping the current configuration", w.Key)
+			return
+		}
+
+		w.mu.Lock()
+		changed := !bytes.Equal(w.content, content)
+		w.content = content
+		w.mu.Unlock()
+
+		if changed {
+			telemetry.Logger(w.Logger).WithField("secret", w.Namespace+"/"+w.Name).Info("storage system secret changed")
+			onChange(content)
+		}
+	}
+	_, err := informer.AddEventHandler(cache.ResourceEventHandlerFuncs{
+		AddFunc:    update,
+		UpdateFunc: func(_, obj interface{}) { update(obj) },
+		DeleteFunc: func(interface{}) {
+			telemetry.Logger(w.Logger).WithField("secret", w.Namespace+"/"+w.Name).Warn("storage system secret was deleted, keeping the current configuration")
+		},
+	})
+	if err != nil {
+		return fmt.Errorf("watching secret %s/%s: %w", w.Namespace, w.Name, err)
+	}
+	return nil
+}
@@ -0,0 +1,149 @@
+/*
+ Copyright (c) 2025 Dell Inc. or its subsidiaries. All Rights Reserved.
+
+ Licensed under the Apache License, Version 2.0 (the "License");
+ you may not use this file except in compliance with the License.
+ You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package k8s_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/dell/karavi-metrics-powerflex/internal/k8s"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func newTestSecret(data map[string]string) *corev1.Secret {
+	secret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: "vxflexos-config", Namespace: "vxflexos"},
+		Data:       map[string][]byte{},
+	}
+	for key, value := range data {
+		secret.Data[key] = []byte(value)
+	}
+	return secret
+}
+
+func newTestSecretWatcher(client kubernetes.Interface) *k8s.SecretWatcher {
+	return &k8s.SecretWatcher{
+		Client:    client,
+		Namespace: "vxflexos",
+		Name:      "vxflexos-config",
+		Key:       "config",
+		Logger:    logrus.New(),
+	}
+}
+
+func Test_SecretWatcher(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	client := fake.NewClientset(newTestSecret(map[string]string{"config": "- systemID: ID1\n"}))
+	watcher := newTestSecretWatcher(client)
+	require.NoError(t, watcher.Start(ctx))
+
+	content, err := watcher.Content()
+	require.NoError(t, err)
+	assert.Equal(t, "- systemID: ID1\n", string(content))
+
+	changes := make(chan string, 10)
+	require.NoError(t, watcher.Watch(func(content []byte) { changes <- string(content) }))
+
+	// the content that was already read is not reported again
+	select {
+	case change := <-changes:
+		t.Fatalf("unexpected change %q", change)
+	case <-time.After(100 * time.Millisecond):
+	}
+
+	// a change to another key is ignored
+	_, err = client.CoreV1().Secrets("vxflexos").Update(ctx, newTestSecret(map[string]string{"config": "- systemID: ID1\n", "other": "value"}), metav1.UpdateOptions{})
+	require.NoError(t, err)
+	select {
+	case change := <-changes:
+		t.Fatalf("unexpected change %q", change)
+	case <-time.After(100 * time.Millisecond):
+	}
+
+	_, err = client.CoreV1().Secrets("vxflexos").Update(ctx, newTestSecret(map[string]string{"config": `[{"systemID": "ID2"}]`}), metav1.UpdateOptions{})
+	require.NoError(t, err)
+	select {
+	case change := <-changes:
+		assert.Equal(t, `[{"systemID": "ID2"}]`, change)
+	case <-time.After(5 * time.Second):
+		t.Fatal("expected the change to be reported")
+	}
+}
+
+func Test_SecretWatcher_ChangeBeforeWatch(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	client := fake.NewClientset(newTestSecret(map[string]string{"config": "- systemID: ID1\n"}))
+	watcher := newTestSecretWatcher(client)
+	require.NoError(t, watcher.Start(ctx))
+	_, err := watcher.Content()
+	require.NoError(t, err)
+
+	// the change is reported whether the handler sees it in the initial replay or as an update
+	_, err = client.CoreV1().Secrets("vxflexos").Update(ctx, newTestSecret(map[string]string{"config": "- systemID: ID2\n"}), metav1.UpdateOptions{})
+	require.NoError(t, err)
+	changes := make(chan string, 10)
+	require.NoError(t, watcher.Watch(func(content []byte) { changes <- string(content) }))
+
+	select {
+	case change := <-changes:
+		assert.Equal(t, "- systemID: ID2\n", change)
+	case <-time.After(5 * time.Second):
+		t.Fatal("expected the change to be reported")
+	}
+	select {
+	case change := <-changes:
+		t.Fatalf("unexpected change %q", change)
+	case <-time.After(100 * time.Millisecond):
+	}
+}
+
+func Test_SecretWatcher_Errors(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	t.Run("not started", func(t *testing.T) {
+		watcher := newTestSecretWatcher(fake.NewClientset())
+		_, err := watcher.Content()
+		assert.Error(t, err)
+		assert.Error(t, watcher.Watch(func([]byte) {}))
+	})
+
+	t.Run("missing secret", func(t *testing.T) {
+		watcher := newTestSecretWatcher(fake.NewClientset())
+		require.NoError(t, watcher.Start(ctx))
+		_, err := watcher.Content()
+		assert.ErrorContains(t, err, "secret vxflexos/vxflexos-config does not exist")
+	})
+
+	t.Run("missing key", func(t *testing.T) {
+		watcher := newTestSecretWatcher(fake.NewClientset(newTestSecret(map[string]string{"other": "value"})))
+		require.NoError(t, watcher.Start(ctx))
+		_, err := watcher.Content()
+		assert.ErrorContains(t, err, "secret vxflexos/vxflexos-config has no config key")
+	})
+}
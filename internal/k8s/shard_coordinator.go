@@ -0,0 +1,371 @@
+/*
+ Copyright (c) 2025 Dell Inc. or its subsidiaries. All Rights Reserved.
+
+ Licensed under the Apache License, Version 2.0 (the "License");
+ you may not use this file except in compliance with the License.
+ You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package k8s
+
+import (
+	"context"
+	"fmt"
+	"hash/fnv"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/dell/karavi-metrics-powerflex/internal/telemetry"
+	"github.com/sirupsen/logrus"
+	coordinationv1 "k8s.io/api/coordination/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+)
+
+const (
+	// ClusterShardKey is the shard that owns work which isn't tied to one storage system, e.g. topology metrics
+	ClusterShardKey = "cluster"
+
+	shardGroupLabel = "metrics.dell.com/shard-group"
+	shardRoleLabel  = "metrics.dell.com/shard-role"
+	roleMember      = "member"
+	roleShard       = "shard"
+)
+
+// ShardCoordinator spreads storage systems across replicas.
+// Every replica renews a member Lease, and each storage system has its own Lease held by the replica that
+// collects it. The preferred owner of a storage system is picked by rendezvous hashing over the live members,
+// so ownership rebalances with little movement when replicas come and go. A replica only takes a storage
+// system's Lease once the previous holder released it or let it expire, so two replicas never collect the same array.
+// A replica that can't renew its Leases, e.g. because the API server is unreachable, stops collecting every storage
+// system when a Sync fails or once its last successful Sync is older than the LeaseDuration.
+type ShardCoordinator struct {
+	Client        kubernetes.Interface
+	Namespace     string
+	Group         string
+	Identity      string
+	LeaseDuration time.Duration
+	RetryPeriod   time.Duration
+	// StorageSystemIDs returns the storage systems to distribute
+	StorageSystemIDs func() []string
+	// OnStartedLeading is called in its own goroutine when this replica acquires the ClusterShardKey shard
+	OnStartedLeading func(context.Context)
+	// OnStoppedLeading is called when this replica gives up the ClusterShardKey shard
+	OnStoppedLeading func()
+	Logger           *logrus.Logger
+
+	mu      sync.RWMutex
+	owned   map[string]bool
+	members []string
+	// renewed is when the Leases in owned were last renewed
+	renewed time.Time
+}
+
+// Run synchronizes ownership every RetryPeriod until ctx is done, then releases the Leases held by this replica
+func (c *ShardCoordinator) Run(ctx context.Context) error {
+	ticker := time.NewTicker(durationOrDefault(c.RetryPeriod, DefaultRetryPeriod))
+	defer ticker.Stop()
+	for {
+		if err := c.Sync(ctx); err != nil {
+			telemetry.Logger(c.Logger).WithError(err).Warn("synchronizing storage system shards")
+		}
+		select {
+		case <-ctx.Done():
+			c.release()
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
+
+// Sync renews this replica's membership and acquires or releases storage system Leases once.
+// If it fails, this replica gives up every storage system until a later Sync succeeds.
+func (c *ShardCoordinator) Sync(ctx context.Context) error {
+	now := time.Now()
+	if err := c.renewMembership(ctx, now); err != nil {
+		c.setOwned(ctx, nil, nil, time.Time{})
+		return err
+	}
+	members, err := c.liveMembers(ctx, now)
+	if err != nil {
+		c.setOwned(ctx, nil, nil, time.Time{})
+		return err
+	}
+
+	var ids []string
+	if c.StorageSystemIDs != nil {
+		ids = append(ids, c.StorageSystemIDs()...)
+	}
+	ids = append(ids, ClusterShardKey)
+
+	owned := make(map[string]bool, len(ids))
+	for _, id := range ids {
+		preferred := rendezvousOwner(members, id) == c.Identity
+		holds, err := c.syncShard(ctx, id, preferred, now)
+		if err != nil {
+			telemetry.Logger(c.Logger).WithError(err).WithField("storage_system_id", id).Debug("synchronizing storage system lease")
+		}
+		owned[id] = holds
+	}
+
+	c.setOwned(ctx, owned, members, now)
+	return nil
+}
+
+// setOwned replaces the storage systems held by this replica, logs the changes and reports a change of the
+// ClusterShardKey shard to OnStartedLeading or OnStoppedLeading
+func (c *ShardCoordinator) setOwned(ctx context.Context, owned map[string]bool, members []string, renewed time.Time) {
+	c.mu.Lock()
+	previous := c.owned
+	c.owned = owned
+	c.members = members
+	c.renewed = renewed
+	c.mu.Unlock()
+
+	for id, holds := range owned {
+		if holds && !previous[id] {
+			telemetry.Logger(c.Logger).WithFields(logrus.Fields{"storage_system_id": id, "identity": c.Identity}).Info("acquired storage system shard")
+		}
+	}
+	for id, held := range previous {
+		if held && !owned[id] {
+			telemetry.Logger(c.Logger).WithFields(logrus.Fields{"storage_system_id": id, "identity": c.Identity}).Info("released storage system shard")
+		}
+	}
+	c.clusterShardChanged(ctx, previous[ClusterShardKey], owned[ClusterShardKey])
+}
+
+func (c *ShardCoordinator) clusterShardChanged(ctx context.Context, held bool, holds bool) {
+	switch {
+	case holds && !held && c.OnStartedLeading != nil:
+		go c.OnStartedLeading(ctx)
+	case held && !holds && c.OnStoppedLeading != nil:
+		c.OnStoppedLeading()
+	}
+}
+
+// Owns returns true if this replica holds the Lease of the storage system and renewed it within the LeaseDuration
+func (c *ShardCoordinator) Owns(storageSystemID string) bool {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	if time.Since(c.renewed) > durationOrDefault(c.LeaseDuration, DefaultLeaseDuration) {
+		// the Lease may have expired and been taken over by another replica
+		return false
+	}
+	return c.owned[storageSystemID]
+}
+
+// Members returns the identities of the replicas seen in the last Sync
+func (c *ShardCoordinator) Members() []string {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return append([]string(nil), c.members...)
+}
+
+func (c *ShardCoordinator) renewMembership(ctx context.Context, now time.Time) error {
+	_, err := c.acquire(ctx, c.leaseName(roleMember, c.Identity), roleMember, now, true)
+	return err
+}
+
+// liveMembers returns the sorted identities of the replicas whose member Lease has not expired
+func (c *ShardCoordinator) liveMembers(ctx context.Context, now time.Time) ([]string, error) {
+	leases, err := c.Client.CoordinationV1().Leases(c.Namespace).List(ctx, metav1.ListOptions{
+		LabelSelector: shardGroupLabel + "=" + sanitizeName(c.Group) + "," + shardRoleLabel + "=" + roleMember,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	members := []string{c.Identity}
+	for _, lease := range leases.Items {
+		holder := holderOf(&lease)
+		if holder == "" || holder == c.Identity || expired(&lease, now) {
+			continue
+		}
+		members = append(members, holder)
+	}
+	sort.Strings(members)
+	return members, nil
+}
+
+// syncShard keeps, takes or gives up the Lease of one storage system and returns whether this replica holds it
+func (c *ShardCoordinator) syncShard(ctx context.Context, id string, preferred bool, now time.Time) (bool, error) {
+	name := c.leaseName(roleShard, id)
+	if preferred {
+		return c.acquire(ctx, name, roleShard, now, false)
+	}
+
+	lease, err := c.Client.CoordinationV1().Leases(c.Namespace).Get(ctx, name, metav1.GetOptions{})
+	if apierrors.IsNotFound(err) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	if holderOf(lease) == c.Identity {
+		// hand the storage system over to its preferred owner
+		return false, c.delete(ctx, lease)
+	}
+	return false, nil
+}
+
+// acquire creates or renews the Lease for this replica. Unless force is set, a Lease held by another
+// replica is only taken over once it has expired.
+func (c *ShardCoordinator) acquire(ctx context.Context, name string, role string, now time.Time, force bool) (bool, error) {
+	leases := c.Client.CoordinationV1().Leases(c.Namespace)
+	lease, err := leases.Get(ctx, name, metav1.GetOptions{})
+	if apierrors.IsNotFound(err) {
+		_, err = leases.Create(ctx, c.newLease(name, role, now), metav1.CreateOptions{})
+		return err == nil, err
+	}
+	if err != nil {
+		return false, err
+	}
+
+	holder := holderOf(lease)
+	if holder != c.Identity && holder != "" && !expired(lease, now) && !force {
+		return false, nil
+	}
+
+	updated := lease.DeepCopy()
+	desired := c.newLease(name, role, now)
+	updated.Labels = desired.Labels
+	if holder != c.Identity {
+		updated.Spec.AcquireTime = desired.Spec.AcquireTime
+	}
+	updated.Spec.HolderIdentity = desired.Spec.HolderIdentity
+	updated.Spec.LeaseDurationSeconds = desired.Spec.LeaseDurationSeconds
+	updated.Spec.RenewTime = desired.Spec.RenewTime
+	_, err = leases.Update(ctx, updated, metav1.UpdateOptions{})
+	return err == nil, err
+}
+
+func (c *ShardCoordinator) delete(ctx context.Context, lease *coordinationv1.Lease) error {
+	resourceVersion := lease.ResourceVersion
+	err := c.Client.CoordinationV1().Leases(c.Namespace).Delete(ctx, lease.Name, metav1.DeleteOptions{
+		Preconditions: &metav1.Preconditions{ResourceVersion: &resourceVersion},
+	})
+	if apierrors.IsNotFound(err) {
+		return nil
+	}
+	return err
+}
+
+// release gives up every Lease held by this replica so the others can take over without waiting for expiry
+func (c *ShardCoordinator) release() {
+	ctx, cancel := context.WithTimeout(context.Background(), durationOrDefault(c.RetryPeriod, DefaultRetryPeriod))
+	defer cancel()
+
+	c.mu.Lock()
+	owned := c.owned
+	c.owned = nil
+	c.mu.Unlock()
+	c.clusterShardChanged(ctx, owned[ClusterShardKey], false)
+
+	names := []string{c.leaseName(roleMember, c.Identity)}
+	for id, holds := range owned {
+		if holds {
+			names = append(names, c.leaseName(roleShard, id))
+		}
+	}
+	for _, name := range names {
+		lease, err := c.Client.CoordinationV1().Leases(c.Namespace).Get(ctx, name, metav1.GetOptions{})
+		if err != nil || holderOf(lease) != c.Identity {
+			continue
+		}
+		if err := c.delete(ctx, lease); err != nil {
+			telemetry.Logger(c.Logger).WithError(err).WithField("lease", name).Debug("releasing lease")
+		}
+	}
+}
+
+func (c *ShardCoordinator) newLease(name string, role string, now time.Time) *coordinationv1.Lease {
+	identity := c.Identity
+	leaseDurationSeconds := int32(durationOrDefault(c.LeaseDuration, DefaultLeaseDuration) / time.Second)
+	renewTime := metav1.NewMicroTime(now)
+	return &coordinationv1.Lease{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: c.Namespace,
+			Labels: map[string]string{
+				shardGroupLabel: sanitizeName(c.Group),
+				shardRoleLabel:  role,
+			},
+		},
+		Spec: coordinationv1.LeaseSpec{
+			HolderIdentity:       &identity,
+			LeaseDurationSeconds: &leaseDurationSeconds,
+			AcquireTime:          &renewTime,
+			RenewTime:            &renewTime,
+		},
+	}
+}
+
+// leaseName returns the name of the Lease of a member or storage system. The identity or storage system ID is
+// hashed, because pod names that share the long prefix of their ReplicaSet would collide if they were cut short.
+func (c *ShardCoordinator) leaseName(role string, id string) string {
+	h := fnv.New64a()
+	_, _ = h.Write([]byte(id))
+	return fmt.Sprintf("%s-%s-%016x", sanitizeName(c.Group), role, h.Sum64())
+}
+
+func holderOf(lease *coordinationv1.Lease) string {
+	if lease.Spec.HolderIdentity == nil {
+		return ""
+	}
+	return *lease.Spec.HolderIdentity
+}
+
+func expired(lease *coordinationv1.Lease, now time.Time) bool {
+	if lease.Spec.RenewTime == nil || lease.Spec.LeaseDurationSeconds == nil {
+		return true
+	}
+	expiry := lease.Spec.RenewTime.Add(time.Duration(*lease.Spec.LeaseDurationSeconds) * time.Second)
+	return now.After(expiry)
+}
+
+// rendezvousOwner returns the member with the highest hash for id
+func rendezvousOwner(members []string, id string) string {
+	var owner string
+	var highest uint64
+	for _, member := range members {
+		h := fnv.New64a()
+		_, _ = h.Write([]byte(member + "/" + id))
+		score := h.Sum64()
+		if owner == "" || score > highest || (score == highest && member < owner) {
+			owner = member
+			highest = score
+		}
+	}
+	return owner
+}
+
+// sanitizeName turns s into a valid object name or label value
+func sanitizeName(s string) string {
+	name := strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'a' && r <= 'z', r >= '0' && r <= '9', r == '-', r == '.':
+			return r
+		case r >= 'A' && r <= 'Z':
+			return r + ('a' - 'A')
+		default:
+			return '-'
+		}
+	}, s)
+	name = strings.Trim(name, "-.")
+	if len(name) > 63 {
+		name = strings.Trim(name[:63], "-.")
+	}
+	return name
+}
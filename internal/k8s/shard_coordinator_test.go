@@ -0,0 +1,232 @@
+/*
+ Copyright (c) 2025 Dell Inc. or its subsidiaries. All Rights Reserved.
+
+ Licensed under the Apache License, Version 2.0 (the "License");
+ you may not use this file except in compliance with the License.
+ You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package k8s_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/dell/karavi-metrics-powerflex/internal/k8s"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/kubernetes/fake"
+	clienttesting "k8s.io/client-go/testing"
+)
+
+func newTestShardCoordinator(client kubernetes.Interface, identity string, ids ...string) *k8s.ShardCoordinator {
+	return &k8s.ShardCoordinator{
+		Client:           client,
+		Namespace:        "powerflex",
+		Group:            "karavi-metrics-powerflex",
+		Identity:         identity,
+		LeaseDuration:    time.Minute,
+		RetryPeriod:      10 * time.Millisecond,
+		StorageSystemIDs: func() []string { return ids },
+		Logger:           logrus.New(),
+	}
+}
+
+func Test_ShardCoordinator(t *testing.T) {
+	ids := []string{"system-1", "system-2", "system-3", "system-4", "system-5", "system-6"}
+	ctx := context.Background()
+
+	t.Run("single replica owns every storage system", func(t *testing.T) {
+		coordinator := newTestShardCoordinator(fake.NewClientset(), "replica-a", ids...)
+		require.NoError(t, coordinator.Sync(ctx))
+
+		for _, id := range append(ids, k8s.ClusterShardKey) {
+			assert.True(t, coordinator.Owns(id), id)
+		}
+		assert.Equal(t, []string{"replica-a"}, coordinator.Members())
+	})
+
+	t.Run("storage systems rebalance when a replica joins and leaves", func(t *testing.T) {
+		client := fake.NewClientset()
+		a := newTestShardCoordinator(client, "replica-a", ids...)
+		b := newTestShardCoordinator(client, "replica-b", ids...)
+
+		require.NoError(t, a.Sync(ctx))
+		require.NoError(t, b.Sync(ctx))
+		// replica-b waits for replica-a to hand over its storage systems
+		for _, id := range ids {
+			assert.False(t, a.Owns(id) && b.Owns(id), id)
+		}
+
+		require.NoError(t, a.Sync(ctx))
+		require.NoError(t, b.Sync(ctx))
+		assert.Equal(t, []string{"replica-a", "replica-b"}, b.Members())
+
+		ownedByA, ownedByB := 0, 0
+		for _, id := range append(ids, k8s.ClusterShardKey) {
+			assert.True(t, a.Owns(id) != b.Owns(id), "%s should be owned by exactly one replica", id)
+			if a.Owns(id) {
+				ownedByA++
+			} else {
+				ownedByB++
+			}
+		}
+		assert.NotZero(t, ownedByA)
+		assert.NotZero(t, ownedByB)
+
+		// replica-b shuts down and releases its leases, so replica-a takes everything back
+		runCtx, cancel := context.WithCancel(ctx)
+		cancel()
+		require.NoError(t, b.Run(runCtx))
+		for _, id := range ids {
+			assert.False(t, b.Owns(id), id)
+		}
+
+		require.NoError(t, a.Sync(ctx))
+		for _, id := range append(ids, k8s.ClusterShardKey) {
+			assert.True(t, a.Owns(id), id)
+		}
+	})
+
+	t.Run("takes over storage systems of an expired replica", func(t *testing.T) {
+		client := fake.NewClientset()
+		gone := newTestShardCoordinator(client, "replica-gone", ids...)
+		require.NoError(t, gone.Sync(ctx))
+
+		// replica-gone stopped renewing its leases an hour ago
+		leases, err := client.CoordinationV1().Leases("powerflex").List(ctx, metav1.ListOptions{})
+		require.NoError(t, err)
+		renewTime := metav1.NewMicroTime(time.Now().Add(-time.Hour))
+		for i := range leases.Items {
+			leases.Items[i].Spec.RenewTime = &renewTime
+			_, err := client.CoordinationV1().Leases("powerflex").Update(ctx, &leases.Items[i], metav1.UpdateOptions{})
+			require.NoError(t, err)
+		}
+
+		coordinator := newTestShardCoordinator(client, "replica-a", ids...)
+		require.NoError(t, coordinator.Sync(ctx))
+		for _, id := range ids {
+			assert.True(t, coordinator.Owns(id), id)
+		}
+	})
+
+	t.Run("does not take over storage systems held by a live replica", func(t *testing.T) {
+		client := fake.NewClientset()
+		b := newTestShardCoordinator(client, "replica-b", "system-1")
+		require.NoError(t, b.Sync(ctx))
+
+		// replica-a doesn't see replica-b as a member, so it prefers every storage system
+		require.NoError(t, client.CoordinationV1().Leases("powerflex").DeleteCollection(ctx, metav1.DeleteOptions{}, metav1.ListOptions{
+			LabelSelector: "metrics.dell.com/shard-role=member",
+		}))
+
+		coordinator := newTestShardCoordinator(client, "replica-a", "system-1", "system-2")
+		require.NoError(t, coordinator.Sync(ctx))
+		assert.False(t, coordinator.Owns("system-1"))
+		assert.True(t, coordinator.Owns("system-2"))
+	})
+
+	t.Run("replicas with long names that share a prefix have their own member leases", func(t *testing.T) {
+		client := fake.NewClientset()
+		a := newTestShardCoordinator(client, "karavi-metrics-powerflex-6b8f9d7c4-xk2lp-with-a-long-suffix", ids...)
+		b := newTestShardCoordinator(client, "karavi-metrics-powerflex-6b8f9d7c4-zq9mv-with-a-long-suffix", ids...)
+
+		for i := 0; i < 3; i++ {
+			require.NoError(t, a.Sync(ctx))
+			require.NoError(t, b.Sync(ctx))
+		}
+		assert.Equal(t, []string{a.Identity, b.Identity}, a.Members())
+		assert.Equal(t, []string{a.Identity, b.Identity}, b.Members())
+
+		members, err := client.CoordinationV1().Leases("powerflex").List(ctx, metav1.ListOptions{
+			LabelSelector: "metrics.dell.com/shard-role=member",
+		})
+		require.NoError(t, err)
+		assert.Len(t, members.Items, 2)
+		for _, lease := range members.Items {
+			assert.LessOrEqual(t, len(lease.Name), 253)
+		}
+		for _, id := range append(ids, k8s.ClusterShardKey) {
+			assert.True(t, a.Owns(id) != b.Owns(id), "%s should be owned by exactly one replica", id)
+		}
+	})
+
+	t.Run("gives up every storage system when the API server is unreachable", func(t *testing.T) {
+		client := fake.NewClientset()
+		coordinator := newTestShardCoordinator(client, "replica-a", ids...)
+		require.NoError(t, coordinator.Sync(ctx))
+		for _, id := range ids {
+			assert.True(t, coordinator.Owns(id), id)
+		}
+
+		client.PrependReactor("*", "leases", func(clienttesting.Action) (bool, runtime.Object, error) {
+			return true, nil, errors.New("connection refused")
+		})
+		assert.Error(t, coordinator.Sync(ctx))
+		for _, id := range append(ids, k8s.ClusterShardKey) {
+			assert.False(t, coordinator.Owns(id), id)
+		}
+	})
+
+	t.Run("gives up every storage system once the leases weren't renewed within the lease duration", func(t *testing.T) {
+		coordinator := newTestShardCoordinator(fake.NewClientset(), "replica-a", ids...)
+		coordinator.LeaseDuration = 500 * time.Millisecond
+		require.NoError(t, coordinator.Sync(ctx))
+		assert.True(t, coordinator.Owns("system-1"))
+
+		// e.g. Sync is stuck on a request to an API server that doesn't answer
+		time.Sleep(600 * time.Millisecond)
+		assert.False(t, coordinator.Owns("system-1"))
+	})
+
+	t.Run("reports acquiring and giving up the cluster shard", func(t *testing.T) {
+		client := fake.NewClientset()
+		coordinator := newTestShardCoordinator(client, "replica-a", ids...)
+		started := make(chan struct{}, 1)
+		stopped := 0
+		coordinator.OnStartedLeading = func(context.Context) { started <- struct{}{} }
+		coordinator.OnStoppedLeading = func() { stopped++ }
+
+		require.NoError(t, coordinator.Sync(ctx))
+		require.NoError(t, coordinator.Sync(ctx))
+		select {
+		case <-started:
+		case <-time.After(time.Second):
+			t.Fatal("OnStartedLeading was not called")
+		}
+		assert.Empty(t, started, "OnStartedLeading should only be called once")
+		assert.Equal(t, 0, stopped)
+
+		client.PrependReactor("*", "leases", func(clienttesting.Action) (bool, runtime.Object, error) {
+			return true, nil, errors.New("connection refused")
+		})
+		assert.Error(t, coordinator.Sync(ctx))
+		assert.Error(t, coordinator.Sync(ctx))
+		assert.Equal(t, 1, stopped)
+	})
+}
+
+func Test_LeaderElector_Owns(t *testing.T) {
+	assert.True(t, (&k8s.LeaderElector{}).Owns("system-1"))
+	assert.True(t, (&k8s.LeaderElector{Disabled: true, Sharding: true}).Owns("system-1"))
+
+	sharded := &k8s.LeaderElector{Sharding: true}
+	assert.False(t, sharded.Owns("system-1"))
+	assert.False(t, sharded.IsLeader())
+	assert.False(t, sharded.HoldsLease())
+	assert.False(t, (&k8s.LeaderElector{}).HoldsLease())
+}
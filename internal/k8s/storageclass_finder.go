@@ -42,12 +42,19 @@ type StorageSystemID struct {
 type StorageClassFinder struct {
 	API             StorageClassGetter
 	StorageSystemID []StorageSystemID
+	// Filter leaves out the storage classes whose volumes are not exported
+	Filter          *VolumeFilter
+	FilteredObjects *FilteredObjects
+	// Labels selects the labels that are exported as attributes of the capacity of the storage class
+	Labels *LabelAllowlist
 }
 
 // StorageClass wraps a kubernetes StorageClass to include a SystemID
 type StorageClass struct {
 	v1.StorageClass
 	SystemID string
+	// Attributes are the allowed labels of the storage class, keyed by attribute name
+	Attributes map[string]string
 }
 
 // GetStorageClasses will return a list of storage classes that match the given DriverName in Kubernetes
@@ -59,11 +66,19 @@ func (f *StorageClassFinder) GetStorageClasses() ([]StorageClass, error) {
 		return nil, err
 	}
 
+	filter, allowlist := f.Filter, f.Labels
+	filtered := 0
 	for _, class := range classes.Items {
 		if sc := f.isMatch(class); sc != nil {
+			if !filter.IncludesStorageClass(class.Name) {
+				filtered++
+				continue
+			}
+			sc.Attributes = allowlist.StorageClassAttributes(class.Labels)
 			storageClasses = append(storageClasses, *sc)
 		}
 	}
+	f.FilteredObjects.Set(KindStorageClass, filtered)
 	return storageClasses, nil
 }
 
@@ -84,7 +99,7 @@ func (f *StorageClassFinder) isMatch(class v1.StorageClass) *StorageClass {
 				for _, matchedLabelExpression := range allowedTopology.MatchLabelExpressions {
 					if matchedLabelExpression.Key == labelKey {
 						if slices.Contains(matchedLabelExpression.Values, zone) {
-							return &StorageClass{class, storage.ID}
+							return &StorageClass{StorageClass: class, SystemID: storage.ID}
 						}
 					}
 				}
@@ -93,7 +108,7 @@ func (f *StorageClassFinder) isMatch(class v1.StorageClass) *StorageClass {
 		}
 
 		if systemID == storage.ID {
-			return &StorageClass{class, storage.ID}
+			return &StorageClass{StorageClass: class, SystemID: storage.ID}
 		}
 	}
 
@@ -105,7 +120,7 @@ func (f *StorageClassFinder) isMatch(class v1.StorageClass) *StorageClass {
 		systemID, systemIDExists := class.Parameters["systemID"]
 		// if a storage system is marked as default, the StorageClass is a match if either the 'systemID' key does not exist or if it matches the storage system ID
 		if storage.IsDefault && (!systemIDExists || systemID == storage.ID) {
-			return &StorageClass{class, storage.ID}
+			return &StorageClass{StorageClass: class, SystemID: storage.ID}
 		}
 	}
 
@@ -505,6 +505,63 @@ func Test_K8sStorageClassFinder(t *testing.T) {
 			},
 			)), ctrl
 		},
+		"success leaving out storage classes excluded by the volume filter": func(*testing.T) (k8s.StorageClassFinder, []checkFn, *gomock.Controller) {
+			ctrl := gomock.NewController(t)
+			api := mocks.NewMockStorageClassGetter(ctrl)
+
+			storageClasses := &v1.StorageClassList{
+				Items: []v1.StorageClass{
+					{
+						ObjectMeta:  metav1.ObjectMeta{Name: "vxflexos"},
+						Provisioner: "csi-vxflexos.dellemc.com",
+						Parameters:  map[string]string{"storagepool": "mypool", "systemID": "storage-system-id-1"},
+					},
+					{
+						ObjectMeta:  metav1.ObjectMeta{Name: "vxflexos-scratch"},
+						Provisioner: "csi-vxflexos.dellemc.com",
+						Parameters:  map[string]string{"storagepool": "mypool", "systemID": "storage-system-id-1"},
+					},
+				},
+			}
+
+			api.EXPECT().GetStorageClasses().Times(1).Return(storageClasses, nil)
+			ids := []k8s.StorageSystemID{{ID: "storage-system-id-1", DriverNames: []string{"csi-vxflexos.dellemc.com"}}}
+
+			filteredObjects := &k8s.FilteredObjects{}
+			finder := k8s.StorageClassFinder{
+				API:             api,
+				StorageSystemID: ids,
+				Filter:          &k8s.VolumeFilter{ExcludeStorageClasses: []string{"vxflexos-scratch"}},
+				FilteredObjects: filteredObjects,
+			}
+			countsFiltered := func(t *testing.T, _ []k8s.StorageClass, _ error) {
+				assert.Equal(t, map[string]int64{k8s.KindStorageClass: 1}, filteredObjects.Counts())
+			}
+			return finder, check(hasNoError, checkExpectedOutput([]k8s.StorageClass{{StorageClass: storageClasses.Items[0], SystemID: "storage-system-id-1"}}), countsFiltered), ctrl
+		},
+		"success exporting the allowed labels of storage classes": func(*testing.T) (k8s.StorageClassFinder, []checkFn, *gomock.Controller) {
+			ctrl := gomock.NewController(t)
+			api := mocks.NewMockStorageClassGetter(ctrl)
+
+			class := v1.StorageClass{
+				ObjectMeta:  metav1.ObjectMeta{Name: "vxflexos", Labels: map[string]string{"tier": "gold", "team": "storage"}},
+				Provisioner: "csi-vxflexos.dellemc.com",
+				Parameters:  map[string]string{"storagepool": "mypool", "systemID": "storage-system-id-1"},
+			}
+			api.EXPECT().GetStorageClasses().Times(1).Return(&v1.StorageClassList{Items: []v1.StorageClass{class}}, nil)
+			ids := []k8s.StorageSystemID{{ID: "storage-system-id-1", DriverNames: []string{"csi-vxflexos.dellemc.com"}}}
+
+			finder := k8s.StorageClassFinder{
+				API:             api,
+				StorageSystemID: ids,
+				Labels:          &k8s.LabelAllowlist{Keys: k8s.LabelKeys{StorageClass: []string{"tier"}}},
+			}
+			return finder, check(hasNoError, checkExpectedOutput([]k8s.StorageClass{{
+				StorageClass: class,
+				SystemID:     "storage-system-id-1",
+				Attributes:   map[string]string{"label_tier": "gold"},
+			}})), ctrl
+		},
 		"error calling k8s": func(*testing.T) (k8s.StorageClassFinder, []checkFn, *gomock.Controller) {
 			ctrl := gomock.NewController(t)
 			api := mocks.NewMockStorageClassGetter(ctrl)
@@ -0,0 +1,130 @@
+/*
+ Copyright (c) 2025 Dell Inc. or its subsidiaries. All Rights Reserved.
+
+ Licensed under the Apache License, Version 2.0 (the "License");
+ you may not use this file except in compliance with the License.
+ You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package k8s
+
+import (
+	"sort"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	// WorkloadKindDeployment is the workload kind of pods whose replica set is owned by a Deployment
+	WorkloadKindDeployment = "Deployment"
+	// WorkloadKindReplicaSet is the workload kind of pods whose replica set has no owner
+	WorkloadKindReplicaSet = "ReplicaSet"
+	// WorkloadKindPod is the workload kind of pods that have no controller
+	WorkloadKindPod = "Pod"
+)
+
+// ConsumerGetter is an interface for getting the pods that mount persistent volume claims and the replica sets that own them
+//
+//go:generate mockgen -destination=mocks/consumer_getter_mocks.go -package=mocks github.com/dell/karavi-metrics-powerflex/internal/k8s ConsumerGetter
+type ConsumerGetter interface {
+	GetPods() (*corev1.PodList, error)
+	GetReplicaSets() (*appsv1.ReplicaSetList, error)
+}
+
+// VolumeConsumer is a pod that mounts a persistent volume claim and the workload that runs it
+type VolumeConsumer struct {
+	Pod          string `json:"pod"`
+	WorkloadKind string `json:"workload_kind"`
+	WorkloadName string `json:"workload_name"`
+}
+
+// GetVolumeConsumers resolves the pods that mount each persistent volume claim and walks their owner references
+// up to the Deployment, StatefulSet, DaemonSet or Job that runs them. Pods that have terminated are not consumers.
+// The consumers are keyed by the namespace/name of the claim and sorted by pod name.
+func GetVolumeConsumers(api ConsumerGetter) (map[string][]VolumeConsumer, error) {
+	pods, err := api.GetPods()
+	if err != nil {
+		return nil, err
+	}
+
+	// replica sets are only needed to find the Deployment of their pods
+	var replicaSets map[string]appsv1.ReplicaSet
+	consumers := make(map[string][]VolumeConsumer)
+	for _, pod := range pods.Items {
+		if pod.Status.Phase == corev1.PodSucceeded || pod.Status.Phase == corev1.PodFailed {
+			continue
+		}
+		claims := podClaims(pod)
+		if len(claims) == 0 {
+			continue
+		}
+
+		kind, name := WorkloadKindPod, pod.Name
+		if owner := metav1.GetControllerOf(&pod); owner != nil {
+			kind, name = owner.Kind, owner.Name
+			if kind == WorkloadKindReplicaSet {
+				if replicaSets == nil {
+					replicaSets, err = getReplicaSets(api)
+					if err != nil {
+						return nil, err
+					}
+				}
+				if replicaSet, ok := replicaSets[pod.Namespace+"/"+name]; ok {
+					if owner := metav1.GetControllerOf(&replicaSet); owner != nil && owner.Kind == WorkloadKindDeployment {
+						kind, name = owner.Kind, owner.Name
+					}
+				}
+			}
+		}
+
+		for _, claim := range claims {
+			key := pod.Namespace + "/" + claim
+			consumers[key] = append(consumers[key], VolumeConsumer{
+				Pod:          pod.Name,
+				WorkloadKind: kind,
+				WorkloadName: name,
+			})
+		}
+	}
+
+	for _, list := range consumers {
+		sort.Slice(list, func(i, j int) bool { return list[i].Pod < list[j].Pod })
+	}
+	return consumers, nil
+}
+
+func getReplicaSets(api ConsumerGetter) (map[string]appsv1.ReplicaSet, error) {
+	list, err := api.GetReplicaSets()
+	if err != nil {
+		return nil, err
+	}
+	replicaSets := make(map[string]appsv1.ReplicaSet, len(list.Items))
+	for _, replicaSet := range list.Items {
+		replicaSets[replicaSet.Namespace+"/"+replicaSet.Name] = replicaSet
+	}
+	return replicaSets, nil
+}
+
+// podClaims returns the names of the persistent volume claims mounted by pod, including the claims of its generic ephemeral volumes
+func podClaims(pod corev1.Pod) []string {
+	var claims []string
+	for _, volume := range pod.Spec.Volumes {
+		switch {
+		case volume.PersistentVolumeClaim != nil:
+			claims = append(claims, volume.PersistentVolumeClaim.ClaimName)
+		case volume.Ephemeral != nil:
+			claims = append(claims, pod.Name+"-"+volume.Name)
+		}
+	}
+	return claims
+}
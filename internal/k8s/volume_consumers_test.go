@@ -0,0 +1,125 @@
+/*
+ Copyright (c) 2025 Dell Inc. or its subsidiaries. All Rights Reserved.
+
+ Licensed under the Apache License, Version 2.0 (the "License");
+ you may not use this file except in compliance with the License.
+ You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package k8s_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/dell/karavi-metrics-powerflex/internal/k8s"
+	"github.com/dell/karavi-metrics-powerflex/internal/k8s/mocks"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func Test_GetVolumeConsumers(t *testing.T) {
+	controller := func(kind string, name string) []metav1.OwnerReference {
+		isController := true
+		return []metav1.OwnerReference{{Kind: kind, Name: name, Controller: &isController}}
+	}
+	newPod := func(name string, owners []metav1.OwnerReference, phase corev1.PodPhase, volumes ...corev1.Volume) corev1.Pod {
+		return corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "team-a", OwnerReferences: owners},
+			Spec:       corev1.PodSpec{Volumes: volumes},
+			Status:     corev1.PodStatus{Phase: phase},
+		}
+	}
+	claim := func(name string) corev1.Volume {
+		return corev1.Volume{
+			Name:         name,
+			VolumeSource: corev1.VolumeSource{PersistentVolumeClaim: &corev1.PersistentVolumeClaimVolumeSource{ClaimName: name}},
+		}
+	}
+	ephemeral := corev1.Volume{
+		Name:         "scratch",
+		VolumeSource: corev1.VolumeSource{Ephemeral: &corev1.EphemeralVolumeSource{}},
+	}
+	config := corev1.Volume{
+		Name:         "config",
+		VolumeSource: corev1.VolumeSource{ConfigMap: &corev1.ConfigMapVolumeSource{}},
+	}
+
+	pods := &corev1.PodList{Items: []corev1.Pod{
+		newPod("web-7d4b9-xk2lp", controller("ReplicaSet", "web-7d4b9"), corev1.PodRunning, claim("shared"), config),
+		newPod("api-5f6c8-q9z7w", controller("ReplicaSet", "api-5f6c8"), corev1.PodRunning, claim("shared")),
+		newPod("db-0", controller("StatefulSet", "db"), corev1.PodRunning, claim("data-db-0")),
+		newPod("backup-28310400-abcde", controller("Job", "backup-28310400"), corev1.PodPending, claim("data-db-0")),
+		newPod("debug", nil, corev1.PodRunning, ephemeral),
+		newPod("migrate-xyz", controller("Job", "migrate"), corev1.PodSucceeded, claim("shared")),
+		newPod("static", nil, corev1.PodRunning, config),
+	}}
+	replicaSets := &appsv1.ReplicaSetList{Items: []appsv1.ReplicaSet{
+		{ObjectMeta: metav1.ObjectMeta{Name: "web-7d4b9", Namespace: "team-a", OwnerReferences: controller("Deployment", "web")}},
+		{ObjectMeta: metav1.ObjectMeta{Name: "api-5f6c8", Namespace: "team-a"}},
+	}}
+
+	t.Run("resolves pods and their workloads", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		api := mocks.NewMockConsumerGetter(ctrl)
+		api.EXPECT().GetPods().Times(1).Return(pods, nil)
+		api.EXPECT().GetReplicaSets().Times(1).Return(replicaSets, nil)
+
+		consumers, err := k8s.GetVolumeConsumers(api)
+		require.NoError(t, err)
+		assert.Equal(t, map[string][]k8s.VolumeConsumer{
+			"team-a/shared": {
+				{Pod: "api-5f6c8-q9z7w", WorkloadKind: k8s.WorkloadKindReplicaSet, WorkloadName: "api-5f6c8"},
+				{Pod: "web-7d4b9-xk2lp", WorkloadKind: k8s.WorkloadKindDeployment, WorkloadName: "web"},
+			},
+			"team-a/data-db-0": {
+				{Pod: "backup-28310400-abcde", WorkloadKind: "Job", WorkloadName: "backup-28310400"},
+				{Pod: "db-0", WorkloadKind: "StatefulSet", WorkloadName: "db"},
+			},
+			"team-a/debug-scratch": {
+				{Pod: "debug", WorkloadKind: k8s.WorkloadKindPod, WorkloadName: "debug"},
+			},
+		}, consumers)
+	})
+
+	t.Run("replica sets are only listed for pods that have one", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		api := mocks.NewMockConsumerGetter(ctrl)
+		api.EXPECT().GetPods().Times(1).Return(&corev1.PodList{Items: pods.Items[2:]}, nil)
+
+		consumers, err := k8s.GetVolumeConsumers(api)
+		require.NoError(t, err)
+		assert.Len(t, consumers, 2)
+	})
+
+	t.Run("error getting pods", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		api := mocks.NewMockConsumerGetter(ctrl)
+		api.EXPECT().GetPods().Times(1).Return(nil, errors.New("forbidden"))
+
+		_, err := k8s.GetVolumeConsumers(api)
+		assert.ErrorContains(t, err, "forbidden")
+	})
+
+	t.Run("error getting replica sets", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		api := mocks.NewMockConsumerGetter(ctrl)
+		api.EXPECT().GetPods().Times(1).Return(pods, nil)
+		api.EXPECT().GetReplicaSets().Times(1).Return(nil, errors.New("forbidden"))
+
+		_, err := k8s.GetVolumeConsumers(api)
+		assert.ErrorContains(t, err, "forbidden")
+	})
+}
@@ -0,0 +1,150 @@
+/*
+ Copyright (c) 2025 Dell Inc. or its subsidiaries. All Rights Reserved.
+
+ Licensed under the Apache License, Version 2.0 (the "License");
+ you may not use this file except in compliance with the License.
+ You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package k8s
+
+import (
+	"context"
+	"slices"
+	"sync"
+
+	"github.com/dell/karavi-metrics-powerflex/internal/telemetry"
+	"github.com/sirupsen/logrus"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
+	"k8s.io/apimachinery/pkg/labels"
+)
+
+const (
+	// KindPersistentVolume is the kind of the persistent volumes counted by FilteredObjects
+	KindPersistentVolume = "PersistentVolume"
+	// KindStorageClass is the kind of the storage classes counted by FilteredObjects
+	KindStorageClass = "StorageClass"
+)
+
+// VolumeFilter selects the persistent volumes whose metrics are exported. An empty include list or a nil
+// selector includes everything, and an object that matches an exclude is left out even if it is included.
+// Namespaces are those of the persistent volume claims, and a nil *VolumeFilter includes every volume.
+type VolumeFilter struct {
+	IncludeNamespaces             []string
+	ExcludeNamespaces             []string
+	IncludeNamespaceSelector      labels.Selector
+	ExcludeNamespaceSelector      labels.Selector
+	IncludeStorageClasses         []string
+	ExcludeStorageClasses         []string
+	PersistentVolumeSelector      labels.Selector
+	PersistentVolumeClaimSelector labels.Selector
+}
+
+// Empty returns true if the filter includes every volume
+func (f *VolumeFilter) Empty() bool {
+	return f == nil || (len(f.IncludeNamespaces) == 0 && len(f.ExcludeNamespaces) == 0 &&
+		f.IncludeNamespaceSelector == nil && f.ExcludeNamespaceSelector == nil &&
+		len(f.IncludeStorageClasses) == 0 && len(f.ExcludeStorageClasses) == 0 &&
+		f.PersistentVolumeSelector == nil && f.PersistentVolumeClaimSelector == nil)
+}
+
+// IncludesStorageClass returns true if volumes of the storage class are included
+func (f *VolumeFilter) IncludesStorageClass(name string) bool {
+	if f == nil {
+		return true
+	}
+	if len(f.IncludeStorageClasses) > 0 && !slices.Contains(f.IncludeStorageClasses, name) {
+		return false
+	}
+	return !slices.Contains(f.ExcludeStorageClasses, name)
+}
+
+// IncludesNamespace returns true if volumes claimed from the namespace, which has namespaceLabels, are included
+func (f *VolumeFilter) IncludesNamespace(name string, namespaceLabels labels.Set) bool {
+	if f == nil {
+		return true
+	}
+	if len(f.IncludeNamespaces) > 0 && !slices.Contains(f.IncludeNamespaces, name) {
+		return false
+	}
+	if f.IncludeNamespaceSelector != nil && !f.IncludeNamespaceSelector.Matches(namespaceLabels) {
+		return false
+	}
+	if slices.Contains(f.ExcludeNamespaces, name) {
+		return false
+	}
+	return f.ExcludeNamespaceSelector == nil || !f.ExcludeNamespaceSelector.Matches(namespaceLabels)
+}
+
+// IncludesPersistentVolume returns true if a volume with the labels of the persistent volume and of its claim is included
+func (f *VolumeFilter) IncludesPersistentVolume(volumeLabels labels.Set, claimLabels labels.Set) bool {
+	if f == nil {
+		return true
+	}
+	if f.PersistentVolumeSelector != nil && !f.PersistentVolumeSelector.Matches(volumeLabels) {
+		return false
+	}
+	return f.PersistentVolumeClaimSelector == nil || f.PersistentVolumeClaimSelector.Matches(claimLabels)
+}
+
+func (f *VolumeFilter) selectsNamespaceLabels() bool {
+	return f != nil && (f.IncludeNamespaceSelector != nil || f.ExcludeNamespaceSelector != nil)
+}
+
+func (f *VolumeFilter) selectsClaimLabels() bool {
+	return f != nil && f.PersistentVolumeClaimSelector != nil
+}
+
+// FilteredObjects exports how many objects of each kind the volume filter left out of the last export as powerflex_filtered_objects.
+// A nil *FilteredObjects discards the counts.
+type FilteredObjects struct {
+	Meter  metric.Meter
+	Logger *logrus.Logger
+
+	mu     sync.Mutex
+	counts map[string]int64
+	gauge  telemetry.Gauge
+}
+
+// Set records how many objects of kind were left out
+func (c *FilteredObjects) Set(kind string, count int) {
+	if c == nil {
+		return
+	}
+	c.gauge.RegisterInt64(c.Meter, c.Logger, "powerflex_filtered_objects", c.observe)
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.counts == nil {
+		c.counts = make(map[string]int64)
+	}
+	c.counts[kind] = int64(count)
+}
+
+// Counts returns the last count of each kind
+func (c *FilteredObjects) Counts() map[string]int64 {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	counts := make(map[string]int64, len(c.counts))
+	for kind, count := range c.counts {
+		counts[kind] = count
+	}
+	return counts
+}
+
+// observe observes the last count of each kind
+func (c *FilteredObjects) observe(_ context.Context, obs metric.Int64Observer) error {
+	for kind, count := range c.Counts() {
+		obs.Observe(count, metric.WithAttributes(attribute.String("Kind", kind)))
+	}
+	return nil
+}
@@ -0,0 +1,93 @@
+/*
+ Copyright (c) 2025 Dell Inc. or its subsidiaries. All Rights Reserved.
+
+ Licensed under the Apache License, Version 2.0 (the "License");
+ you may not use this file except in compliance with the License.
+ You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package k8s_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/dell/karavi-metrics-powerflex/internal/k8s"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata"
+	"k8s.io/apimachinery/pkg/labels"
+)
+
+func Test_VolumeFilter(t *testing.T) {
+	var nilFilter *k8s.VolumeFilter
+	assert.True(t, nilFilter.Empty())
+	assert.True(t, nilFilter.IncludesStorageClass("any"))
+	assert.True(t, nilFilter.IncludesNamespace("any", nil))
+	assert.True(t, nilFilter.IncludesPersistentVolume(nil, nil))
+	assert.True(t, (&k8s.VolumeFilter{}).Empty())
+
+	filter := &k8s.VolumeFilter{
+		IncludeNamespaces:             []string{"team-a", "team-b"},
+		ExcludeNamespaces:             []string{"team-b"},
+		ExcludeNamespaceSelector:      labels.SelectorFromSet(labels.Set{"tier": "test"}),
+		IncludeStorageClasses:         []string{"vxflexos", "vxflexos-xfs"},
+		ExcludeStorageClasses:         []string{"vxflexos-xfs"},
+		PersistentVolumeSelector:      labels.SelectorFromSet(labels.Set{"app": "database"}),
+		PersistentVolumeClaimSelector: labels.SelectorFromSet(labels.Set{"backup": "true"}),
+	}
+	assert.False(t, filter.Empty())
+
+	assert.True(t, filter.IncludesStorageClass("vxflexos"))
+	assert.False(t, filter.IncludesStorageClass("vxflexos-xfs"), "excludes win over includes")
+	assert.False(t, filter.IncludesStorageClass("other"))
+
+	assert.True(t, filter.IncludesNamespace("team-a", labels.Set{"tier": "prod"}))
+	assert.False(t, filter.IncludesNamespace("team-a", labels.Set{"tier": "test"}))
+	assert.False(t, filter.IncludesNamespace("team-b", nil))
+	assert.False(t, filter.IncludesNamespace("team-c", nil))
+
+	assert.True(t, filter.IncludesPersistentVolume(labels.Set{"app": "database"}, labels.Set{"backup": "true"}))
+	assert.False(t, filter.IncludesPersistentVolume(labels.Set{"app": "cache"}, labels.Set{"backup": "true"}))
+	assert.False(t, filter.IncludesPersistentVolume(labels.Set{"app": "database"}, nil))
+
+	selectorOnly := &k8s.VolumeFilter{IncludeNamespaceSelector: labels.SelectorFromSet(labels.Set{"team": "storage"})}
+	assert.True(t, selectorOnly.IncludesNamespace("any", labels.Set{"team": "storage"}))
+	assert.False(t, selectorOnly.IncludesNamespace("any", labels.Set{"team": "compute"}))
+}
+
+func Test_FilteredObjects(t *testing.T) {
+	var nilCounter *k8s.FilteredObjects
+	assert.NotPanics(t, func() { nilCounter.Set(k8s.KindPersistentVolume, 1) })
+
+	reader := sdkmetric.NewManualReader()
+	provider := sdkmetric.NewMeterProvider(sdkmetric.WithReader(reader))
+	counter := &k8s.FilteredObjects{Meter: provider.Meter("test")}
+
+	counter.Set(k8s.KindPersistentVolume, 3)
+	counter.Set(k8s.KindStorageClass, 1)
+	counter.Set(k8s.KindPersistentVolume, 2)
+	assert.Equal(t, map[string]int64{k8s.KindPersistentVolume: 2, k8s.KindStorageClass: 1}, counter.Counts())
+
+	var rm metricdata.ResourceMetrics
+	require.NoError(t, reader.Collect(context.Background(), &rm))
+	require.Len(t, rm.ScopeMetrics, 1)
+	require.Len(t, rm.ScopeMetrics[0].Metrics, 1)
+	assert.Equal(t, "powerflex_filtered_objects", rm.ScopeMetrics[0].Metrics[0].Name)
+	gauge := rm.ScopeMetrics[0].Metrics[0].Data.(metricdata.Gauge[int64])
+	counts := map[string]int64{}
+	for _, point := range gauge.DataPoints {
+		kind, _ := point.Attributes.Value("Kind")
+		counts[kind.AsString()] = point.Value
+	}
+	assert.Equal(t, counter.Counts(), counts)
+}
@@ -22,6 +22,7 @@ import (
 
 	"github.com/sirupsen/logrus"
 	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/labels"
 )
 
 // VolumeGetter is an interface for getting a list of persistent volume information
@@ -31,11 +32,29 @@ type VolumeGetter interface {
 	GetPersistentVolumes() (*corev1.PersistentVolumeList, error)
 }
 
+// VolumeMetadataGetter is an interface for getting the namespaces and persistent volume claims that a VolumeFilter selects on
+//
+//go:generate mockgen -destination=mocks/volume_metadata_getter_mocks.go -package=mocks github.com/dell/karavi-metrics-powerflex/internal/k8s VolumeMetadataGetter
+type VolumeMetadataGetter interface {
+	GetNamespaces() (*corev1.NamespaceList, error)
+	GetPersistentVolumeClaims() (*corev1.PersistentVolumeClaimList, error)
+}
+
 // VolumeFinder is a volume finder that will query the Kubernetes API for Persistent Volumes created by a matching DriverName and StorageSystemID
 type VolumeFinder struct {
 	API             VolumeGetter
 	StorageSystemID []StorageSystemID
 	Logger          *logrus.Logger
+	// Filter leaves out the volumes whose metrics are not exported
+	Filter *VolumeFilter
+	// Metadata is only used when Filter or Labels need namespace or claim labels
+	Metadata        VolumeMetadataGetter
+	FilteredObjects *FilteredObjects
+	// Labels selects the labels that are exported as attributes of the volume
+	Labels *LabelAllowlist
+	// ResolveConsumers resolves the pods and workloads that mount each volume, from Pods
+	ResolveConsumers bool
+	Pods             ConsumerGetter
 }
 
 // VolumeInfo contains information about mapping a Persistent Volume to the volume created on a storage system
@@ -48,6 +67,7 @@ type VolumeInfo struct {
 	StorageClass            string `json:"storage_class"`
 	Driver                  string `json:"driver"`
 	ProvisionedSize         string `json:"provisioned_size"`
+	ProvisionedBytes        int64  `json:"provisioned_bytes"`
 	StorageSystemVolumeName string `json:"storage_system_volume_name"`
 	StoragePoolName         string `json:"storage_pool_name"`
 	StorageSystemID         string `json:"storage_system_id"`
@@ -55,6 +75,11 @@ type VolumeInfo struct {
 	VolumeHandle            string `json:"volume_handle"`
 	Protocol                string `json:"protocol"`
 	StorageSystem           string `json:"storage_system"`
+	// Attributes are the allowed labels of the volume, its claim and its namespace, keyed by attribute name
+	Attributes map[string]string `json:"attributes,omitempty"`
+	// Consumers are the running pods that mount the claim of the volume, if ConsumersResolved
+	Consumers         []VolumeConsumer `json:"consumers,omitempty"`
+	ConsumersResolved bool             `json:"-"`
 }
 
 // GetPersistentVolumes will return a list of persistent volume information
@@ -66,6 +91,17 @@ func (f VolumeFinder) GetPersistentVolumes() ([]VolumeInfo, error) {
 		return nil, err
 	}
 
+	filter, allowlist := f.Filter, f.Labels
+	namespaceLabels, claimLabels, err := f.metadata(filter, allowlist)
+	if err != nil {
+		return nil, err
+	}
+	consumers, err := f.consumers()
+	if err != nil {
+		return nil, err
+	}
+
+	filtered := 0
 	for _, volume := range volumes.Items {
 		if f.isMatch(volume) {
 			capacity := volume.Spec.Capacity[corev1.ResourceStorage]
@@ -83,6 +119,13 @@ func (f VolumeFinder) GetPersistentVolumes() ([]VolumeInfo, error) {
 				continue
 			}
 
+			if !filter.IncludesStorageClass(volume.Spec.StorageClassName) ||
+				!filter.IncludesNamespace(claim.Namespace, namespaceLabels[claim.Namespace]) ||
+				!filter.IncludesPersistentVolume(volume.Labels, claimLabels[claim.Namespace+"/"+claim.Name]) {
+				filtered++
+				continue
+			}
+
 			info := VolumeInfo{
 				Namespace:               claim.Namespace,
 				PersistentVolumeClaim:   string(claim.UID),
@@ -92,6 +135,7 @@ func (f VolumeFinder) GetPersistentVolumes() ([]VolumeInfo, error) {
 				StorageClass:            volume.Spec.StorageClassName,
 				Driver:                  volume.Spec.CSI.Driver,
 				ProvisionedSize:         capacity.String(),
+				ProvisionedBytes:        capacity.Value(),
 				StorageSystemVolumeName: volume.Spec.CSI.VolumeAttributes["Name"],
 				StoragePoolName:         volume.Spec.CSI.VolumeAttributes["StoragePoolName"],
 				StorageSystemID:         storageystemid,
@@ -99,13 +143,68 @@ func (f VolumeFinder) GetPersistentVolumes() ([]VolumeInfo, error) {
 				VolumeHandle:            volume.Spec.CSI.VolumeHandle,
 				StorageSystem:           volume.Spec.CSI.VolumeAttributes["StorageSystem"],
 				Protocol:                volume.Spec.CSI.VolumeAttributes["Protocol"],
+				Attributes:              allowlist.VolumeAttributes(claimLabels[claim.Namespace+"/"+claim.Name], volume.Labels, namespaceLabels[claim.Namespace]),
+				Consumers:               consumers[claim.Namespace+"/"+claim.Name],
+				ConsumersResolved:       f.ResolveConsumers,
 			}
 			volumeInfo = append(volumeInfo, info)
 		}
 	}
+	f.FilteredObjects.Set(KindPersistentVolume, filtered)
 	return volumeInfo, nil
 }
 
+// Filtering returns true if the finder leaves out some of the persistent volumes
+func (f VolumeFinder) Filtering() bool {
+	return !f.Filter.Empty()
+}
+
+// consumers returns the consumers of each claim, by namespace/name, if they are resolved
+func (f VolumeFinder) consumers() (map[string][]VolumeConsumer, error) {
+	if !f.ResolveConsumers {
+		return nil, nil
+	}
+	if f.Pods == nil {
+		return nil, errors.New("resolving volume consumers requires a pod getter")
+	}
+	return GetVolumeConsumers(f.Pods)
+}
+
+// metadata returns the labels of the namespaces, by name, and of the claims, by namespace/name, if the filter or the allowlist use them
+func (f VolumeFinder) metadata(filter *VolumeFilter, allowlist *LabelAllowlist) (map[string]labels.Set, map[string]labels.Set, error) {
+	needNamespaces := filter.selectsNamespaceLabels() || allowlist.selectsNamespaceLabels()
+	needClaims := filter.selectsClaimLabels() || allowlist.selectsClaimLabels()
+	if !needNamespaces && !needClaims {
+		return nil, nil, nil
+	}
+	if f.Metadata == nil {
+		return nil, nil, errors.New("namespace or claim labels are used but no namespace and claim getter is set")
+	}
+
+	var namespaceLabels, claimLabels map[string]labels.Set
+	if needNamespaces {
+		namespaces, err := f.Metadata.GetNamespaces()
+		if err != nil {
+			return nil, nil, err
+		}
+		namespaceLabels = make(map[string]labels.Set, len(namespaces.Items))
+		for _, namespace := range namespaces.Items {
+			namespaceLabels[namespace.Name] = namespace.Labels
+		}
+	}
+	if needClaims {
+		claims, err := f.Metadata.GetPersistentVolumeClaims()
+		if err != nil {
+			return nil, nil, err
+		}
+		claimLabels = make(map[string]labels.Set, len(claims.Items))
+		for _, claim := range claims.Items {
+			claimLabels[claim.Namespace+"/"+claim.Name] = claim.Labels
+		}
+	}
+	return namespaceLabels, claimLabels, nil
+}
+
 func (f *VolumeFinder) isMatch(volume corev1.PersistentVolume) bool {
 	if volume.Spec.CSI == nil {
 		return false
@@ -18,6 +18,7 @@ package k8s_test
 
 import (
 	"errors"
+	"strings"
 	"testing"
 	"time"
 
@@ -30,7 +31,9 @@ import (
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 	"go.uber.org/mock/gomock"
+	"k8s.io/apimachinery/pkg/labels"
 )
 
 func Test_K8sPersistentVolumeFinder(t *testing.T) {
@@ -144,6 +147,7 @@ func Test_K8sPersistentVolumeFinder(t *testing.T) {
 					StorageClass:            "storage-class-name",
 					Driver:                  "csi-vxflexos.dellemc.com",
 					ProvisionedSize:         "16Gi",
+					ProvisionedBytes:        16 * 1024 * 1024 * 1024,
 					StorageSystemVolumeName: "storage-system-volume-name",
 					StorageSystemID:         "storagesystemid1",
 					CreatedTime:             t1.String(),
@@ -239,6 +243,7 @@ func Test_K8sPersistentVolumeFinder(t *testing.T) {
 					StorageClass:            "storage-class-name",
 					Driver:                  "csi-vxflexos.dellemc.com",
 					ProvisionedSize:         "16Gi",
+					ProvisionedBytes:        16 * 1024 * 1024 * 1024,
 					StorageSystemVolumeName: "storage-system-volume-name",
 					StorageSystemID:         "storagesystemid1",
 					CreatedTime:             t1.String(),
@@ -253,6 +258,7 @@ func Test_K8sPersistentVolumeFinder(t *testing.T) {
 					StorageClass:            "storage-class-name-2",
 					Driver:                  "another-csi-driver.dellemc.com",
 					ProvisionedSize:         "8Gi",
+					ProvisionedBytes:        8 * 1024 * 1024 * 1024,
 					StorageSystemVolumeName: "storage-system-volume-name-2",
 					StorageSystemID:         "storagesystemid1",
 					CreatedTime:             t1.String(),
@@ -383,6 +389,7 @@ func Test_K8sPersistentVolumeFinder(t *testing.T) {
 					StorageClass:            "storage-class-name",
 					Driver:                  "csi-vxflexos.dellemc.com",
 					ProvisionedSize:         "16Gi",
+					ProvisionedBytes:        16 * 1024 * 1024 * 1024,
 					StorageSystemVolumeName: "storage-system-volume-name",
 					StorageSystemID:         "storagesystemid1",
 					CreatedTime:             t1.String(),
@@ -474,6 +481,7 @@ func Test_K8sPersistentVolumeFinder(t *testing.T) {
 					StorageClass:            "storage-class-name",
 					Driver:                  "csi-vxflexos.dellemc.com",
 					ProvisionedSize:         "16Gi",
+					ProvisionedBytes:        16 * 1024 * 1024 * 1024,
 					StorageSystemVolumeName: "storage-system-volume-name1",
 					StorageSystemID:         "storagesystemid1",
 					CreatedTime:             t1.String(),
@@ -493,3 +501,196 @@ func Test_K8sPersistentVolumeFinder(t *testing.T) {
 		})
 	}
 }
+
+func Test_K8sPersistentVolumeFinder_Filter(t *testing.T) {
+	newVolume := func(name string, namespace string, storageClass string, volumeLabels map[string]string) corev1.PersistentVolume {
+		return corev1.PersistentVolume{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Labels: volumeLabels},
+			Spec: corev1.PersistentVolumeSpec{
+				PersistentVolumeSource: corev1.PersistentVolumeSource{
+					CSI: &corev1.CSIPersistentVolumeSource{
+						Driver:           "csi-vxflexos.dellemc.com",
+						VolumeAttributes: map[string]string{"Name": name},
+						VolumeHandle:     "storagesystemid1-" + strings.ReplaceAll(name, "-", ""),
+					},
+				},
+				ClaimRef:         &corev1.ObjectReference{Name: "claim-" + name, Namespace: namespace},
+				StorageClassName: storageClass,
+			},
+		}
+	}
+	volumes := &corev1.PersistentVolumeList{
+		Items: []corev1.PersistentVolume{
+			newVolume("pv-1", "team-a", "vxflexos", map[string]string{"app": "database"}),
+			newVolume("pv-2", "team-a", "vxflexos-scratch", map[string]string{"app": "database"}),
+			newVolume("pv-3", "team-b", "vxflexos", map[string]string{"app": "database"}),
+			newVolume("pv-4", "team-a", "vxflexos", map[string]string{"app": "cache"}),
+			newVolume("pv-5", "team-a", "vxflexos", map[string]string{"app": "database"}),
+		},
+	}
+	namespaces := &corev1.NamespaceList{
+		Items: []corev1.Namespace{
+			{ObjectMeta: metav1.ObjectMeta{Name: "team-a", Labels: map[string]string{"tier": "prod"}}},
+			{ObjectMeta: metav1.ObjectMeta{Name: "team-b", Labels: map[string]string{"tier": "test"}}},
+		},
+	}
+	claims := &corev1.PersistentVolumeClaimList{
+		Items: []corev1.PersistentVolumeClaim{
+			{ObjectMeta: metav1.ObjectMeta{Name: "claim-pv-1", Namespace: "team-a", Labels: map[string]string{"backup": "true"}}},
+			{ObjectMeta: metav1.ObjectMeta{Name: "claim-pv-2", Namespace: "team-a", Labels: map[string]string{"backup": "true"}}},
+			{ObjectMeta: metav1.ObjectMeta{Name: "claim-pv-3", Namespace: "team-b", Labels: map[string]string{"backup": "true"}}},
+			{ObjectMeta: metav1.ObjectMeta{Name: "claim-pv-4", Namespace: "team-a", Labels: map[string]string{"backup": "true"}}},
+			{ObjectMeta: metav1.ObjectMeta{Name: "claim-pv-5", Namespace: "team-a", Labels: map[string]string{"backup": "false"}}},
+		},
+	}
+	ids := []k8s.StorageSystemID{{ID: "storagesystemid1", DriverNames: []string{"csi-vxflexos.dellemc.com"}}}
+
+	t.Run("filters by namespace, storage class and labels", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		api := mocks.NewMockVolumeGetter(ctrl)
+		metadata := mocks.NewMockVolumeMetadataGetter(ctrl)
+		api.EXPECT().GetPersistentVolumes().Times(1).Return(volumes, nil)
+		metadata.EXPECT().GetNamespaces().Times(1).Return(namespaces, nil)
+		metadata.EXPECT().GetPersistentVolumeClaims().Times(1).Return(claims, nil)
+
+		filteredObjects := &k8s.FilteredObjects{}
+		finder := k8s.VolumeFinder{
+			API:             api,
+			Metadata:        metadata,
+			StorageSystemID: ids,
+			Logger:          logrus.New(),
+			Filter: &k8s.VolumeFilter{
+				ExcludeNamespaceSelector:      labels.SelectorFromSet(labels.Set{"tier": "test"}),
+				ExcludeStorageClasses:         []string{"vxflexos-scratch"},
+				PersistentVolumeSelector:      labels.SelectorFromSet(labels.Set{"app": "database"}),
+				PersistentVolumeClaimSelector: labels.SelectorFromSet(labels.Set{"backup": "true"}),
+			},
+			FilteredObjects: filteredObjects,
+		}
+		assert.True(t, finder.Filtering())
+
+		found, err := finder.GetPersistentVolumes()
+		require.NoError(t, err)
+		require.Len(t, found, 1)
+		assert.Equal(t, "pv-1", found[0].PersistentVolume)
+		assert.Equal(t, map[string]int64{k8s.KindPersistentVolume: 4}, filteredObjects.Counts())
+	})
+
+	t.Run("namespace names don't need the namespace labels", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		api := mocks.NewMockVolumeGetter(ctrl)
+		api.EXPECT().GetPersistentVolumes().Times(1).Return(volumes, nil)
+
+		finder := k8s.VolumeFinder{
+			API:             api,
+			StorageSystemID: ids,
+			Logger:          logrus.New(),
+			Filter:          &k8s.VolumeFilter{IncludeNamespaces: []string{"team-b"}},
+		}
+		found, err := finder.GetPersistentVolumes()
+		require.NoError(t, err)
+		require.Len(t, found, 1)
+		assert.Equal(t, "pv-3", found[0].PersistentVolume)
+	})
+
+	t.Run("exports the allowed labels as attributes", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		api := mocks.NewMockVolumeGetter(ctrl)
+		metadata := mocks.NewMockVolumeMetadataGetter(ctrl)
+		api.EXPECT().GetPersistentVolumes().Times(1).Return(volumes, nil)
+		metadata.EXPECT().GetNamespaces().Times(1).Return(namespaces, nil)
+		metadata.EXPECT().GetPersistentVolumeClaims().Times(1).Return(claims, nil)
+
+		finder := k8s.VolumeFinder{
+			API:             api,
+			Metadata:        metadata,
+			StorageSystemID: ids,
+			Logger:          logrus.New(),
+			Filter:          &k8s.VolumeFilter{IncludeNamespaces: []string{"team-b"}},
+			Labels: &k8s.LabelAllowlist{Keys: k8s.LabelKeys{
+				PersistentVolumeClaim: []string{"backup"},
+				PersistentVolume:      []string{"app"},
+				Namespace:             []string{"tier"},
+			}},
+		}
+		found, err := finder.GetPersistentVolumes()
+		require.NoError(t, err)
+		require.Len(t, found, 1)
+		assert.Equal(t, map[string]string{"label_backup": "true", "label_app": "database", "label_tier": "test"}, found[0].Attributes)
+	})
+
+	t.Run("error getting namespaces", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		api := mocks.NewMockVolumeGetter(ctrl)
+		metadata := mocks.NewMockVolumeMetadataGetter(ctrl)
+		api.EXPECT().GetPersistentVolumes().Times(1).Return(volumes, nil)
+		metadata.EXPECT().GetNamespaces().Times(1).Return(nil, errors.New("forbidden"))
+
+		finder := k8s.VolumeFinder{
+			API:             api,
+			Metadata:        metadata,
+			StorageSystemID: ids,
+			Filter:          &k8s.VolumeFilter{IncludeNamespaceSelector: labels.Everything()},
+		}
+		_, err := finder.GetPersistentVolumes()
+		assert.ErrorContains(t, err, "forbidden")
+	})
+
+	t.Run("selectors without a metadata getter", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		api := mocks.NewMockVolumeGetter(ctrl)
+		api.EXPECT().GetPersistentVolumes().Times(1).Return(volumes, nil)
+
+		finder := k8s.VolumeFinder{
+			API:             api,
+			StorageSystemID: ids,
+			Filter:          &k8s.VolumeFilter{PersistentVolumeClaimSelector: labels.Everything()},
+		}
+		_, err := finder.GetPersistentVolumes()
+		assert.Error(t, err)
+	})
+
+	t.Run("resolves the consumers of the volumes", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		api := mocks.NewMockVolumeGetter(ctrl)
+		pods := mocks.NewMockConsumerGetter(ctrl)
+		api.EXPECT().GetPersistentVolumes().Times(1).Return(volumes, nil)
+		pods.EXPECT().GetPods().Times(1).Return(&corev1.PodList{Items: []corev1.Pod{{
+			ObjectMeta: metav1.ObjectMeta{Name: "db-0", Namespace: "team-a"},
+			Spec: corev1.PodSpec{Volumes: []corev1.Volume{{
+				Name:         "data",
+				VolumeSource: corev1.VolumeSource{PersistentVolumeClaim: &corev1.PersistentVolumeClaimVolumeSource{ClaimName: "claim-pv-1"}},
+			}}},
+		}}}, nil)
+
+		finder := k8s.VolumeFinder{
+			API:              api,
+			Pods:             pods,
+			StorageSystemID:  ids,
+			Logger:           logrus.New(),
+			ResolveConsumers: true,
+		}
+		found, err := finder.GetPersistentVolumes()
+		require.NoError(t, err)
+		require.Len(t, found, 5)
+		for _, volume := range found {
+			assert.True(t, volume.ConsumersResolved)
+		}
+		assert.Equal(t, []k8s.VolumeConsumer{{Pod: "db-0", WorkloadKind: k8s.WorkloadKindPod, WorkloadName: "db-0"}}, found[0].Consumers)
+		assert.Empty(t, found[1].Consumers)
+	})
+
+	t.Run("consumers without a pod getter", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		api := mocks.NewMockVolumeGetter(ctrl)
+		api.EXPECT().GetPersistentVolumes().Times(1).Return(volumes, nil)
+
+		finder := k8s.VolumeFinder{
+			API:              api,
+			StorageSystemID:  ids,
+			ResolveConsumers: true,
+		}
+		_, err := finder.GetPersistentVolumes()
+		assert.Error(t, err)
+	})
+}
@@ -0,0 +1,246 @@
+/*
+ Copyright (c) 2025 Dell Inc. or its subsidiaries. All Rights Reserved.
+
+ Licensed under the Apache License, Version 2.0 (the "License");
+ you may not use this file except in compliance with the License.
+ You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package service
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"net"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/dell/karavi-metrics-powerflex/internal/domain"
+	"github.com/dell/karavi-metrics-powerflex/internal/telemetry"
+	"github.com/sirupsen/logrus"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
+)
+
+const (
+	// DefaultCertificateCheckInterval is how often the gateway certificates are fetched again
+	DefaultCertificateCheckInterval = time.Hour
+	// DefaultCertificateCheckTimeout bounds the TLS handshake used to fetch a gateway certificate
+	DefaultCertificateCheckTimeout = 10 * time.Second
+)
+
+// CACertificates returns the PEM encoded CA bundle of a storage system, read from caCertificateFile if it is not inline
+func CACertificates(storageSystem domain.ArrayConnectionData) ([]byte, error) {
+	if storageSystem.CACertificate != "" {
+		return []byte(storageSystem.CACertificate), nil
+	}
+	if storageSystem.CACertificateFile == "" {
+		return nil, nil
+	}
+	bundle, err := os.ReadFile(filepath.Clean(storageSystem.CACertificateFile))
+	if err != nil {
+		return nil, fmt.Errorf("reading CA certificates of %s: %w", storageSystem.SystemID, err)
+	}
+	return bundle, nil
+}
+
+// GatewayTLSConfig returns the TLS configuration that verifies the gateway of a storage system.
+// The certificate chain is verified against the system roots plus the storage system's CA bundle,
+// unless certificate validation is skipped.
+func GatewayTLSConfig(storageSystem domain.ArrayConnectionData) (*tls.Config, error) {
+	endpoint, err := url.Parse(storageSystem.Endpoint)
+	if err != nil {
+		return nil, fmt.Errorf("parsing endpoint of %s: %w", storageSystem.SystemID, err)
+	}
+
+	var roots *x509.CertPool
+	bundle, err := CACertificates(storageSystem)
+	if err != nil {
+		return nil, err
+	}
+	if len(bundle) > 0 {
+		roots, err = x509.SystemCertPool()
+		if err != nil {
+			roots = x509.NewCertPool()
+		}
+		if !roots.AppendCertsFromPEM(bundle) {
+			return nil, fmt.Errorf("CA certificates of %s contain no PEM encoded certificate", storageSystem.SystemID)
+		}
+	}
+
+	// backwards compatible with previous 'Insecure' flag
+	insecure := storageSystem.Insecure || storageSystem.SkipCertificateValidation
+	return &tls.Config{
+		MinVersion:         tls.VersionTLS12,
+		ServerName:         endpoint.Hostname(),
+		RootCAs:            roots,
+		InsecureSkipVerify: insecure, // #nosec G402
+	}, nil
+}
+
+// GatewayCertificate connects to the endpoint and returns the certificate it presents, verified with config
+func GatewayCertificate(ctx context.Context, endpoint string, config *tls.Config) (*x509.Certificate, error) {
+	u, err := url.Parse(endpoint)
+	if err != nil {
+		return nil, fmt.Errorf("parsing endpoint %s: %w", endpoint, err)
+	}
+	port := u.Port()
+	if port == "" {
+		port = "443"
+	}
+
+	dialer := &tls.Dialer{Config: config}
+	conn, err := dialer.DialContext(ctx, "tcp", net.JoinHostPort(u.Hostname(), port))
+	if err != nil {
+		return nil, fmt.Errorf("connecting to %s: %w", endpoint, err)
+	}
+	defer conn.Close()
+
+	certs := conn.(*tls.Conn).ConnectionState().PeerCertificates
+	if len(certs) == 0 {
+		return nil, fmt.Errorf("%s presented no certificate", endpoint)
+	}
+	return certs[0], nil
+}
+
+// GatewayTLS is the gateway of a storage system and the TLS configuration that verifies it
+type GatewayTLS struct {
+	StorageSystemID string
+	Endpoint        string
+	Config          *tls.Config
+}
+
+// CertificateMonitor exports the days left before each gateway certificate expires as
+// powerflex_gateway_certificate_expiry_days. The certificates are fetched again in the background every
+// CheckInterval, without verifying them, so an expired certificate shows up with negative days instead
+// of being left out. A gateway whose certificate can't be fetched is logged and left out of the gauge.
+type CertificateMonitor struct {
+	Logger        *logrus.Logger
+	Meter         metric.Meter
+	CheckInterval time.Duration
+	Timeout       time.Duration
+
+	mu         sync.Mutex
+	gateways   []GatewayTLS
+	generation uint64
+	expiry     map[string]time.Time
+	checkedAt  time.Time
+	refreshing bool
+	gauge      telemetry.Gauge
+}
+
+// SetGateways replaces the monitored gateways, e.g. after the storage system configuration changed
+func (m *CertificateMonitor) SetGateways(gateways []GatewayTLS) {
+	m.gauge.RegisterFloat64(m.Meter, m.Logger, "powerflex_gateway_certificate_expiry_days", m.observeExpiry)
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.gateways = gateways
+	m.generation++
+	m.expiry = nil
+	m.checkedAt = time.Time{}
+}
+
+// Expiry returns when the certificate of each storage system's gateway expires, as of the last refresh.
+// It doesn't wait for the gateways: if the last refresh is too old, a new one is started in the background.
+func (m *CertificateMonitor) Expiry() map[string]time.Time {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if !m.refreshing && time.Since(m.checkedAt) >= m.checkInterval() {
+		m.refreshing = true
+		go func() {
+			m.Refresh(context.Background())
+			m.mu.Lock()
+			m.refreshing = false
+			m.mu.Unlock()
+		}()
+	}
+	return m.expiry
+}
+
+// Refresh fetches the certificate of every gateway concurrently. Only the expiry of the certificates is read,
+// so they are fetched without verifying them.
+func (m *CertificateMonitor) Refresh(ctx context.Context) {
+	m.mu.Lock()
+	gateways := m.gateways
+	generation := m.generation
+	m.mu.Unlock()
+
+	var wg sync.WaitGroup
+	var expiryMu sync.Mutex
+	expiry := make(map[string]time.Time, len(gateways))
+	for _, gateway := range gateways {
+		wg.Add(1)
+		go func(gateway GatewayTLS) {
+			defer wg.Done()
+			checkCtx, cancel := context.WithTimeout(ctx, m.timeout())
+			defer cancel()
+			cert, err := GatewayCertificate(checkCtx, gateway.Endpoint, unverifiedTLSConfig(gateway.Config))
+			if err != nil {
+				telemetry.Logger(m.Logger).WithError(err).WithField("storage_system_id", gateway.StorageSystemID).Error("checking gateway certificate")
+				return
+			}
+			expiryMu.Lock()
+			expiry[gateway.StorageSystemID] = cert.NotAfter
+			expiryMu.Unlock()
+		}(gateway)
+	}
+	wg.Wait()
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.generation != generation {
+		// the gateways changed while their certificates were fetched
+		return
+	}
+	m.expiry = expiry
+	m.checkedAt = time.Now()
+}
+
+// unverifiedTLSConfig returns a copy of config that accepts any certificate
+func unverifiedTLSConfig(config *tls.Config) *tls.Config {
+	if config == nil {
+		return &tls.Config{InsecureSkipVerify: true} // #nosec G402
+	}
+	unverified := config.Clone()
+	unverified.InsecureSkipVerify = true // #nosec G402
+	unverified.VerifyPeerCertificate = nil
+	unverified.VerifyConnection = nil
+	return unverified
+}
+
+// observeExpiry observes the days until the certificate of each gateway expires
+func (m *CertificateMonitor) observeExpiry(_ context.Context, obs metric.Float64Observer) error {
+	for storageSystemID, notAfter := range m.Expiry() {
+		obs.Observe(time.Until(notAfter).Hours()/24, metric.WithAttributes(attribute.String("StorageSystemID", storageSystemID)))
+	}
+	return nil
+}
+
+func (m *CertificateMonitor) checkInterval() time.Duration {
+	if m.CheckInterval <= 0 {
+		return DefaultCertificateCheckInterval
+	}
+	return m.CheckInterval
+}
+
+func (m *CertificateMonitor) timeout() time.Duration {
+	if m.Timeout <= 0 {
+		return DefaultCertificateCheckTimeout
+	}
+	return m.Timeout
+}
@@ -0,0 +1,232 @@
+/*
+ Copyright (c) 2025 Dell Inc. or its subsidiaries. All Rights Reserved.
+
+ Licensed under the Apache License, Version 2.0 (the "License");
+ you may not use this file except in compliance with the License.
+ You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package service_test
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/dell/karavi-metrics-powerflex/internal/domain"
+	"github.com/dell/karavi-metrics-powerflex/internal/service"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel"
+)
+
+func newTestGateway(t *testing.T) (*httptest.Server, string) {
+	server := httptest.NewTLSServer(http.HandlerFunc(func(http.ResponseWriter, *http.Request) {}))
+	t.Cleanup(server.Close)
+
+	caCertificate := string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: server.Certificate().Raw}))
+	return server, caCertificate
+}
+
+// newUnrelatedCertificate returns a self-signed CA certificate that did not sign the test gateway's certificate
+func newUnrelatedCertificate(t *testing.T) string {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "unrelated CA"},
+		NotBefore:             time.Now(),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+		KeyUsage:              x509.KeyUsageCertSign,
+	}
+	raw, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	require.NoError(t, err)
+
+	return string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: raw}))
+}
+
+func Test_GatewayTLSConfig(t *testing.T) {
+	server, caCertificate := newTestGateway(t)
+	otherCACertificate := newUnrelatedCertificate(t)
+	caFile := filepath.Join(t.TempDir(), "ca.pem")
+	require.NoError(t, os.WriteFile(caFile, []byte(caCertificate), 0o600))
+
+	tests := map[string]struct {
+		storageSystem domain.ArrayConnectionData
+		expectError   bool
+	}{
+		"unknown authority": {
+			storageSystem: domain.ArrayConnectionData{},
+			expectError:   true,
+		},
+		"skip certificate validation": {
+			storageSystem: domain.ArrayConnectionData{SkipCertificateValidation: true},
+		},
+		"inline CA certificate": {
+			storageSystem: domain.ArrayConnectionData{CACertificate: caCertificate},
+		},
+		"CA certificate file": {
+			storageSystem: domain.ArrayConnectionData{CACertificateFile: caFile},
+		},
+		"wrong CA certificate": {
+			storageSystem: domain.ArrayConnectionData{CACertificate: otherCACertificate},
+			expectError:   true,
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			tc.storageSystem.SystemID = "system-1"
+			tc.storageSystem.Endpoint = server.URL
+
+			config, err := service.GatewayTLSConfig(tc.storageSystem)
+			require.NoError(t, err)
+
+			cert, err := service.GatewayCertificate(context.Background(), server.URL, config)
+			if tc.expectError {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, server.Certificate().NotAfter, cert.NotAfter)
+		})
+	}
+}
+
+func Test_GatewayTLSConfig_Errors(t *testing.T) {
+	for name, storageSystem := range map[string]domain.ArrayConnectionData{
+		"invalid CA certificate": {CACertificate: "not a certificate"},
+		"missing CA file":        {CACertificateFile: filepath.Join(t.TempDir(), "missing.pem")},
+	} {
+		t.Run(name, func(t *testing.T) {
+			storageSystem.SystemID = "system-1"
+			storageSystem.Endpoint = "https://127.0.0.1"
+			_, err := service.GatewayTLSConfig(storageSystem)
+			assert.Error(t, err)
+		})
+	}
+}
+
+// newExpiredGateway returns a gateway whose self-signed certificate expired an hour ago, and that certificate
+func newExpiredGateway(t *testing.T) (*httptest.Server, *x509.Certificate) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(2),
+		Subject:               pkix.Name{CommonName: "expired gateway"},
+		NotBefore:             time.Now().Add(-2 * time.Hour),
+		NotAfter:              time.Now().Add(-time.Hour),
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+		KeyUsage:              x509.KeyUsageCertSign | x509.KeyUsageDigitalSignature,
+		IPAddresses:           []net.IP{net.ParseIP("127.0.0.1")},
+	}
+	raw, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	require.NoError(t, err)
+	cert, err := x509.ParseCertificate(raw)
+	require.NoError(t, err)
+
+	server := httptest.NewUnstartedServer(http.HandlerFunc(func(http.ResponseWriter, *http.Request) {}))
+	server.TLS = &tls.Config{Certificates: []tls.Certificate{{Certificate: [][]byte{raw}, PrivateKey: key}}}
+	server.StartTLS()
+	t.Cleanup(server.Close)
+	return server, cert
+}
+
+func Test_CertificateMonitor(t *testing.T) {
+	server, caCertificate := newTestGateway(t)
+	config, err := service.GatewayTLSConfig(domain.ArrayConnectionData{SystemID: "system-1", Endpoint: server.URL, CACertificate: caCertificate})
+	require.NoError(t, err)
+
+	expiredServer, expiredCert := newExpiredGateway(t)
+	expiredConfig, err := service.GatewayTLSConfig(domain.ArrayConnectionData{
+		SystemID:      "system-3",
+		Endpoint:      expiredServer.URL,
+		CACertificate: string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: expiredCert.Raw})),
+	})
+	require.NoError(t, err)
+	_, err = service.GatewayCertificate(context.Background(), expiredServer.URL, expiredConfig)
+	require.Error(t, err, "the expired certificate doesn't verify")
+
+	monitor := &service.CertificateMonitor{
+		Meter:   otel.Meter("powerflex/certificates_test"),
+		Timeout: time.Second,
+	}
+	monitor.SetGateways([]service.GatewayTLS{
+		{StorageSystemID: "system-1", Endpoint: server.URL, Config: config},
+		{StorageSystemID: "system-2", Endpoint: "https://127.0.0.1:1", Config: config},
+		{StorageSystemID: "system-3", Endpoint: expiredServer.URL, Config: expiredConfig},
+	})
+
+	monitor.Refresh(context.Background())
+	expiry := monitor.Expiry()
+	assert.Equal(t, map[string]time.Time{
+		"system-1": server.Certificate().NotAfter,
+		"system-3": expiredCert.NotAfter,
+	}, expiry)
+
+	// the certificates are not fetched again until the check interval passed
+	server.Close()
+	assert.Equal(t, expiry, monitor.Expiry())
+
+	monitor.SetGateways(nil)
+	assert.Empty(t, monitor.Expiry())
+}
+
+func Test_CertificateMonitor_RefreshesInTheBackground(t *testing.T) {
+	server, caCertificate := newTestGateway(t)
+	config, err := service.GatewayTLSConfig(domain.ArrayConnectionData{SystemID: "system-1", Endpoint: server.URL, CACertificate: caCertificate})
+	require.NoError(t, err)
+
+	// a gateway that accepts connections but never completes the handshake
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = listener.Close() })
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			t.Cleanup(func() { _ = conn.Close() })
+		}
+	}()
+
+	monitor := &service.CertificateMonitor{Timeout: 500 * time.Millisecond}
+	monitor.SetGateways([]service.GatewayTLS{
+		{StorageSystemID: "system-1", Endpoint: server.URL, Config: config},
+		{StorageSystemID: "system-2", Endpoint: "https://" + listener.Addr().String(), Config: config},
+	})
+
+	start := time.Now()
+	assert.Empty(t, monitor.Expiry())
+	assert.Less(t, time.Since(start), 100*time.Millisecond, "Expiry should not wait for the gateways")
+
+	assert.Eventually(t, func() bool {
+		_, ok := monitor.Expiry()["system-1"]
+		return ok
+	}, 5*time.Second, 10*time.Millisecond)
+}
@@ -0,0 +1,323 @@
+/*
+ Copyright (c) 2025 Dell Inc. or its subsidiaries. All Rights Reserved.
+
+ Licensed under the Apache License, Version 2.0 (the "License");
+ you may not use this file except in compliance with the License.
+ You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package service
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	sio "github.com/dell/goscaleio"
+	types "github.com/dell/goscaleio/types/v1"
+	"github.com/dell/karavi-metrics-powerflex/internal/telemetry"
+	"github.com/sirupsen/logrus"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
+)
+
+const (
+	// DefaultCircuitBreakerFailureThreshold is the number of consecutive failures that opens the circuit
+	DefaultCircuitBreakerFailureThreshold = 5
+	// DefaultCircuitBreakerInitialBackoff is how long an open circuit waits before probing the array
+	DefaultCircuitBreakerInitialBackoff = 30 * time.Second
+	// DefaultCircuitBreakerMaxBackoff caps the wait between probes of an array that stays down
+	DefaultCircuitBreakerMaxBackoff = 10 * time.Minute
+)
+
+// ErrCircuitOpen is returned instead of calling an array whose circuit is open
+var ErrCircuitOpen = errors.New("circuit breaker is open")
+
+// CircuitState is the state of a CircuitBreakerClient
+type CircuitState int
+
+const (
+	// CircuitClosed lets every call through
+	CircuitClosed CircuitState = iota
+	// CircuitHalfOpen lets one call at a time through to probe whether the array recovered
+	CircuitHalfOpen
+	// CircuitOpen rejects every call until the backoff expires
+	CircuitOpen
+)
+
+// String returns the name of the circuit state
+func (s CircuitState) String() string {
+	switch s {
+	case CircuitClosed:
+		return "closed"
+	case CircuitHalfOpen:
+		return "half-open"
+	case CircuitOpen:
+		return "open"
+	default:
+		return "unknown"
+	}
+}
+
+// AvailabilityReporter is implemented by clients that know whether their array should be queried right now
+type AvailabilityReporter interface {
+	Available() bool
+}
+
+var (
+	_ PowerFlexClient      = (*CircuitBreakerClient)(nil)
+	_ AvailabilityReporter = (*CircuitBreakerClient)(nil)
+	_ GatewayCaller        = (*CircuitBreakerClient)(nil)
+)
+
+// CircuitBreakerClient wraps a PowerFlexClient and stops calling an array after consecutive failures.
+// While open, the array is skipped with exponential backoff until a half-open probe succeeds.
+// Requests made through goscaleio objects count towards the failures when they are run with CallGateway.
+type CircuitBreakerClient struct {
+	PowerFlexClient
+	StorageSystemID  string
+	FailureThreshold int
+	InitialBackoff   time.Duration
+	MaxBackoff       time.Duration
+	Logger           *logrus.Logger
+	Meter            metric.Meter
+
+	mu        sync.Mutex
+	state     CircuitState
+	failures  int
+	backoff   time.Duration
+	openUntil time.Time
+	probing   bool
+	gauge     telemetry.Gauge
+}
+
+// Unwrap returns the client wrapped by the circuit breaker
+func (c *CircuitBreakerClient) Unwrap() PowerFlexClient {
+	return c.PowerFlexClient
+}
+
+// State returns the current state of the circuit
+func (c *CircuitBreakerClient) State() CircuitState {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.state
+}
+
+// Available returns true if the array should be queried, moving an expired open circuit to half-open.
+// A half-open circuit is unavailable while its probe is in flight.
+func (c *CircuitBreakerClient) Available() bool {
+	c.registerGauge()
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.available()
+}
+
+// available is Available for callers that hold mu
+func (c *CircuitBreakerClient) available() bool {
+	switch c.state {
+	case CircuitOpen:
+		if time.Now().Before(c.openUntil) {
+			return false
+		}
+		c.setState(CircuitHalfOpen, nil)
+	case CircuitHalfOpen:
+		return !c.probing
+	}
+	return true
+}
+
+// acquire returns whether a call may go to the array and whether it is the probe of a half-open circuit
+func (c *CircuitBreakerClient) acquire() (bool, bool) {
+	c.registerGauge()
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if !c.available() {
+		return false, false
+	}
+	if c.state == CircuitHalfOpen {
+		c.probing = true
+		return true, true
+	}
+	return true, false
+}
+
+// GetInstance calls GetInstance on the wrapped client unless the circuit is open
+func (c *CircuitBreakerClient) GetInstance(href string) ([]*types.System, error) {
+	var systems []*types.System
+	err := c.call(func() (err error) {
+		systems, err = c.PowerFlexClient.GetInstance(href)
+		return err
+	})
+	return systems, err
+}
+
+// FindSystem calls FindSystem on the wrapped client unless the circuit is open
+func (c *CircuitBreakerClient) FindSystem(id string, name string, href string) (*sio.System, error) {
+	var system *sio.System
+	err := c.call(func() (err error) {
+		system, err = c.PowerFlexClient.FindSystem(id, name, href)
+		return err
+	})
+	return system, err
+}
+
+// GetStoragePool calls GetStoragePool on the wrapped client unless the circuit is open
+func (c *CircuitBreakerClient) GetStoragePool(href string) ([]*types.StoragePool, error) {
+	var pools []*types.StoragePool
+	err := c.call(func() (err error) {
+		pools, err = c.PowerFlexClient.GetStoragePool(href)
+		return err
+	})
+	return pools, err
+}
+
+// GetMetrics calls GetMetrics on the wrapped client unless the circuit is open
+func (c *CircuitBreakerClient) GetMetrics(resource string, ids []string) (*types.MetricsResponse, error) {
+	var resp *types.MetricsResponse
+	err := c.call(func() (err error) {
+		resp, err = c.PowerFlexClient.GetMetrics(resource, ids)
+		return err
+	})
+	return resp, err
+}
+
+// CallGateway runs a request of a goscaleio object built from the client unless the circuit is open
+func (c *CircuitBreakerClient) CallGateway(ctx context.Context, call func() error) error {
+	return c.call(func() error {
+		return CallGateway(ctx, c.PowerFlexClient, call)
+	})
+}
+
+func (c *CircuitBreakerClient) call(fn func() error) error {
+	allowed, probe := c.acquire()
+	if !allowed {
+		return ErrCircuitOpen
+	}
+	err := fn()
+	c.record(err, probe)
+	return err
+}
+
+// record updates the circuit with the outcome of a call
+func (c *CircuitBreakerClient) record(err error, probe bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if probe {
+		c.probing = false
+	}
+
+	if err == nil {
+		c.failures = 0
+		c.backoff = 0
+		if c.state != CircuitClosed {
+			c.setState(CircuitClosed, nil)
+		}
+		return
+	}
+
+	switch c.state {
+	case CircuitHalfOpen:
+		if !probe {
+			// a call that started before the circuit opened, the probe decides
+			return
+		}
+		// the probe failed, wait longer before the next one
+		c.backoff *= 2
+		if maxBackoff := c.maxBackoff(); c.backoff > maxBackoff {
+			c.backoff = maxBackoff
+		}
+		c.open(err)
+	case CircuitClosed:
+		c.failures++
+		if c.failures >= c.failureThreshold() {
+			c.backoff = c.initialBackoff()
+			c.open(err)
+		}
+	}
+}
+
+func (c *CircuitBreakerClient) open(err error) {
+	c.openUntil = time.Now().Add(c.backoff)
+	c.setState(CircuitOpen, err)
+}
+
+// setState changes the state and logs the transition. It is the only place the breaker logs,
+// so an array that stays down produces one line per state change instead of one per call.
+func (c *CircuitBreakerClient) setState(state CircuitState, err error) {
+	previous := c.state
+	c.state = state
+	if previous == state {
+		return
+	}
+
+	entry := telemetry.Logger(c.Logger).WithFields(logrus.Fields{
+		"storage_system_id": c.StorageSystemID,
+		"from":              previous.String(),
+		"to":                state.String(),
+	})
+	switch state {
+	case CircuitOpen:
+		entry.WithError(err).WithField("retry_in", c.backoff.String()).Warn("circuit breaker opened, skipping storage system")
+	case CircuitHalfOpen:
+		entry.Info("circuit breaker half-open, probing storage system")
+	case CircuitClosed:
+		entry.Info("circuit breaker closed, storage system recovered")
+	}
+}
+
+// registerGauge exports the breaker state as powerflex_circuit_breaker_state (0=closed, 1=half-open, 2=open)
+func (c *CircuitBreakerClient) registerGauge() {
+	c.gauge.RegisterInt64(c.Meter, c.Logger, "powerflex_circuit_breaker_state", func(_ context.Context, obs metric.Int64Observer) error {
+		obs.Observe(int64(c.State()), metric.WithAttributes(attribute.String("StorageSystemID", c.StorageSystemID)))
+		return nil
+	})
+}
+
+func (c *CircuitBreakerClient) failureThreshold() int {
+	if c.FailureThreshold <= 0 {
+		return DefaultCircuitBreakerFailureThreshold
+	}
+	return c.FailureThreshold
+}
+
+func (c *CircuitBreakerClient) initialBackoff() time.Duration {
+	if c.InitialBackoff <= 0 {
+		return DefaultCircuitBreakerInitialBackoff
+	}
+	return c.InitialBackoff
+}
+
+func (c *CircuitBreakerClient) maxBackoff() time.Duration {
+	if c.MaxBackoff <= 0 {
+		return DefaultCircuitBreakerMaxBackoff
+	}
+	return c.MaxBackoff
+}
+
+// ClientAvailable returns false if the client, or any client it wraps, reports that its array should be skipped
+func ClientAvailable(client PowerFlexClient) bool {
+	for client != nil {
+		if reporter, ok := client.(AvailabilityReporter); ok && !reporter.Available() {
+			return false
+		}
+		wrapper, ok := client.(ClientUnwrapper)
+		if !ok {
+			return true
+		}
+		client = wrapper.Unwrap()
+	}
+	return true
+}
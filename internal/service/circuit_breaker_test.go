@@ -0,0 +1,194 @@
+/*
+ Copyright (c) 2025 Dell Inc. or its subsidiaries. All Rights Reserved.
+
+ Licensed under the Apache License, Version 2.0 (the "License");
+ you may not use this file except in compliance with the License.
+ You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package service_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	sio "github.com/dell/goscaleio"
+	types "github.com/dell/goscaleio/types/v1"
+	"github.com/dell/karavi-metrics-powerflex/internal/service"
+	"github.com/dell/karavi-metrics-powerflex/internal/service/mocks"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"go.opentelemetry.io/otel"
+	"go.uber.org/mock/gomock"
+)
+
+func newTestCircuitBreaker(client service.PowerFlexClient) *service.CircuitBreakerClient {
+	return &service.CircuitBreakerClient{
+		PowerFlexClient:  client,
+		StorageSystemID:  "system-1",
+		FailureThreshold: 2,
+		InitialBackoff:   20 * time.Millisecond,
+		MaxBackoff:       40 * time.Millisecond,
+		Logger:           logrus.New(),
+		Meter:            otel.Meter("powerflex/circuit_breaker_test"),
+	}
+}
+
+func Test_CircuitBreakerClient(t *testing.T) {
+	down := errors.New("connection refused")
+
+	t.Run("stays closed below the failure threshold", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		client := mocks.NewMockPowerFlexClient(ctrl)
+		gomock.InOrder(
+			client.EXPECT().GetInstance("").Return(nil, down),
+			client.EXPECT().GetInstance("").Return([]*types.System{}, nil),
+			client.EXPECT().GetInstance("").Return(nil, down),
+		)
+
+		breaker := newTestCircuitBreaker(client)
+		for i := 0; i < 3; i++ {
+			_, _ = breaker.GetInstance("")
+		}
+		assert.Equal(t, service.CircuitClosed, breaker.State())
+		assert.True(t, breaker.Available())
+	})
+
+	t.Run("opens after consecutive failures and rejects calls", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		client := mocks.NewMockPowerFlexClient(ctrl)
+		client.EXPECT().GetMetrics("sdc", gomock.Any()).Return(nil, down).Times(2)
+
+		breaker := newTestCircuitBreaker(client)
+		_, _ = breaker.GetMetrics("sdc", []string{"1"})
+		_, _ = breaker.GetMetrics("sdc", []string{"1"})
+		assert.Equal(t, service.CircuitOpen, breaker.State())
+		assert.False(t, breaker.Available())
+
+		_, err := breaker.GetMetrics("sdc", []string{"1"})
+		assert.ErrorIs(t, err, service.ErrCircuitOpen)
+	})
+
+	t.Run("closes after a successful half-open probe", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		client := mocks.NewMockPowerFlexClient(ctrl)
+		gomock.InOrder(
+			client.EXPECT().GetStoragePool("").Return(nil, down).Times(2),
+			client.EXPECT().GetStoragePool("").Return([]*types.StoragePool{}, nil),
+		)
+
+		breaker := newTestCircuitBreaker(client)
+		_, _ = breaker.GetStoragePool("")
+		_, _ = breaker.GetStoragePool("")
+		assert.Equal(t, service.CircuitOpen, breaker.State())
+
+		time.Sleep(30 * time.Millisecond)
+		assert.True(t, breaker.Available())
+		assert.Equal(t, service.CircuitHalfOpen, breaker.State())
+
+		_, err := breaker.GetStoragePool("")
+		assert.NoError(t, err)
+		assert.Equal(t, service.CircuitClosed, breaker.State())
+	})
+
+	t.Run("reopens when the half-open probe fails", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		client := mocks.NewMockPowerFlexClient(ctrl)
+		client.EXPECT().FindSystem("system-1", "", "").Return(nil, down).Times(3)
+
+		breaker := newTestCircuitBreaker(client)
+		_, _ = breaker.FindSystem("system-1", "", "")
+		_, _ = breaker.FindSystem("system-1", "", "")
+
+		time.Sleep(30 * time.Millisecond)
+		_, err := breaker.FindSystem("system-1", "", "")
+		assert.ErrorIs(t, err, down)
+		assert.Equal(t, service.CircuitOpen, breaker.State())
+		// the backoff doubled, so the breaker is still open after the initial backoff
+		time.Sleep(25 * time.Millisecond)
+		assert.False(t, breaker.Available())
+	})
+
+	t.Run("lets one probe through while half-open", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		client := mocks.NewMockPowerFlexClient(ctrl)
+		client.EXPECT().GetInstance("").Return(nil, down).Times(2)
+
+		breaker := newTestCircuitBreaker(client)
+		_, _ = breaker.GetInstance("")
+		_, _ = breaker.GetInstance("")
+		time.Sleep(30 * time.Millisecond)
+
+		probing := make(chan struct{})
+		release := make(chan struct{})
+		done := make(chan error)
+		go func() {
+			done <- service.CallGateway(context.Background(), breaker, func() error {
+				close(probing)
+				<-release
+				return nil
+			})
+		}()
+		<-probing
+
+		assert.False(t, breaker.Available())
+		err := service.CallGateway(context.Background(), breaker, func() error {
+			t.Error("a second call went through while the probe was in flight")
+			return nil
+		})
+		assert.ErrorIs(t, err, service.ErrCircuitOpen)
+
+		close(release)
+		assert.NoError(t, <-done)
+		assert.Equal(t, service.CircuitClosed, breaker.State())
+	})
+
+	t.Run("counts failures of goscaleio object requests", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		client := mocks.NewMockPowerFlexClient(ctrl)
+
+		breaker := newTestCircuitBreaker(client)
+		wrapped := &service.SessionClient{PowerFlexClient: breaker, ConfigConnect: &sio.ConfigConnect{}}
+		for i := 0; i < 2; i++ {
+			err := service.CallGateway(context.Background(), wrapped, func() error { return down })
+			assert.ErrorIs(t, err, down)
+		}
+		assert.Equal(t, service.CircuitOpen, breaker.State())
+
+		err := service.CallGateway(context.Background(), wrapped, func() error { return nil })
+		assert.ErrorIs(t, err, service.ErrCircuitOpen)
+	})
+}
+
+func Test_CircuitState_String(t *testing.T) {
+	assert.Equal(t, "closed", service.CircuitClosed.String())
+	assert.Equal(t, "half-open", service.CircuitHalfOpen.String())
+	assert.Equal(t, "open", service.CircuitOpen.String())
+	assert.Equal(t, "unknown", service.CircuitState(42).String())
+}
+
+func Test_ClientAvailable(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	client := mocks.NewMockPowerFlexClient(ctrl)
+	client.EXPECT().GetInstance("").Return(nil, errors.New("down")).Times(2)
+
+	breaker := newTestCircuitBreaker(client)
+	wrapped := &service.SessionClient{PowerFlexClient: breaker, ConfigConnect: &sio.ConfigConnect{}}
+	assert.True(t, service.ClientAvailable(client))
+	assert.True(t, service.ClientAvailable(wrapped))
+
+	_, _ = breaker.GetInstance("")
+	_, _ = breaker.GetInstance("")
+	assert.False(t, service.ClientAvailable(breaker))
+	assert.False(t, service.ClientAvailable(wrapped))
+}
@@ -17,6 +17,7 @@
 package service
 
 import (
+	"crypto/x509"
 	"fmt"
 	"os"
 	"path/filepath"
@@ -40,13 +41,18 @@ func (c *ConfigurationReader) GetStorageSystemConfiguration(file string) ([]doma
 		return nil, fmt.Errorf("%s", fmt.Sprintf("File %s errors: %v", file, err))
 	}
 
+	return c.ParseStorageSystemConfiguration(config)
+}
+
+// ParseStorageSystemConfiguration returns the storage systems from the content of the configuration file
+func (c *ConfigurationReader) ParseStorageSystemConfiguration(config []byte) ([]domain.ArrayConnectionData, error) {
 	if string(config) == "" {
 		return nil, fmt.Errorf("arrays details are not provided in vxflexos-config secret")
 	}
 
 	connectionData := make([]domain.ArrayConnectionData, 0)
 	// support backward compatibility
-	config, err = yaml.JSONToYAML(config)
+	config, err := yaml.JSONToYAML(config)
 	if err != nil {
 		return nil, fmt.Errorf("%s", fmt.Sprintf("converting json to yaml: %v", err))
 	}
@@ -83,5 +89,19 @@ func validateStorageSystem(system domain.ArrayConnectionData, i int) error {
 	if system.Endpoint == "" {
 		return fmt.Errorf("%s", fmt.Sprintf("invalid value for Endpoint at index %d", i))
 	}
+	if system.RateLimit != nil && (system.RateLimit.RequestsPerSecond <= 0 || system.RateLimit.Burst < 0) {
+		return fmt.Errorf("%s", fmt.Sprintf("invalid value for rateLimit at index %d", i))
+	}
+	if system.CACertificate != "" && system.CACertificateFile != "" {
+		return fmt.Errorf("%s", fmt.Sprintf("caCertificate and caCertificateFile are mutually exclusive at index %d", i))
+	}
+	if system.CACertificate != "" && !x509.NewCertPool().AppendCertsFromPEM([]byte(system.CACertificate)) {
+		return fmt.Errorf("%s", fmt.Sprintf("invalid value for caCertificate at index %d", i))
+	}
+	// goscaleio builds its own transport and only verifies the gateway against a CA file, so a pinned
+	// fingerprint could only be checked once, not on every connection the client makes
+	if len(system.CertificateFingerprints) > 0 {
+		return fmt.Errorf("%s", fmt.Sprintf("certificateFingerprints at index %d are not supported, use caCertificate or caCertificateFile", i))
+	}
 	return nil
 }
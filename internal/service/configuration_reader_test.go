@@ -121,6 +121,53 @@ func Test_ConfigurationReader(t *testing.T) {
 
 			return configReader, file, check(hasNoError, checkExpectedOutput(expectedResult))
 		},
+		"success yaml with rate limit override": func(*testing.T) (service.ConfigurationReader, string, []checkFn) {
+			file := "testdata/config-with-rate-limit.yaml"
+			configReader := service.ConfigurationReader{}
+
+			expectedResult := []domain.ArrayConnectionData{
+				{
+					Username:  "admin",
+					Password:  "password",
+					SystemID:  "ID1",
+					Endpoint:  "https://127.0.0.1",
+					RateLimit: &domain.RateLimit{RequestsPerSecond: 2.5, Burst: 5},
+				},
+				{
+					Username: "admin",
+					Password: "password",
+					SystemID: "ID2",
+					Endpoint: "https://127.0.0.1",
+				},
+			}
+
+			return configReader, file, check(hasNoError, checkExpectedOutput(expectedResult))
+		},
+		"error when file has invalid rate limit": func(*testing.T) (service.ConfigurationReader, string, []checkFn) {
+			file := "testdata/config-invalid-rate-limit.json"
+			configReader := service.ConfigurationReader{}
+			return configReader, file, check(hasError)
+		},
+		"success with a CA file": func(*testing.T) (service.ConfigurationReader, string, []checkFn) {
+			file := "testdata/config-with-certificates.yaml"
+			configReader := service.ConfigurationReader{}
+
+			expectedResult := []domain.ArrayConnectionData{
+				{
+					Username:          "admin",
+					Password:          "password",
+					SystemID:          "ID1",
+					Endpoint:          "https://127.0.0.1",
+					CACertificateFile: "/etc/powerflex/ca.pem",
+				},
+			}
+			return configReader, file, check(hasNoError, checkExpectedOutput(expectedResult))
+		},
+		"error with certificate fingerprints": func(*testing.T) (service.ConfigurationReader, string, []checkFn) {
+			file := "testdata/config-certificate-fingerprints.yaml"
+			configReader := service.ConfigurationReader{}
+			return configReader, file, check(hasError)
+		},
 		"error when file doesn't exist": func(*testing.T) (service.ConfigurationReader, string, []checkFn) {
 			file := "testdata/non-existant-file.json"
 			configReader := service.ConfigurationReader{}
@@ -183,3 +230,17 @@ func Test_ConfigurationReader(t *testing.T) {
 		})
 	}
 }
+
+func Test_ConfigurationReader_ParseStorageSystemConfiguration(t *testing.T) {
+	configReader := service.ConfigurationReader{}
+
+	result, err := configReader.ParseStorageSystemConfiguration([]byte("- username: admin\n  password: password\n  systemID: ID1\n  endpoint: https://127.0.0.1\n"))
+	assert.NoError(t, err)
+	assert.Equal(t, []domain.ArrayConnectionData{{Username: "admin", Password: "password", SystemID: "ID1", Endpoint: "https://127.0.0.1"}}, result)
+
+	_, err = configReader.ParseStorageSystemConfiguration([]byte(""))
+	assert.Error(t, err)
+
+	_, err = configReader.ParseStorageSystemConfiguration([]byte("- username: admin\n  systemID: ID1\n  endpoint: https://127.0.0.1\n"))
+	assert.EqualError(t, err, "invalid value for Password at index 0")
+}
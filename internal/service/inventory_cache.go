@@ -0,0 +1,236 @@
+/*
+ Copyright (c) 2025 Dell Inc. or its subsidiaries. All Rights Reserved.
+
+ Licensed under the Apache License, Version 2.0 (the "License");
+ you may not use this file except in compliance with the License.
+ You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package service
+
+import (
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	sio "github.com/dell/goscaleio"
+	"github.com/dell/karavi-metrics-powerflex/internal/k8s"
+)
+
+// DefaultInventoryRefreshInterval is how long discovered systems, SDCs, pools and volume mappings are reused.
+// The cache is opt-in: volumes mapped to an SDC while its entry is cached aren't reported until the entry expires.
+const DefaultInventoryRefreshInterval time.Duration = 0
+
+// InventoryCache holds the slow-changing inventory of each storage system so that metric polls
+// don't have to rediscover systems, SDCs, storage pools and volume mappings on every tick.
+// Entries expire after RefreshInterval, and are dropped early when the Kubernetes objects they were
+// discovered from change. A nil *InventoryCache is valid and never returns a hit.
+type InventoryCache struct {
+	RefreshInterval time.Duration
+
+	mu          sync.Mutex
+	inventories map[PowerFlexClient]*inventory
+}
+
+// inventory is the cached discovery state of the storage system behind one client
+type inventory struct {
+	sdcs           []SdcMetricsRetriever
+	sdcFingerprint string
+	sdcsExpire     time.Time
+	volumes        map[string][]*sio.Volume
+
+	storageClassMetas       []StorageClassMeta
+	storageClassFingerprint string
+	storageClassMetasExpire time.Time
+}
+
+// NewInventoryCache returns an empty cache whose entries expire after refreshInterval
+func NewInventoryCache(refreshInterval time.Duration) *InventoryCache {
+	return &InventoryCache{
+		RefreshInterval: refreshInterval,
+		inventories:     make(map[PowerFlexClient]*inventory),
+	}
+}
+
+// SDCs returns the SDCs discovered for client if they are fresh and were found for the same keys, see sdcInventoryKeys
+func (c *InventoryCache) SDCs(client PowerFlexClient, sdcKeys []string) ([]SdcMetricsRetriever, bool) {
+	if c == nil {
+		return nil, false
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	inv, ok := c.inventories[client]
+	if !ok || time.Now().After(inv.sdcsExpire) || inv.sdcFingerprint != fingerprint(sdcKeys) {
+		return nil, false
+	}
+	return inv.sdcs, true
+}
+
+// SetSDCs stores the SDCs discovered for client. Volume mappings of the previous SDCs are dropped.
+func (c *InventoryCache) SetSDCs(client PowerFlexClient, sdcKeys []string, sdcs []SdcMetricsRetriever) {
+	if c == nil {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	inv := c.inventory(client)
+	inv.sdcs = sdcs
+	inv.sdcFingerprint = fingerprint(sdcKeys)
+	inv.sdcsExpire = time.Now().Add(c.RefreshInterval)
+	inv.volumes = make(map[string][]*sio.Volume)
+}
+
+// Volumes returns the volumes mapped to sdc if they were discovered since the SDCs were last refreshed
+func (c *InventoryCache) Volumes(client PowerFlexClient, sdc *sio.Sdc) ([]*sio.Volume, bool) {
+	if c == nil || sdc == nil || sdc.Sdc == nil {
+		return nil, false
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	inv, ok := c.inventories[client]
+	if !ok || time.Now().After(inv.sdcsExpire) {
+		return nil, false
+	}
+	volumes, ok := inv.volumes[sdc.Sdc.ID]
+	return volumes, ok
+}
+
+// SetVolumes stores the volumes mapped to sdc. The mappings expire with the SDCs.
+func (c *InventoryCache) SetVolumes(client PowerFlexClient, sdc *sio.Sdc, volumes []*sio.Volume) {
+	if c == nil || sdc == nil || sdc.Sdc == nil {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	inv, ok := c.inventories[client]
+	if !ok || inv.volumes == nil {
+		// the SDCs weren't cached, so there is nothing to expire the mappings with
+		return
+	}
+	inv.volumes[sdc.Sdc.ID] = volumes
+}
+
+// StorageClasses returns the storage classes and pools discovered for client if they are fresh
+// and were found for the same set of Kubernetes StorageClass objects
+func (c *InventoryCache) StorageClasses(client PowerFlexClient, storageClasses []k8s.StorageClass) ([]StorageClassMeta, bool) {
+	if c == nil {
+		return nil, false
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	inv, ok := c.inventories[client]
+	if !ok || time.Now().After(inv.storageClassMetasExpire) ||
+		inv.storageClassFingerprint != storageClassFingerprint(storageClasses) {
+		return nil, false
+	}
+	return inv.storageClassMetas, true
+}
+
+// SetStorageClasses stores the storage classes and pools discovered for client
+func (c *InventoryCache) SetStorageClasses(client PowerFlexClient, storageClasses []k8s.StorageClass, storageClassMetas []StorageClassMeta) {
+	if c == nil {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	inv := c.inventory(client)
+	inv.storageClassMetas = storageClassMetas
+	inv.storageClassFingerprint = storageClassFingerprint(storageClasses)
+	inv.storageClassMetasExpire = time.Now().Add(c.RefreshInterval)
+}
+
+// SetRefreshInterval changes how long new entries are reused. Existing entries are dropped
+// so that a shorter interval takes effect immediately. An interval of zero disables caching.
+func (c *InventoryCache) SetRefreshInterval(refreshInterval time.Duration) {
+	if c == nil {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.RefreshInterval == refreshInterval {
+		return
+	}
+	c.RefreshInterval = refreshInterval
+	c.inventories = make(map[PowerFlexClient]*inventory)
+}
+
+// Enabled returns true if entries are reused, i.e. the refresh interval isn't zero
+func (c *InventoryCache) Enabled() bool {
+	if c == nil {
+		return false
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.RefreshInterval > 0
+}
+
+// Invalidate drops the cached inventory of every storage system
+func (c *InventoryCache) Invalidate() {
+	if c == nil {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.inventories = make(map[PowerFlexClient]*inventory)
+}
+
+// inventory returns the entry for client, creating it if needed. c.mu must be held.
+func (c *InventoryCache) inventory(client PowerFlexClient) *inventory {
+	if c.inventories == nil {
+		c.inventories = make(map[PowerFlexClient]*inventory)
+	}
+	inv, ok := c.inventories[client]
+	if !ok {
+		inv = &inventory{}
+		c.inventories[client] = inv
+	}
+	return inv
+}
+
+// fingerprint returns an order-independent key for a set of identifiers
+func fingerprint(ids []string) string {
+	sorted := append([]string(nil), ids...)
+	sort.Strings(sorted)
+	return strings.Join(sorted, ",")
+}
+
+// sdcInventoryKeys identifies the SDCs of the cache by their GUID and the node whose CSINode reported it,
+// so that the SDCs are discovered again when a GUID moves to another node
+func sdcInventoryKeys(sdcGUIDs []string, sdcNodes map[string]string) []string {
+	keys := make([]string, 0, len(sdcGUIDs))
+	for _, sdcGUID := range sdcGUIDs {
+		keys = append(keys, sdcGUID+"/"+sdcNodes[sdcGUID])
+	}
+	return keys
+}
+
+// storageClassFingerprint changes whenever a StorageClass is added, removed or updated
+func storageClassFingerprint(storageClasses []k8s.StorageClass) string {
+	ids := make([]string, 0, len(storageClasses))
+	for _, class := range storageClasses {
+		id := string(class.UID) + "/" + class.ResourceVersion
+		// the exported labels change with the allowlist even if the storage class doesn't
+		attributes := make([]string, 0, len(class.Attributes))
+		for attribute, value := range class.Attributes {
+			attributes = append(attributes, attribute+"="+value)
+		}
+		sort.Strings(attributes)
+		ids = append(ids, strings.Join(append([]string{id}, attributes...), "/"))
+	}
+	return fingerprint(ids)
+}
@@ -0,0 +1,244 @@
+/*
+ Copyright (c) 2025 Dell Inc. or its subsidiaries. All Rights Reserved.
+
+ Licensed under the Apache License, Version 2.0 (the "License");
+ you may not use this file except in compliance with the License.
+ You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package service_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/agiledragon/gomonkey/v2"
+	sio "github.com/dell/goscaleio"
+	types "github.com/dell/goscaleio/types/v1"
+	"github.com/dell/karavi-metrics-powerflex/internal/k8s"
+	"github.com/dell/karavi-metrics-powerflex/internal/service"
+	"github.com/dell/karavi-metrics-powerflex/internal/service/mocks"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+	"golang.org/x/time/rate"
+	v1 "k8s.io/api/storage/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func Test_InventoryCache(t *testing.T) {
+	sdcs := []service.SdcMetricsRetriever{service.SdcMetricsHandler{GenType: "v1"}}
+	sdc := &sio.Sdc{Sdc: &types.Sdc{ID: "sdc-1"}}
+	volumes := []*sio.Volume{{Volume: &types.Volume{ID: "vol-1"}}}
+
+	t.Run("nil cache never hits", func(t *testing.T) {
+		var cache *service.InventoryCache
+		ctrl := gomock.NewController(t)
+		client := mocks.NewMockPowerFlexClient(ctrl)
+
+		cache.SetSDCs(client, []string{"g1"}, sdcs)
+		_, ok := cache.SDCs(client, []string{"g1"})
+		assert.False(t, ok)
+		cache.Invalidate()
+	})
+
+	t.Run("SDCs hit for the same GUIDs in any order", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		client := mocks.NewMockPowerFlexClient(ctrl)
+		cache := service.NewInventoryCache(time.Minute)
+
+		cache.SetSDCs(client, []string{"g1", "g2"}, sdcs)
+		got, ok := cache.SDCs(client, []string{"g2", "g1"})
+		assert.True(t, ok)
+		assert.Equal(t, sdcs, got)
+
+		_, ok = cache.SDCs(client, []string{"g1", "g2", "g3"})
+		assert.False(t, ok, "a new CSINode should invalidate the SDCs")
+
+		_, ok = cache.SDCs(mocks.NewMockPowerFlexClient(ctrl), []string{"g1", "g2"})
+		assert.False(t, ok, "entries are per storage system")
+	})
+
+	t.Run("entries expire after the refresh interval", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		client := mocks.NewMockPowerFlexClient(ctrl)
+		cache := service.NewInventoryCache(10 * time.Millisecond)
+
+		cache.SetSDCs(client, []string{"g1"}, sdcs)
+		cache.SetVolumes(client, sdc, volumes)
+		_, ok := cache.Volumes(client, sdc)
+		assert.True(t, ok)
+
+		time.Sleep(20 * time.Millisecond)
+		_, ok = cache.SDCs(client, []string{"g1"})
+		assert.False(t, ok)
+		_, ok = cache.Volumes(client, sdc)
+		assert.False(t, ok)
+	})
+
+	t.Run("volumes are only cached alongside SDCs", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		client := mocks.NewMockPowerFlexClient(ctrl)
+		cache := service.NewInventoryCache(time.Minute)
+
+		cache.SetVolumes(client, sdc, volumes)
+		_, ok := cache.Volumes(client, sdc)
+		assert.False(t, ok)
+
+		cache.SetSDCs(client, []string{"g1"}, sdcs)
+		cache.SetVolumes(client, sdc, volumes)
+		got, ok := cache.Volumes(client, sdc)
+		assert.True(t, ok)
+		assert.Equal(t, volumes, got)
+
+		// rediscovering the SDCs drops their volume mappings
+		cache.SetSDCs(client, []string{"g1"}, sdcs)
+		_, ok = cache.Volumes(client, sdc)
+		assert.False(t, ok)
+	})
+
+	t.Run("storage classes are invalidated when a StorageClass changes", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		client := mocks.NewMockPowerFlexClient(ctrl)
+		cache := service.NewInventoryCache(time.Minute)
+
+		classes := []k8s.StorageClass{{StorageClass: v1.StorageClass{ObjectMeta: metav1.ObjectMeta{UID: "uid-1", ResourceVersion: "1"}}}}
+		metas := []service.StorageClassMeta{{ID: "uid-1"}}
+		cache.SetStorageClasses(client, classes, metas)
+
+		got, ok := cache.StorageClasses(client, classes)
+		assert.True(t, ok)
+		assert.Equal(t, metas, got)
+
+		updated := []k8s.StorageClass{{StorageClass: v1.StorageClass{ObjectMeta: metav1.ObjectMeta{UID: "uid-1", ResourceVersion: "2"}}}}
+		_, ok = cache.StorageClasses(client, updated)
+		assert.False(t, ok)
+
+		relabeled := []k8s.StorageClass{{StorageClass: classes[0].StorageClass, Attributes: map[string]string{"label_team": "storage"}}}
+		_, ok = cache.StorageClasses(client, relabeled)
+		assert.False(t, ok, "a change of the exported labels should invalidate the storage classes")
+	})
+
+	t.Run("invalidate and refresh interval changes drop everything", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		client := mocks.NewMockPowerFlexClient(ctrl)
+		cache := service.NewInventoryCache(time.Minute)
+
+		cache.SetSDCs(client, []string{"g1"}, sdcs)
+		cache.Invalidate()
+		_, ok := cache.SDCs(client, []string{"g1"})
+		assert.False(t, ok)
+
+		cache.SetSDCs(client, []string{"g1"}, sdcs)
+		cache.SetRefreshInterval(time.Minute)
+		_, ok = cache.SDCs(client, []string{"g1"})
+		assert.True(t, ok, "an unchanged interval keeps the entries")
+
+		cache.SetRefreshInterval(0)
+		_, ok = cache.SDCs(client, []string{"g1"})
+		assert.False(t, ok)
+		cache.SetSDCs(client, []string{"g1"}, sdcs)
+		_, ok = cache.SDCs(client, []string{"g1"})
+		assert.False(t, ok, "a zero interval disables caching")
+	})
+}
+
+func Test_GetSDCs_InventoryCache(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	finder := mocks.NewMockSDCFinder(ctrl)
+	client := mocks.NewMockPowerFlexClient(ctrl)
+
+	finder.EXPECT().GetSDCGuids().Return([]string{"g1"}, nil).Times(3)
+	// the second poll is served from the cache, the third runs after the cache was invalidated
+	client.EXPECT().GetInstance("").Return([]*types.System{{Name: "sys1", ID: "sid1"}}, nil).Times(1)
+
+	patches := gomonkey.NewPatches()
+	patches.ApplyFunc(service.SystemFinder, func(service.PowerFlexClient, string, string, string) (service.PowerFlexSystem, error) {
+		var sf service.PowerFlexSystem = &fakeSystemFinderTarget{
+			byGUID: map[string]*sio.Sdc{
+				"g1": {Sdc: &types.Sdc{SdcGUID: "g1", ID: "sdc-id-1"}},
+			},
+		}
+		return sf, nil
+	})
+	patches.ApplyFunc(service.GetGenType, func(*sio.System) (string, error) {
+		return "v1", nil
+	})
+	t.Cleanup(patches.Reset)
+
+	svc := &service.PowerFlexService{Logger: logrus.New(), InventoryCache: service.NewInventoryCache(time.Minute)}
+	first, err := svc.GetSDCs(context.Background(), client, finder)
+	require.NoError(t, err)
+	second, err := svc.GetSDCs(context.Background(), client, finder)
+	require.NoError(t, err)
+	assert.Equal(t, first, second)
+
+	svc.InventoryCache.Invalidate()
+	client.EXPECT().GetInstance("").Return([]*types.System{}, nil).Times(1)
+	third, err := svc.GetSDCs(context.Background(), client, finder)
+	require.NoError(t, err)
+	assert.Empty(t, third)
+}
+
+func Test_GetSDCs_InventoryCacheNodeChange(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	client := mocks.NewMockPowerFlexClient(ctrl)
+	// the SDCs are discovered again once the CSINode of their GUID moved to another node
+	client.EXPECT().GetInstance("").Return([]*types.System{{Name: "sys1", ID: "sid1"}}, nil).Times(2)
+
+	patches := gomonkey.NewPatches()
+	patches.ApplyFunc(service.SystemFinder, func(service.PowerFlexClient, string, string, string) (service.PowerFlexSystem, error) {
+		return &fakeSystemFinderTarget{byGUID: map[string]*sio.Sdc{"g1": {Sdc: &types.Sdc{SdcGUID: "g1", ID: "sdc-id-1"}}}}, nil
+	})
+	patches.ApplyFunc(service.GetGenType, func(*sio.System) (string, error) {
+		return "v1", nil
+	})
+	t.Cleanup(patches.Reset)
+
+	svc := &service.PowerFlexService{Logger: logrus.New(), InventoryCache: service.NewInventoryCache(time.Minute)}
+	for _, node := range []string{"node-1", "node-1", "node-2"} {
+		sdcs, err := svc.GetSDCs(context.Background(), client, csiNodeSDCFinder{guids: []string{"g1"}, nodes: map[string]string{"g1": node}})
+		require.NoError(t, err)
+		require.Len(t, sdcs, 1)
+		assert.Equal(t, node, sdcs[0].GetNodeName())
+	}
+}
+
+func Test_InventoryCache_Enabled(t *testing.T) {
+	var cache *service.InventoryCache
+	assert.False(t, cache.Enabled())
+	assert.False(t, service.NewInventoryCache(service.DefaultInventoryRefreshInterval).Enabled(), "the cache is opt-in")
+	assert.True(t, service.NewInventoryCache(time.Minute).Enabled())
+}
+
+func Test_GetVolumes_InventoryCacheDoesNotWaitOnRateLimit(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	limiter := rate.NewLimiter(rate.Every(time.Hour), 1)
+	limiter.Allow()
+	client := &service.RateLimitedClient{PowerFlexClient: mocks.NewMockPowerFlexClient(ctrl), Limiter: limiter}
+
+	sdc := &sio.Sdc{Sdc: &types.Sdc{ID: "sdc-1"}}
+	cache := service.NewInventoryCache(time.Minute)
+	cache.SetSDCs(client, []string{"g1"}, nil)
+	cache.SetVolumes(client, sdc, []*sio.Volume{})
+
+	// the limiter has no tokens left, so waiting on it with a canceled context fails
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	svc := &service.PowerFlexService{Logger: logrus.New(), InventoryCache: cache}
+	volumes, err := svc.GetVolumes(ctx, client, []service.SdcMetricsRetriever{
+		service.SdcMetricsHandler{Sdc: sdc, GenType: "v1", Client: client},
+	})
+	require.NoError(t, err)
+	assert.Empty(t, volumes)
+}
@@ -0,0 +1,61 @@
+/*
+ Copyright (c) 2025 Dell Inc. or its subsidiaries. All Rights Reserved.
+
+ Licensed under the Apache License, Version 2.0 (the "License");
+ you may not use this file except in compliance with the License.
+ You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package service
+
+import "sync"
+
+// LocalSDC remembers the ID of the local SDC on each storage system, for pods that only collect their own node's SDC.
+// Once a listing of the SDCs of a storage system found it, it is fetched on its own instead of listing every SDC of the
+// storage system from every node. A nil *LocalSDC remembers nothing.
+type LocalSDC struct {
+	mu  sync.Mutex
+	ids map[string]string
+}
+
+// id returns the ID of the local SDC on a storage system, if it was found before
+func (l *LocalSDC) id(storageSystemID string) (string, bool) {
+	if l == nil {
+		return "", false
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	id, ok := l.ids[storageSystemID]
+	return id, ok
+}
+
+// set stores the ID of the local SDC on a storage system
+func (l *LocalSDC) set(storageSystemID string, id string) {
+	if l == nil {
+		return
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.ids == nil {
+		l.ids = make(map[string]string)
+	}
+	l.ids[storageSystemID] = id
+}
+
+// forget drops the ID of the local SDC on a storage system, e.g. after the SDC was removed, so that it is listed again
+func (l *LocalSDC) forget(storageSystemID string) {
+	if l == nil {
+		return
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	delete(l.ids, storageSystemID)
+}
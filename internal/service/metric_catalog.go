@@ -0,0 +1,251 @@
+/*
+ Copyright (c) 2025 Dell Inc. or its subsidiaries. All Rights Reserved.
+
+ Licensed under the Apache License, Version 2.0 (the "License");
+ you may not use this file except in compliance with the License.
+ You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package service
+
+import (
+	"fmt"
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	types "github.com/dell/goscaleio/types/v1"
+	"sigs.k8s.io/yaml"
+)
+
+// Resource types of the PowerFlex metrics API
+const (
+	MetricsResourceSDC         = "sdc"
+	MetricsResourceVolume      = "volume"
+	MetricsResourceStoragePool = "storage_pool"
+)
+
+// Instruments of the SDC, volume and storage pool series. Their values are read from the metrics named by the catalog
+// entries of these instruments, and the other entries of the catalog add instruments.
+const (
+	InstrumentReadBandwidth            = "read_bw_megabytes_per_second"
+	InstrumentWriteBandwidth           = "write_bw_megabytes_per_second"
+	InstrumentReadIOPS                 = "read_iops_per_second"
+	InstrumentWriteIOPS                = "write_iops_per_second"
+	InstrumentReadLatency              = "read_latency_milliseconds"
+	InstrumentWriteLatency             = "write_latency_milliseconds"
+	InstrumentTotalLogicalCapacity     = "total_logical_capacity_gigabytes"
+	InstrumentLogicalCapacityAvailable = "logical_capacity_available_gigabytes"
+	InstrumentLogicalCapacityInUse     = "logical_capacity_in_use_gigabytes"
+	InstrumentLogicalProvisioned       = "logical_provisioned_gigabytes"
+	// the data reduction ratios are named apart from the compression_ratio and data_reduction_ratio metrics of the
+	// API, which catalog files may already export as instruments of their own
+	InstrumentCompressionRatio      = "efficiency_compression_ratio"
+	InstrumentDataReductionRatio    = "efficiency_data_reduction_ratio"
+	InstrumentSnapshotCapacityInUse = "snapshot_capacity_in_use_gigabytes"
+	// InstrumentThinOvercommit is computed from the capacity of the storage pools, so it has no catalog entry
+	InstrumentThinOvercommit = "thin_overcommit_ratio"
+)
+
+// catalogPrefixes are the prefixes of the instruments of each resource type
+var catalogPrefixes = map[string]string{
+	MetricsResourceSDC:         "powerflex_export_node_",
+	MetricsResourceVolume:      "powerflex_volume_",
+	MetricsResourceStoragePool: "powerflex_storage_pool_",
+}
+
+// seriesInstruments are the instruments of the series of each resource type, which can't have attributes
+var seriesInstruments = map[string][]string{
+	MetricsResourceSDC: {
+		InstrumentReadBandwidth, InstrumentWriteBandwidth, InstrumentReadIOPS, InstrumentWriteIOPS, InstrumentReadLatency, InstrumentWriteLatency,
+	},
+	MetricsResourceVolume: {
+		InstrumentReadBandwidth, InstrumentWriteBandwidth, InstrumentReadIOPS, InstrumentWriteIOPS, InstrumentReadLatency, InstrumentWriteLatency,
+	},
+	MetricsResourceStoragePool: {
+		InstrumentTotalLogicalCapacity, InstrumentLogicalCapacityAvailable, InstrumentLogicalCapacityInUse, InstrumentLogicalProvisioned,
+		InstrumentCompressionRatio, InstrumentDataReductionRatio, InstrumentSnapshotCapacityInUse,
+	},
+}
+
+// computedInstruments are the instruments of each resource type that aren't read from a metric, which a catalog
+// file can't add
+var computedInstruments = map[string][]string{
+	MetricsResourceStoragePool: {InstrumentThinOvercommit},
+}
+
+// MetricCatalogEntry maps a metric of a resource type of the PowerFlex metrics API to an instrument, whose name is
+// the instrument appended to the prefix of the resource type, e.g. powerflex_volume_
+type MetricCatalogEntry struct {
+	ResourceType string `json:"resourceType"`
+	Metric       string `json:"metric"`
+	Instrument   string `json:"instrument"`
+	Unit         string `json:"unit,omitempty"`
+	// Scale multiplies the value of the metric, e.g. to convert bytes to megabytes. 0 keeps the value.
+	Scale float64 `json:"scale,omitempty"`
+	// Attributes are added to the series of the instrument, so that several metrics can be exported by one instrument
+	Attributes map[string]string `json:"attributes,omitempty"`
+}
+
+// InstrumentName returns the name of the instrument of the entry
+func (e MetricCatalogEntry) InstrumentName() string {
+	return catalogPrefixes[e.ResourceType] + e.Instrument
+}
+
+// value returns the scaled value of the metric of the entry, or 0 if metrics doesn't have it
+func (e MetricCatalogEntry) value(metrics []types.Metric) (float64, bool) {
+	for _, m := range metrics {
+		if m.Name == e.Metric && len(m.Values) > 0 {
+			if e.Scale == 0 {
+				return m.Values[0], true
+			}
+			return m.Values[0] * e.Scale, true
+		}
+	}
+	return 0, false
+}
+
+// key identifies the series of the entry, entries of a file replace the built in entry with the same key
+func (e MetricCatalogEntry) key() string {
+	var b strings.Builder
+	b.WriteString(e.ResourceType + "/" + e.Instrument)
+	for _, name := range slices.Sorted(maps.Keys(e.Attributes)) {
+		b.WriteString("," + name + "=" + e.Attributes[name])
+	}
+	return b.String()
+}
+
+// MetricCatalog maps the metrics of the PowerFlex metrics API, which the SDCs, volumes and storage pools of EC systems
+// are queried with, to instruments
+type MetricCatalog struct {
+	Metrics []MetricCatalogEntry `json:"metrics"`
+}
+
+// defaultMetricCatalog is the catalog of a service without one
+var defaultMetricCatalog = DefaultMetricCatalog()
+
+// CatalogMetricValue is the value of a metric of a catalog entry that adds an instrument
+type CatalogMetricValue struct {
+	Entry MetricCatalogEntry
+	Value float64
+}
+
+// DefaultMetricCatalog returns the built in catalog, which exports the series of the SDCs, volumes and storage pools
+func DefaultMetricCatalog() *MetricCatalog {
+	const (
+		megabyte = 1.0 / (1024 * 1024)
+		gigabyte = 1.0 / (1 << 30)
+		// the latency of volumes is reported in microseconds
+		millisecond = 1.0 / 1000
+	)
+	return &MetricCatalog{Metrics: []MetricCatalogEntry{
+		{ResourceType: MetricsResourceSDC, Metric: "host_read_bandwidth", Instrument: InstrumentReadBandwidth},
+		{ResourceType: MetricsResourceSDC, Metric: "host_write_bandwidth", Instrument: InstrumentWriteBandwidth},
+		{ResourceType: MetricsResourceSDC, Metric: "host_read_iops", Instrument: InstrumentReadIOPS},
+		{ResourceType: MetricsResourceSDC, Metric: "host_write_iops", Instrument: InstrumentWriteIOPS},
+		{ResourceType: MetricsResourceSDC, Metric: "avg_host_read_latency", Instrument: InstrumentReadLatency},
+		{ResourceType: MetricsResourceSDC, Metric: "avg_host_write_latency", Instrument: InstrumentWriteLatency},
+
+		{ResourceType: MetricsResourceVolume, Metric: "host_read_bandwidth", Instrument: InstrumentReadBandwidth, Scale: megabyte},
+		{ResourceType: MetricsResourceVolume, Metric: "host_write_bandwidth", Instrument: InstrumentWriteBandwidth, Scale: megabyte},
+		{ResourceType: MetricsResourceVolume, Metric: "host_read_iops", Instrument: InstrumentReadIOPS},
+		{ResourceType: MetricsResourceVolume, Metric: "host_write_iops", Instrument: InstrumentWriteIOPS},
+		{ResourceType: MetricsResourceVolume, Metric: "avg_host_read_latency", Instrument: InstrumentReadLatency, Scale: millisecond},
+		{ResourceType: MetricsResourceVolume, Metric: "avg_host_write_latency", Instrument: InstrumentWriteLatency, Scale: millisecond},
+
+		{ResourceType: MetricsResourceStoragePool, Metric: "physical_total", Instrument: InstrumentTotalLogicalCapacity, Scale: gigabyte},
+		{ResourceType: MetricsResourceStoragePool, Metric: "physical_free", Instrument: InstrumentLogicalCapacityAvailable, Scale: gigabyte},
+		{ResourceType: MetricsResourceStoragePool, Metric: "physical_used", Instrument: InstrumentLogicalCapacityInUse, Scale: gigabyte},
+		{ResourceType: MetricsResourceStoragePool, Metric: "logical_provisioned", Instrument: InstrumentLogicalProvisioned, Scale: gigabyte},
+		// the efficiency metrics of a gateway that names them differently are remapped by a catalog file
+		{ResourceType: MetricsResourceStoragePool, Metric: "compression_ratio", Instrument: InstrumentCompressionRatio},
+		{ResourceType: MetricsResourceStoragePool, Metric: "data_reduction_ratio", Instrument: InstrumentDataReductionRatio},
+		{ResourceType: MetricsResourceStoragePool, Metric: "snapshot_used", Instrument: InstrumentSnapshotCapacityInUse, Scale: gigabyte},
+	}}
+}
+
+// LoadMetricCatalog returns the built in catalog with the entries of a YAML or JSON file. An entry of the file
+// replaces the built in entry of the same resource type, instrument and attributes, and the other entries are added.
+func LoadMetricCatalog(file string) (*MetricCatalog, error) {
+	content, err := os.ReadFile(filepath.Clean(file))
+	if err != nil {
+		return nil, fmt.Errorf("reading metric catalog: %w", err)
+	}
+	var overrides MetricCatalog
+	if err := yaml.UnmarshalStrict(content, &overrides); err != nil {
+		return nil, fmt.Errorf("parsing metric catalog %s: %w", file, err)
+	}
+
+	catalog := DefaultMetricCatalog()
+	index := make(map[string]int, len(catalog.Metrics))
+	for i, entry := range catalog.Metrics {
+		index[entry.key()] = i
+	}
+	for i, entry := range overrides.Metrics {
+		if err := entry.validate(); err != nil {
+			return nil, fmt.Errorf("invalid metric catalog entry %d in %s: %w", i, file, err)
+		}
+		if j, ok := index[entry.key()]; ok {
+			catalog.Metrics[j] = entry
+			continue
+		}
+		index[entry.key()] = len(catalog.Metrics)
+		catalog.Metrics = append(catalog.Metrics, entry)
+	}
+	return catalog, nil
+}
+
+func (e MetricCatalogEntry) validate() error {
+	if _, ok := catalogPrefixes[e.ResourceType]; !ok {
+		return fmt.Errorf("unknown resource type %q", e.ResourceType)
+	}
+	if e.Metric == "" {
+		return fmt.Errorf("no metric")
+	}
+	if e.Instrument == "" {
+		return fmt.Errorf("no instrument")
+	}
+	if slices.Contains(computedInstruments[e.ResourceType], e.Instrument) {
+		return fmt.Errorf("instrument %s of the %s series is computed by the service and can't be added", e.Instrument, e.ResourceType)
+	}
+	if len(e.Attributes) > 0 && slices.Contains(seriesInstruments[e.ResourceType], e.Instrument) {
+		return fmt.Errorf("instrument %s of the %s series can't have attributes", e.Instrument, e.ResourceType)
+	}
+	return nil
+}
+
+// Value returns the value of the instrument of a resource type from its metrics
+func (c *MetricCatalog) Value(resourceType, instrument string, metrics []types.Metric) float64 {
+	for _, entry := range c.Metrics {
+		if entry.ResourceType == resourceType && entry.Instrument == instrument && len(entry.Attributes) == 0 {
+			value, _ := entry.value(metrics)
+			return value
+		}
+	}
+	return 0
+}
+
+// Additional returns the values of the entries of a resource type that add instruments, for the metrics
+// that are in the response
+func (c *MetricCatalog) Additional(resourceType string, metrics []types.Metric) []CatalogMetricValue {
+	var values []CatalogMetricValue
+	for _, entry := range c.Metrics {
+		if entry.ResourceType != resourceType || slices.Contains(seriesInstruments[resourceType], entry.Instrument) {
+			continue
+		}
+		if value, ok := entry.value(metrics); ok {
+			values = append(values, CatalogMetricValue{Entry: entry, Value: value})
+		}
+	}
+	return values
+}
@@ -0,0 +1,203 @@
+/*
+ Copyright (c) 2025 Dell Inc. or its subsidiaries. All Rights Reserved.
+
+ Licensed under the Apache License, Version 2.0 (the "License");
+ you may not use this file except in compliance with the License.
+ You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package service_test
+
+import (
+	"context"
+	"os"
+	"sync"
+	"testing"
+
+	sio "github.com/dell/goscaleio"
+	types "github.com/dell/goscaleio/types/v1"
+	"github.com/dell/karavi-metrics-powerflex/internal/service"
+	"github.com/dell/karavi-metrics-powerflex/internal/service/mocks"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+)
+
+func Test_DefaultMetricCatalog(t *testing.T) {
+	catalog := service.DefaultMetricCatalog()
+	metrics := []types.Metric{
+		{Name: "host_read_bandwidth", Values: []float64{2 * 1024 * 1024}},
+		{Name: "avg_host_read_latency", Values: []float64{1500}},
+		{Name: "physical_total", Values: []float64{3 * (1 << 30)}},
+		{Name: "host_write_iops", Values: []float64{}},
+	}
+
+	assert.Equal(t, float64(2*1024*1024), catalog.Value(service.MetricsResourceSDC, service.InstrumentReadBandwidth, metrics))
+	assert.Equal(t, float64(2), catalog.Value(service.MetricsResourceVolume, service.InstrumentReadBandwidth, metrics))
+	assert.InDelta(t, 1.5, catalog.Value(service.MetricsResourceVolume, service.InstrumentReadLatency, metrics), 1e-9)
+	assert.Equal(t, float64(3), catalog.Value(service.MetricsResourceStoragePool, service.InstrumentTotalLogicalCapacity, metrics))
+	assert.Equal(t, float64(0), catalog.Value(service.MetricsResourceVolume, service.InstrumentWriteIOPS, metrics))
+	assert.Equal(t, float64(0), catalog.Value(service.MetricsResourceVolume, service.InstrumentWriteBandwidth, metrics))
+	assert.Empty(t, catalog.Additional(service.MetricsResourceVolume, metrics))
+}
+
+func Test_LoadMetricCatalog(t *testing.T) {
+	tests := map[string]struct {
+		file  string
+		check func(*testing.T, *service.MetricCatalog)
+		err   string
+	}{
+		"overrides and additions": {
+			file: "testdata/metric-catalog.yaml",
+			check: func(t *testing.T, catalog *service.MetricCatalog) {
+				// 19 built in entries, one of which is replaced
+				assert.Len(t, catalog.Metrics, 23)
+				metrics := []types.Metric{
+					{Name: "host_read_bandwidth", Values: []float64{2048}},
+					{Name: "backend_read_bandwidth", Values: []float64{3 * 1024 * 1024}},
+					{Name: "backend_write_bandwidth", Values: []float64{4 * 1024 * 1024}},
+					{Name: "compression_ratio", Values: []float64{1.5}},
+				}
+				assert.Equal(t, float64(2), catalog.Value(service.MetricsResourceVolume, service.InstrumentReadBandwidth, metrics))
+				assert.Equal(t, float64(2048), catalog.Value(service.MetricsResourceSDC, service.InstrumentReadBandwidth, metrics))
+
+				additional := catalog.Additional(service.MetricsResourceVolume, metrics)
+				require.Len(t, additional, 2)
+				assert.Equal(t, "powerflex_volume_backend_bw_megabytes_per_second", additional[0].Entry.InstrumentName())
+				assert.Equal(t, map[string]string{"direction": "read"}, additional[0].Entry.Attributes)
+				assert.Equal(t, float64(3), additional[0].Value)
+				assert.Equal(t, "MB/s", additional[1].Entry.Unit)
+				assert.Equal(t, float64(4), additional[1].Value)
+
+				// only the metrics in the response are exported
+				additional = catalog.Additional(service.MetricsResourceStoragePool, metrics)
+				require.Len(t, additional, 1)
+				assert.Equal(t, "powerflex_storage_pool_compression_ratio", additional[0].Entry.InstrumentName())
+				assert.Equal(t, 1.5, additional[0].Value)
+				// the built in data reduction ratios don't clash with the instrument added by the file
+				assert.Equal(t, 1.5, catalog.Value(service.MetricsResourceStoragePool, service.InstrumentCompressionRatio, metrics))
+			},
+		},
+		"attributes on a series instrument": {
+			file: "testdata/metric-catalog-invalid.yaml",
+			err:  "instrument read_bw_megabytes_per_second of the sdc series can't have attributes",
+		},
+		"unknown field": {
+			file: "testdata/config-with-rate-limit.yaml",
+			err:  "parsing metric catalog",
+		},
+		"missing file": {
+			file: "testdata/no-such-catalog.yaml",
+			err:  "reading metric catalog",
+		},
+	}
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			catalog, err := service.LoadMetricCatalog(tc.file)
+			if tc.err != "" {
+				assert.ErrorContains(t, err, tc.err)
+				assert.Nil(t, catalog)
+				return
+			}
+			require.NoError(t, err)
+			tc.check(t, catalog)
+		})
+	}
+}
+
+func Test_LoadMetricCatalog_Invalid(t *testing.T) {
+	tests := map[string]string{
+		"unknown resource type": "metrics:\n- resourceType: device\n  metric: m\n  instrument: i\n",
+		"no metric":             "metrics:\n- resourceType: sdc\n  instrument: i\n",
+		"no instrument":         "metrics:\n- resourceType: sdc\n  metric: m\n",
+		"computed instrument":   "metrics:\n- resourceType: storage_pool\n  metric: m\n  instrument: thin_overcommit_ratio\n",
+	}
+	for name, content := range tests {
+		t.Run(name, func(t *testing.T) {
+			file := t.TempDir() + "/catalog.yaml"
+			require.NoError(t, os.WriteFile(file, []byte(content), 0o600))
+			_, err := service.LoadMetricCatalog(file)
+			assert.ErrorContains(t, err, "invalid metric catalog entry 0")
+		})
+	}
+}
+
+func Test_GetSDCStatistics_MetricCatalog(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	client := mocks.NewMockPowerFlexClient(ctrl)
+	metrics := mocks.NewMockMetricsRecorder(ctrl)
+
+	catalog := service.DefaultMetricCatalog()
+	catalog.Metrics[0].Scale = 0.5
+	catalog.Metrics = append(catalog.Metrics, service.MetricCatalogEntry{
+		ResourceType: service.MetricsResourceSDC, Metric: "backend_read_iops", Instrument: "backend_read_iops_per_second",
+	})
+
+	client.EXPECT().GetMetrics("sdc", []string{"sdc-1"}).Return(&types.MetricsResponse{Resources: []types.Resource{{ID: "sdc-1", Metrics: []types.Metric{
+		{Name: "host_read_bandwidth", Values: []float64{8}},
+		{Name: "backend_read_iops", Values: []float64{20}},
+	}}}}, nil)
+	metrics.EXPECT().Record(gomock.Any(), gomock.Any(), float64(4), float64(0), float64(0), float64(0), float64(0), float64(0)).Return(nil)
+	var mu sync.Mutex
+	var got []service.CatalogMetricValue
+	metrics.EXPECT().RecordCatalogMetrics(gomock.Any(), gomock.Any(), gomock.Any()).
+		DoAndReturn(func(_ context.Context, meta interface{}, values []service.CatalogMetricValue) error {
+			mu.Lock()
+			defer mu.Unlock()
+			assert.Equal(t, "sdc-1", meta.(*service.SDCMeta).ID)
+			got = values
+			return nil
+		})
+
+	retrievers := []service.SdcMetricsRetriever{ecRetriever{sdc: &sio.Sdc{Sdc: &types.Sdc{ID: "sdc-1"}}, client: client, gen: types.GenTypeEC}}
+	svc := &service.PowerFlexService{MetricsWrapper: metrics, Logger: logrus.New(), MetricCatalog: catalog}
+	svc.GetSDCStatistics(context.Background(), nil, retrievers)
+
+	require.Len(t, got, 1)
+	assert.Equal(t, "powerflex_export_node_backend_read_iops_per_second", got[0].Entry.InstrumentName())
+	assert.Equal(t, float64(20), got[0].Value)
+}
+
+func Test_GetStoragePoolStatistics_MetricCatalog(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	client := mocks.NewMockPowerFlexClient(ctrl)
+	metrics := mocks.NewMockMetricsRecorder(ctrl)
+	catalog, err := service.LoadMetricCatalog("testdata/metric-catalog.yaml")
+	require.NoError(t, err)
+
+	client.EXPECT().GetMetrics("storage_pool", []string{"pool-1", "pool-2"}).Return(&types.MetricsResponse{Resources: []types.Resource{
+		{ID: "pool-1", Metrics: []types.Metric{{Name: "compression_ratio", Values: []float64{2}}}},
+		{ID: "pool-2", Metrics: []types.Metric{{Name: "physical_total", Values: []float64{1 << 30}}}},
+	}}, nil)
+	metrics.EXPECT().RecordCapacity(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Return(nil).Times(2)
+	metrics.EXPECT().RecordPoolEfficiency(gomock.Any(), gomock.Any(), gomock.Any()).Return(nil).Times(2)
+	// only pool-1 has a metric of the catalog, which is recorded for that pool alone
+	metrics.EXPECT().RecordCatalogMetrics(gomock.Any(), gomock.Any(), gomock.Any()).
+		DoAndReturn(func(_ context.Context, meta interface{}, values []service.CatalogMetricValue) error {
+			scMeta := meta.(service.StorageClassMeta)
+			assert.Equal(t, "class-a", scMeta.Name)
+			assert.Len(t, scMeta.StoragePools, 1)
+			assert.Contains(t, scMeta.StoragePools, "pool-1")
+			require.Len(t, values, 1)
+			assert.Equal(t, float64(2), values[0].Value)
+			return nil
+		})
+
+	pool := func() service.StoragePoolMetricsRetriever {
+		return ecPoolRetriever{client: client, stats: mocks.NewMockStoragePoolStatisticsGetter(ctrl), gen: types.GenTypeEC}
+	}
+	scMetas := []service.StorageClassMeta{
+		{ID: "1", Name: "class-a", StoragePools: map[string]service.StoragePoolMetricsRetriever{"pool-1": pool(), "pool-2": pool()}},
+	}
+	svc := &service.PowerFlexService{MetricsWrapper: metrics, Logger: logrus.New(), MetricCatalog: catalog}
+	svc.GetStoragePoolStatistics(context.Background(), scMetas)
+}
@@ -19,6 +19,8 @@ package service
 import (
 	"context"
 	"errors"
+	"slices"
+	"sort"
 	"sync"
 
 	"go.opentelemetry.io/otel/attribute"
@@ -36,6 +38,10 @@ type MetricsRecorder interface {
 	RecordCapacity(ctx context.Context, meta interface{},
 		totalLogicalCapacity, logicalCapacityAvailable, logicalCapacityInUse, logicalProvisioned float64) error
 	RecordTopologyMetrics(ctx context.Context, meta interface{}, topologyMetrics *TopologyMetricsRecord) error
+	RecordVolumeAggregate(ctx context.Context, meta interface{}, aggregate *VolumeAggregateRecord) error
+	RecordCatalogMetrics(ctx context.Context, meta interface{}, values []CatalogMetricValue) error
+	RecordPoolEfficiency(ctx context.Context, meta interface{}, efficiency *PoolEfficiencyRecord) error
+	RecordNodeConditions(ctx context.Context, meta interface{}, conditions *NodeConditionsRecord) error
 }
 
 // MeterCreater interface is used to create and provide Meter instances, which are used to report measurements.
@@ -49,11 +55,21 @@ type MeterCreater interface {
 
 // MetricsWrapper contains data used for pushing metrics data
 type MetricsWrapper struct {
-	Meter           metric.Meter
+	Meter metric.Meter
+	// NodeName labels SDC and volume metrics with the node they were collected on, in node-local mode
+	NodeName        string
 	Metrics         sync.Map
 	Labels          sync.Map
 	CapacityMetrics sync.Map
 	TopologyMetrics sync.Map
+	// AggregateMetrics holds the instruments of the namespace and storage class rollups, keyed by prefix
+	AggregateMetrics sync.Map
+	// CatalogMetrics holds the instruments added by the metric catalog, keyed by name
+	CatalogMetrics sync.Map
+	// EfficiencyMetrics holds the data reduction and thin provisioning instruments of the storage pools
+	EfficiencyMetrics sync.Map
+	// NodeConditionMetrics holds the ready and cordoned instruments of the nodes of the SDCs
+	NodeConditionMetrics sync.Map
 }
 
 // Metrics contains the list of metrics data that is collected
@@ -77,6 +93,34 @@ type CapacityMetrics struct {
 // TopologyMetrics contains the metrics related to PV availability in the cluster.
 type TopologyMetrics struct {
 	PvAvailabilityMetric metric.Float64ObservableUpDownCounter
+	// ConsumerInfoMetric has a series for each pod that mounts the volume, IdleMountMetric is 1 when no pod mounts it
+	ConsumerInfoMetric metric.Float64ObservableUpDownCounter
+	IdleMountMetric    metric.Float64ObservableUpDownCounter
+}
+
+// EfficiencyMetrics contains the data reduction and thin provisioning metrics of a storage pool
+type EfficiencyMetrics struct {
+	CompressionRatio      metric.Float64ObservableUpDownCounter
+	DataReductionRatio    metric.Float64ObservableUpDownCounter
+	ThinOvercommit        metric.Float64ObservableUpDownCounter
+	SnapshotCapacityInUse metric.Float64ObservableUpDownCounter
+}
+
+// NodeConditionMetrics contains the conditions of the node of an SDC, as 0 or 1
+type NodeConditionMetrics struct {
+	Ready    metric.Float64ObservableUpDownCounter
+	Cordoned metric.Float64ObservableUpDownCounter
+}
+
+// AggregateMetrics contains the metrics of the volumes of a namespace or storage class
+type AggregateMetrics struct {
+	ReadBW                 metric.Float64ObservableUpDownCounter
+	WriteBW                metric.Float64ObservableUpDownCounter
+	ReadIOPS               metric.Float64ObservableUpDownCounter
+	WriteIOPS              metric.Float64ObservableUpDownCounter
+	ProvisionedCapacity    metric.Float64ObservableUpDownCounter
+	VolumeSize             metric.Float64ObservableUpDownCounter
+	PersistentVolumeClaims metric.Float64ObservableUpDownCounter
 }
 
 func (mw *MetricsWrapper) initMetrics(prefix, metaID string, labels []attribute.KeyValue) (*Metrics, error) {
@@ -128,15 +172,8 @@ func (mw *MetricsWrapper) initCapacityMetrics(prefix, metaID string, _ []attribu
 	return metrics, nil
 }
 
-// Record will publish metrics data for a given instance
-func (mw *MetricsWrapper) Record(_ context.Context, meta interface{},
-	readBW, writeBW,
-	readIOPS, writeIOPS,
-	readLatency, writeLatency float64,
-) error {
-	var prefix string
-	var metaID string
-	var labels []attribute.KeyValue
+// ioLabels returns the prefix, ID and labels of the I/O series of an SDC or a volume
+func (mw *MetricsWrapper) ioLabels(meta interface{}) (prefix, metaID string, labels []attribute.KeyValue, err error) {
 	switch v := meta.(type) {
 	case *VolumeMeta:
 		prefix, metaID = "powerflex_volume_", v.ID
@@ -157,6 +194,7 @@ func (mw *MetricsWrapper) Record(_ context.Context, meta interface{},
 			attribute.String("MappedNodeIPs", mappedSDCIPs),
 			attribute.String("PlotWithMean", "No"),
 		}
+		labels = append(labels, labelAttributes(v.Attributes)...)
 	case *SDCMeta:
 		prefix, metaID = "powerflex_export_node_", v.ID
 		labels = []attribute.KeyValue{
@@ -166,8 +204,25 @@ func (mw *MetricsWrapper) Record(_ context.Context, meta interface{},
 			attribute.String("NodeGUID", v.SdcGUID),
 			attribute.String("PlotWithMean", "No"),
 		}
+		labels = append(labels, labelAttributes(v.Attributes)...)
 	default:
-		return errors.New("unknown MetaData type")
+		return "", "", nil, errors.New("unknown MetaData type")
+	}
+	if mw.NodeName != "" {
+		labels = append(labels, attribute.String("NodeName", mw.NodeName))
+	}
+	return prefix, metaID, labels, nil
+}
+
+// Record will publish metrics data for a given instance
+func (mw *MetricsWrapper) Record(_ context.Context, meta interface{},
+	readBW, writeBW,
+	readIOPS, writeIOPS,
+	readLatency, writeLatency float64,
+) error {
+	prefix, metaID, labels, err := mw.ioLabels(meta)
+	if err != nil {
+		return err
 	}
 
 	metricsMapValue, ok := mw.Metrics.Load(metaID)
@@ -247,12 +302,7 @@ func (mw *MetricsWrapper) RecordCapacity(_ context.Context, meta interface{},
 		case "csi-vxflexos.dellemc.com":
 			prefix, metaID := "powerflex_storage_pool_", v.ID
 			for pool := range v.StoragePools {
-				labels := []attribute.KeyValue{
-					attribute.String("StorageClass", v.Name),
-					attribute.String("Driver", v.Driver),
-					attribute.String("StoragePool", pool),
-					attribute.String("StorageSystemID", v.StorageSystemID),
-				}
+				labels := capacityLabels(v, pool)
 
 				metricsMapValue, ok := mw.CapacityMetrics.Load(metaID)
 				if !ok {
@@ -293,14 +343,45 @@ func (mw *MetricsWrapper) RecordCapacity(_ context.Context, meta interface{},
 	return nil
 }
 
+// capacityLabels returns the labels of the capacity series of a storage pool of a storage class
+func capacityLabels(v StorageClassMeta, pool string) []attribute.KeyValue {
+	labels := []attribute.KeyValue{
+		attribute.String("StorageClass", v.Name),
+		attribute.String("Driver", v.Driver),
+		attribute.String("StoragePool", pool),
+		attribute.String("StorageSystemID", v.StorageSystemID),
+	}
+	return append(labels, labelAttributes(v.Attributes)...)
+}
+
+// labelAttributes returns the attributes of the allowed kubernetes labels, sorted by name
+func labelAttributes(attributes map[string]string) []attribute.KeyValue {
+	labels := make([]attribute.KeyValue, 0, len(attributes))
+	for name, value := range attributes {
+		labels = append(labels, attribute.String(name, value))
+	}
+	sort.Slice(labels, func(i, j int) bool { return labels[i].Key < labels[j].Key })
+	return labels
+}
+
 // initTopologyMetrics initializes and stores topology metrics and associated labels for a given volume.
 func (mw *MetricsWrapper) initTopologyMetrics(metaID string, labels []attribute.KeyValue) (*TopologyMetrics, error) {
 	pvcSize, err := mw.Meter.Float64ObservableUpDownCounter("karavi_topology_metrics")
 	if err != nil {
 		return nil, err
 	}
+	consumerInfo, err := mw.Meter.Float64ObservableUpDownCounter("powerflex_volume_consumer_info")
+	if err != nil {
+		return nil, err
+	}
+	idleMount, err := mw.Meter.Float64ObservableUpDownCounter("powerflex_volume_idle_mount")
+	if err != nil {
+		return nil, err
+	}
 	metrics := &TopologyMetrics{
 		PvAvailabilityMetric: pvcSize,
+		ConsumerInfoMetric:   consumerInfo,
+		IdleMountMetric:      idleMount,
 	}
 
 	mw.TopologyMetrics.Store(metaID, metrics)
@@ -313,9 +394,26 @@ func (mw *MetricsWrapper) initTopologyMetrics(metaID string, labels []attribute.
 func (mw *MetricsWrapper) RecordTopologyMetrics(_ context.Context, meta interface{}, topologyMetrics *TopologyMetricsRecord) error {
 	var metaID string
 	var labels []attribute.KeyValue
+	var consumerLabels [][]attribute.KeyValue
+	var volumeLabels []attribute.KeyValue
 
 	switch v := meta.(type) {
 	case *TopologyMeta:
+		if v.ConsumersResolved {
+			// the consumer series use the attributes of the volume I/O series, so that they can be joined to them
+			volumeLabels = []attribute.KeyValue{
+				attribute.String("PersistentVolumeName", v.PersistentVolume),
+				attribute.String("PersistentVolumeClaimName", v.PersistentVolumeClaim),
+				attribute.String("Namespace", v.Namespace),
+			}
+			for _, consumer := range v.Consumers {
+				consumerLabels = append(consumerLabels, append(slices.Clip(volumeLabels),
+					attribute.String("pod", consumer.Pod),
+					attribute.String("workload_kind", consumer.WorkloadKind),
+					attribute.String("workload_name", consumer.WorkloadName),
+				))
+			}
+		}
 		metaID = v.PersistentVolume
 		labels = []attribute.KeyValue{
 			attribute.String("Namespace", v.Namespace),
@@ -332,6 +430,7 @@ func (mw *MetricsWrapper) RecordTopologyMetrics(_ context.Context, meta interfac
 			attribute.String("CreatedTime", v.CreatedTime),
 			attribute.String("PlotWithMean", "No"),
 		}
+		labels = append(labels, labelAttributes(v.Attributes)...)
 	default:
 		return errors.New("unknown MetaData type")
 	}
@@ -372,15 +471,357 @@ func (mw *MetricsWrapper) RecordTopologyMetrics(_ context.Context, meta interfac
 	done := make(chan struct{})
 	reg, err := mw.Meter.RegisterCallback(func(_ context.Context, obs metric.Observer) error {
 		obs.ObserveFloat64(metrics.PvAvailabilityMetric, float64(topologyMetrics.pvAvailable), metric.ObserveOption(metric.WithAttributes(labels...)))
+		if volumeLabels != nil {
+			for _, consumer := range consumerLabels {
+				obs.ObserveFloat64(metrics.ConsumerInfoMetric, 1, metric.WithAttributes(consumer...))
+			}
+			idle := 0.0
+			if len(consumerLabels) == 0 {
+				idle = 1
+			}
+			obs.ObserveFloat64(metrics.IdleMountMetric, idle, metric.WithAttributes(volumeLabels...))
+		}
+		go func() {
+			done <- struct{}{}
+		}()
+		return nil
+	}, metrics.PvAvailabilityMetric, metrics.ConsumerInfoMetric, metrics.IdleMountMetric)
+	if err != nil {
+		return err
+	}
+
+	<-done
+	_ = reg.Unregister()
+
+	return nil
+}
+
+// initAggregateMetrics initializes and stores the instruments of the rollups with prefix
+func (mw *MetricsWrapper) initAggregateMetrics(prefix string) (*AggregateMetrics, error) {
+	names := []string{
+		"read_bw_megabytes_per_second",
+		"write_bw_megabytes_per_second",
+		"read_iops_per_second",
+		"write_iops_per_second",
+		"provisioned_gigabytes",
+		"volume_size_gigabytes",
+		"persistent_volume_claims",
+	}
+	instruments := make([]metric.Float64ObservableUpDownCounter, 0, len(names))
+	for _, name := range names {
+		instrument, err := mw.Meter.Float64ObservableUpDownCounter(prefix + name)
+		if err != nil {
+			return nil, err
+		}
+		instruments = append(instruments, instrument)
+	}
+	metrics := &AggregateMetrics{
+		ReadBW:                 instruments[0],
+		WriteBW:                instruments[1],
+		ReadIOPS:               instruments[2],
+		WriteIOPS:              instruments[3],
+		ProvisionedCapacity:    instruments[4],
+		VolumeSize:             instruments[5],
+		PersistentVolumeClaims: instruments[6],
+	}
+
+	mw.AggregateMetrics.Store(prefix, metrics)
+	return metrics, nil
+}
+
+// RecordVolumeAggregate publishes the totals of the volumes of a namespace or a storage class
+func (mw *MetricsWrapper) RecordVolumeAggregate(_ context.Context, meta interface{}, aggregate *VolumeAggregateRecord) error {
+	var prefix string
+	var labels []attribute.KeyValue
+
+	switch v := meta.(type) {
+	case *NamespaceAggregateMeta:
+		prefix = "powerflex_namespace_"
+		labels = []attribute.KeyValue{
+			attribute.String("StorageSystemID", v.StorageSystemID),
+			attribute.String("Namespace", v.Namespace),
+			attribute.String("PlotWithMean", "No"),
+		}
+	case *StorageClassAggregateMeta:
+		prefix = "powerflex_storage_class_"
+		labels = []attribute.KeyValue{
+			attribute.String("StorageSystemID", v.StorageSystemID),
+			attribute.String("StorageClass", v.StorageClass),
+			attribute.String("PlotWithMean", "No"),
+		}
+	default:
+		return errors.New("unknown MetaData type")
+	}
+	if mw.NodeName != "" {
+		labels = append(labels, attribute.String("NodeName", mw.NodeName))
+	}
+
+	metricsMapValue, ok := mw.AggregateMetrics.Load(prefix)
+	if !ok {
+		newMetrics, err := mw.initAggregateMetrics(prefix)
+		if err != nil {
+			return err
+		}
+		metricsMapValue = newMetrics
+	}
+	metrics := metricsMapValue.(*AggregateMetrics)
+
+	done := make(chan struct{})
+	reg, err := mw.Meter.RegisterCallback(func(_ context.Context, obs metric.Observer) error {
+		obs.ObserveFloat64(metrics.ReadBW, aggregate.ReadBW, metric.WithAttributes(labels...))
+		obs.ObserveFloat64(metrics.WriteBW, aggregate.WriteBW, metric.WithAttributes(labels...))
+		obs.ObserveFloat64(metrics.ReadIOPS, aggregate.ReadIOPS, metric.WithAttributes(labels...))
+		obs.ObserveFloat64(metrics.WriteIOPS, aggregate.WriteIOPS, metric.WithAttributes(labels...))
+		obs.ObserveFloat64(metrics.ProvisionedCapacity, aggregate.ProvisionedGigabytes, metric.WithAttributes(labels...))
+		obs.ObserveFloat64(metrics.VolumeSize, aggregate.VolumeSizeGigabytes, metric.WithAttributes(labels...))
+		obs.ObserveFloat64(metrics.PersistentVolumeClaims, float64(aggregate.PersistentVolumeClaims), metric.WithAttributes(labels...))
 		go func() {
 			done <- struct{}{}
 		}()
 		return nil
-	}, metrics.PvAvailabilityMetric)
+	},
+		metrics.ReadBW,
+		metrics.WriteBW,
+		metrics.ReadIOPS,
+		metrics.WriteIOPS,
+		metrics.ProvisionedCapacity,
+		metrics.VolumeSize,
+		metrics.PersistentVolumeClaims,
+	)
 	if err != nil {
 		return err
 	}
+	<-done
+	_ = reg.Unregister()
+
+	return nil
+}
+
+// catalogInstrument returns the instrument of a catalog entry, which is created the first time it is recorded
+func (mw *MetricsWrapper) catalogInstrument(entry MetricCatalogEntry) (metric.Float64ObservableUpDownCounter, error) {
+	name := entry.InstrumentName()
+	if instrument, ok := mw.CatalogMetrics.Load(name); ok {
+		return instrument.(metric.Float64ObservableUpDownCounter), nil
+	}
+	var options []metric.Float64ObservableUpDownCounterOption
+	if entry.Unit != "" {
+		options = append(options, metric.WithUnit(entry.Unit))
+	}
+	instrument, err := mw.Meter.Float64ObservableUpDownCounter(name, options...)
+	if err != nil {
+		return nil, err
+	}
+	actual, _ := mw.CatalogMetrics.LoadOrStore(name, instrument)
+	return actual.(metric.Float64ObservableUpDownCounter), nil
+}
 
+// RecordCatalogMetrics publishes the values of the instruments that the metric catalog adds to the series of an SDC,
+// a volume or a storage pool of a storage class
+func (mw *MetricsWrapper) RecordCatalogMetrics(_ context.Context, meta interface{}, values []CatalogMetricValue) error {
+	var labels []attribute.KeyValue
+	switch v := meta.(type) {
+	case *SDCMeta, *VolumeMeta:
+		var err error
+		if _, _, labels, err = mw.ioLabels(v); err != nil {
+			return err
+		}
+	case StorageClassMeta:
+		if len(v.StoragePools) != 1 {
+			return errors.New("catalog metrics of a storage class need a single storage pool")
+		}
+		for pool := range v.StoragePools {
+			labels = capacityLabels(v, pool)
+		}
+	default:
+		return errors.New("unknown MetaData type")
+	}
+
+	instruments := make([]metric.Observable, 0, len(values))
+	counters := make([]metric.Float64ObservableUpDownCounter, 0, len(values))
+	for _, value := range values {
+		instrument, err := mw.catalogInstrument(value.Entry)
+		if err != nil {
+			return err
+		}
+		instruments = append(instruments, instrument)
+		counters = append(counters, instrument)
+	}
+
+	done := make(chan struct{})
+	reg, err := mw.Meter.RegisterCallback(func(_ context.Context, obs metric.Observer) error {
+		for i, value := range values {
+			attributes := append(slices.Clone(labels), labelAttributes(value.Entry.Attributes)...)
+			obs.ObserveFloat64(counters[i], value.Value, metric.WithAttributes(attributes...))
+		}
+		go func() {
+			done <- struct{}{}
+		}()
+		return nil
+	}, instruments...)
+	if err != nil {
+		return err
+	}
+	<-done
+	_ = reg.Unregister()
+
+	return nil
+}
+
+// initEfficiencyMetrics initializes and stores the data reduction and thin provisioning instruments of the storage pools
+func (mw *MetricsWrapper) initEfficiencyMetrics(prefix string) (*EfficiencyMetrics, error) {
+	names := []string{
+		InstrumentCompressionRatio,
+		InstrumentDataReductionRatio,
+		InstrumentThinOvercommit,
+		InstrumentSnapshotCapacityInUse,
+	}
+	instruments := make([]metric.Float64ObservableUpDownCounter, 0, len(names))
+	for _, name := range names {
+		instrument, err := mw.Meter.Float64ObservableUpDownCounter(prefix + name)
+		if err != nil {
+			return nil, err
+		}
+		instruments = append(instruments, instrument)
+	}
+	metrics := &EfficiencyMetrics{
+		CompressionRatio:      instruments[0],
+		DataReductionRatio:    instruments[1],
+		ThinOvercommit:        instruments[2],
+		SnapshotCapacityInUse: instruments[3],
+	}
+
+	mw.EfficiencyMetrics.Store(prefix, metrics)
+	return metrics, nil
+}
+
+// RecordPoolEfficiency publishes the data reduction and thin provisioning metrics of a storage pool of a storage class,
+// with the labels of its capacity series. The ratios a storage pool doesn't report aren't published.
+func (mw *MetricsWrapper) RecordPoolEfficiency(_ context.Context, meta interface{}, efficiency *PoolEfficiencyRecord) error {
+	const prefix = "powerflex_storage_pool_"
+	var labels []attribute.KeyValue
+
+	switch v := meta.(type) {
+	case StorageClassMeta:
+		if len(v.StoragePools) != 1 {
+			return errors.New("efficiency metrics of a storage class need a single storage pool")
+		}
+		for pool := range v.StoragePools {
+			labels = capacityLabels(v, pool)
+		}
+	default:
+		return errors.New("unknown MetaData type")
+	}
+
+	metricsMapValue, ok := mw.EfficiencyMetrics.Load(prefix)
+	if !ok {
+		newMetrics, err := mw.initEfficiencyMetrics(prefix)
+		if err != nil {
+			return err
+		}
+		metricsMapValue = newMetrics
+	}
+	metrics := metricsMapValue.(*EfficiencyMetrics)
+
+	done := make(chan struct{})
+	reg, err := mw.Meter.RegisterCallback(func(_ context.Context, obs metric.Observer) error {
+		if efficiency.CompressionRatio > 0 {
+			obs.ObserveFloat64(metrics.CompressionRatio, efficiency.CompressionRatio, metric.WithAttributes(labels...))
+		}
+		if efficiency.DataReductionRatio > 0 {
+			obs.ObserveFloat64(metrics.DataReductionRatio, efficiency.DataReductionRatio, metric.WithAttributes(labels...))
+		}
+		obs.ObserveFloat64(metrics.ThinOvercommit, efficiency.ThinOvercommit, metric.WithAttributes(labels...))
+		obs.ObserveFloat64(metrics.SnapshotCapacityInUse, efficiency.SnapshotCapacityGigabytes, metric.WithAttributes(labels...))
+		go func() {
+			done <- struct{}{}
+		}()
+		return nil
+	},
+		metrics.CompressionRatio,
+		metrics.DataReductionRatio,
+		metrics.ThinOvercommit,
+		metrics.SnapshotCapacityInUse,
+	)
+	if err != nil {
+		return err
+	}
+	<-done
+	_ = reg.Unregister()
+
+	return nil
+}
+
+// initNodeConditionMetrics initializes and stores the ready and cordoned instruments of the nodes of the SDCs
+func (mw *MetricsWrapper) initNodeConditionMetrics(prefix string) (*NodeConditionMetrics, error) {
+	ready, err := mw.Meter.Float64ObservableUpDownCounter(prefix + "node_ready")
+	if err != nil {
+		return nil, err
+	}
+	cordoned, err := mw.Meter.Float64ObservableUpDownCounter(prefix + "node_cordoned")
+	if err != nil {
+		return nil, err
+	}
+	metrics := &NodeConditionMetrics{
+		Ready:    ready,
+		Cordoned: cordoned,
+	}
+
+	mw.NodeConditionMetrics.Store(prefix, metrics)
+	return metrics, nil
+}
+
+// RecordNodeConditions publishes whether the node of an SDC is ready and cordoned. The conditions are separate series
+// from the SDC I/O, so that the I/O series don't start over when a node is cordoned or stops being ready.
+func (mw *MetricsWrapper) RecordNodeConditions(_ context.Context, meta interface{}, conditions *NodeConditionsRecord) error {
+	const prefix = "powerflex_sdc_"
+	var labels []attribute.KeyValue
+
+	switch v := meta.(type) {
+	case *SDCMeta:
+		labels = []attribute.KeyValue{
+			attribute.String("ID", v.ID),
+			attribute.String("Name", v.Name),
+			attribute.String("IP", v.IP),
+			attribute.String("NodeGUID", v.SdcGUID),
+		}
+		labels = append(labels, labelAttributes(v.Attributes)...)
+	default:
+		return errors.New("unknown MetaData type")
+	}
+
+	metricsMapValue, ok := mw.NodeConditionMetrics.Load(prefix)
+	if !ok {
+		newMetrics, err := mw.initNodeConditionMetrics(prefix)
+		if err != nil {
+			return err
+		}
+		metricsMapValue = newMetrics
+	}
+	metrics := metricsMapValue.(*NodeConditionMetrics)
+
+	ready, cordoned := 0.0, 0.0
+	if conditions.Ready {
+		ready = 1
+	}
+	if conditions.Cordoned {
+		cordoned = 1
+	}
+
+	done := make(chan struct{})
+	reg, err := mw.Meter.RegisterCallback(func(_ context.Context, obs metric.Observer) error {
+		obs.ObserveFloat64(metrics.Ready, ready, metric.WithAttributes(labels...))
+		obs.ObserveFloat64(metrics.Cordoned, cordoned, metric.WithAttributes(labels...))
+		go func() {
+			done <- struct{}{}
+		}()
+		return nil
+	},
+		metrics.Ready,
+		metrics.Cordoned,
+	)
+	if err != nil {
+		return err
+	}
 	<-done
 	_ = reg.Unregister()
 
@@ -0,0 +1,134 @@
+/*
+ Copyright (c) 2025 Dell Inc. or its subsidiaries. All Rights Reserved.
+
+ Licensed under the Apache License, Version 2.0 (the "License");
+ you may not use this file except in compliance with the License.
+ You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"slices"
+
+	types "github.com/dell/goscaleio/types/v1"
+	"github.com/sirupsen/logrus"
+)
+
+// DefaultMetricsBatchSize is the number of resources whose metrics are queried by one GetMetrics request
+const DefaultMetricsBatchSize = 100
+
+// metricsKey identifies a resource of a storage system
+type metricsKey struct {
+	client PowerFlexClient
+	id     string
+}
+
+// batchedMetrics holds the metrics of resources queried in batches, and the error of each resource they couldn't be queried for
+type batchedMetrics struct {
+	resources map[metricsKey]types.Resource
+	errs      map[metricsKey]error
+}
+
+// get returns the metrics of the resource id of client, false if the response had no metrics for it,
+// or the error querying its metrics
+func (m *batchedMetrics) get(client PowerFlexClient, id string) (types.Resource, bool, error) {
+	key := metricsKey{client: client, id: id}
+	if err, ok := m.errs[key]; ok {
+		return types.Resource{}, false, err
+	}
+	resource, ok := m.resources[key]
+	return resource, ok, nil
+}
+
+// add stores the metrics of a request for ids, or its error for each of them
+func (m *batchedMetrics) add(client PowerFlexClient, ids []string, resources []types.Resource, err error) {
+	if err != nil {
+		for _, id := range ids {
+			m.errs[metricsKey{client: client, id: id}] = err
+		}
+		return
+	}
+	for _, resource := range resources {
+		m.resources[metricsKey{client: client, id: resource.ID}] = resource
+	}
+}
+
+// metricsBatchSize returns the number of resources queried by one GetMetrics request
+func (s *PowerFlexService) metricsBatchSize() int {
+	if s.MetricsBatchSize <= 0 {
+		return DefaultMetricsBatchSize
+	}
+	return s.MetricsBatchSize
+}
+
+// getBatchedMetrics queries the metrics of the resources of each client with one GetMetrics request per
+// chunk of MetricsBatchSize IDs. If the gateway rejects the request of a chunk, e.g. because of an ID it
+// doesn't know, the chunk is split in halves until the IDs it rejects are found, so that they only lose
+// the metrics of their resources. Any other error, e.g. the gateway being down or the circuit being open,
+// fails the whole chunk without sending more requests to the gateway.
+func (s *PowerFlexService) getBatchedMetrics(ctx context.Context, resourceType string, ids map[PowerFlexClient][]string) *batchedMetrics {
+	metrics := &batchedMetrics{
+		resources: make(map[metricsKey]types.Resource),
+		errs:      make(map[metricsKey]error),
+	}
+	for client, clientIDs := range ids {
+		for chunk := range slices.Chunk(clientIDs, s.metricsBatchSize()) {
+			s.getChunkMetrics(ctx, client, resourceType, chunk, metrics)
+		}
+	}
+	return metrics
+}
+
+// getChunkMetrics queries the metrics of a chunk of IDs, bisecting it while the gateway rejects the request
+func (s *PowerFlexService) getChunkMetrics(ctx context.Context, client PowerFlexClient, resourceType string, chunk []string, metrics *batchedMetrics) {
+	resources, err := s.getMetrics(ctx, client, resourceType, chunk)
+	if err != nil && len(chunk) > 1 && isRejectedRequest(err) {
+		s.Logger.WithError(err).WithFields(logrus.Fields{"resource_type": resourceType, "ids": len(chunk)}).Warn("gateway rejected the metrics of a batch, splitting it")
+		half := len(chunk) / 2
+		s.getChunkMetrics(ctx, client, resourceType, chunk[:half], metrics)
+		s.getChunkMetrics(ctx, client, resourceType, chunk[half:], metrics)
+		return
+	}
+	metrics.add(client, chunk, resources, err)
+}
+
+// isRejectedRequest returns true if the gateway rejected a request because of its content, e.g. an unknown ID.
+// An expired session and throttling are not caused by the content, so they aren't.
+func isRejectedRequest(err error) bool {
+	var apiErr *types.Error
+	if !errors.As(err, &apiErr) {
+		var apiErrValue types.Error
+		if !errors.As(err, &apiErrValue) {
+			return false
+		}
+		apiErr = &apiErrValue
+	}
+	code := apiErr.HTTPStatusCode
+	return code >= http.StatusBadRequest && code < http.StatusInternalServerError &&
+		code != http.StatusUnauthorized && code != http.StatusTooManyRequests
+}
+
+func (s *PowerFlexService) getMetrics(ctx context.Context, client PowerFlexClient, resourceType string, ids []string) ([]types.Resource, error) {
+	s.Logger.WithFields(logrus.Fields{"resource_type": resourceType, "ids": ids}).Debug("calling GetMetrics")
+	resp, err := client.GetMetrics(resourceType, ids)
+	if err != nil {
+		return nil, err
+	}
+	if resp == nil {
+		return nil, fmt.Errorf("no metrics response for %s", resourceType)
+	}
+	return resp.Resources, nil
+}
@@ -0,0 +1,180 @@
+/*
+ Copyright (c) 2025 Dell Inc. or its subsidiaries. All Rights Reserved.
+
+ Licensed under the Apache License, Version 2.0 (the "License");
+ you may not use this file except in compliance with the License.
+ You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package service_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"sync"
+	"testing"
+
+	sio "github.com/dell/goscaleio"
+	types "github.com/dell/goscaleio/types/v1"
+	"github.com/dell/karavi-metrics-powerflex/internal/service"
+	"github.com/dell/karavi-metrics-powerflex/internal/service/mocks"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/mock/gomock"
+)
+
+func metricsResource(id string, value float64) types.Resource {
+	return types.Resource{ID: id, Metrics: []types.Metric{
+		{Name: "host_read_bandwidth", Values: []float64{value}},
+		{Name: "physical_total", Values: []float64{value * (1 << 30)}},
+	}}
+}
+
+func Test_GetSDCStatistics_BatchedMetrics(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	client := mocks.NewMockPowerFlexClient(ctrl)
+	metrics := mocks.NewMockMetricsRecorder(ctrl)
+
+	var retrievers []service.SdcMetricsRetriever
+	for _, id := range []string{"sdc-1", "sdc-2", "sdc-3", "sdc-4", "sdc-5"} {
+		retrievers = append(retrievers, ecRetriever{sdc: &sio.Sdc{Sdc: &types.Sdc{ID: id}}, client: client, gen: types.GenTypeEC})
+	}
+
+	gomock.InOrder(
+		client.EXPECT().GetMetrics("sdc", []string{"sdc-1", "sdc-2"}).Return(&types.MetricsResponse{
+			Resources: []types.Resource{metricsResource("sdc-2", 2), metricsResource("sdc-1", 1)},
+		}, nil),
+		// the gateway rejects the batch because of sdc-4, which only loses the metrics of sdc-4
+		client.EXPECT().GetMetrics("sdc", []string{"sdc-3", "sdc-4"}).Return(nil, &types.Error{HTTPStatusCode: http.StatusBadRequest}),
+		client.EXPECT().GetMetrics("sdc", []string{"sdc-3"}).Return(&types.MetricsResponse{
+			Resources: []types.Resource{metricsResource("sdc-3", 3)},
+		}, nil),
+		client.EXPECT().GetMetrics("sdc", []string{"sdc-4"}).Return(nil, &types.Error{HTTPStatusCode: http.StatusBadRequest}),
+		// the response has no metrics for sdc-5
+		client.EXPECT().GetMetrics("sdc", []string{"sdc-5"}).Return(&types.MetricsResponse{}, nil),
+	)
+
+	var mu sync.Mutex
+	got := map[string]float64{}
+	metrics.EXPECT().Record(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).
+		DoAndReturn(func(_ context.Context, meta interface{}, readBW, _, _, _, _, _ float64) error {
+			mu.Lock()
+			defer mu.Unlock()
+			got[meta.(*service.SDCMeta).ID] = readBW
+			return nil
+		}).Times(3)
+
+	svc := &service.PowerFlexService{MetricsWrapper: metrics, Logger: logrus.New(), MetricsBatchSize: 2}
+	svc.GetSDCStatistics(context.Background(), nil, retrievers)
+	assert.Equal(t, map[string]float64{"sdc-1": 1, "sdc-2": 2, "sdc-3": 3}, got)
+}
+
+func Test_GetSDCStatistics_BatchedMetricsBisect(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	client := mocks.NewMockPowerFlexClient(ctrl)
+	metrics := mocks.NewMockMetricsRecorder(ctrl)
+
+	var retrievers []service.SdcMetricsRetriever
+	for _, id := range []string{"sdc-1", "sdc-2", "sdc-3", "sdc-4"} {
+		retrievers = append(retrievers, ecRetriever{sdc: &sio.Sdc{Sdc: &types.Sdc{ID: id}}, client: client, gen: types.GenTypeEC})
+	}
+
+	notFound := &types.Error{HTTPStatusCode: http.StatusNotFound}
+	gomock.InOrder(
+		// the batch is split in halves until the ID the gateway doesn't know is found
+		client.EXPECT().GetMetrics("sdc", []string{"sdc-1", "sdc-2", "sdc-3", "sdc-4"}).Return(nil, notFound),
+		client.EXPECT().GetMetrics("sdc", []string{"sdc-1", "sdc-2"}).Return(&types.MetricsResponse{
+			Resources: []types.Resource{metricsResource("sdc-1", 1), metricsResource("sdc-2", 2)},
+		}, nil),
+		client.EXPECT().GetMetrics("sdc", []string{"sdc-3", "sdc-4"}).Return(nil, notFound),
+		client.EXPECT().GetMetrics("sdc", []string{"sdc-3"}).Return(nil, notFound),
+		client.EXPECT().GetMetrics("sdc", []string{"sdc-4"}).Return(&types.MetricsResponse{
+			Resources: []types.Resource{metricsResource("sdc-4", 4)},
+		}, nil),
+	)
+
+	var mu sync.Mutex
+	got := map[string]float64{}
+	metrics.EXPECT().Record(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).
+		DoAndReturn(func(_ context.Context, meta interface{}, readBW, _, _, _, _, _ float64) error {
+			mu.Lock()
+			defer mu.Unlock()
+			got[meta.(*service.SDCMeta).ID] = readBW
+			return nil
+		}).Times(3)
+
+	svc := &service.PowerFlexService{MetricsWrapper: metrics, Logger: logrus.New()}
+	svc.GetSDCStatistics(context.Background(), nil, retrievers)
+	assert.Equal(t, map[string]float64{"sdc-1": 1, "sdc-2": 2, "sdc-4": 4}, got)
+}
+
+func Test_GetSDCStatistics_BatchedMetricsGatewayFailure(t *testing.T) {
+	tests := map[string]error{
+		"transport error":      errors.New("connection refused"),
+		"server error":         &types.Error{HTTPStatusCode: http.StatusServiceUnavailable},
+		"throttled":            &types.Error{HTTPStatusCode: http.StatusTooManyRequests},
+		"circuit breaker open": service.ErrCircuitOpen,
+	}
+	for name, err := range tests {
+		t.Run(name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			client := mocks.NewMockPowerFlexClient(ctrl)
+			metrics := mocks.NewMockMetricsRecorder(ctrl)
+
+			var retrievers []service.SdcMetricsRetriever
+			for _, id := range []string{"sdc-1", "sdc-2", "sdc-3"} {
+				retrievers = append(retrievers, ecRetriever{sdc: &sio.Sdc{Sdc: &types.Sdc{ID: id}}, client: client, gen: types.GenTypeEC})
+			}
+
+			// the whole batch fails without querying its resources one by one
+			client.EXPECT().GetMetrics("sdc", []string{"sdc-1", "sdc-2", "sdc-3"}).Return(nil, err).Times(1)
+			metrics.EXPECT().Record(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Times(0)
+
+			svc := &service.PowerFlexService{MetricsWrapper: metrics, Logger: logrus.New()}
+			svc.GetSDCStatistics(context.Background(), nil, retrievers)
+		})
+	}
+}
+
+func Test_GetStoragePoolStatistics_BatchedMetrics(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	client := mocks.NewMockPowerFlexClient(ctrl)
+	metrics := mocks.NewMockMetricsRecorder(ctrl)
+	pool := func() service.StoragePoolMetricsRetriever {
+		return ecPoolRetriever{client: client, stats: mocks.NewMockStoragePoolStatisticsGetter(ctrl), gen: types.GenTypeEC}
+	}
+
+	// pool-2 is used by both storage classes but queried once
+	client.EXPECT().GetMetrics("storage_pool", []string{"pool-1", "pool-2", "pool-3"}).Return(&types.MetricsResponse{
+		Resources: []types.Resource{metricsResource("pool-1", 1), metricsResource("pool-2", 2), metricsResource("pool-3", 3)},
+	}, nil).Times(1)
+
+	var mu sync.Mutex
+	var got []string
+	metrics.EXPECT().RecordCapacity(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).
+		DoAndReturn(func(_ context.Context, meta interface{}, total, _, _, _ float64) error {
+			mu.Lock()
+			defer mu.Unlock()
+			got = append(got, fmt.Sprintf("%s/%g", meta.(service.StorageClassMeta).Name, total))
+			return nil
+		}).Times(4)
+	metrics.EXPECT().RecordPoolEfficiency(gomock.Any(), gomock.Any(), gomock.Any()).Return(nil).Times(4)
+
+	scMetas := []service.StorageClassMeta{
+		{ID: "1", Name: "class-a", StoragePools: map[string]service.StoragePoolMetricsRetriever{"pool-1": pool(), "pool-2": pool()}},
+		{ID: "2", Name: "class-b", StoragePools: map[string]service.StoragePoolMetricsRetriever{"pool-2": pool(), "pool-3": pool()}},
+	}
+	svc := &service.PowerFlexService{MetricsWrapper: metrics, Logger: logrus.New()}
+	svc.GetStoragePoolStatistics(context.Background(), scMetas)
+	assert.ElementsMatch(t, []string{"class-a/1", "class-a/2", "class-b/2", "class-b/3"}, got)
+}
@@ -26,16 +26,18 @@ import (
 )
 
 type mockSdcMetricsRetriever struct {
-	sg  service.StatisticsGetter
-	gen string
-	sdc *sio.Sdc
-	c   service.PowerFlexClient
+	sg       service.StatisticsGetter
+	gen      string
+	sdc      *sio.Sdc
+	c        service.PowerFlexClient
+	nodeName string
 }
 
 func (m *mockSdcMetricsRetriever) GetStatisticsGetter() service.StatisticsGetter { return m.sg }
 func (m *mockSdcMetricsRetriever) GetGen() string                                { return m.gen }
 func (m *mockSdcMetricsRetriever) GetSdc() *sio.Sdc                              { return m.sdc }
 func (m *mockSdcMetricsRetriever) GetClient() service.PowerFlexClient            { return m.c }
+func (m *mockSdcMetricsRetriever) GetNodeName() string                           { return m.nodeName }
 
 var _ service.SdcMetricsRetriever = (*mockSdcMetricsRetriever)(nil)
 
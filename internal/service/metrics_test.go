@@ -19,16 +19,50 @@ package service_test
 import (
 	"context"
 	"testing"
+	"time"
 
 	types "github.com/dell/goscaleio/types/v1"
+	"github.com/dell/karavi-metrics-powerflex/internal/k8s"
 	"github.com/dell/karavi-metrics-powerflex/internal/service"
-	otlexporters "github.com/dell/karavi-metrics-powerflex/opentelemetry/exporters"
-	"go.opentelemetry.io/otel"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata"
 )
 
+// collectedMeter returns a meter collected every few milliseconds until the test ends. The wrapper waits for its
+// observations to be collected before a record returns, which the exporter's reader would only do every few seconds.
+func collectedMeter(t *testing.T) metric.Meter {
+	reader := sdkmetric.NewManualReader()
+	provider := sdkmetric.NewMeterProvider(sdkmetric.WithReader(reader))
+	ctx, cancel := context.WithCancel(context.Background())
+	stopped := make(chan struct{})
+	go func() {
+		defer close(stopped)
+		ticker := time.NewTicker(5 * time.Millisecond)
+		defer ticker.Stop()
+		for {
+			var rm metricdata.ResourceMetrics
+			_ = reader.Collect(ctx, &rm)
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+	t.Cleanup(func() {
+		cancel()
+		<-stopped
+	})
+	return provider.Meter("powerflex-test")
+}
+
 func TestMetricsWrapper_Record(t *testing.T) {
 	mw := &service.MetricsWrapper{
-		Meter: otel.Meter("powerflex-test"),
+		Meter: collectedMeter(t),
 	}
 	volumeMetas := []interface{}{
 		&service.VolumeMeta{
@@ -44,12 +78,6 @@ func TestMetricsWrapper_Record(t *testing.T) {
 		},
 	}
 
-	exporter := &otlexporters.OtlCollectorExporter{}
-	err := exporter.InitExporter()
-	if err != nil {
-		t.Fatal(err)
-	}
-
 	type args struct {
 		ctx          context.Context
 		meta         interface{}
@@ -114,7 +142,7 @@ func (m *MockStoragePoolStatisticsGetter) GetStatistics() (*types.Statistics, er
 
 func TestMetricsWrapper_RecordCapacity(t *testing.T) {
 	mw := &service.MetricsWrapper{
-		Meter: otel.Meter("powerflex-test"),
+		Meter: collectedMeter(t),
 	}
 	retriever := newRetriever(t, "v1")
 	storageClassMeta := service.StorageClassMeta{
@@ -142,12 +170,6 @@ func TestMetricsWrapper_RecordCapacity(t *testing.T) {
 		logicalProvisioned       float64
 	}
 
-	exporter := &otlexporters.OtlCollectorExporter{}
-	err := exporter.InitExporter()
-	if err != nil {
-		t.Fatal(err)
-	}
-
 	tests := []struct {
 		name    string
 		mw      *service.MetricsWrapper
@@ -192,7 +214,7 @@ func TestMetricsWrapper_RecordCapacity(t *testing.T) {
 
 func TestMetricsWrapper_RecordTopologyMetrics(t *testing.T) {
 	mw := &service.MetricsWrapper{
-		Meter: otel.Meter("powerflex-test"),
+		Meter: collectedMeter(t),
 	}
 	tests := []struct {
 		name    string
@@ -226,11 +248,6 @@ func TestMetricsWrapper_RecordTopologyMetrics(t *testing.T) {
 }
 
 func TestMetricsWrapper_Record_AdditionalPaths(t *testing.T) {
-	exporter := &otlexporters.OtlCollectorExporter{}
-	if err := exporter.InitExporter(); err != nil {
-		t.Fatal(err)
-	}
-
 	tt := []struct {
 		name    string
 		calls   func(mw *service.MetricsWrapper) error
@@ -244,6 +261,16 @@ func TestMetricsWrapper_Record_AdditionalPaths(t *testing.T) {
 				}, 1, 2, 3, 4, 5, 6)
 			},
 		},
+		{
+			name: "node-local record",
+			calls: func(mw *service.MetricsWrapper) error {
+				mw.NodeName = "worker-1"
+				if err := mw.Record(context.Background(), &service.SDCMeta{ID: "sdc-local", IP: "10.0.0.2"}, 1, 2, 3, 4, 5, 6); err != nil {
+					return err
+				}
+				return mw.Record(context.Background(), &service.VolumeMeta{ID: "vol-local", Name: "vol-name"}, 1, 2, 3, 4, 5, 6)
+			},
+		},
 		{
 			name: "existing metrics no label change",
 			calls: func(mw *service.MetricsWrapper) error {
@@ -268,7 +295,7 @@ func TestMetricsWrapper_Record_AdditionalPaths(t *testing.T) {
 	}
 	for _, tc := range tt {
 		t.Run(tc.name, func(t *testing.T) {
-			mw := &service.MetricsWrapper{Meter: otel.Meter("powerflex-test-" + tc.name)}
+			mw := &service.MetricsWrapper{Meter: collectedMeter(t)}
 			if err := tc.calls(mw); (err != nil) != tc.wantErr {
 				t.Errorf("Record() error = %v, wantErr %v", err, tc.wantErr)
 			}
@@ -308,7 +335,7 @@ func TestMetricsWrapper_RecordTopologyMetrics_AdditionalPaths(t *testing.T) {
 	}
 	for _, tc := range tt {
 		t.Run(tc.name, func(t *testing.T) {
-			mw := &service.MetricsWrapper{Meter: otel.Meter("powerflex-topo-" + tc.name)}
+			mw := &service.MetricsWrapper{Meter: collectedMeter(t)}
 			if err := tc.calls(mw); (err != nil) != tc.wantErr {
 				t.Errorf("RecordTopologyMetrics() error = %v, wantErr %v", err, tc.wantErr)
 			}
@@ -317,12 +344,7 @@ func TestMetricsWrapper_RecordTopologyMetrics_AdditionalPaths(t *testing.T) {
 }
 
 func TestMetricsWrapper_RecordCapacity_ExistingMetrics(t *testing.T) {
-	exporter := &otlexporters.OtlCollectorExporter{}
-	if err := exporter.InitExporter(); err != nil {
-		t.Fatal(err)
-	}
-
-	mw := &service.MetricsWrapper{Meter: otel.Meter("powerflex-test-cap-existing")}
+	mw := &service.MetricsWrapper{Meter: collectedMeter(t)}
 	retriever := newRetriever(t, "v1")
 	meta := service.StorageClassMeta{
 		ID: "test-existing", Name: "test-name", Driver: "csi-vxflexos.dellemc.com", StorageSystemID: "test-system-id",
@@ -345,3 +367,345 @@ func TestMetricsWrapper_RecordCapacity_ExistingMetrics(t *testing.T) {
 		})
 	}
 }
+
+func TestMetricsWrapper_LabelAttributes(t *testing.T) {
+	reader := sdkmetric.NewManualReader()
+	provider := sdkmetric.NewMeterProvider(sdkmetric.WithReader(reader))
+	mw := &service.MetricsWrapper{Meter: provider.Meter("powerflex-test")}
+	attributes := map[string]string{"label_team": "storage", "label_app": "database"}
+
+	// the wrapper waits for its observations to be collected, so collect until the record returns
+	series := map[string]*attribute.Set{}
+	record := func(record func() error) {
+		errs := make(chan error, 1)
+		go func() { errs <- record() }()
+		for {
+			var rm metricdata.ResourceMetrics
+			require.NoError(t, reader.Collect(context.Background(), &rm))
+			for _, scope := range rm.ScopeMetrics {
+				for _, m := range scope.Metrics {
+					if data, ok := m.Data.(metricdata.Sum[float64]); ok && len(data.DataPoints) > 0 {
+						series[m.Name] = &data.DataPoints[0].Attributes
+					}
+				}
+			}
+			select {
+			case err := <-errs:
+				require.NoError(t, err)
+				return
+			case <-time.After(10 * time.Millisecond):
+			}
+		}
+	}
+
+	record(func() error {
+		return mw.Record(context.Background(), &service.VolumeMeta{ID: "vol-1", Attributes: attributes}, 1, 2, 3, 4, 5, 6)
+	})
+	record(func() error {
+		return mw.RecordTopologyMetrics(context.Background(), &service.TopologyMeta{PersistentVolume: "pv-1", Attributes: attributes}, &service.TopologyMetricsRecord{})
+	})
+	record(func() error {
+		return mw.RecordCapacity(context.Background(), service.StorageClassMeta{
+			ID:           "class-1",
+			Driver:       "csi-vxflexos.dellemc.com",
+			StoragePools: map[string]service.StoragePoolMetricsRetriever{"pool-1": nil},
+			Attributes:   map[string]string{"label_team": "storage"},
+		}, 1, 2, 3, 4)
+	})
+
+	for _, name := range []string{"powerflex_volume_read_bw_megabytes_per_second", "karavi_topology_metrics", "powerflex_storage_pool_total_logical_capacity_gigabytes"} {
+		require.Contains(t, series, name)
+		team, ok := series[name].Value("label_team")
+		assert.True(t, ok, name)
+		assert.Equal(t, "storage", team.AsString(), name)
+	}
+	app, ok := series["powerflex_volume_read_bw_megabytes_per_second"].Value("label_app")
+	assert.True(t, ok)
+	assert.Equal(t, "database", app.AsString())
+}
+
+func TestMetricsWrapper_RecordTopologyMetrics_Consumers(t *testing.T) {
+	reader := sdkmetric.NewManualReader()
+	provider := sdkmetric.NewMeterProvider(sdkmetric.WithReader(reader))
+	mw := &service.MetricsWrapper{Meter: provider.Meter("powerflex-test")}
+
+	// the wrapper waits for its observations to be collected, so collect until the record returns
+	record := func(meta *service.TopologyMeta) map[string][]metricdata.DataPoint[float64] {
+		series := map[string][]metricdata.DataPoint[float64]{}
+		errs := make(chan error, 1)
+		go func() { errs <- mw.RecordTopologyMetrics(context.Background(), meta, &service.TopologyMetricsRecord{}) }()
+		for {
+			var rm metricdata.ResourceMetrics
+			require.NoError(t, reader.Collect(context.Background(), &rm))
+			for _, scope := range rm.ScopeMetrics {
+				for _, m := range scope.Metrics {
+					if data, ok := m.Data.(metricdata.Sum[float64]); ok && len(data.DataPoints) > 0 {
+						series[m.Name] = data.DataPoints
+					}
+				}
+			}
+			select {
+			case err := <-errs:
+				require.NoError(t, err)
+				return series
+			case <-time.After(10 * time.Millisecond):
+			}
+		}
+	}
+	value := func(point metricdata.DataPoint[float64], key attribute.Key) string {
+		v, _ := point.Attributes.Value(key)
+		return v.AsString()
+	}
+
+	series := record(&service.TopologyMeta{
+		PersistentVolume:      "pv-1",
+		PersistentVolumeClaim: "data",
+		Namespace:             "team-a",
+		Consumers: []k8s.VolumeConsumer{
+			{Pod: "web-1", WorkloadKind: "Deployment", WorkloadName: "web"},
+			{Pod: "web-2", WorkloadKind: "Deployment", WorkloadName: "web"},
+		},
+		ConsumersResolved: true,
+	})
+	require.Len(t, series["powerflex_volume_consumer_info"], 2)
+	pods := []string{}
+	for _, point := range series["powerflex_volume_consumer_info"] {
+		assert.Equal(t, 1.0, point.Value)
+		assert.Equal(t, "pv-1", value(point, "PersistentVolumeName"))
+		assert.Equal(t, "data", value(point, "PersistentVolumeClaimName"))
+		assert.Equal(t, "Deployment", value(point, "workload_kind"))
+		assert.Equal(t, "web", value(point, "workload_name"))
+		pods = append(pods, value(point, "pod"))
+	}
+	assert.ElementsMatch(t, []string{"web-1", "web-2"}, pods)
+	require.Len(t, series["powerflex_volume_idle_mount"], 1)
+	assert.Equal(t, 0.0, series["powerflex_volume_idle_mount"][0].Value)
+
+	series = record(&service.TopologyMeta{PersistentVolume: "pv-2", Namespace: "team-a", ConsumersResolved: true})
+	assert.Empty(t, series["powerflex_volume_consumer_info"])
+	require.Len(t, series["powerflex_volume_idle_mount"], 1)
+	assert.Equal(t, 1.0, series["powerflex_volume_idle_mount"][0].Value)
+	assert.Equal(t, "pv-2", value(series["powerflex_volume_idle_mount"][0], "PersistentVolumeName"))
+
+	// consumers that weren't resolved aren't exported
+	series = record(&service.TopologyMeta{PersistentVolume: "pv-3"})
+	assert.Contains(t, series, "karavi_topology_metrics")
+	assert.Empty(t, series["powerflex_volume_idle_mount"])
+}
+
+func TestMetricsWrapper_RecordVolumeAggregate(t *testing.T) {
+	reader := sdkmetric.NewManualReader()
+	provider := sdkmetric.NewMeterProvider(sdkmetric.WithReader(reader))
+	mw := &service.MetricsWrapper{Meter: provider.Meter("powerflex-test"), NodeName: "worker-1"}
+	aggregate := &service.VolumeAggregateRecord{ReadBW: 1, WriteBW: 2, ReadIOPS: 3, WriteIOPS: 4, ProvisionedGigabytes: 5, VolumeSizeGigabytes: 8, PersistentVolumeClaims: 2}
+
+	// the wrapper waits for its observations to be collected, so collect until the record returns
+	record := func(meta interface{}) map[string]metricdata.DataPoint[float64] {
+		series := map[string]metricdata.DataPoint[float64]{}
+		errs := make(chan error, 1)
+		go func() { errs <- mw.RecordVolumeAggregate(context.Background(), meta, aggregate) }()
+		for {
+			var rm metricdata.ResourceMetrics
+			require.NoError(t, reader.Collect(context.Background(), &rm))
+			for _, scope := range rm.ScopeMetrics {
+				for _, m := range scope.Metrics {
+					if data, ok := m.Data.(metricdata.Sum[float64]); ok && len(data.DataPoints) > 0 {
+						series[m.Name] = data.DataPoints[0]
+					}
+				}
+			}
+			select {
+			case err := <-errs:
+				require.NoError(t, err)
+				return series
+			case <-time.After(10 * time.Millisecond):
+			}
+		}
+	}
+
+	series := record(&service.NamespaceAggregateMeta{StorageSystemID: "system-1", Namespace: "team-a"})
+	assert.Equal(t, 3.0, series["powerflex_namespace_read_iops_per_second"].Value)
+	assert.Equal(t, 8.0, series["powerflex_namespace_volume_size_gigabytes"].Value)
+	assert.Equal(t, 2.0, series["powerflex_namespace_persistent_volume_claims"].Value)
+	attributes := series["powerflex_namespace_provisioned_gigabytes"].Attributes
+	namespace, _ := attributes.Value("Namespace")
+	assert.Equal(t, "team-a", namespace.AsString())
+	node, _ := attributes.Value("NodeName")
+	assert.Equal(t, "worker-1", node.AsString())
+
+	series = record(&service.StorageClassAggregateMeta{StorageSystemID: "system-1", StorageClass: "vxflexos"})
+	assert.Equal(t, 1.0, series["powerflex_storage_class_read_bw_megabytes_per_second"].Value)
+	attributes = series["powerflex_storage_class_persistent_volume_claims"].Attributes
+	class, _ := attributes.Value("StorageClass")
+	assert.Equal(t, "vxflexos", class.AsString())
+
+	assert.Error(t, mw.RecordVolumeAggregate(context.Background(), "unknown", aggregate))
+}
+
+func TestMetricsWrapper_RecordCatalogMetrics(t *testing.T) {
+	reader := sdkmetric.NewManualReader()
+	provider := sdkmetric.NewMeterProvider(sdkmetric.WithReader(reader))
+	mw := &service.MetricsWrapper{Meter: provider.Meter("powerflex-test")}
+	backend := func(direction string, value float64) service.CatalogMetricValue {
+		return service.CatalogMetricValue{
+			Entry: service.MetricCatalogEntry{
+				ResourceType: service.MetricsResourceVolume, Metric: "backend_" + direction + "_bandwidth",
+				Instrument: "backend_bw_megabytes_per_second", Unit: "MB/s", Attributes: map[string]string{"direction": direction},
+			},
+			Value: value,
+		}
+	}
+
+	// the wrapper waits for its observations to be collected, so collect until the record returns
+	record := func(meta interface{}, values []service.CatalogMetricValue) map[string]metricdata.Metrics {
+		series := map[string]metricdata.Metrics{}
+		errs := make(chan error, 1)
+		go func() { errs <- mw.RecordCatalogMetrics(context.Background(), meta, values) }()
+		for {
+			var rm metricdata.ResourceMetrics
+			require.NoError(t, reader.Collect(context.Background(), &rm))
+			for _, scope := range rm.ScopeMetrics {
+				for _, m := range scope.Metrics {
+					if data, ok := m.Data.(metricdata.Sum[float64]); ok && len(data.DataPoints) > 0 {
+						series[m.Name] = m
+					}
+				}
+			}
+			select {
+			case err := <-errs:
+				require.NoError(t, err)
+				return series
+			case <-time.After(10 * time.Millisecond):
+			}
+		}
+	}
+
+	series := record(&service.VolumeMeta{ID: "vol-1", Namespace: "team-a"}, []service.CatalogMetricValue{backend("read", 1), backend("write", 2)})
+	backendBW := series["powerflex_volume_backend_bw_megabytes_per_second"]
+	assert.Equal(t, "MB/s", backendBW.Unit)
+	values := map[string]float64{}
+	for _, point := range backendBW.Data.(metricdata.Sum[float64]).DataPoints {
+		direction, _ := point.Attributes.Value("direction")
+		namespace, _ := point.Attributes.Value("Namespace")
+		assert.Equal(t, "team-a", namespace.AsString())
+		values[direction.AsString()] = point.Value
+	}
+	assert.Equal(t, map[string]float64{"read": 1, "write": 2}, values)
+
+	series = record(service.StorageClassMeta{
+		Name:         "class-a",
+		StoragePools: map[string]service.StoragePoolMetricsRetriever{"pool-1": nil},
+	}, []service.CatalogMetricValue{{
+		Entry: service.MetricCatalogEntry{ResourceType: service.MetricsResourceStoragePool, Metric: "compression_ratio", Instrument: "compression_ratio"},
+		Value: 1.5,
+	}})
+	point := series["powerflex_storage_pool_compression_ratio"].Data.(metricdata.Sum[float64]).DataPoints[0]
+	assert.Equal(t, 1.5, point.Value)
+	pool, _ := point.Attributes.Value("StoragePool")
+	assert.Equal(t, "pool-1", pool.AsString())
+
+	assert.Error(t, mw.RecordCatalogMetrics(context.Background(), service.StorageClassMeta{}, nil))
+	assert.Error(t, mw.RecordCatalogMetrics(context.Background(), "unknown", nil))
+}
+
+func TestMetricsWrapper_RecordPoolEfficiency(t *testing.T) {
+	reader := sdkmetric.NewManualReader()
+	provider := sdkmetric.NewMeterProvider(sdkmetric.WithReader(reader))
+	mw := &service.MetricsWrapper{Meter: provider.Meter("powerflex-test")}
+	meta := service.StorageClassMeta{
+		Name:            "class-a",
+		Driver:          "csi-vxflexos.dellemc.com",
+		StorageSystemID: "system-1",
+		StoragePools:    map[string]service.StoragePoolMetricsRetriever{"pool-1": nil},
+	}
+
+	// the wrapper waits for its observations to be collected, so collect until the record returns
+	record := func(efficiency *service.PoolEfficiencyRecord) map[string]metricdata.DataPoint[float64] {
+		series := map[string]metricdata.DataPoint[float64]{}
+		errs := make(chan error, 1)
+		go func() { errs <- mw.RecordPoolEfficiency(context.Background(), meta, efficiency) }()
+		for {
+			var rm metricdata.ResourceMetrics
+			require.NoError(t, reader.Collect(context.Background(), &rm))
+			for _, scope := range rm.ScopeMetrics {
+				for _, m := range scope.Metrics {
+					if data, ok := m.Data.(metricdata.Sum[float64]); ok && len(data.DataPoints) > 0 {
+						series[m.Name] = data.DataPoints[0]
+					}
+				}
+			}
+			select {
+			case err := <-errs:
+				require.NoError(t, err)
+				return series
+			case <-time.After(10 * time.Millisecond):
+			}
+		}
+	}
+
+	series := record(&service.PoolEfficiencyRecord{CompressionRatio: 1.8, DataReductionRatio: 2.5, ThinOvercommit: 1.5, SnapshotCapacityGigabytes: 2})
+	assert.Equal(t, 1.8, series["powerflex_storage_pool_efficiency_compression_ratio"].Value)
+	assert.Equal(t, 2.5, series["powerflex_storage_pool_efficiency_data_reduction_ratio"].Value)
+	assert.Equal(t, 1.5, series["powerflex_storage_pool_thin_overcommit_ratio"].Value)
+	assert.Equal(t, 2.0, series["powerflex_storage_pool_snapshot_capacity_in_use_gigabytes"].Value)
+	attributes := series["powerflex_storage_pool_thin_overcommit_ratio"].Attributes
+	pool, _ := attributes.Value("StoragePool")
+	assert.Equal(t, "pool-1", pool.AsString())
+	class, _ := attributes.Value("StorageClass")
+	assert.Equal(t, "class-a", class.AsString())
+
+	// the ratios that aren't reported are not published
+	series = record(&service.PoolEfficiencyRecord{ThinOvercommit: 3})
+	assert.NotContains(t, series, "powerflex_storage_pool_efficiency_compression_ratio")
+	assert.NotContains(t, series, "powerflex_storage_pool_efficiency_data_reduction_ratio")
+	assert.Equal(t, 3.0, series["powerflex_storage_pool_thin_overcommit_ratio"].Value)
+
+	assert.Error(t, mw.RecordPoolEfficiency(context.Background(), service.StorageClassMeta{}, &service.PoolEfficiencyRecord{}))
+	assert.Error(t, mw.RecordPoolEfficiency(context.Background(), "unknown", &service.PoolEfficiencyRecord{}))
+}
+
+func TestMetricsWrapper_RecordNodeConditions(t *testing.T) {
+	reader := sdkmetric.NewManualReader()
+	provider := sdkmetric.NewMeterProvider(sdkmetric.WithReader(reader))
+	mw := &service.MetricsWrapper{Meter: provider.Meter("powerflex-test")}
+	meta := &service.SDCMeta{ID: "sdc-1", Name: "worker-1", IP: "1.2.3.4", Attributes: map[string]string{"label_rack": "r1"}}
+
+	// the wrapper waits for its observations to be collected, so collect until the record returns
+	record := func(conditions *service.NodeConditionsRecord) map[string]metricdata.DataPoint[float64] {
+		series := map[string]metricdata.DataPoint[float64]{}
+		errs := make(chan error, 1)
+		go func() { errs <- mw.RecordNodeConditions(context.Background(), meta, conditions) }()
+		for {
+			var rm metricdata.ResourceMetrics
+			require.NoError(t, reader.Collect(context.Background(), &rm))
+			for _, scope := range rm.ScopeMetrics {
+				for _, m := range scope.Metrics {
+					if data, ok := m.Data.(metricdata.Sum[float64]); ok && len(data.DataPoints) > 0 {
+						series[m.Name] = data.DataPoints[0]
+					}
+				}
+			}
+			select {
+			case err := <-errs:
+				require.NoError(t, err)
+				return series
+			case <-time.After(10 * time.Millisecond):
+			}
+		}
+	}
+
+	series := record(&service.NodeConditionsRecord{Ready: true})
+	assert.Equal(t, 1.0, series["powerflex_sdc_node_ready"].Value)
+	assert.Equal(t, 0.0, series["powerflex_sdc_node_cordoned"].Value)
+	attributes := series["powerflex_sdc_node_ready"].Attributes
+	name, _ := attributes.Value("Name")
+	assert.Equal(t, "worker-1", name.AsString())
+	rack, _ := attributes.Value("label_rack")
+	assert.Equal(t, "r1", rack.AsString())
+
+	series = record(&service.NodeConditionsRecord{Cordoned: true})
+	assert.Equal(t, 0.0, series["powerflex_sdc_node_ready"].Value)
+	assert.Equal(t, 1.0, series["powerflex_sdc_node_cordoned"].Value)
+
+	assert.Error(t, mw.RecordNodeConditions(context.Background(), "unknown", &service.NodeConditionsRecord{}))
+}
@@ -0,0 +1,122 @@
+/*
+ Copyright (c) 2025 Dell Inc. or its subsidiaries. All Rights Reserved.
+
+ Licensed under the Apache License, Version 2.0 (the "License");
+ you may not use this file except in compliance with the License.
+ You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package service
+
+import (
+	"slices"
+	"sync"
+
+	"github.com/sirupsen/logrus"
+)
+
+// MissingSDCs remembers which CSINode GUIDs each storage system has an SDC for, to report the GUIDs that have no SDC
+// on any storage system, e.g. the node of a CSINode is connected to an array that isn't configured. The GUIDs are only
+// reported once this process looked up every configured storage system, so a replica that collects some of the arrays,
+// e.g. with sharding, doesn't report the SDCs of the others. A nil *MissingSDCs doesn't report them.
+type MissingSDCs struct {
+	mu             sync.Mutex
+	storageSystems []string
+	guids          []string
+	found          map[string]map[string]bool
+	missing        []string
+}
+
+// GUIDs returns the CSINode GUIDs that had no SDC on any storage system in the last lookup of each storage system
+func (m *MissingSDCs) GUIDs() []string {
+	if m == nil {
+		return nil
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return slices.Clone(m.missing)
+}
+
+// SetStorageSystems sets the configured storage systems. The lookups of the storage systems that are no longer
+// configured are forgotten.
+func (m *MissingSDCs) SetStorageSystems(storageSystemIDs []string) {
+	if m == nil {
+		return
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.storageSystems = slices.Clone(storageSystemIDs)
+	for storageSystemID := range m.found {
+		if !slices.Contains(m.storageSystems, storageSystemID) {
+			delete(m.found, storageSystemID)
+		}
+	}
+	if !m.lookedUpAll() {
+		m.missing = nil
+	}
+}
+
+// record stores the GUIDs found on a storage system and logs the GUIDs missing from every storage system when they change
+func (m *MissingSDCs) record(storageSystemID string, sdcGUIDs []string, found map[string]bool, logger *logrus.Logger) {
+	if m == nil {
+		return
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if !slices.Contains(m.storageSystems, storageSystemID) {
+		return
+	}
+	if m.found == nil {
+		m.found = make(map[string]map[string]bool)
+	}
+	m.guids = sdcGUIDs
+	m.found[storageSystemID] = found
+	if !m.lookedUpAll() {
+		return
+	}
+
+	var missing []string
+	for _, sdcGUID := range m.guids {
+		if !m.foundOnAnySystem(sdcGUID) {
+			missing = append(missing, sdcGUID)
+		}
+	}
+	slices.Sort(missing)
+	if slices.Equal(missing, m.missing) {
+		return
+	}
+	m.missing = missing
+	if len(missing) > 0 {
+		logger.WithField("sdc_guids", missing).Warn("no SDC on any storage system for the GUIDs of these CSINodes")
+	}
+}
+
+// lookedUpAll returns true if every configured storage system was looked up
+func (m *MissingSDCs) lookedUpAll() bool {
+	if len(m.storageSystems) == 0 {
+		return false
+	}
+	for _, storageSystemID := range m.storageSystems {
+		if _, ok := m.found[storageSystemID]; !ok {
+			return false
+		}
+	}
+	return true
+}
+
+func (m *MissingSDCs) foundOnAnySystem(sdcGUID string) bool {
+	for _, found := range m.found {
+		if found[sdcGUID] {
+			return true
+		}
+	}
+	return false
+}
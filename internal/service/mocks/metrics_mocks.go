@@ -70,6 +70,48 @@ func (mr *MockMetricsRecorderMockRecorder) RecordCapacity(ctx, meta, totalLogica
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RecordCapacity", reflect.TypeOf((*MockMetricsRecorder)(nil).RecordCapacity), ctx, meta, totalLogicalCapacity, logicalCapacityAvailable, logicalCapacityInUse, logicalProvisioned)
 }
 
+// RecordCatalogMetrics mocks base method.
+func (m *MockMetricsRecorder) RecordCatalogMetrics(ctx context.Context, meta any, values []service.CatalogMetricValue) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RecordCatalogMetrics", ctx, meta, values)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// RecordCatalogMetrics indicates an expected call of RecordCatalogMetrics.
+func (mr *MockMetricsRecorderMockRecorder) RecordCatalogMetrics(ctx, meta, values any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RecordCatalogMetrics", reflect.TypeOf((*MockMetricsRecorder)(nil).RecordCatalogMetrics), ctx, meta, values)
+}
+
+// RecordNodeConditions mocks base method.
+func (m *MockMetricsRecorder) RecordNodeConditions(ctx context.Context, meta any, conditions *service.NodeConditionsRecord) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RecordNodeConditions", ctx, meta, conditions)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// RecordNodeConditions indicates an expected call of RecordNodeConditions.
+func (mr *MockMetricsRecorderMockRecorder) RecordNodeConditions(ctx, meta, conditions any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RecordNodeConditions", reflect.TypeOf((*MockMetricsRecorder)(nil).RecordNodeConditions), ctx, meta, conditions)
+}
+
+// RecordPoolEfficiency mocks base method.
+func (m *MockMetricsRecorder) RecordPoolEfficiency(ctx context.Context, meta any, efficiency *service.PoolEfficiencyRecord) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RecordPoolEfficiency", ctx, meta, efficiency)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// RecordPoolEfficiency indicates an expected call of RecordPoolEfficiency.
+func (mr *MockMetricsRecorderMockRecorder) RecordPoolEfficiency(ctx, meta, efficiency any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RecordPoolEfficiency", reflect.TypeOf((*MockMetricsRecorder)(nil).RecordPoolEfficiency), ctx, meta, efficiency)
+}
+
 // RecordTopologyMetrics mocks base method.
 func (m *MockMetricsRecorder) RecordTopologyMetrics(ctx context.Context, meta any, topologyMetrics *service.TopologyMetricsRecord) error {
 	m.ctrl.T.Helper()
@@ -84,6 +126,20 @@ func (mr *MockMetricsRecorderMockRecorder) RecordTopologyMetrics(ctx, meta, topo
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RecordTopologyMetrics", reflect.TypeOf((*MockMetricsRecorder)(nil).RecordTopologyMetrics), ctx, meta, topologyMetrics)
 }
 
+// RecordVolumeAggregate mocks base method.
+func (m *MockMetricsRecorder) RecordVolumeAggregate(ctx context.Context, meta any, aggregate *service.VolumeAggregateRecord) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RecordVolumeAggregate", ctx, meta, aggregate)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// RecordVolumeAggregate indicates an expected call of RecordVolumeAggregate.
+func (mr *MockMetricsRecorderMockRecorder) RecordVolumeAggregate(ctx, meta, aggregate any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RecordVolumeAggregate", reflect.TypeOf((*MockMetricsRecorder)(nil).RecordVolumeAggregate), ctx, meta, aggregate)
+}
+
 // MockMeterCreater is a mock of MeterCreater interface.
 type MockMeterCreater struct {
 	ctrl     *gomock.Controller
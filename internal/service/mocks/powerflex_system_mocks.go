@@ -13,6 +13,7 @@ import (
 	reflect "reflect"
 
 	goscaleio "github.com/dell/goscaleio"
+	goscaleio0 "github.com/dell/goscaleio/types/v1"
 	gomock "go.uber.org/mock/gomock"
 )
 
@@ -40,17 +41,32 @@ func (m *MockPowerFlexSystem) EXPECT() *MockPowerFlexSystemMockRecorder {
 	return m.recorder
 }
 
-// FindSdc mocks base method.
-func (m *MockPowerFlexSystem) FindSdc(arg0, arg1 string) (*goscaleio.Sdc, error) {
+// GetSdc mocks base method.
+func (m *MockPowerFlexSystem) GetSdc() ([]goscaleio0.Sdc, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "FindSdc", arg0, arg1)
+	ret := m.ctrl.Call(m, "GetSdc")
+	ret0, _ := ret[0].([]goscaleio0.Sdc)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetSdc indicates an expected call of GetSdc.
+func (mr *MockPowerFlexSystemMockRecorder) GetSdc() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetSdc", reflect.TypeOf((*MockPowerFlexSystem)(nil).GetSdc))
+}
+
+// GetSdcByID mocks base method.
+func (m *MockPowerFlexSystem) GetSdcByID(id string) (*goscaleio.Sdc, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetSdcByID", id)
 	ret0, _ := ret[0].(*goscaleio.Sdc)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
-// FindSdc indicates an expected call of FindSdc.
-func (mr *MockPowerFlexSystemMockRecorder) FindSdc(arg0, arg1 any) *gomock.Call {
+// GetSdcByID indicates an expected call of GetSdcByID.
+func (mr *MockPowerFlexSystemMockRecorder) GetSdcByID(id any) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FindSdc", reflect.TypeOf((*MockPowerFlexSystem)(nil).FindSdc), arg0, arg1)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetSdcByID", reflect.TypeOf((*MockPowerFlexSystem)(nil).GetSdcByID), id)
 }
@@ -0,0 +1,152 @@
+/*
+ Copyright (c) 2025 Dell Inc. or its subsidiaries. All Rights Reserved.
+
+ Licensed under the Apache License, Version 2.0 (the "License");
+ you may not use this file except in compliance with the License.
+ You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package service
+
+import (
+	"context"
+	"math"
+	"sync"
+	"time"
+
+	sio "github.com/dell/goscaleio"
+	types "github.com/dell/goscaleio/types/v1"
+	"github.com/dell/karavi-metrics-powerflex/internal/domain"
+	"github.com/sirupsen/logrus"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
+	"golang.org/x/time/rate"
+)
+
+// NewRateLimiter returns a token bucket limiter for requests to a PowerFlex gateway.
+// A rate of zero disables limiting, and a burst of zero defaults to one second's worth of requests.
+func NewRateLimiter(limit domain.RateLimit) *rate.Limiter {
+	if limit.RequestsPerSecond <= 0 {
+		return rate.NewLimiter(rate.Inf, 0)
+	}
+	burst := limit.Burst
+	if burst <= 0 {
+		burst = int(math.Ceil(limit.RequestsPerSecond))
+	}
+	return rate.NewLimiter(rate.Limit(limit.RequestsPerSecond), burst)
+}
+
+var (
+	_ PowerFlexClient = (*RateLimitedClient)(nil)
+	_ GatewayCaller   = (*RateLimitedClient)(nil)
+)
+
+// RateLimitedClient wraps a PowerFlexClient and waits on a token bucket before each request.
+// Clients for arrays behind the same gateway share one Limiter.
+type RateLimitedClient struct {
+	PowerFlexClient
+	Limiter         *rate.Limiter
+	StorageSystemID string
+	Gateway         string
+	Logger          *logrus.Logger
+	Meter           metric.Meter
+
+	histogramOnce sync.Once
+	waitHistogram metric.Float64Histogram
+}
+
+// Unwrap returns the client wrapped by the rate limiter
+func (c *RateLimitedClient) Unwrap() PowerFlexClient {
+	return c.PowerFlexClient
+}
+
+// Wait blocks until the gateway's limiter allows another request and records how long it waited
+func (c *RateLimitedClient) Wait(ctx context.Context) error {
+	if c.Limiter == nil {
+		return nil
+	}
+	start := time.Now()
+	err := c.Limiter.Wait(ctx)
+	c.recordWait(ctx, time.Since(start))
+	return err
+}
+
+// CallGateway runs a request of a goscaleio object built from the client once the limiter allows it
+func (c *RateLimitedClient) CallGateway(ctx context.Context, call func() error) error {
+	if err := c.Wait(ctx); err != nil {
+		return err
+	}
+	return CallGateway(ctx, c.PowerFlexClient, call)
+}
+
+// GetInstance calls GetInstance on the wrapped client once the limiter allows it
+func (c *RateLimitedClient) GetInstance(href string) ([]*types.System, error) {
+	if err := c.Wait(context.Background()); err != nil {
+		return nil, err
+	}
+	return c.PowerFlexClient.GetInstance(href)
+}
+
+// FindSystem calls FindSystem on the wrapped client once the limiter allows it
+func (c *RateLimitedClient) FindSystem(id string, name string, href string) (*sio.System, error) {
+	if err := c.Wait(context.Background()); err != nil {
+		return nil, err
+	}
+	return c.PowerFlexClient.FindSystem(id, name, href)
+}
+
+// GetStoragePool calls GetStoragePool on the wrapped client once the limiter allows it
+func (c *RateLimitedClient) GetStoragePool(href string) ([]*types.StoragePool, error) {
+	if err := c.Wait(context.Background()); err != nil {
+		return nil, err
+	}
+	return c.PowerFlexClient.GetStoragePool(href)
+}
+
+// GetMetrics calls GetMetrics on the wrapped client once the limiter allows it
+func (c *RateLimitedClient) GetMetrics(resource string, ids []string) (*types.MetricsResponse, error) {
+	if err := c.Wait(context.Background()); err != nil {
+		return nil, err
+	}
+	return c.PowerFlexClient.GetMetrics(resource, ids)
+}
+
+// Authenticate calls Authenticate on the wrapped client once the limiter allows it
+func (c *RateLimitedClient) Authenticate(configConnect *sio.ConfigConnect) (sio.Cluster, error) {
+	if err := c.Wait(context.Background()); err != nil {
+		return sio.Cluster{}, err
+	}
+	return c.PowerFlexClient.Authenticate(configConnect)
+}
+
+// recordWait records the time spent waiting in powerflex_rate_limiter_wait_seconds
+func (c *RateLimitedClient) recordWait(ctx context.Context, waited time.Duration) {
+	if c.Meter == nil {
+		return
+	}
+	c.histogramOnce.Do(func() {
+		histogram, err := c.Meter.Float64Histogram("powerflex_rate_limiter_wait_seconds", metric.WithUnit("s"))
+		if err != nil {
+			if c.Logger != nil {
+				c.Logger.WithError(err).Warn("creating rate limiter wait histogram")
+			}
+			return
+		}
+		c.waitHistogram = histogram
+	})
+	if c.waitHistogram == nil {
+		return
+	}
+	c.waitHistogram.Record(ctx, waited.Seconds(), metric.WithAttributes(
+		attribute.String("StorageSystemID", c.StorageSystemID),
+		attribute.String("Gateway", c.Gateway),
+	))
+}
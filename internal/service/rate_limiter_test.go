@@ -0,0 +1,153 @@
+/*
+ Copyright (c) 2025 Dell Inc. or its subsidiaries. All Rights Reserved.
+
+ Licensed under the Apache License, Version 2.0 (the "License");
+ you may not use this file except in compliance with the License.
+ You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package service_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	types "github.com/dell/goscaleio/types/v1"
+	"github.com/dell/karavi-metrics-powerflex/internal/domain"
+	"github.com/dell/karavi-metrics-powerflex/internal/service"
+	"github.com/dell/karavi-metrics-powerflex/internal/service/mocks"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"go.opentelemetry.io/otel"
+	"go.uber.org/mock/gomock"
+	"golang.org/x/time/rate"
+)
+
+func newTestRateLimitedClient(client service.PowerFlexClient, limiter *rate.Limiter) *service.RateLimitedClient {
+	return &service.RateLimitedClient{
+		PowerFlexClient: client,
+		Limiter:         limiter,
+		StorageSystemID: "system-1",
+		Gateway:         "https://gateway",
+		Logger:          logrus.New(),
+		Meter:           otel.Meter("powerflex/rate_limiter_test"),
+	}
+}
+
+func Test_RateLimitedClient(t *testing.T) {
+	t.Run("passes calls through to the wrapped client", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		client := mocks.NewMockPowerFlexClient(ctrl)
+		client.EXPECT().GetInstance("").Return([]*types.System{{ID: "system-1"}}, nil).Times(1)
+		client.EXPECT().GetStoragePool("").Return([]*types.StoragePool{}, nil).Times(1)
+		client.EXPECT().GetMetrics("volume", []string{"v1"}).Return(&types.MetricsResponse{}, nil).Times(1)
+
+		limited := newTestRateLimitedClient(client, service.NewRateLimiter(domain.RateLimit{}))
+		systems, err := limited.GetInstance("")
+		assert.NoError(t, err)
+		assert.Len(t, systems, 1)
+		_, err = limited.GetStoragePool("")
+		assert.NoError(t, err)
+		_, err = limited.GetMetrics("volume", []string{"v1"})
+		assert.NoError(t, err)
+	})
+
+	t.Run("waits for a token once the burst is spent", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		client := mocks.NewMockPowerFlexClient(ctrl)
+		client.EXPECT().GetInstance("").Return([]*types.System{}, nil).Times(3)
+
+		limited := newTestRateLimitedClient(client, service.NewRateLimiter(domain.RateLimit{RequestsPerSecond: 20, Burst: 1}))
+		start := time.Now()
+		for i := 0; i < 3; i++ {
+			_, err := limited.GetInstance("")
+			assert.NoError(t, err)
+		}
+		// the first call uses the burst, the next two wait 50ms each
+		assert.GreaterOrEqual(t, time.Since(start), 90*time.Millisecond)
+	})
+
+	t.Run("clients on the same gateway share a limiter", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		client := mocks.NewMockPowerFlexClient(ctrl)
+		client.EXPECT().GetStoragePool("").Return([]*types.StoragePool{}, nil).Times(2)
+
+		limiter := service.NewRateLimiter(domain.RateLimit{RequestsPerSecond: 20, Burst: 1})
+		first := newTestRateLimitedClient(client, limiter)
+		second := newTestRateLimitedClient(client, limiter)
+		start := time.Now()
+		_, err := first.GetStoragePool("")
+		assert.NoError(t, err)
+		_, err = second.GetStoragePool("")
+		assert.NoError(t, err)
+		assert.GreaterOrEqual(t, time.Since(start), 40*time.Millisecond)
+	})
+
+	t.Run("returns error without calling the client when the context is done", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		client := mocks.NewMockPowerFlexClient(ctrl)
+
+		limited := newTestRateLimitedClient(client, service.NewRateLimiter(domain.RateLimit{RequestsPerSecond: 0.001, Burst: 1}))
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+		assert.NoError(t, limited.Wait(context.Background()))
+		assert.Error(t, limited.Wait(ctx))
+	})
+
+	t.Run("nil limiter does not wait", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		client := mocks.NewMockPowerFlexClient(ctrl)
+		assert.NoError(t, newTestRateLimitedClient(client, nil).Wait(context.Background()))
+	})
+}
+
+func Test_NewRateLimiter(t *testing.T) {
+	tests := map[string]struct {
+		limit         domain.RateLimit
+		expectedLimit rate.Limit
+		expectedBurst int
+	}{
+		"disabled":      {domain.RateLimit{}, rate.Inf, 0},
+		"default burst": {domain.RateLimit{RequestsPerSecond: 2.5}, 2.5, 3},
+		"explicit":      {domain.RateLimit{RequestsPerSecond: 10, Burst: 20}, 10, 20},
+	}
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			limiter := service.NewRateLimiter(tc.limit)
+			assert.Equal(t, tc.expectedLimit, limiter.Limit())
+			assert.Equal(t, tc.expectedBurst, limiter.Burst())
+		})
+	}
+}
+
+func Test_RateLimitedClient_CallGateway(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	client := mocks.NewMockPowerFlexClient(ctrl)
+
+	// spend the only token so the next request has to wait
+	limiter := service.NewRateLimiter(domain.RateLimit{RequestsPerSecond: 0.001, Burst: 1})
+	wrapped := newTestSessionClient(newTestRateLimitedClient(client, limiter))
+
+	calls := 0
+	call := func() error {
+		calls++
+		return nil
+	}
+	assert.NoError(t, service.CallGateway(context.Background(), wrapped, call))
+	assert.Equal(t, 1, calls)
+
+	// the wait is given up with the context and the request isn't sent
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	assert.Error(t, service.CallGateway(ctx, wrapped, call))
+	assert.Equal(t, 1, calls)
+}
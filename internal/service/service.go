@@ -20,6 +20,7 @@ import (
 	"context"
 	"fmt"
 	"runtime/debug"
+	"slices"
 	"strings"
 	"sync"
 	"time"
@@ -30,6 +31,7 @@ import (
 	sio "github.com/dell/goscaleio"
 	types "github.com/dell/goscaleio/types/v1"
 	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/labels"
 )
 
 var _ Service = (*PowerFlexService)(nil)
@@ -59,6 +61,8 @@ type SdcMetricsRetriever interface {
 	GetGen() string
 	GetSdc() *sio.Sdc
 	GetClient() PowerFlexClient
+	// GetNodeName returns the node whose CSINode reported the GUID of the SDC, or an empty string if it isn't known
+	GetNodeName() string
 }
 
 // StatisticsGetter supports getting statistics
@@ -93,7 +97,27 @@ type PowerFlexClient interface {
 //
 //go:generate mockgen -destination=mocks/powerflex_system_mocks.go -package=mocks github.com/dell/karavi-metrics-powerflex/internal/service PowerFlexSystem
 type PowerFlexSystem interface {
-	FindSdc(string, string) (*sio.Sdc, error)
+	GetSdc() ([]types.Sdc, error)
+	GetSdcByID(id string) (*sio.Sdc, error)
+}
+
+type maxPowerFlexConnectionsKey struct{}
+
+// WithMaxPowerFlexConnections returns a context that overrides MaxPowerFlexConnections for the queries made with it,
+// e.g. to query one storage system with more or fewer workers than the others. Zero keeps MaxPowerFlexConnections.
+func WithMaxPowerFlexConnections(ctx context.Context, maxConnections int) context.Context {
+	if maxConnections <= 0 {
+		return ctx
+	}
+	return context.WithValue(ctx, maxPowerFlexConnectionsKey{}, maxConnections)
+}
+
+// maxPowerFlexConnections returns the number of workers that can query powerflex at a time for ctx
+func (s *PowerFlexService) maxPowerFlexConnections(ctx context.Context) int {
+	if maxConnections, ok := ctx.Value(maxPowerFlexConnectionsKey{}).(int); ok {
+		return maxConnections
+	}
+	return s.MaxPowerFlexConnections
 }
 
 // PowerFlexService represents the service for getting SDC metrics data for a PowerFlex system
@@ -102,6 +126,24 @@ type PowerFlexService struct {
 	MaxPowerFlexConnections int
 	Logger                  *logrus.Logger
 	VolumeFinder            VolumeFinder
+	InventoryCache          *InventoryCache
+	// VolumeAggregation sums the volume metrics by namespace and storage class
+	VolumeAggregation VolumeAggregation
+	// NodeLabels selects the labels of the node of each SDC that are exported as attributes of the SDC series
+	NodeLabels *k8s.LabelAllowlist
+	// NodeConditions exports whether the node of each SDC is ready and cordoned as series of their own
+	NodeConditions bool
+	// UnmappedSDCs counts the SDCs that couldn't be mapped to a node
+	UnmappedSDCs *UnmappedSDCs
+	// MissingSDCs reports the CSINode GUIDs that have no SDC on any storage system
+	MissingSDCs *MissingSDCs
+	// LocalSDC is set when only the SDC of the local node is collected, so that it is fetched on its own
+	LocalSDC *LocalSDC
+	// MetricsBatchSize is the number of EC SDCs or storage pools whose metrics are queried by one request,
+	// DefaultMetricsBatchSize if it is 0
+	MetricsBatchSize int
+	// MetricCatalog maps the metrics of EC SDCs, volumes and storage pools to instruments, the built in catalog if nil
+	MetricCatalog *MetricCatalog
 }
 
 // SDCFinder is used to find SDC GUIDs
@@ -111,6 +153,11 @@ type SDCFinder interface {
 	GetSDCGuids() ([]string, error)
 }
 
+// SDCNodeFinder is implemented by an SDCFinder that knows the node of each SDC GUID
+type SDCNodeFinder interface {
+	GetSDCNodes() (map[string]string, error)
+}
+
 // StorageClassFinder is used to find storage classes in kubernetes
 //
 //go:generate mockgen -destination=mocks/storage_class_finder_mocks.go -package=mocks github.com/dell/karavi-metrics-powerflex/internal/service StorageClassFinder
@@ -126,6 +173,13 @@ type VolumeFinder interface {
 	GetPersistentVolumes() ([]k8s.VolumeInfo, error)
 }
 
+// VolumeFilterReporter is implemented by a VolumeFinder that leaves out some persistent volumes.
+// While it filters, storage system volumes without a persistent volume are not exported, because
+// it can't be told whether they would have been left out.
+type VolumeFilterReporter interface {
+	Filtering() bool
+}
+
 // NodeFinder is a node finder that will query the Kubernetes API for a slice of cluster nodes
 //
 //go:generate mockgen -destination=mocks/node_finder_mocks.go -package=mocks github.com/dell/karavi-metrics-powerflex/internal/service NodeFinder
@@ -181,6 +235,17 @@ type LeaderElector interface {
 	IsLeader() bool
 }
 
+// StorageSystemOwner is implemented by leader electors that spread storage systems across replicas
+type StorageSystemOwner interface {
+	Owns(storageSystemID string) bool
+}
+
+// OwnsStorageSystem returns false if the leader elector assigned the storage system to another replica
+func OwnsStorageSystem(elector LeaderElector, storageSystemID string) bool {
+	owner, ok := elector.(StorageSystemOwner)
+	return !ok || owner.Owns(storageSystemID)
+}
+
 // TopologyMetricsRecord used for holding output of the Topology metric query results
 type TopologyMetricsRecord struct {
 	topologyMeta *TopologyMeta
@@ -193,6 +258,8 @@ type SdcMetricsHandler struct {
 	StatisticsGetter StatisticsGetter
 	GenType          string
 	Client           PowerFlexClient
+	// NodeName is the node whose CSINode reported the GUID of the SDC
+	NodeName string
 }
 
 // storagePoolMetricsRecord used for holding output of the Storage pool stat query results
@@ -202,6 +269,8 @@ type storagePoolMetricsRecord struct {
 	storageClassMeta *StorageClassMeta
 	TotalLogicalCapacity, LogicalCapacityAvailable,
 	LogicalCapacityInUse, LogicalProvisioned float64
+	efficiency     *PoolEfficiencyRecord
+	catalogMetrics []CatalogMetricValue
 }
 
 // IDedPoolStatisticGetter offers PoolStatisticGetter with its corresponding pool ID
@@ -216,6 +285,7 @@ type SDCMetricsRecord struct {
 	readBW, writeBW,
 	readIOPS, writeIOPS,
 	readLatency, writeLatency float64
+	catalogMetrics []CatalogMetricValue
 }
 
 // VolumeMetricsRecord used for holding output of the Volume stat query results
@@ -224,32 +294,75 @@ type VolumeMetricsRecord struct {
 	readBW, writeBW,
 	readIOPS, writeIOPS,
 	readLatency, writeLatency float64
+	catalogMetrics []CatalogMetricValue
+
+	// used by the aggregation stage
+	storageClass     string
+	provisionedBytes int64
+	sizeInKb         int
 }
 
-// GetGenType queries the PowerFlex system for its gen type
+// GenTypeMixed is the gen type of a system with both medium granularity and EC protection domains. The gen type of
+// each of its SDCs is decided from the volumes mapped to it, see sdcGenType.
+const GenTypeMixed = "mixed"
+
+// GetGenType queries the PowerFlex system for the gen type of its SDCs: EC if every protection domain is EC,
+// the gen type of the first medium granularity protection domain if none is, and GenTypeMixed otherwise.
 func GetGenType(system *sio.System) (string, error) {
 	pds, err := system.GetProtectionDomain("")
 	if err != nil {
 		return "", err
 	}
-	if len(pds) > 0 {
-		return pds[0].GenType, nil
+	genType, ec := "", false
+	for _, pd := range pds {
+		if pd.GenType == types.GenTypeEC {
+			ec = true
+		} else if genType == "" {
+			genType = pd.GenType
+		}
+	}
+	switch {
+	case ec && genType != "":
+		return GenTypeMixed, nil
+	case ec:
+		return types.GenTypeEC, nil
 	}
-	return "", nil
+	return genType, nil
 }
 
-// getMetric retrieves a specific metric value from a slice of metrics
-func getMetric(metrics []types.Metric, name string) float64 {
-	for _, m := range metrics {
-		if m.Name == name {
-			return m.Values[0]
+// sdcGenType returns the gen type of an SDC of a mixed system: EC if every volume mapped to it is EC, so that
+// its metrics are queried with GetMetrics. An SDC with a medium granularity volume, or with no volume, keeps
+// GenTypeMixed and is read with the statistics API, as the SDCs of a medium granularity system are.
+func sdcGenType(volumes []*sio.Volume) string {
+	if len(volumes) == 0 {
+		return GenTypeMixed
+	}
+	for _, volume := range volumes {
+		if volume.Volume.GenType != types.GenTypeEC {
+			return GenTypeMixed
 		}
 	}
-	return 0
+	return types.GenTypeEC
+}
+
+// volumeGenType returns the gen type of a volume, or the gen type of its SDC if the volume doesn't report one
+func volumeGenType(volume *sio.Volume, sdcGenType string) string {
+	if volume.Volume.GenType != "" {
+		return volume.Volume.GenType
+	}
+	return sdcGenType
+}
+
+// metricCatalog returns the catalog of the metrics of EC SDCs, volumes and storage pools
+func (s *PowerFlexService) metricCatalog() *MetricCatalog {
+	if s.MetricCatalog == nil {
+		return defaultMetricCatalog
+	}
+	return s.MetricCatalog
 }
 
 // GetSDCs returns a slice of SDCs
-func (s *PowerFlexService) GetSDCs(_ context.Context, client PowerFlexClient, sdcFinder SDCFinder) ([]SdcMetricsRetriever, error) {
+func (s *PowerFlexService) GetSDCs(ctx context.Context, client PowerFlexClient, sdcFinder SDCFinder) ([]SdcMetricsRetriever, error) {
 	var sdcs []SdcMetricsRetriever
 	sdcGUIDs, err := sdcFinder.GetSDCGuids()
 	if err != nil {
@@ -259,42 +372,119 @@ func (s *PowerFlexService) GetSDCs(_ context.Context, client PowerFlexClient, sd
 	if len(sdcGUIDs) == 0 {
 		return sdcs, nil
 	}
+	var sdcNodes map[string]string
+	if nodeFinder, ok := sdcFinder.(SDCNodeFinder); ok {
+		sdcNodes, err = nodeFinder.GetSDCNodes()
+		if err != nil {
+			s.Logger.WithError(err).Warn("getting the nodes of the sdcs, falling back to their addresses")
+		}
+	}
+	sdcKeys := sdcInventoryKeys(sdcGUIDs, sdcNodes)
+	if cached, ok := s.InventoryCache.SDCs(client, sdcKeys); ok {
+		s.Logger.WithField("sdcs", len(cached)).Debug("using cached sdcs")
+		return cached, nil
+	}
 	systems, err := client.GetInstance("")
 	if err != nil {
 		return nil, err
 	}
+	// the SDCs query the gateway through the goscaleio client behind the decorators
+	sioClient, _ := UnwrapClient(client).(*sio.Client)
+	sdcVolumes := make(map[*sio.Sdc][]*sio.Volume)
 	for _, system := range systems {
 		s.Logger.WithFields(logrus.Fields{"system_id": system.ID, "system_name": system.Name}).Debug("looking up system")
 		sys, err := SystemFinder(client, system.ID, system.Name, "")
 		if err != nil {
 			return nil, err
 		}
-		realSystem, err := client.FindSystem(system.ID, system.Name, "")
+		// SystemFinder returns the goscaleio system, which isn't looked up a second time for its gen type
+		realSystem, _ := sys.(*sio.System)
+		var genType string
+		err = CallGateway(ctx, client, func() (err error) {
+			genType, err = GetGenType(realSystem)
+			return err
+		})
 		if err != nil {
 			return nil, err
 		}
-		genType, err := GetGenType(realSystem)
+		systemSdcs, err := s.getSystemSdcs(ctx, client, sys, system.ID, sdcGUIDs)
 		if err != nil {
 			return nil, err
 		}
+		sdcsByGUID := make(map[string]*types.Sdc, len(systemSdcs))
+		for i := range systemSdcs {
+			sdcsByGUID[systemSdcs[i].SdcGUID] = &systemSdcs[i]
+		}
+		found := make(map[string]bool, len(sdcGUIDs))
 		for _, sdcGUID := range sdcGUIDs {
-			sdc, err := sys.FindSdc("SdcGUID", sdcGUID)
-			if err != nil {
-				s.Logger.WithField("sdc_guid", sdcGUID).Warn("unable to find SDC with GUID")
-			} else {
-				s.Logger.WithFields(logrus.Fields{"sdc_guid": sdcGUID}).Debug("found sdc")
-				sdcs = append(sdcs, SdcMetricsHandler{
-					Sdc:              sdc,
-					Client:           client,
-					StatisticsGetter: sdc,
-					GenType:          genType,
+			sdcInfo, ok := sdcsByGUID[sdcGUID]
+			if !ok {
+				s.Logger.WithFields(logrus.Fields{"sdc_guid": sdcGUID, "system_id": system.ID}).Debug("no sdc with guid on system")
+				continue
+			}
+			found[sdcGUID] = true
+			s.LocalSDC.set(system.ID, sdcInfo.ID)
+			s.Logger.WithFields(logrus.Fields{"sdc_guid": sdcGUID}).Debug("found sdc")
+			sdc := sio.NewSdc(sioClient, sdcInfo)
+			sdcGen := genType
+			if genType == GenTypeMixed {
+				// the volumes are kept for GetVolumes, which would otherwise look them up again
+				var volumes []*sio.Volume
+				err := CallGateway(ctx, client, func() (err error) {
+					volumes, err = sdc.FindVolumes()
+					return err
 				})
+				if err != nil {
+					return nil, err
+				}
+				sdcGen = sdcGenType(volumes)
+				sdcVolumes[sdc] = volumes
 			}
+			sdcs = append(sdcs, SdcMetricsHandler{
+				Sdc:              sdc,
+				Client:           client,
+				StatisticsGetter: sdc,
+				GenType:          sdcGen,
+				NodeName:         sdcNodes[sdcGUID],
+			})
 		}
+		s.MissingSDCs.record(system.ID, sdcGUIDs, found, s.Logger)
+	}
+	s.InventoryCache.SetSDCs(client, sdcKeys, sdcs)
+	for sdc, volumes := range sdcVolumes {
+		s.InventoryCache.SetVolumes(client, sdc, volumes)
 	}
 	return sdcs, nil
 }
 
+// getSystemSdcs returns the SDCs of a storage system to match the GUIDs against. They are listed in one request and
+// matched locally, rather than looking up each GUID, which lists every SDC of the system on each lookup. The local SDC
+// of a node-local pod is fetched on its own once a listing found its ID.
+func (s *PowerFlexService) getSystemSdcs(ctx context.Context, client PowerFlexClient, sys PowerFlexSystem, storageSystemID string, sdcGUIDs []string) ([]types.Sdc, error) {
+	if id, ok := s.LocalSDC.id(storageSystemID); ok && len(sdcGUIDs) == 1 {
+		var sdc *sio.Sdc
+		err := CallGateway(ctx, client, func() (err error) {
+			sdc, err = sys.GetSdcByID(id)
+			return err
+		})
+		if err == nil && sdc.Sdc.SdcGUID == sdcGUIDs[0] {
+			return []types.Sdc{*sdc.Sdc}, nil
+		}
+		// the SDC was removed or replaced by one with another ID, so it is looked up in the listing again
+		s.LocalSDC.forget(storageSystemID)
+		if err != nil && !isRejectedRequest(err) {
+			return nil, err
+		}
+	}
+
+	var sdcs []types.Sdc
+	err := CallGateway(ctx, client, func() (err error) {
+		sdcs, err = sys.GetSdc()
+		return err
+	})
+	return sdcs, err
+}
+
 func (s SdcMetricsHandler) GetClient() PowerFlexClient {
 	return s.Client
 }
@@ -311,23 +501,24 @@ func (s SdcMetricsHandler) GetGen() string {
 	return s.GenType
 }
 
-// GetSDCMeta returns SDC meta information from a goscaleio SDC.
+func (s SdcMetricsHandler) GetNodeName() string {
+	return s.NodeName
+}
+
+// GetSDCMeta returns SDC meta information from a goscaleio SDC. The node of the SDC is the node named nodeName, the node
+// whose CSINode reported the GUID of the SDC, or else the node that has one of the addresses of the SDC.
 // This function is exported for direct testing.
-func GetSDCMeta(sdc interface{}, nodes []corev1.Node) (*SDCMeta, error) {
+func GetSDCMeta(sdc interface{}, nodeName string, nodes []corev1.Node) (*SDCMeta, error) {
 	if sdc == nil {
 		return nil, fmt.Errorf("nil sdc")
 	}
 
 	switch v := sdc.(type) {
 	case *sio.Sdc:
-		var name string
-	loop:
-		for _, node := range nodes {
-			for _, addr := range node.Status.Addresses {
-				if addr.Address == v.Sdc.SdcIP {
-					name = node.GetName()
-					break loop
-				}
+		name := nodeName
+		if name == "" {
+			if node := findNodeByAddress(v.Sdc, nodes); node != nil {
+				name = node.GetName()
 			}
 		}
 
@@ -343,6 +534,51 @@ func GetSDCMeta(sdc interface{}, nodes []corev1.Node) (*SDCMeta, error) {
 	}
 }
 
+// findNodeByAddress returns the node that has the address, or any of the addresses, of an SDC, or nil
+func findNodeByAddress(sdc *types.Sdc, nodes []corev1.Node) *corev1.Node {
+	for i, node := range nodes {
+		for _, addr := range node.Status.Addresses {
+			if addr.Address == sdc.SdcIP || slices.Contains(sdc.SdcIPs, addr.Address) {
+				return &nodes[i]
+			}
+		}
+	}
+	return nil
+}
+
+// findNodeByName returns the node named name, or nil
+func findNodeByName(name string, nodes []corev1.Node) *corev1.Node {
+	if name == "" {
+		return nil
+	}
+	for i := range nodes {
+		if nodes[i].Name == name {
+			return &nodes[i]
+		}
+	}
+	return nil
+}
+
+// nodeAttributes returns the allowed labels of the node of an SDC
+func (s *PowerFlexService) nodeAttributes(node *corev1.Node) map[string]string {
+	var nodeLabels labels.Set
+	if node != nil {
+		nodeLabels = node.Labels
+	}
+	return s.NodeLabels.NodeAttributes(nodeLabels)
+}
+
+// nodeConditions returns whether the node of an SDC is ready and cordoned
+func nodeConditions(node *corev1.Node) *NodeConditionsRecord {
+	conditions := &NodeConditionsRecord{Cordoned: node.Spec.Unschedulable}
+	for _, condition := range node.Status.Conditions {
+		if condition.Type == corev1.NodeReady {
+			conditions.Ready = condition.Status == corev1.ConditionTrue
+		}
+	}
+	return conditions
+}
+
 // GetSDCStatistics records I/O statistics for the given list of SDCs
 func (s *PowerFlexService) GetSDCStatistics(ctx context.Context, nodes []corev1.Node, sdcs []SdcMetricsRetriever) {
 	start := time.Now()
@@ -358,11 +594,23 @@ func (s *PowerFlexService) GetSDCStatistics(ctx context.Context, nodes []corev1.
 		s.MaxPowerFlexConnections = DefaultMaxPowerFlexConnections
 	}
 
-	for range s.pushSDCMetrics(ctx, s.gatherSDCMetrics(ctx, nodes, s.sdcServer(sdcs))) {
+	ecMetrics := s.getBatchedMetrics(ctx, MetricsResourceSDC, ecSDCIDs(sdcs))
+	for range s.pushSDCMetrics(ctx, s.gatherSDCMetrics(ctx, nodes, ecMetrics, s.sdcServer(sdcs))) {
 		// consume the channel until it is empty and closed
 	} // revive:disable-line:empty-block
 }
 
+// ecSDCIDs returns the IDs of the EC SDCs of each client, whose metrics are queried in batches
+func ecSDCIDs(sdcs []SdcMetricsRetriever) map[PowerFlexClient][]string {
+	ids := make(map[PowerFlexClient][]string)
+	for _, sdc := range sdcs {
+		if sdc.GetGen() == types.GenTypeEC && sdc.GetSdc() != nil && sdc.GetSdc().Sdc != nil {
+			ids[sdc.GetClient()] = append(ids[sdc.GetClient()], sdc.GetSdc().Sdc.ID)
+		}
+	}
+	return ids
+}
+
 // sdcServer will create a channel and push all SDCs into it
 func (s *PowerFlexService) sdcServer(sdcs []SdcMetricsRetriever) <-chan SdcMetricsRetriever {
 	sdcChan := make(chan SdcMetricsRetriever, len(sdcs))
@@ -376,13 +624,15 @@ func (s *PowerFlexService) sdcServer(sdcs []SdcMetricsRetriever) <-chan SdcMetri
 }
 
 // gatherSDCMetrics will collect, in parallel, stats against each SDC referenced by 'statGetters'
-func (s *PowerFlexService) gatherSDCMetrics(_ context.Context, nodes []corev1.Node, sdcs <-chan SdcMetricsRetriever) <-chan *SDCMetricsRecord {
+func (s *PowerFlexService) gatherSDCMetrics(ctx context.Context, nodes []corev1.Node, ecMetrics *batchedMetrics, sdcs <-chan SdcMetricsRetriever) <-chan *SDCMetricsRecord {
 	start := time.Now()
 	defer s.timeSince(start, "gatherMetrics")
 
 	ch := make(chan *SDCMetricsRecord)
 	var wg sync.WaitGroup
-	sem := make(chan struct{}, s.MaxPowerFlexConnections)
+	sem := make(chan struct{}, s.maxPowerFlexConnections(ctx))
+	unmapped := &unmappedSDCCounts{}
+	catalog := s.metricCatalog()
 
 	go func() {
 		for sdc := range sdcs {
@@ -397,7 +647,7 @@ func (s *PowerFlexService) gatherSDCMetrics(_ context.Context, nodes []corev1.No
 					wg.Done()
 				}()
 
-				sdcMeta, err := GetSDCMeta(sdc.GetSdc(), nodes)
+				sdcMeta, err := GetSDCMeta(sdc.GetSdc(), sdc.GetNodeName(), nodes)
 				if err != nil {
 					s.Logger.WithError(err).Warn("GetSDCMeta failed")
 					return
@@ -406,26 +656,36 @@ func (s *PowerFlexService) gatherSDCMetrics(_ context.Context, nodes []corev1.No
 					s.Logger.Warn("GetSDCMeta returned nil meta")
 					return
 				}
+				unmapped.add(sdc.GetSdc().Sdc.SystemID, sdcMeta.Name == "")
+				if sdcMeta.Name == "" {
+					s.Logger.WithFields(logrus.Fields{"sdc": sdcMeta.ID, "sdc_guid": sdcMeta.SdcGUID, "sdc_ip": sdcMeta.IP}).Debug("unable to map sdc to a node")
+				}
+				node := findNodeByName(sdcMeta.Name, nodes)
+				sdcMeta.Attributes = s.nodeAttributes(node)
+				if s.NodeConditions && node != nil {
+					if err := s.MetricsWrapper.RecordNodeConditions(ctx, sdcMeta, nodeConditions(node)); err != nil {
+						s.Logger.WithError(err).WithField("sdc", sdcMeta.ID).Error("recording node conditions of sdc")
+					}
+				}
 
 				if sdc.GetGen() == types.GenTypeEC {
-					stats, err := sdc.GetClient().GetMetrics("sdc", []string{sdc.GetSdc().Sdc.ID})
+					stats, ok, err := ecMetrics.get(sdc.GetClient(), sdcMeta.ID)
 					if err != nil {
 						s.Logger.WithError(err).WithField("sdc", sdcMeta.ID).Error("getting statistics for sdc")
 						return
 					}
-					s.Logger.WithField("sdc_ids_for_metrics", sdc.GetSdc().Sdc.ID).Debug("calling GetMetrics(sdc)")
-
-					if len(stats.Resources) == 0 {
-						s.Logger.Warn("No resources found in metrics response for SDC")
+					if !ok {
+						s.Logger.WithField("sdc", sdcMeta.ID).Warn("No resources found in metrics response for SDC")
 						return
 					}
 
-					readBW := getMetric(stats.Resources[0].Metrics, "host_read_bandwidth")
-					writeBW := getMetric(stats.Resources[0].Metrics, "host_write_bandwidth")
-					readIOPS := getMetric(stats.Resources[0].Metrics, "host_read_iops")
-					writeIOPS := getMetric(stats.Resources[0].Metrics, "host_write_iops")
-					readLatency := getMetric(stats.Resources[0].Metrics, "avg_host_read_latency")
-					writeLatency := getMetric(stats.Resources[0].Metrics, "avg_host_write_latency")
+					metrics := stats.Metrics
+					readBW := catalog.Value(MetricsResourceSDC, InstrumentReadBandwidth, metrics)
+					writeBW := catalog.Value(MetricsResourceSDC, InstrumentWriteBandwidth, metrics)
+					readIOPS := catalog.Value(MetricsResourceSDC, InstrumentReadIOPS, metrics)
+					writeIOPS := catalog.Value(MetricsResourceSDC, InstrumentWriteIOPS, metrics)
+					readLatency := catalog.Value(MetricsResourceSDC, InstrumentReadLatency, metrics)
+					writeLatency := catalog.Value(MetricsResourceSDC, InstrumentWriteLatency, metrics)
 
 					s.Logger.WithFields(logrus.Fields{
 						"sdc_meta":        sdcMeta,
@@ -442,14 +702,19 @@ func (s *PowerFlexService) gatherSDCMetrics(_ context.Context, nodes []corev1.No
 						readBW:  readBW, writeBW: writeBW,
 						readIOPS: readIOPS, writeIOPS: writeIOPS,
 						readLatency: readLatency, writeLatency: writeLatency,
+						catalogMetrics: catalog.Additional(MetricsResourceSDC, metrics),
 					}
 				} else {
-					stats, err := sdc.GetStatisticsGetter().GetStatistics()
+					var stats *types.SdcStatistics
+					err := CallGateway(ctx, sdc.GetClient(), func() (err error) {
+						stats, err = sdc.GetStatisticsGetter().GetStatistics()
+						return err
+					})
 					if err != nil {
 						// Fallback: use the new metrics query API (PowerFlex 5.0+)
 						// The legacy /api/Sdc/relationship/Statistics link was removed in PowerFlex 5.1
 						s.Logger.WithError(err).WithField("sdc", sdcMeta.ID).Warn("legacy statistics API failed, falling back to metrics query API")
-						metricsResp, metricsErr := sdc.GetClient().GetMetrics("sdc", []string{sdc.GetSdc().Sdc.ID})
+						metricsResp, metricsErr := sdc.GetClient().GetMetrics(MetricsResourceSDC, []string{sdc.GetSdc().Sdc.ID})
 						if metricsErr != nil {
 							s.Logger.WithError(metricsErr).WithField("sdc", sdcMeta.ID).Error("getting statistics for sdc via legacy and metrics APIs")
 							return
@@ -459,12 +724,13 @@ func (s *PowerFlexService) gatherSDCMetrics(_ context.Context, nodes []corev1.No
 							return
 						}
 
-						readBW := getMetric(metricsResp.Resources[0].Metrics, "host_read_bandwidth")
-						writeBW := getMetric(metricsResp.Resources[0].Metrics, "host_write_bandwidth")
-						readIOPS := getMetric(metricsResp.Resources[0].Metrics, "host_read_iops")
-						writeIOPS := getMetric(metricsResp.Resources[0].Metrics, "host_write_iops")
-						readLatency := getMetric(metricsResp.Resources[0].Metrics, "avg_host_read_latency")
-						writeLatency := getMetric(metricsResp.Resources[0].Metrics, "avg_host_write_latency")
+						metrics := metricsResp.Resources[0].Metrics
+						readBW := catalog.Value(MetricsResourceSDC, InstrumentReadBandwidth, metrics)
+						writeBW := catalog.Value(MetricsResourceSDC, InstrumentWriteBandwidth, metrics)
+						readIOPS := catalog.Value(MetricsResourceSDC, InstrumentReadIOPS, metrics)
+						writeIOPS := catalog.Value(MetricsResourceSDC, InstrumentWriteIOPS, metrics)
+						readLatency := catalog.Value(MetricsResourceSDC, InstrumentReadLatency, metrics)
+						writeLatency := catalog.Value(MetricsResourceSDC, InstrumentWriteLatency, metrics)
 
 						s.Logger.WithFields(logrus.Fields{
 							"sdc_meta":        sdcMeta,
@@ -481,6 +747,7 @@ func (s *PowerFlexService) gatherSDCMetrics(_ context.Context, nodes []corev1.No
 							readBW:  readBW, writeBW: writeBW,
 							readIOPS: readIOPS, writeIOPS: writeIOPS,
 							readLatency: readLatency, writeLatency: writeLatency,
+							catalogMetrics: catalog.Additional(MetricsResourceSDC, metrics),
 						}
 						return
 					}
@@ -508,6 +775,7 @@ func (s *PowerFlexService) gatherSDCMetrics(_ context.Context, nodes []corev1.No
 			}(sdc)
 		}
 		wg.Wait()
+		s.UnmappedSDCs.set(unmapped)
 		close(ch)
 		close(sem)
 	}()
@@ -539,6 +807,10 @@ func (s *PowerFlexService) pushSDCMetrics(ctx context.Context, sdcMetrics <-chan
 					mr.readLatency, mr.writeLatency,
 				)
 
+				if err == nil && len(mr.catalogMetrics) > 0 {
+					err = s.MetricsWrapper.RecordCatalogMetrics(ctx, mr.sdcMeta, mr.catalogMetrics)
+				}
+
 				if err != nil {
 					s.Logger.WithError(err).WithField("sdc", mr.sdcMeta.ID).Error("recording statistics for sdc")
 				} else {
@@ -567,6 +839,7 @@ func getVolumeMetaMetrics(volume interface{}) *VolumeMetaMetrics {
 			Name:       v.Volume.Name,
 			ID:         v.Volume.ID,
 			MappedSDCs: sdcsInfo,
+			SizeInKb:   v.Volume.SizeInKb,
 		}
 	default:
 		return &VolumeMetaMetrics{
@@ -578,125 +851,129 @@ func getVolumeMetaMetrics(volume interface{}) *VolumeMetaMetrics {
 }
 
 // GetVolumes returns all unique, mapped volumes in sdcs along with their metadata and metrics
-func (s *PowerFlexService) GetVolumes(_ context.Context, client PowerFlexClient, sdcs []SdcMetricsRetriever) ([]*VolumeMetaMetrics, error) {
+func (s *PowerFlexService) GetVolumes(ctx context.Context, client PowerFlexClient, sdcs []SdcMetricsRetriever) ([]*VolumeMetaMetrics, error) {
 	var uniqueVolumes []*VolumeMetaMetrics
 	visited := make(map[string]bool)
+	catalog := s.metricCatalog()
 
 	for _, sdc := range sdcs {
-		vols, err := sdc.GetSdc().FindVolumes()
-		if err != nil {
-			return nil, err
-		}
-
-		genType := ""
-		if len(vols) > 0 {
-			genType = vols[0].Volume.GenType
-		}
-
-		if genType == types.GenTypeEC {
-			volumeIDs := make([]string, 0, len(vols))
-			for _, v := range vols {
-				volumeIDs = append(volumeIDs, v.Volume.ID)
+		vols, ok := s.InventoryCache.Volumes(client, sdc.GetSdc())
+		if !ok {
+			err := CallGateway(ctx, sdc.GetClient(), func() (err error) {
+				vols, err = sdc.GetSdc().FindVolumes()
+				return err
+			})
+			if err != nil {
+				return nil, err
 			}
+			s.InventoryCache.SetVolumes(client, sdc.GetSdc(), vols)
+		}
 
-			cleanIDs := make([]string, 0, len(volumeIDs))
-			for _, id := range volumeIDs {
-				if id != "" {
-					cleanIDs = append(cleanIDs, id)
-				}
+		// the volumes of an SDC can be in both medium granularity and EC storage pools,
+		// so each gen type is read with its own API
+		var ecIDs []string
+		ec, gen1 := false, false
+		for _, v := range vols {
+			if volumeGenType(v, sdc.GetGen()) != types.GenTypeEC {
+				gen1 = true
+				continue
 			}
-
-			if len(cleanIDs) == 0 {
-				s.Logger.Warn("no valid volume IDs found for EC metrics; skipping GetMetrics(volume)")
-				return uniqueVolumes, nil
+			ec = true
+			if v.Volume.ID != "" {
+				ecIDs = append(ecIDs, v.Volume.ID)
 			}
+		}
 
-			s.Logger.WithField("volume_ids_for_metrics", cleanIDs).Debug("calling GetMetrics(volume)")
-			metrics, err := client.GetMetrics("volume", cleanIDs)
+		var ecMetrics map[string]types.Resource
+		if len(ecIDs) > 0 {
+			s.Logger.WithField("volume_ids_for_metrics", ecIDs).Debug("calling GetMetrics(volume)")
+			metrics, err := client.GetMetrics(MetricsResourceVolume, ecIDs)
 			if err != nil {
 				return nil, err
 			}
-
 			if len(metrics.Resources) == 0 {
 				s.Logger.Warn("No resources found in metrics response for volume")
-				return nil, fmt.Errorf("no volume metrics found for volume IDs: %v", cleanIDs)
+				return nil, fmt.Errorf("no volume metrics found for volume IDs: %v", ecIDs)
 			}
-			volMetrics := make(map[string]types.Resource, len(metrics.Resources))
+			ecMetrics = make(map[string]types.Resource, len(metrics.Resources))
 			for _, m := range metrics.Resources {
-				volMetrics[m.ID] = m
+				ecMetrics[m.ID] = m
 			}
+		} else if ec {
+			s.Logger.Warn("no valid volume IDs found for EC metrics; skipping GetMetrics(volume)")
+		}
 
-			for _, v := range vols {
-				volumeMeta := getVolumeMetaMetrics(v)
-				if !visited[volumeMeta.ID] {
-					s.Logger.WithFields(logrus.Fields{
-						"volume_id":   volumeMeta.ID,
-						"volume_name": volumeMeta.Name,
-						"mapped_sdcs": volumeMeta.MappedSDCs,
-					}).Debug("Processing volume")
-
-					if metrics, ok := volMetrics[volumeMeta.ID]; ok {
-						s.Logger.WithField("metrics_found", true).Debug("Volume metrics available")
-
-						volumeMeta.HostReadBandwith = getMetric(metrics.Metrics, "host_read_bandwidth")
-						volumeMeta.HostWriteBandwith = getMetric(metrics.Metrics, "host_write_bandwidth")
-						volumeMeta.HostReadIOPS = getMetric(metrics.Metrics, "host_read_iops")
-						volumeMeta.HostWriteIOPS = getMetric(metrics.Metrics, "host_write_iops")
-						volumeMeta.AvgHostReadLatency = getMetric(metrics.Metrics, "avg_host_read_latency")
-						volumeMeta.AvgHostWriteLatency = getMetric(metrics.Metrics, "avg_host_write_latency")
-
-						// Normalize units for readability
-						// Bandwidth: bytes/sec → MB/sec
-						volumeMeta.HostReadBandwith = volumeMeta.HostReadBandwith / (1024 * 1024)
-						volumeMeta.HostWriteBandwith = volumeMeta.HostWriteBandwith / (1024 * 1024)
-						// Latency: microseconds → milliseconds
-						volumeMeta.AvgHostReadLatency = volumeMeta.AvgHostReadLatency / 1000
-						volumeMeta.AvgHostWriteLatency = volumeMeta.AvgHostWriteLatency / 1000
-
-						// set the GenType
-						volumeMeta.GenType = genType
-
-						s.Logger.WithFields(logrus.Fields{
-							"read_bw":    volumeMeta.HostReadBandwith,
-							"write_bw":   volumeMeta.HostWriteBandwith,
-							"read_iops":  volumeMeta.HostReadIOPS,
-							"write_iops": volumeMeta.HostWriteIOPS,
-							"read_lat":   volumeMeta.AvgHostReadLatency,
-							"write_lat":  volumeMeta.AvgHostWriteLatency,
-						}).Debug("Volume metrics populated")
-					} else {
-						s.Logger.WithField("metrics_found", false).Warn("No metrics found for volume")
-					}
-					uniqueVolumes = append(uniqueVolumes, volumeMeta)
-					visited[volumeMeta.ID] = true
-				}
-			}
-		} else {
-			metrics, err := sdc.GetStatisticsGetter().GetVolumeMetrics()
+		var volMetrics map[string]*types.SdcVolumeMetrics
+		if gen1 {
+			var metrics []*types.SdcVolumeMetrics
+			err := CallGateway(ctx, sdc.GetClient(), func() (err error) {
+				metrics, err = sdc.GetStatisticsGetter().GetVolumeMetrics()
+				return err
+			})
 			if err != nil {
 				return nil, err
 			}
-			volMetrics := make(map[string]*types.SdcVolumeMetrics, len(metrics))
+			volMetrics = make(map[string]*types.SdcVolumeMetrics, len(metrics))
 			for _, m := range metrics {
 				volMetrics[m.VolumeID] = m
 			}
+		}
 
-			for _, v := range vols {
-				volumeMeta := getVolumeMetaMetrics(v)
-				if !visited[volumeMeta.ID] {
-					s.Logger.WithField("volume_id", volumeMeta.ID).Debug("found volume")
-					if _, ok := volMetrics[volumeMeta.ID]; ok {
-						volumeMeta.ReadBwc = volMetrics[volumeMeta.ID].ReadBwc
-						volumeMeta.WriteBwc = volMetrics[volumeMeta.ID].WriteBwc
-						volumeMeta.ReadLatencyBwc = volMetrics[volumeMeta.ID].ReadLatencyBwc
-						volumeMeta.WriteLatencyBwc = volMetrics[volumeMeta.ID].WriteLatencyBwc
-						volumeMeta.TrimBwc = volMetrics[volumeMeta.ID].TrimBwc
-						volumeMeta.TrimLatencyBwc = volMetrics[volumeMeta.ID].TrimLatencyBwc
-					}
-					uniqueVolumes = append(uniqueVolumes, volumeMeta)
-					visited[volumeMeta.ID] = true
+		for _, v := range vols {
+			genType := volumeGenType(v, sdc.GetGen())
+			if genType == types.GenTypeEC && ecMetrics == nil {
+				// none of the EC volumes of the SDC has an ID to query the metrics of
+				continue
+			}
+			volumeMeta := getVolumeMetaMetrics(v)
+			if visited[volumeMeta.ID] {
+				continue
+			}
+			if genType == types.GenTypeEC {
+				s.Logger.WithFields(logrus.Fields{
+					"volume_id":   volumeMeta.ID,
+					"volume_name": volumeMeta.Name,
+					"mapped_sdcs": volumeMeta.MappedSDCs,
+				}).Debug("Processing volume")
+
+				if metrics, ok := ecMetrics[volumeMeta.ID]; ok {
+					s.Logger.WithField("metrics_found", true).Debug("Volume metrics available")
+
+					volumeMeta.HostReadBandwith = catalog.Value(MetricsResourceVolume, InstrumentReadBandwidth, metrics.Metrics)
+					volumeMeta.HostWriteBandwith = catalog.Value(MetricsResourceVolume, InstrumentWriteBandwidth, metrics.Metrics)
+					volumeMeta.HostReadIOPS = catalog.Value(MetricsResourceVolume, InstrumentReadIOPS, metrics.Metrics)
+					volumeMeta.HostWriteIOPS = catalog.Value(MetricsResourceVolume, InstrumentWriteIOPS, metrics.Metrics)
+					volumeMeta.AvgHostReadLatency = catalog.Value(MetricsResourceVolume, InstrumentReadLatency, metrics.Metrics)
+					volumeMeta.AvgHostWriteLatency = catalog.Value(MetricsResourceVolume, InstrumentWriteLatency, metrics.Metrics)
+					volumeMeta.CatalogMetrics = catalog.Additional(MetricsResourceVolume, metrics.Metrics)
+
+					// set the GenType
+					volumeMeta.GenType = genType
+
+					s.Logger.WithFields(logrus.Fields{
+						"read_bw":    volumeMeta.HostReadBandwith,
+						"write_bw":   volumeMeta.HostWriteBandwith,
+						"read_iops":  volumeMeta.HostReadIOPS,
+						"write_iops": volumeMeta.HostWriteIOPS,
+						"read_lat":   volumeMeta.AvgHostReadLatency,
+						"write_lat":  volumeMeta.AvgHostWriteLatency,
+					}).Debug("Volume metrics populated")
+				} else {
+					s.Logger.WithField("metrics_found", false).Warn("No metrics found for volume")
+				}
+			} else {
+				s.Logger.WithField("volume_id", volumeMeta.ID).Debug("found volume")
+				if m, ok := volMetrics[volumeMeta.ID]; ok {
+					volumeMeta.ReadBwc = m.ReadBwc
+					volumeMeta.WriteBwc = m.WriteBwc
+					volumeMeta.ReadLatencyBwc = m.ReadLatencyBwc
+					volumeMeta.WriteLatencyBwc = m.WriteLatencyBwc
+					volumeMeta.TrimBwc = m.TrimBwc
+					volumeMeta.TrimLatencyBwc = m.TrimLatencyBwc
 				}
 			}
+			uniqueVolumes = append(uniqueVolumes, volumeMeta)
+			visited[volumeMeta.ID] = true
 		}
 	}
 	return uniqueVolumes, nil
@@ -717,7 +994,11 @@ func (s *PowerFlexService) ExportVolumeStatistics(ctx context.Context, volumes [
 		s.MaxPowerFlexConnections = DefaultMaxPowerFlexConnections
 	}
 
-	for range s.pushVolumeMetrics(ctx, s.gatherVolumeMetrics(ctx, volumeFinder, s.volumeServer(volumes))) {
+	records := s.gatherVolumeMetrics(ctx, volumeFinder, s.volumeServer(volumes))
+	if s.VolumeAggregation.Enabled() {
+		records = s.aggregateVolumeMetrics(ctx, records)
+	}
+	for range s.pushVolumeMetrics(ctx, records) {
 		// consume the channel until it is empty and closed
 	} // revive:disable-line:empty-block
 }
@@ -747,13 +1028,13 @@ func (s *PowerFlexService) getVolumeInfo(_ context.Context, volumes []k8s.Volume
 }
 
 // gatherVolumeMetrics will return a channel of volume metrics based on the input of volumes
-func (s *PowerFlexService) gatherVolumeMetrics(_ context.Context, volumeFinder VolumeFinder, volumes <-chan *VolumeMetaMetrics) <-chan *VolumeMetricsRecord {
+func (s *PowerFlexService) gatherVolumeMetrics(ctx context.Context, volumeFinder VolumeFinder, volumes <-chan *VolumeMetaMetrics) <-chan *VolumeMetricsRecord {
 	start := time.Now()
 	defer s.timeSince(start, "gatherVolumeMetrics")
 
 	ch := make(chan *VolumeMetricsRecord)
 	var wg sync.WaitGroup
-	sem := make(chan struct{}, s.MaxPowerFlexConnections)
+	sem := make(chan struct{}, s.maxPowerFlexConnections(ctx))
 
 	go func() {
 		persistentVolumes := make(map[string]k8s.VolumeInfo)
@@ -765,9 +1046,15 @@ func (s *PowerFlexService) gatherVolumeMetrics(_ context.Context, volumeFinder V
 		for _, v := range pvs {
 			persistentVolumes[v.StorageSystemVolumeName] = v
 		}
+		reporter, ok := volumeFinder.(VolumeFilterReporter)
+		filtering := ok && reporter.Filtering()
 
 		exported := false
 		for volume := range volumes {
+			if _, ok := persistentVolumes[volume.Name]; !ok && filtering {
+				s.Logger.WithField("volume_id", volume.ID).Debug("skipping storage system volume that is not selected by the volume filter")
+				continue
+			}
 			exported = true
 			wg.Add(1)
 			sem <- struct{}{}
@@ -782,6 +1069,9 @@ func (s *PowerFlexService) gatherVolumeMetrics(_ context.Context, volumeFinder V
 					volume.StorageSystemID = pv.StorageSystemID
 					volume.Namespace = pv.Namespace
 					volume.PersistentVolumeClaimName = pv.VolumeClaimName
+					volume.Attributes = pv.Attributes
+					volume.StorageClass = pv.StorageClass
+					volume.ProvisionedBytes = pv.ProvisionedBytes
 				} else {
 					s.Logger.WithField("volume_id", volume.ID).Error("could not find a Persistent Volume that maps to storage system volume ID")
 				}
@@ -798,6 +1088,7 @@ func (s *PowerFlexService) gatherVolumeMetrics(_ context.Context, volumeFinder V
 					Namespace:                 volume.Namespace,
 					StorageSystemID:           volume.StorageSystemID,
 					MappedSDCs:                volume.MappedSDCs,
+					Attributes:                volume.Attributes,
 				}
 
 				s.Logger.WithFields(logrus.Fields{
@@ -815,6 +1106,10 @@ func (s *PowerFlexService) gatherVolumeMetrics(_ context.Context, volumeFinder V
 					readBW:     readBW, writeBW: writeBW,
 					readIOPS: readIOPS, writeIOPS: writeIOPS,
 					readLatency: readLatency, writeLatency: writeLatency,
+					catalogMetrics:   volume.CatalogMetrics,
+					storageClass:     volume.StorageClass,
+					provisionedBytes: volume.ProvisionedBytes,
+					sizeInKb:         volume.SizeInKb,
 				}
 			}(volume)
 		}
@@ -854,6 +1149,9 @@ func (s *PowerFlexService) pushVolumeMetrics(ctx context.Context, volumeMetrics
 					metrics.readIOPS, metrics.writeIOPS,
 					metrics.readLatency, metrics.writeLatency,
 				)
+				if err == nil && len(metrics.catalogMetrics) > 0 {
+					err = s.MetricsWrapper.RecordCatalogMetrics(ctx, metrics.volumeMeta, metrics.catalogMetrics)
+				}
 				if err != nil {
 					s.Logger.WithError(err).WithField("volume_id", metrics.volumeMeta.ID).Error("recording statistics for volume")
 				} else {
@@ -871,7 +1169,7 @@ func (s *PowerFlexService) pushVolumeMetrics(ctx context.Context, volumeMetrics
 // GetStorageClasses returns a list of StorageClassMeta
 func (s *PowerFlexService) GetStorageClasses(_ context.Context, client PowerFlexClient, storageClassFinder StorageClassFinder) ([]StorageClassMeta, error) {
 	var c *sio.Client
-	switch underlyingClient := client.(type) {
+	switch underlyingClient := UnwrapClient(client).(type) {
 	case *sio.Client:
 		c = underlyingClient
 	default:
@@ -888,6 +1186,10 @@ func (s *PowerFlexService) GetStorageClasses(_ context.Context, client PowerFlex
 	if err != nil {
 		return nil, err
 	}
+	if cached, ok := s.InventoryCache.StorageClasses(client, storageClasses); ok {
+		s.Logger.WithField("storage_classes", len(cached)).Debug("using cached storage classes")
+		return cached, nil
+	}
 
 	systems, err := client.GetInstance("")
 	if err != nil {
@@ -911,6 +1213,7 @@ func (s *PowerFlexService) GetStorageClasses(_ context.Context, client PowerFlex
 					Driver:          class.Provisioner,
 					StorageSystemID: systemid,
 					StoragePools:    storageClassFinder.GetStoragePools(class),
+					Attributes:      class.Attributes,
 				}
 				s.Logger.WithField("storage_class_info", storageClassInfo).Debug("found storage class")
 				storageClassInfos = append(storageClassInfos, storageClassInfo)
@@ -930,6 +1233,7 @@ func (s *PowerFlexService) GetStorageClasses(_ context.Context, client PowerFlex
 			Driver:          class.Driver,
 			StorageSystemID: class.StorageSystemID,
 			StoragePools:    make(map[string]StoragePoolMetricsRetriever),
+			Attributes:      class.Attributes,
 		}
 
 		for _, systemPool := range systemStoragePools {
@@ -945,6 +1249,7 @@ func (s *PowerFlexService) GetStorageClasses(_ context.Context, client PowerFlex
 		storageClassMetas = append(storageClassMetas, storageClassMeta)
 	}
 
+	s.InventoryCache.SetStorageClasses(client, storageClasses, storageClassMetas)
 	return storageClassMetas, nil
 }
 
@@ -962,13 +1267,35 @@ func (s *PowerFlexService) GetStoragePoolStatistics(ctx context.Context, storage
 		s.MaxPowerFlexConnections = DefaultMaxPowerFlexConnections
 	}
 
+	// the pools of every storage class are queried together, once even if several classes use them
+	ecMetrics := s.getBatchedMetrics(ctx, MetricsResourceStoragePool, ecPoolIDs(storageClassMetas))
 	for i, storageClassMeta := range storageClassMetas {
-		for range s.pushPoolStatistics(ctx, s.gatherPoolStatistics(ctx, &storageClassMetas[i], s.storagePoolServer(storageClassMeta.StoragePools))) {
+		for range s.pushPoolStatistics(ctx, s.gatherPoolStatistics(ctx, &storageClassMetas[i], ecMetrics, s.storagePoolServer(storageClassMeta.StoragePools))) {
 			// consume the channel until empty and closed
 		} // revive:disable-line:empty-block
 	}
 }
 
+// ecPoolIDs returns the IDs of the EC storage pools of each client, whose metrics are queried in batches
+func ecPoolIDs(storageClassMetas []StorageClassMeta) map[PowerFlexClient][]string {
+	ids := make(map[PowerFlexClient][]string)
+	seen := make(map[metricsKey]bool)
+	for _, storageClassMeta := range storageClassMetas {
+		for id, pool := range storageClassMeta.StoragePools {
+			key := metricsKey{client: pool.GetClient(), id: id}
+			if pool.GetGen() != types.GenTypeEC || seen[key] {
+				continue
+			}
+			seen[key] = true
+			ids[key.client] = append(ids[key.client], id)
+		}
+	}
+	for _, clientIDs := range ids {
+		slices.Sort(clientIDs)
+	}
+	return ids
+}
+
 // storagePoolServer will create a channel and push all StoragePools into it
 func (s *PowerFlexService) storagePoolServer(pools map[string]StoragePoolMetricsRetriever) <-chan IDedPoolStatisticGetter {
 	poolChannel := make(chan IDedPoolStatisticGetter)
@@ -982,13 +1309,14 @@ func (s *PowerFlexService) storagePoolServer(pools map[string]StoragePoolMetrics
 }
 
 // gatherPoolStatistics will collect, in parallel, stats against each StoragePool referenced by 'pool'
-func (s *PowerFlexService) gatherPoolStatistics(_ context.Context, scMeta *StorageClassMeta, pool <-chan IDedPoolStatisticGetter) <-chan *storagePoolMetricsRecord {
+func (s *PowerFlexService) gatherPoolStatistics(ctx context.Context, scMeta *StorageClassMeta, ecMetrics *batchedMetrics, pool <-chan IDedPoolStatisticGetter) <-chan *storagePoolMetricsRecord {
 	start := time.Now()
 	defer s.timeSince(start, "gatherPoolStatistics")
 
 	ch := make(chan *storagePoolMetricsRecord)
 	var wg sync.WaitGroup
-	sem := make(chan struct{}, s.MaxPowerFlexConnections)
+	sem := make(chan struct{}, s.maxPowerFlexConnections(ctx))
+	catalog := s.metricCatalog()
 
 	go func() {
 		for pl := range pool {
@@ -1001,37 +1329,24 @@ func (s *PowerFlexService) gatherPoolStatistics(_ context.Context, scMeta *Stora
 				}()
 
 				if pl.Getter.GetGen() == types.GenTypeEC {
-					s.Logger.WithFields(logrus.Fields{
-						"pool_id_used_for_metrics": pl.ID,
-					}).Debug("calling GetMetrics(storage_pool)")
-
-					stats, err := pl.Getter.GetClient().GetMetrics("storage_pool", []string{pl.ID})
+					stats, ok, err := ecMetrics.get(pl.Getter.GetClient(), pl.ID)
 					if err != nil {
 						s.Logger.WithError(err).WithField("pool_id", pl.ID).Error("getting statistics pool")
 						return
 					}
-
-					if len(stats.Resources) == 0 {
-						s.Logger.Warn("No resources found in metrics response for storage pool")
+					if !ok {
+						s.Logger.WithField("pool_id", pl.ID).Warn("No resources found in metrics response for storage pool")
 						return
 					}
-					// convert bytes to GB
-					storgaePoolMetrcisMap := stats.Resources[0].Metrics
-					if storgaePoolMetrcisMap == nil {
+					if stats.Metrics == nil {
 						s.Logger.WithField("pool_id", pl.ID).Warn("metrics map is nil")
 						return
 					}
 
-					const giB = float64(1 << 30)
-					getMetric := func(metricName string) float64 {
-						metricValue := getMetric(storgaePoolMetrcisMap, metricName)
-						return metricValue / giB
-					}
-
-					totalCapacity := getMetric("physical_total")
-					capacityAvailable := getMetric("physical_free")
-					capacityInUse := getMetric("physical_used")
-					provisioned := getMetric("logical_provisioned")
+					totalCapacity := catalog.Value(MetricsResourceStoragePool, InstrumentTotalLogicalCapacity, stats.Metrics)
+					capacityAvailable := catalog.Value(MetricsResourceStoragePool, InstrumentLogicalCapacityAvailable, stats.Metrics)
+					capacityInUse := catalog.Value(MetricsResourceStoragePool, InstrumentLogicalCapacityInUse, stats.Metrics)
+					provisioned := catalog.Value(MetricsResourceStoragePool, InstrumentLogicalProvisioned, stats.Metrics)
 
 					s.Logger.WithFields(logrus.Fields{
 						"pool_id":                    pl.ID,
@@ -1049,9 +1364,20 @@ func (s *PowerFlexService) gatherPoolStatistics(_ context.Context, scMeta *Stora
 						LogicalCapacityAvailable: capacityAvailable,
 						LogicalCapacityInUse:     capacityInUse,
 						LogicalProvisioned:       provisioned,
+						efficiency: &PoolEfficiencyRecord{
+							CompressionRatio:          catalog.Value(MetricsResourceStoragePool, InstrumentCompressionRatio, stats.Metrics),
+							DataReductionRatio:        catalog.Value(MetricsResourceStoragePool, InstrumentDataReductionRatio, stats.Metrics),
+							ThinOvercommit:            thinOvercommit(provisioned, totalCapacity),
+							SnapshotCapacityGigabytes: catalog.Value(MetricsResourceStoragePool, InstrumentSnapshotCapacityInUse, stats.Metrics),
+						},
+						catalogMetrics: catalog.Additional(MetricsResourceStoragePool, stats.Metrics),
 					}
 				} else {
-					stats, err := pl.Getter.GetStatisticsGetter().GetStatistics()
+					var stats *types.Statistics
+					err := CallGateway(ctx, pl.Getter.GetClient(), func() (err error) {
+						stats, err = pl.Getter.GetStatisticsGetter().GetStatistics()
+						return err
+					})
 					if err != nil {
 						s.Logger.WithError(err).WithField("pool_id", pl.ID).Error("getting statistics pool")
 						return
@@ -1071,6 +1397,7 @@ func (s *PowerFlexService) gatherPoolStatistics(_ context.Context, scMeta *Stora
 						"logical_provisioned":        logicalProvisioned,
 					}).Debug("pool statistics")
 
+					// Gen1 statistics have no compression or data reduction ratio, see PoolEfficiencyRecord
 					ch <- &storagePoolMetricsRecord{
 						ID:                       pl.ID,
 						storageClassMeta:         scMeta,
@@ -1078,6 +1405,10 @@ func (s *PowerFlexService) gatherPoolStatistics(_ context.Context, scMeta *Stora
 						LogicalCapacityAvailable: logicalCapacityAvailable,
 						LogicalCapacityInUse:     logicalCapacityInUse,
 						LogicalProvisioned:       logicalProvisioned,
+						efficiency: &PoolEfficiencyRecord{
+							ThinOvercommit:            thinOvercommit(logicalProvisioned, totalLogicalCapacity),
+							SnapshotCapacityGigabytes: GetSnapshotCapacityInUse(stats),
+						},
 					}
 				}
 			}(pl)
@@ -1102,6 +1433,15 @@ func (s *PowerFlexService) pushPoolStatistics(ctx context.Context, spMetricRecor
 			go func(i *storagePoolMetricsRecord) {
 				defer wg.Done()
 				err := s.MetricsWrapper.RecordCapacity(ctx, *(i.storageClassMeta), i.TotalLogicalCapacity, i.LogicalCapacityAvailable, i.LogicalCapacityInUse, i.LogicalProvisioned)
+				// the efficiency and catalog metrics are of the pool of the record only
+				poolMeta := *(i.storageClassMeta)
+				poolMeta.StoragePools = map[string]StoragePoolMetricsRetriever{i.ID: i.storageClassMeta.StoragePools[i.ID]}
+				if err == nil && i.efficiency != nil {
+					err = s.MetricsWrapper.RecordPoolEfficiency(ctx, poolMeta, i.efficiency)
+				}
+				if err == nil && len(i.catalogMetrics) > 0 {
+					err = s.MetricsWrapper.RecordCatalogMetrics(ctx, poolMeta, i.catalogMetrics)
+				}
 				if err != nil {
 					s.Logger.WithError(err).Error("recording statistics for storage pool")
 				}
@@ -1170,6 +1510,9 @@ func (s *PowerFlexService) gatherTopologyMetrics(volumes <-chan k8s.VolumeInfo)
 					StorageSystem:           volume.StorageSystem,
 					Protocol:                volume.Protocol,
 					CreatedTime:             volume.CreatedTime,
+					Attributes:              volume.Attributes,
+					Consumers:               volume.Consumers,
+					ConsumersResolved:       volume.ConsumersResolved,
 				}
 
 				pvAvailable := int64(1)
@@ -1389,6 +1732,23 @@ func GetLogicalProvisioned(stats *types.Statistics) float64 {
 	return float64(stats.VolumeAddressSpaceInKb) / (1024.0 * 1024.0)
 }
 
+// GetSnapshotCapacityInUse returns the capacity used by snapshots in GB from the given storage pool statistics
+func GetSnapshotCapacityInUse(stats *types.Statistics) float64 {
+	if stats == nil {
+		return 0
+	}
+
+	return float64(stats.SnapCapacityInUseOccupiedInKb) / (1024.0 * 1024.0)
+}
+
+// thinOvercommit returns the provisioned capacity of a storage pool divided by its usable capacity, or 0 if it has none
+func thinOvercommit(provisioned, usable float64) float64 {
+	if usable <= 0 {
+		return 0
+	}
+	return provisioned / usable
+}
+
 // contains checks if a string slice contains a specific string value
 func contains(slice []string, value string) bool {
 	for _, element := range slice {
@@ -20,8 +20,10 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"net/http"
 	"reflect"
 	"strconv"
+	"sync"
 	"testing"
 	"time"
 
@@ -31,6 +33,8 @@ import (
 	"github.com/dell/karavi-metrics-powerflex/internal/service/mocks"
 	"github.com/sirupsen/logrus"
 	"go.opentelemetry.io/otel"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -57,6 +61,7 @@ func (e ecRetriever) GetSdc() *sio.Sdc                              { return e.s
 func (e ecRetriever) GetClient() service.PowerFlexClient            { return e.client }
 func (e ecRetriever) GetGen() string                                { return e.gen }
 func (e ecRetriever) GetStatisticsGetter() service.StatisticsGetter { return e.stats }
+func (e ecRetriever) GetNodeName() string                           { return "" }
 
 type ecPoolRetriever struct {
 	client service.PowerFlexClient
@@ -70,10 +75,27 @@ func (e ecPoolRetriever) GetGen() string
 
 type fakeSystemFinderTarget struct {
 	byGUID map[string]*sio.Sdc
+	err    error
+	// calls counts the requests listing the SDCs of the system
+	calls int
+	// byIDCalls counts the requests fetching one SDC
+	byIDCalls int
 }
 
 var _ service.PowerFlexSystem = (*fakeSystemFinderTarget)(nil)
 
+// csiNodeSDCFinder is an SDCFinder that also knows the node of each SDC GUID
+type csiNodeSDCFinder struct {
+	guids    []string
+	nodes    map[string]string
+	nodesErr error
+}
+
+func (f csiNodeSDCFinder) GetSDCGuids() ([]string, error)              { return f.guids, nil }
+func (f csiNodeSDCFinder) GetSDCNodes() (map[string]string, error) { return f.nodes, f.nodesErr }
+
+var _ service.SDCNodeFinder = csiNodeSDCFinder{}
+
 type sdcToVolumes map[*sio.Sdc][]*sio.Volume
 
 func Test_GetSDCStatistics(t *testing.T) {
@@ -474,11 +496,29 @@ func Test_GetSDCLatency(t *testing.T) {
 	}
 }
 
-func (f *fakeSystemFinderTarget) FindSdc(_, value string) (*sio.Sdc, error) {
-	if s, ok := f.byGUID[value]; ok {
-		return s, nil
+func (f *fakeSystemFinderTarget) GetSdc() ([]types.Sdc, error) {
+	f.calls++
+	if f.err != nil {
+		return nil, f.err
+	}
+	sdcs := make([]types.Sdc, 0, len(f.byGUID))
+	for _, sdc := range f.byGUID {
+		sdcs = append(sdcs, *sdc.Sdc)
+	}
+	return sdcs, nil
+}
+
+func (f *fakeSystemFinderTarget) GetSdcByID(id string) (*sio.Sdc, error) {
+	f.byIDCalls++
+	if f.err != nil {
+		return nil, f.err
+	}
+	for _, sdc := range f.byGUID {
+		if sdc.Sdc.ID == id {
+			return sdc, nil
+		}
 	}
-	return nil, errors.New("not found")
+	return nil, &types.Error{HTTPStatusCode: http.StatusNotFound, Message: "Could not find the SDC"}
 }
 
 func Test_GetSDCs(t *testing.T) {
@@ -555,8 +595,6 @@ func Test_GetSDCs(t *testing.T) {
 
 			finder.EXPECT().GetSDCGuids().Return([]string{"g1"}, nil).Times(1)
 			client.EXPECT().GetInstance("").Return([]*types.System{{Name: "sys1", ID: "sid1"}}, nil).Times(1)
-			// client.EXPECT().FindSystem("sid1", "sys1", "").Return(&types.System{}, nil).Times(1)
-			client.EXPECT().FindSystem("sid1", "sys1", "").Return((*sio.System)(nil), nil).Times(1)
 
 			patches := gomonkey.NewPatches()
 			patches.ApplyFunc(service.SystemFinder, func(service.PowerFlexClient, string, string, string) (service.PowerFlexSystem, error) {
@@ -582,8 +620,6 @@ func Test_GetSDCs(t *testing.T) {
 
 			finder.EXPECT().GetSDCGuids().Return([]string{"g1", "g2"}, nil).Times(1)
 			client.EXPECT().GetInstance("").Return([]*types.System{{Name: "sys1", ID: "sid1"}}, nil).Times(1)
-			// client.EXPECT().FindSystem("sid1", "sys1", "").Return(&types.System{}, nil).Times(1)
-			client.EXPECT().FindSystem("sid1", "sys1", "").Return((*sio.System)(nil), nil).Times(1)
 
 			patches := gomonkey.NewPatches()
 			patches.ApplyFunc(service.SystemFinder, func(service.PowerFlexClient, string, string, string) (service.PowerFlexSystem, error) {
@@ -616,10 +652,6 @@ func Test_GetSDCs(t *testing.T) {
 				{Name: "sys1", ID: "sid1"},
 				{Name: "sys2", ID: "sid2"},
 			}, nil).Times(1)
-			// client.EXPECT().FindSystem("sid1", "sys1", "").Return(&types.System{}, nil).Times(1)
-			client.EXPECT().FindSystem("sid1", "sys1", "").Return((*sio.System)(nil), nil).Times(1)
-			// client.EXPECT().FindSystem("sid2", "sys2", "").Return(&types.System{}, nil).Times(1)
-			client.EXPECT().FindSystem("sid2", "sys2", "").Return((*sio.System)(nil), nil).Times(1)
 
 			patches := gomonkey.NewPatches()
 			patches.ApplyFunc(service.SystemFinder, func(service.PowerFlexClient, string, string, string) (service.PowerFlexSystem, error) {
@@ -643,6 +675,131 @@ func Test_GetSDCs(t *testing.T) {
 			// 2 systems × 2 GUIDs → 4 retrievers
 			return svc, client, finder, check(noErrorAndLen(4)), ctrl
 		},
+
+		"node names from the CSINodes": func(t *testing.T) (*service.PowerFlexService, service.PowerFlexClient, service.SDCFinder, []checkFn, *gomock.Controller) {
+			ctrl := gomock.NewController(t)
+			client := mocks.NewMockPowerFlexClient(ctrl)
+			finder := csiNodeSDCFinder{guids: []string{"g1", "g2"}, nodes: map[string]string{"g1": "worker-1"}}
+
+			client.EXPECT().GetInstance("").Return([]*types.System{{Name: "sys1", ID: "sid1"}}, nil).Times(1)
+
+			patches := gomonkey.NewPatches()
+			patches.ApplyFunc(service.SystemFinder, func(service.PowerFlexClient, string, string, string) (service.PowerFlexSystem, error) {
+				var sf service.PowerFlexSystem = &fakeSystemFinderTarget{
+					byGUID: map[string]*sio.Sdc{
+						"g1": {Sdc: &types.Sdc{SdcGUID: "g1", ID: "sdc-id-1", SdcIP: "1.2.3.4"}},
+						"g2": {Sdc: &types.Sdc{SdcGUID: "g2", ID: "sdc-id-2", SdcIP: "1.2.3.5"}},
+					},
+				}
+				return sf, nil
+			})
+			patches.ApplyFunc(service.GetGenType, func(*sio.System) (string, error) {
+				return "v1", nil
+			})
+			t.Cleanup(patches.Reset)
+
+			hasNodeNames := func(t *testing.T, got []service.SdcMetricsRetriever, _ error) {
+				require.Len(t, got, 2)
+				assert.Equal(t, "worker-1", got[0].GetNodeName())
+				assert.Empty(t, got[1].GetNodeName())
+			}
+
+			svc := &service.PowerFlexService{Logger: logrus.New()}
+			return svc, client, finder, check(noErrorAndLen(2), hasNodeNames), ctrl
+		},
+
+		"error getting the node names falls back to the addresses": func(t *testing.T) (*service.PowerFlexService, service.PowerFlexClient, service.SDCFinder, []checkFn, *gomock.Controller) {
+			ctrl := gomock.NewController(t)
+			client := mocks.NewMockPowerFlexClient(ctrl)
+			finder := csiNodeSDCFinder{guids: []string{"g1"}, nodesErr: errors.New("forbidden")}
+
+			client.EXPECT().GetInstance("").Return([]*types.System{{Name: "sys1", ID: "sid1"}}, nil).Times(1)
+
+			patches := gomonkey.NewPatches()
+			patches.ApplyFunc(service.SystemFinder, func(service.PowerFlexClient, string, string, string) (service.PowerFlexSystem, error) {
+				var sf service.PowerFlexSystem = &fakeSystemFinderTarget{
+					byGUID: map[string]*sio.Sdc{
+						"g1": {Sdc: &types.Sdc{SdcGUID: "g1", ID: "sdc-id-1", SdcIP: "1.2.3.4"}},
+					},
+				}
+				return sf, nil
+			})
+			patches.ApplyFunc(service.GetGenType, func(*sio.System) (string, error) {
+				return "v1", nil
+			})
+			t.Cleanup(patches.Reset)
+
+			hasNoNodeName := func(t *testing.T, got []service.SdcMetricsRetriever, _ error) {
+				require.Len(t, got, 1)
+				assert.Empty(t, got[0].GetNodeName())
+			}
+
+			svc := &service.PowerFlexService{Logger: logrus.New()}
+			return svc, client, finder, check(noErrorAndLen(1), hasNoNodeName), ctrl
+		},
+
+		"error listing the SDCs of the system": func(t *testing.T) (*service.PowerFlexService, service.PowerFlexClient, service.SDCFinder, []checkFn, *gomock.Controller) {
+			ctrl := gomock.NewController(t)
+			finder := mocks.NewMockSDCFinder(ctrl)
+			client := mocks.NewMockPowerFlexClient(ctrl)
+
+			finder.EXPECT().GetSDCGuids().Return([]string{"g1"}, nil).Times(1)
+			client.EXPECT().GetInstance("").Return([]*types.System{{Name: "sys1", ID: "sid1"}}, nil).Times(1)
+
+			patches := gomonkey.NewPatches()
+			patches.ApplyFunc(service.SystemFinder, func(service.PowerFlexClient, string, string, string) (service.PowerFlexSystem, error) {
+				return &fakeSystemFinderTarget{err: errors.New("gateway unavailable")}, nil
+			})
+			patches.ApplyFunc(service.GetGenType, func(*sio.System) (string, error) {
+				return "v1", nil
+			})
+			t.Cleanup(patches.Reset)
+
+			svc := &service.PowerFlexService{Logger: logrus.New()}
+			return svc, client, finder, check(hasError), ctrl
+		},
+
+		"one request per system; GUIDs without an SDC on any system are reported": func(t *testing.T) (*service.PowerFlexService, service.PowerFlexClient, service.SDCFinder, []checkFn, *gomock.Controller) {
+			ctrl := gomock.NewController(t)
+			finder := mocks.NewMockSDCFinder(ctrl)
+			client := mocks.NewMockPowerFlexClient(ctrl)
+
+			finder.EXPECT().GetSDCGuids().Return([]string{"g1", "g2", "g3"}, nil).Times(1)
+			client.EXPECT().GetInstance("").Return([]*types.System{
+				{Name: "sys1", ID: "sid1"},
+				{Name: "sys2", ID: "sid2"},
+			}, nil).Times(1)
+
+			systems := map[string]*fakeSystemFinderTarget{
+				"sid1": {byGUID: map[string]*sio.Sdc{
+					"g1":    {Sdc: &types.Sdc{SdcGUID: "g1", ID: "sdc-id-1", SdcIP: "1.2.3.4"}},
+					"other": {Sdc: &types.Sdc{SdcGUID: "other", ID: "sdc-id-9", SdcIP: "1.2.3.9"}},
+				}},
+				"sid2": {byGUID: map[string]*sio.Sdc{
+					"g2": {Sdc: &types.Sdc{SdcGUID: "g2", ID: "sdc-id-2", SdcIP: "1.2.3.5"}},
+				}},
+			}
+			patches := gomonkey.NewPatches()
+			patches.ApplyFunc(service.SystemFinder, func(_ service.PowerFlexClient, id string, _ string, _ string) (service.PowerFlexSystem, error) {
+				return systems[id], nil
+			})
+			patches.ApplyFunc(service.GetGenType, func(*sio.System) (string, error) {
+				return "v1", nil
+			})
+			t.Cleanup(patches.Reset)
+
+			svc := &service.PowerFlexService{Logger: logrus.New(), MissingSDCs: &service.MissingSDCs{}}
+			svc.MissingSDCs.SetStorageSystems([]string{"sid1", "sid2"})
+			hasSDCs := func(t *testing.T, got []service.SdcMetricsRetriever, _ error) {
+				require.Len(t, got, 2)
+				assert.Equal(t, "sdc-id-1", got[0].GetSdc().Sdc.ID)
+				assert.Equal(t, "sdc-id-2", got[1].GetSdc().Sdc.ID)
+				assert.Equal(t, 1, systems["sid1"].calls)
+				assert.Equal(t, 1, systems["sid2"].calls)
+				assert.Equal(t, []string{"g3"}, svc.MissingSDCs.GUIDs())
+			}
+			return svc, client, finder, check(hasSDCs), ctrl
+		},
 	}
 
 	for name, tc := range tests {
@@ -662,6 +819,147 @@ func Test_GetSDCs(t *testing.T) {
 	assert.Equal(t, reflect.Func, reflect.ValueOf(service.GetGenType).Kind())
 }
 
+func Test_GetSDCs_MissingSDCs(t *testing.T) {
+	systems := map[string]*fakeSystemFinderTarget{
+		"sid1": {byGUID: map[string]*sio.Sdc{"g1": {Sdc: &types.Sdc{SdcGUID: "g1", ID: "sdc-id-1"}}}},
+		"sid2": {byGUID: map[string]*sio.Sdc{"g2": {Sdc: &types.Sdc{SdcGUID: "g2", ID: "sdc-id-2"}}}},
+		"sid9": {byGUID: map[string]*sio.Sdc{"g3": {Sdc: &types.Sdc{SdcGUID: "g3", ID: "sdc-id-3"}}}},
+	}
+	patches := gomonkey.NewPatches()
+	patches.ApplyFunc(service.SystemFinder, func(_ service.PowerFlexClient, id string, _ string, _ string) (service.PowerFlexSystem, error) {
+		return systems[id], nil
+	})
+	patches.ApplyFunc(service.GetGenType, func(*sio.System) (string, error) {
+		return "v1", nil
+	})
+	t.Cleanup(patches.Reset)
+
+	missing := &service.MissingSDCs{}
+	svc := &service.PowerFlexService{Logger: logrus.New(), MissingSDCs: missing}
+	// lookup looks up the SDCs of the CSINodes on one storage system
+	lookup := func(id string) {
+		ctrl := gomock.NewController(t)
+		finder := mocks.NewMockSDCFinder(ctrl)
+		finder.EXPECT().GetSDCGuids().Return([]string{"g1", "g2", "g3"}, nil)
+		client := mocks.NewMockPowerFlexClient(ctrl)
+		client.EXPECT().GetInstance("").Return([]*types.System{{Name: id, ID: id}}, nil)
+		_, err := svc.GetSDCs(context.Background(), client, finder)
+		require.NoError(t, err)
+	}
+
+	// nothing is reported until every configured storage system was looked up
+	missing.SetStorageSystems([]string{"sid1", "sid2", "sid3"})
+	lookup("sid1")
+	lookup("sid2")
+	assert.Empty(t, missing.GUIDs())
+
+	missing.SetStorageSystems([]string{"sid1", "sid2"})
+	lookup("sid1")
+	assert.Equal(t, []string{"g3"}, missing.GUIDs())
+
+	// a storage system that isn't configured doesn't count
+	lookup("sid9")
+	assert.Equal(t, []string{"g3"}, missing.GUIDs())
+
+	// the SDCs of a storage system that is no longer configured are forgotten
+	missing.SetStorageSystems([]string{"sid1"})
+	lookup("sid1")
+	assert.Equal(t, []string{"g2", "g3"}, missing.GUIDs())
+}
+
+func Test_GetSDCs_LocalSDC(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	finder := mocks.NewMockSDCFinder(ctrl)
+	client := mocks.NewMockPowerFlexClient(ctrl)
+	finder.EXPECT().GetSDCGuids().Return([]string{"g1"}, nil).AnyTimes()
+	client.EXPECT().GetInstance("").Return([]*types.System{{Name: "sys1", ID: "sid1"}}, nil).AnyTimes()
+
+	system := &fakeSystemFinderTarget{byGUID: map[string]*sio.Sdc{
+		"g1": {Sdc: &types.Sdc{SdcGUID: "g1", ID: "sdc-1"}},
+		"g2": {Sdc: &types.Sdc{SdcGUID: "g2", ID: "sdc-2"}},
+	}}
+	patches := gomonkey.NewPatches()
+	patches.ApplyFunc(service.SystemFinder, func(service.PowerFlexClient, string, string, string) (service.PowerFlexSystem, error) {
+		return system, nil
+	})
+	patches.ApplyFunc(service.GetGenType, func(*sio.System) (string, error) {
+		return "v1", nil
+	})
+	t.Cleanup(patches.Reset)
+
+	svc := &service.PowerFlexService{Logger: logrus.New(), LocalSDC: &service.LocalSDC{}}
+	getSDCIDs := func() []string {
+		sdcs, err := svc.GetSDCs(context.Background(), client, finder)
+		require.NoError(t, err)
+		var ids []string
+		for _, sdc := range sdcs {
+			ids = append(ids, sdc.GetSdc().Sdc.ID)
+		}
+		return ids
+	}
+
+	// the SDCs are listed until the local SDC was found, then it is fetched on its own
+	assert.Equal(t, []string{"sdc-1"}, getSDCIDs())
+	assert.Equal(t, []string{"sdc-1"}, getSDCIDs())
+	assert.Equal(t, 1, system.calls)
+	assert.Equal(t, 1, system.byIDCalls)
+
+	// an SDC that was replaced by one with another ID is listed again
+	system.byGUID["g1"] = &sio.Sdc{Sdc: &types.Sdc{SdcGUID: "g1", ID: "sdc-3"}}
+	assert.Equal(t, []string{"sdc-3"}, getSDCIDs())
+	assert.Equal(t, 2, system.calls)
+	assert.Equal(t, 2, system.byIDCalls)
+	assert.Equal(t, []string{"sdc-3"}, getSDCIDs())
+	assert.Equal(t, 2, system.calls)
+	assert.Equal(t, 3, system.byIDCalls)
+}
+
+func Test_GetSDCs_MixedGenTypes(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	finder := mocks.NewMockSDCFinder(ctrl)
+	client := mocks.NewMockPowerFlexClient(ctrl)
+	finder.EXPECT().GetSDCGuids().Return([]string{"g1", "g2", "g3"}, nil)
+	client.EXPECT().GetInstance("").Return([]*types.System{{Name: "sys1", ID: "sid1"}}, nil)
+
+	system := &fakeSystemFinderTarget{byGUID: map[string]*sio.Sdc{
+		"g1": {Sdc: &types.Sdc{SdcGUID: "g1", ID: "sdc-ec"}},
+		"g2": {Sdc: &types.Sdc{SdcGUID: "g2", ID: "sdc-both"}},
+		"g3": {Sdc: &types.Sdc{SdcGUID: "g3", ID: "sdc-none"}},
+	}}
+	volumes := map[string][]*sio.Volume{
+		"sdc-ec":   {{Volume: &types.Volume{ID: "v1", GenType: types.GenTypeEC}}, {Volume: &types.Volume{ID: "v2", GenType: types.GenTypeEC}}},
+		"sdc-both": {{Volume: &types.Volume{ID: "v3", GenType: types.GenTypeEC}}, {Volume: &types.Volume{ID: "v4", GenType: "v1"}}},
+	}
+	patches := gomonkey.NewPatches()
+	patches.ApplyFunc(service.SystemFinder, func(service.PowerFlexClient, string, string, string) (service.PowerFlexSystem, error) {
+		return system, nil
+	})
+	patches.ApplyFunc(service.GetGenType, func(*sio.System) (string, error) {
+		return service.GenTypeMixed, nil
+	})
+	patches.ApplyMethod(reflect.TypeOf(&sio.Sdc{}), "FindVolumes", func(sdc *sio.Sdc) ([]*sio.Volume, error) {
+		return volumes[sdc.Sdc.ID], nil
+	})
+	t.Cleanup(patches.Reset)
+
+	cache := service.NewInventoryCache(time.Minute)
+	svc := &service.PowerFlexService{Logger: logrus.New(), InventoryCache: cache}
+	sdcs, err := svc.GetSDCs(context.Background(), client, finder)
+	require.NoError(t, err)
+
+	// an SDC is EC when every volume mapped to it is EC, otherwise it is read with the statistics API
+	got := map[string]string{}
+	for _, sdc := range sdcs {
+		got[sdc.GetSdc().Sdc.ID] = sdc.GetGen()
+	}
+	assert.Equal(t, map[string]string{"sdc-ec": types.GenTypeEC, "sdc-both": service.GenTypeMixed, "sdc-none": service.GenTypeMixed}, got)
+
+	// the volumes looked up for the gen type are cached for GetVolumes
+	cached, ok := cache.Volumes(client, sdcs[0].GetSdc())
+	require.True(t, ok)
+	assert.Equal(t, volumes[sdcs[0].GetSdc().Sdc.ID], cached)
+}
+
 func Test_GetSDCMeta(t *testing.T) {
 	type checkFn func(*testing.T, *service.SDCMeta, error)
 	check := func(fns ...checkFn) []checkFn { return fns }
@@ -673,8 +971,8 @@ func Test_GetSDCMeta(t *testing.T) {
 		}
 	}
 
-	tests := map[string]func(t *testing.T) (*sio.Sdc, []corev1.Node, []checkFn){
-		"success": func(*testing.T) (*sio.Sdc, []corev1.Node, []checkFn) {
+	tests := map[string]func(t *testing.T) (*sio.Sdc, string, []corev1.Node, []checkFn){
+		"success": func(*testing.T) (*sio.Sdc, string, []corev1.Node, []checkFn) {
 			sdc := &sio.Sdc{
 				Sdc: &types.Sdc{
 					SdcIP:   "1.2.3.4",
@@ -696,9 +994,9 @@ func Test_GetSDCMeta(t *testing.T) {
 				IP:      "1.2.3.4",
 				SdcGUID: "guid-xyz-789",
 			}
-			return sdc, nodes, check(checkSdcMeta(expectedOutput))
+			return sdc, "", nodes, check(checkSdcMeta(expectedOutput))
 		},
-		"no-match": func(*testing.T) (*sio.Sdc, []corev1.Node, []checkFn) {
+		"no-match": func(*testing.T) (*sio.Sdc, string, []corev1.Node, []checkFn) {
 			sdc := &sio.Sdc{
 				Sdc: &types.Sdc{
 					SdcIP:   "5.6.7.8",
@@ -720,14 +1018,64 @@ func Test_GetSDCMeta(t *testing.T) {
 				IP:      "5.6.7.8",
 				SdcGUID: "guid-abc-123",
 			}
-			return sdc, nodes, check(checkSdcMeta(expectedOutput))
+			return sdc, "", nodes, check(checkSdcMeta(expectedOutput))
+		},
+		"node of the csinode": func(*testing.T) (*sio.Sdc, string, []corev1.Node, []checkFn) {
+			// the SDC uses a storage network address that isn't an address of the node
+			sdc := &sio.Sdc{
+				Sdc: &types.Sdc{
+					SdcIP:   "192.168.10.4",
+					ID:      "sdc-id-123",
+					SdcGUID: "guid-xyz-789",
+				},
+			}
+			nodes := []corev1.Node{
+				{
+					ObjectMeta: metav1.ObjectMeta{Name: "node1"},
+					Status: corev1.NodeStatus{
+						Addresses: []corev1.NodeAddress{{Address: "1.2.3.4"}},
+					},
+				},
+			}
+			expectedOutput := &service.SDCMeta{
+				Name:    "node1",
+				ID:      "sdc-id-123",
+				IP:      "192.168.10.4",
+				SdcGUID: "guid-xyz-789",
+			}
+			return sdc, "node1", nodes, check(checkSdcMeta(expectedOutput))
+		},
+		"any address of the sdc": func(*testing.T) (*sio.Sdc, string, []corev1.Node, []checkFn) {
+			sdc := &sio.Sdc{
+				Sdc: &types.Sdc{
+					SdcIP:   "192.168.10.4",
+					SdcIPs:  []string{"192.168.10.4", "1.2.3.4"},
+					ID:      "sdc-id-123",
+					SdcGUID: "guid-xyz-789",
+				},
+			}
+			nodes := []corev1.Node{
+				{
+					ObjectMeta: metav1.ObjectMeta{Name: "node1"},
+					Status: corev1.NodeStatus{
+						Addresses: []corev1.NodeAddress{{Address: "1.2.3.4"}},
+					},
+				},
+			}
+			expectedOutput := &service.SDCMeta{
+				Name:    "node1",
+				ID:      "sdc-id-123",
+				IP:      "192.168.10.4",
+				SdcGUID: "guid-xyz-789",
+			}
+			return sdc, "", nodes, check(checkSdcMeta(expectedOutput))
 		},
 	}
 
 	for name, tc := range tests {
 		t.Run(name, func(t *testing.T) {
-			sdc, nodes, checks := tc(t)
-			sdcMeta, err := service.GetSDCMeta(sdc, nodes)
+			sdc, nodeName, nodes, checks := tc(t)
+			sdcMeta, err := service.GetSDCMeta(sdc, nodeName, nodes)
 			for _, c := range checks {
 				c(t, sdcMeta, err)
 			}
@@ -735,14 +1083,14 @@ func Test_GetSDCMeta(t *testing.T) {
 	}
 
 	t.Run("nil sdc", func(t *testing.T) {
-		meta, err := service.GetSDCMeta(nil, nil)
+		meta, err := service.GetSDCMeta(nil, "", nil)
 		require.Error(t, err)
 		assert.Nil(t, meta)
 	})
 
 	t.Run("unsupported sdc type", func(t *testing.T) {
 		type bogus struct{}
-		meta, err := service.GetSDCMeta(bogus{}, nil) // anything not *sio.Sdc triggers default
+		meta, err := service.GetSDCMeta(bogus{}, "", nil) // anything not *sio.Sdc triggers default
 		require.Error(t, err)
 		assert.Nil(t, meta)
 		assert.Contains(t, err.Error(), "unsupported sdc type")
@@ -1112,6 +1460,7 @@ func Test_GetStoragePoolStatistics(t *testing.T) {
 			}
 
 			metrics.EXPECT().RecordCapacity(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Times(2)
+			metrics.EXPECT().RecordPoolEfficiency(gomock.Any(), gomock.Any(), gomock.Any()).Return(nil).Times(2)
 
 			service := service.PowerFlexService{MetricsWrapper: metrics}
 			return setup{
@@ -1155,6 +1504,7 @@ func Test_GetStoragePoolStatistics(t *testing.T) {
 
 			service := service.PowerFlexService{MetricsWrapper: metrics}
 			metrics.EXPECT().RecordCapacity(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Times(1)
+			metrics.EXPECT().RecordPoolEfficiency(gomock.Any(), gomock.Any(), gomock.Any()).Return(nil).Times(1)
 			return setup{
 				Service: &service,
 			}, scMetas, ctrl
@@ -1237,6 +1587,9 @@ func Test_GetStoragePoolStatistics(t *testing.T) {
 			metrics.EXPECT().
 				RecordCapacity(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).
 				Times(1)
+			metrics.EXPECT().
+				RecordPoolEfficiency(gomock.Any(), gomock.Any(), gomock.Any()).
+				Times(1)
 
 			svc := service.PowerFlexService{MetricsWrapper: metrics}
 			return setup{Service: &svc}, scMetas, ctrl
@@ -1509,6 +1862,74 @@ func Test_GetVolumes(t *testing.T) {
 			return svc, client, []service.SdcMetricsRetriever{r}, check(hasError), ctrl, patches
 		},
 
+		"mixed: medium granularity volumes use the statistics API and EC volumes the metrics API": func(t *testing.T) (*service.PowerFlexService, service.PowerFlexClient, []service.SdcMetricsRetriever, []checkFn, *gomock.Controller, *gomonkey.Patches) {
+			ctrl := gomock.NewController(t)
+			client := mocks.NewMockPowerFlexClient(ctrl)
+
+			stats := mocks.NewMockStatisticsGetter(ctrl)
+			sdc := &sio.Sdc{Sdc: &types.Sdc{ID: "sdc-mixed", SdcIP: "1.1.1.12"}}
+			r := newSdcRetriever(t, ctrl, stats, "v1", sdc)
+
+			patches := gomonkey.NewPatches()
+			patches.ApplyMethod(reflect.TypeOf(&sio.Sdc{}), "FindVolumes", func(_ *sio.Sdc) ([]*sio.Volume, error) {
+				return []*sio.Volume{ecVol1, vol1, ecVol2, vol2}, nil
+			})
+			t.Cleanup(patches.Reset)
+
+			stats.EXPECT().GetVolumeMetrics().Return([]*types.SdcVolumeMetrics{vm1, vm2}, nil).Times(1)
+			client.EXPECT().
+				GetMetrics("volume", []string{"ec1", "ec2"}).
+				Return(&types.MetricsResponse{Resources: []types.Resource{
+					{ID: "ec1", Metrics: []types.Metric{{Name: "host_read_iops", Values: []float64{111}}}},
+					{ID: "ec2", Metrics: []types.Metric{{Name: "host_read_iops", Values: []float64{222}}}},
+				}}, nil).
+				Times(1)
+
+			checkGens := func(t *testing.T, out []*service.VolumeMetaMetrics, err error) {
+				require.NoError(t, err)
+				byID := map[string]*service.VolumeMetaMetrics{}
+				for _, m := range out {
+					byID[m.ID] = m
+				}
+				assert.Equal(t, types.GenTypeEC, byID["ec1"].GenType)
+				assert.Equal(t, float64(111), byID["ec1"].HostReadIOPS)
+				assert.Equal(t, float64(222), byID["ec2"].HostReadIOPS)
+				assert.Empty(t, byID["1"].GenType)
+				assert.Equal(t, bwc, byID["1"].ReadBwc)
+				assert.Equal(t, bwc, byID["2"].WriteLatencyBwc)
+			}
+
+			svc := &service.PowerFlexService{Logger: logrus.New()}
+			return svc, client, []service.SdcMetricsRetriever{r}, check(noErrorAndLen(4), checkGens), ctrl, patches
+		},
+
+		"EC SDC: volumes that report a medium granularity gen type use the statistics API": func(t *testing.T) (*service.PowerFlexService, service.PowerFlexClient, []service.SdcMetricsRetriever, []checkFn, *gomock.Controller, *gomonkey.Patches) {
+			ctrl := gomock.NewController(t)
+			client := mocks.NewMockPowerFlexClient(ctrl)
+
+			stats := mocks.NewMockStatisticsGetter(ctrl)
+			sdc := &sio.Sdc{Sdc: &types.Sdc{ID: "sdc-ec-gen1", SdcIP: "1.1.1.13"}}
+			r := newSdcRetriever(t, ctrl, stats, types.GenTypeEC, sdc)
+
+			gen1Vol := &sio.Volume{Volume: &types.Volume{ID: "1", Name: "vol-1", GenType: "v1"}}
+			// the gen type of a volume that doesn't report one is the gen type of its SDC
+			ecVol := &sio.Volume{Volume: &types.Volume{ID: "ec3", Name: "ec-3"}}
+			patches := gomonkey.NewPatches()
+			patches.ApplyMethod(reflect.TypeOf(&sio.Sdc{}), "FindVolumes", func(_ *sio.Sdc) ([]*sio.Volume, error) {
+				return []*sio.Volume{gen1Vol, ecVol}, nil
+			})
+			t.Cleanup(patches.Reset)
+
+			stats.EXPECT().GetVolumeMetrics().Return([]*types.SdcVolumeMetrics{vm1}, nil).Times(1)
+			client.EXPECT().
+				GetMetrics("volume", []string{"ec3"}).
+				Return(&types.MetricsResponse{Resources: []types.Resource{{ID: "ec3"}}}, nil).
+				Times(1)
+
+			svc := &service.PowerFlexService{Logger: logrus.New()}
+			return svc, client, []service.SdcMetricsRetriever{r}, check(noErrorAndLen(2)), ctrl, patches
+		},
+
 		"EC: metrics success -> populates & normalizes values": func(t *testing.T) (*service.PowerFlexService, service.PowerFlexClient, []service.SdcMetricsRetriever, []checkFn, *gomock.Controller, *gomonkey.Patches) {
 			ctrl := gomock.NewController(t)
 			client := mocks.NewMockPowerFlexClient(ctrl)
@@ -1597,6 +2018,13 @@ func Test_GetVolumes(t *testing.T) {
 	}
 }
 
+// filteringVolumeFinder is a VolumeFinder with an active volume filter
+type filteringVolumeFinder struct {
+	service.VolumeFinder
+}
+
+func (filteringVolumeFinder) Filtering() bool { return true }
+
 func Test_ExportVolumeStatistics(t *testing.T) {
 	type setup struct {
 		Service *service.PowerFlexService
@@ -1641,6 +2069,22 @@ func Test_ExportVolumeStatistics(t *testing.T) {
 				Service: &svc,
 			}, nil, volFinder, ctrl
 		},
+		"volume filter skips volumes without a persistent volume": func(*testing.T) (setup, []*service.VolumeMetaMetrics, service.VolumeFinder, *gomock.Controller) {
+			ctrl := gomock.NewController(t)
+			metrics := mocks.NewMockMetricsRecorder(ctrl)
+			volFinder := mocks.NewMockVolumeFinder(ctrl)
+
+			vols := []*service.VolumeMetaMetrics{{ID: "vol1", Name: "k8s-volume-1"}, {ID: "vol2", Name: "k8s-volume-2"}}
+			volFinder.EXPECT().GetPersistentVolumes().Return([]k8s.VolumeInfo{{StorageSystemVolumeName: "k8s-volume-1", PersistentVolume: "pv-1"}}, nil)
+
+			svc := service.PowerFlexService{MetricsWrapper: metrics}
+			metrics.EXPECT().Record(gomock.Any(), gomock.Cond(func(meta interface{}) bool {
+				return meta.(*service.VolumeMeta).PersistentVolumeName == "pv-1"
+			}), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Times(1)
+			return setup{
+				Service: &svc,
+			}, vols, filteringVolumeFinder{volFinder}, ctrl
+		},
 		"error recording": func(*testing.T) (setup, []*service.VolumeMetaMetrics, service.VolumeFinder, *gomock.Controller) {
 			ctrl := gomock.NewController(t)
 			metrics := mocks.NewMockMetricsRecorder(ctrl)
@@ -1670,6 +2114,66 @@ func Test_ExportVolumeStatistics(t *testing.T) {
 	}
 }
 
+// countingClient is a PowerFlexClient that counts the requests made to the gateway while discovering SDCs
+type countingClient struct {
+	service.PowerFlexClient
+	systems []*types.System
+	calls   int
+}
+
+func (c *countingClient) GetInstance(string) ([]*types.System, error) {
+	c.calls++
+	return c.systems, nil
+}
+
+func (c *countingClient) FindSystem(string, string, string) (*sio.System, error) {
+	c.calls++
+	return nil, nil
+}
+
+func Benchmark_GetSDCs(b *testing.B) {
+	numOfSDCs := 400
+	b.Logf("For %d CSINodes on one system\n", numOfSDCs)
+	b.ReportAllocs()
+
+	ctrl := gomock.NewController(b)
+	b.Cleanup(ctrl.Finish)
+
+	guids := make([]string, 0, numOfSDCs)
+	system := &fakeSystemFinderTarget{byGUID: make(map[string]*sio.Sdc, numOfSDCs)}
+	for i := 0; i < numOfSDCs; i++ {
+		guid := fmt.Sprintf("guid-%d", i)
+		guids = append(guids, guid)
+		system.byGUID[guid] = &sio.Sdc{Sdc: &types.Sdc{SdcGUID: guid, ID: fmt.Sprintf("sdc-%d", i), SdcIP: "1.2.3.4"}}
+	}
+	finder := mocks.NewMockSDCFinder(ctrl)
+	finder.EXPECT().GetSDCGuids().Return(guids, nil).AnyTimes()
+	client := &countingClient{systems: []*types.System{{Name: "sys1", ID: "sid1"}}}
+
+	patches := gomonkey.NewPatches()
+	patches.ApplyFunc(service.SystemFinder, func(service.PowerFlexClient, string, string, string) (service.PowerFlexSystem, error) {
+		return system, nil
+	})
+	patches.ApplyFunc(service.GetGenType, func(*sio.System) (string, error) {
+		return "v1", nil
+	})
+	b.Cleanup(patches.Reset)
+
+	svc := &service.PowerFlexService{Logger: logrus.New()}
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		sdcs, err := svc.GetSDCs(context.Background(), client, finder)
+		if err != nil {
+			b.Fatal(err)
+		}
+		if len(sdcs) != numOfSDCs {
+			b.Fatalf("expected %d sdcs, got %d", numOfSDCs, len(sdcs))
+		}
+	}
+	b.ReportMetric(float64(client.calls+system.calls)/float64(b.N), "requests/op")
+}
+
 func Benchmark_GetVolumes(b *testing.B) {
 	numOfSDCs, sdcQueryTime := 500, "100ms"
 	b.Logf("For %d SDCs and assuming each sdc query takes %s\n", numOfSDCs, sdcQueryTime)
@@ -2056,6 +2560,39 @@ func Test_GetLogicalProvisioned(t *testing.T) {
 	}
 }
 
+func Test_GetSnapshotCapacityInUse(t *testing.T) {
+	tt := []struct {
+		Name             string
+		Statistics       *types.Statistics
+		ExpectedCapacity float64
+	}{
+		{
+			"success",
+			&types.Statistics{
+				SnapCapacityInUseOccupiedInKb: 12582912,
+			},
+			12,
+		},
+		{
+			"nil statistics",
+			nil,
+			0.0,
+		},
+		{
+			"no data",
+			&types.Statistics{},
+			0.0,
+		},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.Name, func(t *testing.T) {
+			capacity := service.GetSnapshotCapacityInUse(tc.Statistics)
+			assert.InDelta(t, tc.ExpectedCapacity, capacity, 0.001)
+		})
+	}
+}
+
 func TestExportTopologyMetrics(t *testing.T) {
 	ctrl := gomock.NewController(t)
 	defer ctrl.Finish()
@@ -2095,7 +2632,7 @@ func TestExportTopologyMetrics(t *testing.T) {
 }
 
 func Test_GetGenType(t *testing.T) {
-	t.Run("success - returns first PD GenType", func(t *testing.T) {
+	t.Run("success - returns the GenType of the first PD that is not EC", func(t *testing.T) {
 		sys := &sio.System{}
 		pds := []*types.ProtectionDomain{
 			{ID: "pd-1", Name: "pd1", GenType: "v2"},
@@ -2110,7 +2647,43 @@ func Test_GetGenType(t *testing.T) {
 
 		got, err := service.GetGenType(sys)
 		require.NoError(t, err)
-		assert.Equal(t, "v2", got, "should return the GenType of the first PD")
+		assert.Equal(t, "v2", got, "should return the GenType of the first PD that is not EC")
+	})
+
+	t.Run("mixed EC and medium granularity PDs - returns mixed", func(t *testing.T) {
+		sys := &sio.System{}
+		pds := []*types.ProtectionDomain{
+			{ID: "pd-1", Name: "pd1", GenType: types.GenTypeEC},
+			{ID: "pd-2", Name: "pd2", GenType: "v1"},
+		}
+		patches := gomonkey.NewPatches()
+		defer patches.Reset()
+		patches.ApplyMethod(reflect.TypeOf(sys), "GetProtectionDomain",
+			func(_ *sio.System, _ string) ([]*types.ProtectionDomain, error) {
+				return pds, nil
+			})
+
+		got, err := service.GetGenType(sys)
+		require.NoError(t, err)
+		assert.Equal(t, service.GenTypeMixed, got)
+	})
+
+	t.Run("only EC PDs - returns EC", func(t *testing.T) {
+		sys := &sio.System{}
+		pds := []*types.ProtectionDomain{
+			{ID: "pd-1", Name: "pd1", GenType: types.GenTypeEC},
+			{ID: "pd-2", Name: "pd2", GenType: types.GenTypeEC},
+		}
+		patches := gomonkey.NewPatches()
+		defer patches.Reset()
+		patches.ApplyMethod(reflect.TypeOf(sys), "GetProtectionDomain",
+			func(_ *sio.System, _ string) ([]*types.ProtectionDomain, error) {
+				return pds, nil
+			})
+
+		got, err := service.GetGenType(sys)
+		require.NoError(t, err)
+		assert.Equal(t, types.GenTypeEC, got)
 	})
 
 	t.Run("empty list - returns empty string with no error", func(t *testing.T) {
@@ -2454,3 +3027,290 @@ func TestGetStoragePoolStatistics_ECEdgeCases(t *testing.T) {
 		})
 	}
 }
+
+func Test_GetStoragePoolStatistics_MixedGenTypes(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	client := mocks.NewMockPowerFlexClient(ctrl)
+	metrics := mocks.NewMockMetricsRecorder(ctrl)
+
+	// the medium granularity pool is read with the statistics API and only the EC pool with the metrics API
+	gen1Stats := mocks.NewMockStoragePoolStatisticsGetter(ctrl)
+	gen1Stats.EXPECT().GetStatistics().Return(&types.Statistics{NetUnusedCapacityInKb: 2 * 1024 * 1024}, nil).Times(1)
+	client.EXPECT().GetMetrics("storage_pool", []string{"pool-ec"}).Return(&types.MetricsResponse{Resources: []types.Resource{
+		{ID: "pool-ec", Metrics: []types.Metric{{Name: "physical_total", Values: []float64{3 * (1 << 30)}}}},
+	}}, nil).Times(1)
+
+	var mu sync.Mutex
+	var got []float64
+	metrics.EXPECT().RecordCapacity(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).
+		DoAndReturn(func(_ context.Context, _ interface{}, total, _, _, _ float64) error {
+			mu.Lock()
+			defer mu.Unlock()
+			got = append(got, total)
+			return nil
+		}).Times(2)
+	metrics.EXPECT().RecordPoolEfficiency(gomock.Any(), gomock.Any(), gomock.Any()).Return(nil).Times(2)
+
+	scMetas := []service.StorageClassMeta{{
+		ID:   "1",
+		Name: "class-a",
+		StoragePools: map[string]service.StoragePoolMetricsRetriever{
+			"pool-mg": ecPoolRetriever{client: client, stats: gen1Stats, gen: "v1"},
+			"pool-ec": ecPoolRetriever{client: client, stats: mocks.NewMockStoragePoolStatisticsGetter(ctrl), gen: types.GenTypeEC},
+		},
+	}}
+	svc := &service.PowerFlexService{MetricsWrapper: metrics, Logger: logrus.New()}
+	svc.GetStoragePoolStatistics(context.Background(), scMetas)
+	assert.ElementsMatch(t, []float64{2, 3}, got)
+}
+
+func Test_GetStoragePoolStatistics_Efficiency(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	client := mocks.NewMockPowerFlexClient(ctrl)
+	metrics := mocks.NewMockMetricsRecorder(ctrl)
+
+	gen1Stats := mocks.NewMockStoragePoolStatisticsGetter(ctrl)
+	gen1Stats.EXPECT().GetStatistics().Return(&types.Statistics{
+		NetUnusedCapacityInKb:         3 * 1024 * 1024,
+		NetUserDataCapacityInKb:       1 * 1024 * 1024,
+		VolumeAddressSpaceInKb:        10 * 1024 * 1024,
+		SnapCapacityInUseOccupiedInKb: 512 * 1024,
+	}, nil).Times(1)
+	client.EXPECT().GetMetrics("storage_pool", []string{"pool-ec"}).Return(&types.MetricsResponse{Resources: []types.Resource{{ID: "pool-ec", Metrics: []types.Metric{
+		{Name: "physical_total", Values: []float64{8 * (1 << 30)}},
+		{Name: "logical_provisioned", Values: []float64{12 * (1 << 30)}},
+		{Name: "compression_ratio", Values: []float64{1.8}},
+		{Name: "data_reduction_ratio", Values: []float64{2.5}},
+		{Name: "snapshot_used", Values: []float64{2 * (1 << 30)}},
+	}}}}, nil).Times(1)
+
+	metrics.EXPECT().RecordCapacity(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Return(nil).Times(2)
+	var mu sync.Mutex
+	got := map[string]service.PoolEfficiencyRecord{}
+	metrics.EXPECT().RecordPoolEfficiency(gomock.Any(), gomock.Any(), gomock.Any()).
+		DoAndReturn(func(_ context.Context, meta interface{}, efficiency *service.PoolEfficiencyRecord) error {
+			mu.Lock()
+			defer mu.Unlock()
+			scMeta := meta.(service.StorageClassMeta)
+			require.Len(t, scMeta.StoragePools, 1)
+			for pool := range scMeta.StoragePools {
+				got[pool] = *efficiency
+			}
+			return nil
+		}).Times(2)
+
+	scMetas := []service.StorageClassMeta{{
+		ID:   "1",
+		Name: "class-a",
+		StoragePools: map[string]service.StoragePoolMetricsRetriever{
+			"pool-mg": ecPoolRetriever{client: client, stats: gen1Stats, gen: "v1"},
+			"pool-ec": ecPoolRetriever{client: client, stats: mocks.NewMockStoragePoolStatisticsGetter(ctrl), gen: types.GenTypeEC},
+		},
+	}}
+	svc := &service.PowerFlexService{MetricsWrapper: metrics, Logger: logrus.New()}
+	svc.GetStoragePoolStatistics(context.Background(), scMetas)
+
+	// Gen1 statistics don't report the compression and data reduction ratios
+	assert.Equal(t, service.PoolEfficiencyRecord{ThinOvercommit: 2.5, SnapshotCapacityGigabytes: 0.5}, got["pool-mg"])
+	assert.Equal(t, service.PoolEfficiencyRecord{CompressionRatio: 1.8, DataReductionRatio: 2.5, ThinOvercommit: 1.5, SnapshotCapacityGigabytes: 2}, got["pool-ec"])
+}
+
+func Test_GetSDCStatistics_NodeAttributes(t *testing.T) {
+	nodes := []corev1.Node{
+		{
+			ObjectMeta: metav1.ObjectMeta{Name: "worker-1", Labels: map[string]string{"topology.kubernetes.io/zone": "zone-a", "rack": "r1"}},
+			Spec:       corev1.NodeSpec{Unschedulable: true},
+			Status: corev1.NodeStatus{
+				Addresses:  []corev1.NodeAddress{{Address: "1.2.3.4"}},
+				Conditions: []corev1.NodeCondition{{Type: corev1.NodeReady, Status: corev1.ConditionFalse}},
+			},
+		},
+	}
+
+	tests := map[string]struct {
+		service  service.PowerFlexService
+		expected map[string]map[string]string
+	}{
+		"no node attributes by default": {
+			expected: map[string]map[string]string{"sdc-1": nil, "sdc-2": nil},
+		},
+		"node labels": {
+			service: service.PowerFlexService{NodeLabels: &k8s.LabelAllowlist{Keys: k8s.LabelKeys{Node: []string{"topology.kubernetes.io/zone", "rack"}}}},
+			expected: map[string]map[string]string{
+				"sdc-1": {"label_topology_kubernetes_io_zone": "zone-a", "label_rack": "r1"},
+				"sdc-2": {"label_topology_kubernetes_io_zone": "", "label_rack": ""},
+			},
+		},
+	}
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			metrics := mocks.NewMockMetricsRecorder(ctrl)
+			sdc1 := mocks.NewMockStatisticsGetter(ctrl)
+			sdc1.EXPECT().GetStatistics().Return(&types.SdcStatistics{}, nil)
+			sdc2 := mocks.NewMockStatisticsGetter(ctrl)
+			sdc2.EXPECT().GetStatistics().Return(&types.SdcStatistics{}, nil)
+			retrievers := []service.SdcMetricsRetriever{
+				newSdcRetriever(t, ctrl, sdc1, "v1", &sio.Sdc{Sdc: &types.Sdc{SdcIP: "1.2.3.4", ID: "sdc-1"}}),
+				newSdcRetriever(t, ctrl, sdc2, "v1", &sio.Sdc{Sdc: &types.Sdc{SdcIP: "1.2.3.5", ID: "sdc-2"}}),
+			}
+
+			var mu sync.Mutex
+			got := map[string]map[string]string{}
+			metrics.EXPECT().Record(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).
+				DoAndReturn(func(_ context.Context, meta interface{}, _, _, _, _, _, _ float64) error {
+					mu.Lock()
+					defer mu.Unlock()
+					sdcMeta := meta.(*service.SDCMeta)
+					got[sdcMeta.ID] = sdcMeta.Attributes
+					return nil
+				}).Times(2)
+
+			svc := tc.service
+			svc.MetricsWrapper = metrics
+			svc.Logger = logrus.New()
+			svc.GetSDCStatistics(context.Background(), nodes, retrievers)
+			assert.Equal(t, tc.expected, got)
+		})
+	}
+}
+
+func Test_GetSDCStatistics_NodeConditions(t *testing.T) {
+	nodes := []corev1.Node{
+		{
+			ObjectMeta: metav1.ObjectMeta{Name: "worker-1"},
+			Spec:       corev1.NodeSpec{Unschedulable: true},
+			Status: corev1.NodeStatus{
+				Addresses:  []corev1.NodeAddress{{Address: "1.2.3.4"}},
+				Conditions: []corev1.NodeCondition{{Type: corev1.NodeReady, Status: corev1.ConditionFalse}},
+			},
+		},
+		{
+			ObjectMeta: metav1.ObjectMeta{Name: "worker-2"},
+			Status: corev1.NodeStatus{
+				Addresses:  []corev1.NodeAddress{{Address: "1.2.3.5"}},
+				Conditions: []corev1.NodeCondition{{Type: corev1.NodeReady, Status: corev1.ConditionTrue}},
+			},
+		},
+	}
+
+	ctrl := gomock.NewController(t)
+	metrics := mocks.NewMockMetricsRecorder(ctrl)
+	var retrievers []service.SdcMetricsRetriever
+	for _, sdc := range []*types.Sdc{{SdcIP: "1.2.3.4", ID: "sdc-1"}, {SdcIP: "1.2.3.5", ID: "sdc-2"}, {SdcIP: "1.2.3.6", ID: "sdc-3"}} {
+		stats := mocks.NewMockStatisticsGetter(ctrl)
+		stats.EXPECT().GetStatistics().Return(&types.SdcStatistics{}, nil)
+		retrievers = append(retrievers, newSdcRetriever(t, ctrl, stats, "v1", &sio.Sdc{Sdc: sdc}))
+	}
+
+	var mu sync.Mutex
+	attributes := map[string]map[string]string{}
+	conditions := map[string]service.NodeConditionsRecord{}
+	metrics.EXPECT().Record(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).
+		DoAndReturn(func(_ context.Context, meta interface{}, _, _, _, _, _, _ float64) error {
+			mu.Lock()
+			defer mu.Unlock()
+			sdcMeta := meta.(*service.SDCMeta)
+			attributes[sdcMeta.ID] = sdcMeta.Attributes
+			return nil
+		}).Times(3)
+	metrics.EXPECT().RecordNodeConditions(gomock.Any(), gomock.Any(), gomock.Any()).
+		DoAndReturn(func(_ context.Context, meta interface{}, record *service.NodeConditionsRecord) error {
+			mu.Lock()
+			defer mu.Unlock()
+			conditions[meta.(*service.SDCMeta).ID] = *record
+			return nil
+		}).Times(2)
+
+	svc := service.PowerFlexService{MetricsWrapper: metrics, Logger: logrus.New(), NodeConditions: true}
+	svc.GetSDCStatistics(context.Background(), nodes, retrievers)
+
+	// the conditions are series of their own, the SDC I/O series keep only the node labels
+	assert.Equal(t, map[string]map[string]string{"sdc-1": nil, "sdc-2": nil, "sdc-3": nil}, attributes)
+	// an SDC that isn't mapped to a node has no conditions
+	assert.Equal(t, map[string]service.NodeConditionsRecord{
+		"sdc-1": {Ready: false, Cordoned: true},
+		"sdc-2": {Ready: true, Cordoned: false},
+	}, conditions)
+}
+
+func Test_GetSDCStatistics_UnmappedSDCs(t *testing.T) {
+	nodes := []corev1.Node{
+		{
+			ObjectMeta: metav1.ObjectMeta{Name: "worker-1", Labels: map[string]string{"rack": "r1"}},
+			Status:     corev1.NodeStatus{Addresses: []corev1.NodeAddress{{Address: "10.0.0.1"}}},
+		},
+		{
+			ObjectMeta: metav1.ObjectMeta{Name: "worker-2", Labels: map[string]string{"rack": "r2"}},
+			Status:     corev1.NodeStatus{Addresses: []corev1.NodeAddress{{Address: "10.0.0.2"}}},
+		},
+	}
+
+	ctrl := gomock.NewController(t)
+	newRetriever := func(id string, ip string, nodeName string) service.SdcMetricsRetriever {
+		statsGetter := mocks.NewMockStatisticsGetter(ctrl)
+		statsGetter.EXPECT().GetStatistics().Return(&types.SdcStatistics{}, nil)
+		return &mockSdcMetricsRetriever{
+			sg:       statsGetter,
+			gen:      "v1",
+			sdc:      &sio.Sdc{Sdc: &types.Sdc{SystemID: "system-1", SdcIP: ip, ID: id}},
+			c:        mocks.NewMockPowerFlexClient(ctrl),
+			nodeName: nodeName,
+		}
+	}
+	retrievers := []service.SdcMetricsRetriever{
+		// mapped by its CSINode, on a storage network address
+		newRetriever("sdc-1", "192.168.10.1", "worker-1"),
+		// mapped by its address
+		newRetriever("sdc-2", "10.0.0.2", ""),
+		// not mapped
+		newRetriever("sdc-3", "192.168.10.3", ""),
+	}
+
+	var mu sync.Mutex
+	got := map[string]*service.SDCMeta{}
+	metrics := mocks.NewMockMetricsRecorder(ctrl)
+	metrics.EXPECT().Record(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).
+		DoAndReturn(func(_ context.Context, meta interface{}, _, _, _, _, _, _ float64) error {
+			mu.Lock()
+			defer mu.Unlock()
+			sdcMeta := meta.(*service.SDCMeta)
+			got[sdcMeta.ID] = sdcMeta
+			return nil
+		}).Times(3)
+
+	reader := sdkmetric.NewManualReader()
+	provider := sdkmetric.NewMeterProvider(sdkmetric.WithReader(reader))
+	svc := service.PowerFlexService{
+		MetricsWrapper: metrics,
+		Logger:         logrus.New(),
+		NodeLabels:     &k8s.LabelAllowlist{Keys: k8s.LabelKeys{Node: []string{"rack"}}},
+		UnmappedSDCs:   &service.UnmappedSDCs{Meter: provider.Meter("test")},
+	}
+	svc.GetSDCStatistics(context.Background(), nodes, retrievers)
+
+	assert.Equal(t, "worker-1", got["sdc-1"].Name)
+	assert.Equal(t, map[string]string{"label_rack": "r1"}, got["sdc-1"].Attributes)
+	assert.Equal(t, "worker-2", got["sdc-2"].Name)
+	assert.Equal(t, map[string]string{"label_rack": "r2"}, got["sdc-2"].Attributes)
+	assert.Empty(t, got["sdc-3"].Name)
+	assert.Equal(t, map[string]string{"label_rack": ""}, got["sdc-3"].Attributes)
+	assert.Equal(t, map[string]int64{"system-1": 1}, svc.UnmappedSDCs.Counts())
+
+	var rm metricdata.ResourceMetrics
+	require.NoError(t, reader.Collect(context.Background(), &rm))
+	require.Len(t, rm.ScopeMetrics, 1)
+	require.Len(t, rm.ScopeMetrics[0].Metrics, 1)
+	assert.Equal(t, "powerflex_unmapped_sdcs", rm.ScopeMetrics[0].Metrics[0].Name)
+	gauge := rm.ScopeMetrics[0].Metrics[0].Data.(metricdata.Gauge[int64])
+	require.Len(t, gauge.DataPoints, 1)
+	storageSystemID, _ := gauge.DataPoints[0].Attributes.Value("StorageSystemID")
+	assert.Equal(t, "system-1", storageSystemID.AsString())
+	assert.Equal(t, int64(1), gauge.DataPoints[0].Value)
+
+	// a nil counter discards the counts
+	svc.UnmappedSDCs = nil
+	retrievers = []service.SdcMetricsRetriever{newRetriever("sdc-3", "192.168.10.3", "")}
+	metrics.EXPECT().Record(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Return(nil)
+	assert.NotPanics(t, func() { svc.GetSDCStatistics(context.Background(), nodes, retrievers) })
+}
@@ -0,0 +1,327 @@
+/*
+ Copyright (c) 2025 Dell Inc. or its subsidiaries. All Rights Reserved.
+
+ Licensed under the Apache License, Version 2.0 (the "License");
+ you may not use this file except in compliance with the License.
+ You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	sio "github.com/dell/goscaleio"
+	types "github.com/dell/goscaleio/types/v1"
+	"github.com/dell/karavi-metrics-powerflex/internal/telemetry"
+	"github.com/sirupsen/logrus"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
+)
+
+const (
+	// DefaultReauthAttempts is the number of times a session will try to log in again before giving up
+	DefaultReauthAttempts = 3
+	// DefaultReauthInitialBackoff is the wait before the second login attempt
+	DefaultReauthInitialBackoff = time.Second
+	// DefaultReauthMaxBackoff caps the wait between login attempts
+	DefaultReauthMaxBackoff = 30 * time.Second
+)
+
+// ClientUnwrapper is implemented by PowerFlexClient decorators that wrap another client
+type ClientUnwrapper interface {
+	Unwrap() PowerFlexClient
+}
+
+// GatewayCaller is implemented by PowerFlexClient decorators that also apply to the requests of the goscaleio
+// systems, SDCs and storage pools built from the client, which go to the gateway without calling the PowerFlexClient
+type GatewayCaller interface {
+	CallGateway(ctx context.Context, call func() error) error
+}
+
+var (
+	_ PowerFlexClient = (*SessionClient)(nil)
+	_ GatewayCaller   = (*SessionClient)(nil)
+)
+
+// SessionClient wraps a PowerFlexClient and re-authenticates with the stored credentials
+// when the gateway rejects a request because the session token expired or the gateway restarted.
+// Requests made through goscaleio objects share the session when they are run with CallGateway.
+type SessionClient struct {
+	PowerFlexClient
+	ConfigConnect   *sio.ConfigConnect
+	StorageSystemID string
+	Logger          *logrus.Logger
+	Meter           metric.Meter
+	MaxAttempts     int
+	InitialBackoff  time.Duration
+	MaxBackoff      time.Duration
+
+	mu            sync.Mutex
+	generation    uint64
+	login         *loginAttempt
+	counterOnce   sync.Once
+	reauthCounter metric.Int64Counter
+}
+
+// loginAttempt is a login in progress, which the callers that need a new session wait on
+type loginAttempt struct {
+	done chan struct{}
+	err  error
+}
+
+// Unwrap returns the client wrapped by the session
+func (c *SessionClient) Unwrap() PowerFlexClient {
+	return c.PowerFlexClient
+}
+
+// GetInstance calls GetInstance on the wrapped client, logging in again if the session expired
+func (c *SessionClient) GetInstance(href string) ([]*types.System, error) {
+	var systems []*types.System
+	err := c.withSession(func() (err error) {
+		systems, err = c.PowerFlexClient.GetInstance(href)
+		return err
+	})
+	return systems, err
+}
+
+// FindSystem calls FindSystem on the wrapped client, logging in again if the session expired.
+// goscaleio flattens the error of the request behind FindSystem into a string, so such a failure is only retried
+// if checking the session with GetInstance, which keeps the status code, logged in again.
+func (c *SessionClient) FindSystem(id string, name string, href string) (*sio.System, error) {
+	system, err := c.PowerFlexClient.FindSystem(id, name, href)
+	if err == nil {
+		return system, nil
+	}
+	if !mayBeUnauthorized(err) {
+		return nil, err
+	}
+
+	c.mu.Lock()
+	generation := c.generation
+	c.mu.Unlock()
+	if _, instanceErr := c.GetInstance(href); instanceErr != nil {
+		return nil, err
+	}
+	c.mu.Lock()
+	reauthenticated := c.generation != generation
+	c.mu.Unlock()
+	if !reauthenticated {
+		return nil, err
+	}
+	return c.PowerFlexClient.FindSystem(id, name, href)
+}
+
+// GetStoragePool calls GetStoragePool on the wrapped client, logging in again if the session expired
+func (c *SessionClient) GetStoragePool(href string) ([]*types.StoragePool, error) {
+	var pools []*types.StoragePool
+	err := c.withSession(func() (err error) {
+		pools, err = c.PowerFlexClient.GetStoragePool(href)
+		return err
+	})
+	return pools, err
+}
+
+// GetMetrics calls GetMetrics on the wrapped client, logging in again if the session expired
+func (c *SessionClient) GetMetrics(resource string, ids []string) (*types.MetricsResponse, error) {
+	var resp *types.MetricsResponse
+	err := c.withSession(func() (err error) {
+		resp, err = c.PowerFlexClient.GetMetrics(resource, ids)
+		return err
+	})
+	return resp, err
+}
+
+// CallGateway runs a request of a goscaleio object built from the client, logging in again if the session expired
+func (c *SessionClient) CallGateway(ctx context.Context, call func() error) error {
+	return c.withSession(func() error {
+		return CallGateway(ctx, c.PowerFlexClient, call)
+	})
+}
+
+// withSession runs call and, if it failed because the session is no longer valid, logs in again and retries once
+func (c *SessionClient) withSession(call func() error) error {
+	c.mu.Lock()
+	generation := c.generation
+	c.mu.Unlock()
+
+	err := call()
+	if err == nil || !IsUnauthorized(err) {
+		return err
+	}
+
+	if err := c.reauthenticate(generation); err != nil {
+		return err
+	}
+	return call()
+}
+
+// reauthenticate logs in to the gateway again. Concurrent callers that observed the same session generation
+// wait on the first caller instead of each sending their own login request. The lock isn't held while logging in,
+// so the requests that still have a valid session aren't held up by the backoff between attempts.
+func (c *SessionClient) reauthenticate(generation uint64) error {
+	if c.ConfigConnect == nil {
+		return errors.New("no credentials available to re-authenticate")
+	}
+
+	c.mu.Lock()
+	if c.generation != generation {
+		// another worker already refreshed the session
+		c.mu.Unlock()
+		return nil
+	}
+	if login := c.login; login != nil {
+		c.mu.Unlock()
+		<-login.done
+		return login.err
+	}
+	login := &loginAttempt{done: make(chan struct{})}
+	c.login = login
+	c.mu.Unlock()
+
+	login.err = c.authenticate()
+
+	c.mu.Lock()
+	if login.err == nil {
+		c.generation++
+	}
+	c.login = nil
+	c.mu.Unlock()
+	close(login.done)
+	return login.err
+}
+
+// authenticate logs in with the stored credentials, backing off between failed attempts
+func (c *SessionClient) authenticate() error {
+	attempts := c.MaxAttempts
+	if attempts <= 0 {
+		attempts = DefaultReauthAttempts
+	}
+	backoff := c.InitialBackoff
+	if backoff <= 0 {
+		backoff = DefaultReauthInitialBackoff
+	}
+	maxBackoff := c.MaxBackoff
+	if maxBackoff <= 0 {
+		maxBackoff = DefaultReauthMaxBackoff
+	}
+
+	var err error
+	for attempt := 1; attempt <= attempts; attempt++ {
+		configConnect := *c.ConfigConnect
+		_, err = c.PowerFlexClient.Authenticate(&configConnect)
+		if err == nil {
+			c.recordReauthentication("success")
+			telemetry.Logger(c.Logger).WithFields(logrus.Fields{
+				"storage_system_id": c.StorageSystemID,
+				"attempt":           attempt,
+			}).Info("re-authenticated to powerflex")
+			return nil
+		}
+
+		telemetry.Logger(c.Logger).WithError(err).WithFields(logrus.Fields{
+			"storage_system_id": c.StorageSystemID,
+			"attempt":           attempt,
+		}).Warn("re-authenticating to powerflex")
+
+		if attempt < attempts {
+			time.Sleep(backoff)
+			backoff *= 2
+			if backoff > maxBackoff {
+				backoff = maxBackoff
+			}
+		}
+	}
+
+	c.recordReauthentication("failure")
+	return fmt.Errorf("re-authenticating to powerflex %s: %w", c.StorageSystemID, err)
+}
+
+func (c *SessionClient) recordReauthentication(result string) {
+	if c.Meter == nil {
+		return
+	}
+	c.counterOnce.Do(func() {
+		counter, err := c.Meter.Int64Counter("powerflex_session_reauthentications_total")
+		if err != nil {
+			telemetry.Logger(c.Logger).WithError(err).Warn("creating re-authentication counter")
+			return
+		}
+		c.reauthCounter = counter
+	})
+	if c.reauthCounter == nil {
+		return
+	}
+	c.reauthCounter.Add(context.Background(), 1, metric.WithAttributes(
+		attribute.String("StorageSystemID", c.StorageSystemID),
+		attribute.String("Result", result),
+	))
+}
+
+// findSystemRequestError starts the flattened error goscaleio returns when the request behind FindSystem failed
+const findSystemRequestError = "err: problem getting instances"
+
+// mayBeUnauthorized returns true if err could hide an expired session: a status-less error of the request behind
+// FindSystem. An error with a status code, a system that wasn't found or an error of the decorators can't.
+func mayBeUnauthorized(err error) bool {
+	var apiErr *types.Error
+	var apiErrValue types.Error
+	if errors.As(err, &apiErr) || errors.As(err, &apiErrValue) {
+		return IsUnauthorized(err)
+	}
+	return strings.HasPrefix(err.Error(), findSystemRequestError)
+}
+
+// IsUnauthorized returns true if err indicates that the gateway rejected the session
+func IsUnauthorized(err error) bool {
+	if err == nil {
+		return false
+	}
+	var apiErr *types.Error
+	if errors.As(err, &apiErr) && apiErr.HTTPStatusCode == http.StatusUnauthorized {
+		return true
+	}
+	var apiErrValue types.Error
+	return errors.As(err, &apiErrValue) && apiErrValue.HTTPStatusCode == http.StatusUnauthorized
+}
+
+// CallGateway runs call through the decorators of client that implement GatewayCaller, or runs it directly
+// if there are none. It is used for goscaleio calls that go straight to the gateway instead of through the PowerFlexClient.
+func CallGateway(ctx context.Context, client PowerFlexClient, call func() error) error {
+	for client != nil {
+		if caller, ok := client.(GatewayCaller); ok {
+			return caller.CallGateway(ctx, call)
+		}
+		wrapper, ok := client.(ClientUnwrapper)
+		if !ok {
+			break
+		}
+		client = wrapper.Unwrap()
+	}
+	return call()
+}
+
+// UnwrapClient returns the innermost PowerFlexClient behind any decorators
+func UnwrapClient(client PowerFlexClient) PowerFlexClient {
+	for {
+		wrapper, ok := client.(ClientUnwrapper)
+		if !ok {
+			return client
+		}
+		client = wrapper.Unwrap()
+	}
+}
@@ -0,0 +1,308 @@
+/*
+ Copyright (c) 2025 Dell Inc. or its subsidiaries. All Rights Reserved.
+
+ Licensed under the Apache License, Version 2.0 (the "License");
+ you may not use this file except in compliance with the License.
+ You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package service_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	sio "github.com/dell/goscaleio"
+	types "github.com/dell/goscaleio/types/v1"
+	"github.com/dell/karavi-metrics-powerflex/internal/service"
+	"github.com/dell/karavi-metrics-powerflex/internal/service/mocks"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"go.opentelemetry.io/otel"
+	"go.uber.org/mock/gomock"
+)
+
+func newTestSessionClient(client service.PowerFlexClient) *service.SessionClient {
+	return &service.SessionClient{
+		PowerFlexClient: client,
+		ConfigConnect:   &sio.ConfigConnect{Username: "admin", Password: "password"},
+		StorageSystemID: "system-1",
+		Logger:          logrus.New(),
+		Meter:           otel.Meter("powerflex/session_test"),
+		InitialBackoff:  time.Millisecond,
+		MaxBackoff:      time.Millisecond,
+	}
+}
+
+func Test_SessionClient(t *testing.T) {
+	unauthorized := &types.Error{HTTPStatusCode: 401, Message: "401 Unauthorized"}
+
+	t.Run("passes through successful calls", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		client := mocks.NewMockPowerFlexClient(ctrl)
+		client.EXPECT().GetInstance("").Return([]*types.System{{ID: "system-1"}}, nil).Times(1)
+		client.EXPECT().Authenticate(gomock.Any()).Times(0)
+
+		systems, err := newTestSessionClient(client).GetInstance("")
+		assert.NoError(t, err)
+		assert.Len(t, systems, 1)
+	})
+
+	t.Run("does not re-authenticate on other errors", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		client := mocks.NewMockPowerFlexClient(ctrl)
+		client.EXPECT().GetStoragePool("").Return(nil, errors.New("connection refused")).Times(1)
+		client.EXPECT().Authenticate(gomock.Any()).Times(0)
+
+		_, err := newTestSessionClient(client).GetStoragePool("")
+		assert.EqualError(t, err, "connection refused")
+	})
+
+	t.Run("re-authenticates and retries on unauthorized", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		client := mocks.NewMockPowerFlexClient(ctrl)
+		gomock.InOrder(
+			client.EXPECT().GetMetrics("volume", []string{"v1"}).Return(nil, unauthorized),
+			client.EXPECT().Authenticate(&sio.ConfigConnect{Username: "admin", Password: "password"}).Return(sio.Cluster{}, nil),
+			client.EXPECT().GetMetrics("volume", []string{"v1"}).Return(&types.MetricsResponse{}, nil),
+		)
+
+		resp, err := newTestSessionClient(client).GetMetrics("volume", []string{"v1"})
+		assert.NoError(t, err)
+		assert.NotNil(t, resp)
+	})
+
+	t.Run("retries login with backoff", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		client := mocks.NewMockPowerFlexClient(ctrl)
+		gomock.InOrder(
+			client.EXPECT().GetStoragePool("").Return(nil, unauthorized),
+			client.EXPECT().Authenticate(gomock.Any()).Return(sio.Cluster{}, errors.New("gateway unavailable")),
+			client.EXPECT().Authenticate(gomock.Any()).Return(sio.Cluster{}, nil),
+			client.EXPECT().GetStoragePool("").Return([]*types.StoragePool{}, nil),
+		)
+
+		_, err := newTestSessionClient(client).GetStoragePool("")
+		assert.NoError(t, err)
+	})
+
+	t.Run("checks the session of a failed FindSystem with GetInstance", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		client := mocks.NewMockPowerFlexClient(ctrl)
+		gomock.InOrder(
+			client.EXPECT().FindSystem("system-1", "", "").Return(nil, fmt.Errorf("err: problem getting instances: %s", unauthorized)),
+			client.EXPECT().GetInstance("").Return(nil, unauthorized),
+			client.EXPECT().Authenticate(gomock.Any()).Return(sio.Cluster{}, nil),
+			client.EXPECT().GetInstance("").Return([]*types.System{}, nil),
+			client.EXPECT().FindSystem("system-1", "", "").Return(&sio.System{}, nil),
+		)
+
+		_, err := newTestSessionClient(client).FindSystem("system-1", "", "")
+		assert.NoError(t, err)
+	})
+
+	t.Run("does not retry FindSystem when the session is valid", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		client := mocks.NewMockPowerFlexClient(ctrl)
+		gomock.InOrder(
+			client.EXPECT().FindSystem("system-1", "", "").Return(nil, errors.New("err: problem getting instances: connection refused")),
+			client.EXPECT().GetInstance("").Return([]*types.System{}, nil),
+		)
+		client.EXPECT().Authenticate(gomock.Any()).Times(0)
+
+		_, err := newTestSessionClient(client).FindSystem("system-1", "", "")
+		assert.EqualError(t, err, "err: problem getting instances: connection refused")
+	})
+
+	t.Run("does not check the session for FindSystem errors that can't be an expired session", func(t *testing.T) {
+		for _, findErr := range []error{
+			errors.New("err: systemid or systemname not found"),
+			service.ErrCircuitOpen,
+			&types.Error{HTTPStatusCode: 500, Message: "500 Internal Server Error"},
+		} {
+			ctrl := gomock.NewController(t)
+			client := mocks.NewMockPowerFlexClient(ctrl)
+			client.EXPECT().FindSystem("system-1", "", "").Return(nil, findErr)
+			client.EXPECT().GetInstance(gomock.Any()).Times(0)
+
+			_, err := newTestSessionClient(client).FindSystem("system-1", "", "")
+			assert.ErrorIs(t, err, findErr)
+		}
+	})
+
+	t.Run("re-authenticates gateway calls of goscaleio objects", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		client := mocks.NewMockPowerFlexClient(ctrl)
+		client.EXPECT().Authenticate(gomock.Any()).Return(sio.Cluster{}, nil).Times(1)
+
+		calls := 0
+		err := service.CallGateway(context.Background(), newTestSessionClient(client), func() error {
+			calls++
+			if calls == 1 {
+				return unauthorized
+			}
+			return nil
+		})
+		assert.NoError(t, err)
+		assert.Equal(t, 2, calls)
+	})
+
+	t.Run("returns error when login keeps failing", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		client := mocks.NewMockPowerFlexClient(ctrl)
+		client.EXPECT().GetInstance("").Return(nil, unauthorized).Times(1)
+		client.EXPECT().Authenticate(gomock.Any()).Return(sio.Cluster{}, errors.New("bad credentials")).Times(2)
+
+		session := newTestSessionClient(client)
+		session.MaxAttempts = 2
+		_, err := session.GetInstance("")
+		assert.ErrorContains(t, err, "bad credentials")
+	})
+
+	t.Run("concurrent callers share one login", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		client := mocks.NewMockPowerFlexClient(ctrl)
+
+		var mu sync.Mutex
+		authenticated := false
+		client.EXPECT().GetInstance("").DoAndReturn(func(string) ([]*types.System, error) {
+			mu.Lock()
+			defer mu.Unlock()
+			if !authenticated {
+				return nil, unauthorized
+			}
+			return []*types.System{}, nil
+		}).AnyTimes()
+		client.EXPECT().Authenticate(gomock.Any()).DoAndReturn(func(*sio.ConfigConnect) (sio.Cluster, error) {
+			mu.Lock()
+			defer mu.Unlock()
+			authenticated = true
+			return sio.Cluster{}, nil
+		}).Times(1)
+
+		session := newTestSessionClient(client)
+		var wg sync.WaitGroup
+		start := make(chan struct{})
+		for i := 0; i < 10; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				<-start
+				_, err := session.GetInstance("")
+				assert.NoError(t, err)
+			}()
+		}
+		close(start)
+		wg.Wait()
+	})
+
+	t.Run("other requests are not held up by a login", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		client := mocks.NewMockPowerFlexClient(ctrl)
+
+		loggingIn := make(chan struct{})
+		release := make(chan struct{})
+		client.EXPECT().GetInstance("").Return(nil, unauthorized)
+		client.EXPECT().Authenticate(gomock.Any()).DoAndReturn(func(*sio.ConfigConnect) (sio.Cluster, error) {
+			close(loggingIn)
+			<-release
+			return sio.Cluster{}, nil
+		})
+		client.EXPECT().GetInstance("").Return([]*types.System{}, nil)
+		client.EXPECT().GetStoragePool("").Return([]*types.StoragePool{}, nil)
+
+		session := newTestSessionClient(client)
+		done := make(chan error)
+		go func() {
+			_, err := session.GetInstance("")
+			done <- err
+		}()
+
+		<-loggingIn
+		_, err := session.GetStoragePool("")
+		assert.NoError(t, err)
+		close(release)
+		assert.NoError(t, <-done)
+	})
+}
+
+func Test_IsUnauthorized(t *testing.T) {
+	tests := map[string]struct {
+		err      error
+		expected bool
+	}{
+		"nil":                 {nil, false},
+		"api error pointer":   {&types.Error{HTTPStatusCode: 401}, true},
+		"api error value":     {types.Error{HTTPStatusCode: 401}, true},
+		"api error 500":       {&types.Error{HTTPStatusCode: 500, Message: "500 Internal Server Error"}, false},
+		"flattened message":   {errors.New("err: problem getting instances: 401 Unauthorized"), false},
+		"unrelated error":     {errors.New("connection refused"), false},
+		"wrapped api error":   {fmt.Errorf("getting metrics: %w", &types.Error{HTTPStatusCode: 401}), true},
+		"wrapped other error": {fmt.Errorf("getting metrics: %w", errors.New("timeout")), false},
+	}
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			assert.Equal(t, tc.expected, service.IsUnauthorized(tc.err))
+		})
+	}
+}
+
+func Test_CallGateway(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	client := mocks.NewMockPowerFlexClient(ctrl)
+
+	calls := 0
+	err := service.CallGateway(context.Background(), client, func() error {
+		calls++
+		return errors.New("connection refused")
+	})
+	assert.EqualError(t, err, "connection refused")
+	assert.Equal(t, 1, calls)
+}
+
+func Test_UnwrapClient(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	client := mocks.NewMockPowerFlexClient(ctrl)
+
+	assert.Equal(t, client, service.UnwrapClient(client))
+	assert.Equal(t, client, service.UnwrapClient(newTestSessionClient(client)))
+	assert.Equal(t, client, service.UnwrapClient(newTestSessionClient(newTestSessionClient(client))))
+}
+
+func Test_GetSDCStatistics_ReauthenticatesSdcRequests(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	client := mocks.NewMockPowerFlexClient(ctrl)
+	client.EXPECT().Authenticate(gomock.Any()).Return(sio.Cluster{}, nil).Times(1)
+	session := newTestSessionClient(client)
+
+	getter := mocks.NewMockStatisticsGetter(ctrl)
+	gomock.InOrder(
+		getter.EXPECT().GetStatistics().Return(nil, &types.Error{HTTPStatusCode: 401}),
+		getter.EXPECT().GetStatistics().Return(&types.SdcStatistics{}, nil),
+	)
+	metrics := mocks.NewMockMetricsRecorder(ctrl)
+	metrics.EXPECT().Record(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(),
+		gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Times(1)
+
+	svc := service.PowerFlexService{MetricsWrapper: metrics, Logger: logrus.New()}
+	svc.GetSDCStatistics(context.Background(), nil, []service.SdcMetricsRetriever{
+		service.SdcMetricsHandler{
+			Sdc:              &sio.Sdc{Sdc: &types.Sdc{ID: "sdc-1", SdcIP: "1.2.3.4"}},
+			StatisticsGetter: getter,
+			GenType:          "v1",
+			Client:           session,
+		},
+	})
+}
@@ -16,7 +16,10 @@
 
 package service
 
-import types "github.com/dell/goscaleio/types/v1"
+import (
+	types "github.com/dell/goscaleio/types/v1"
+	"github.com/dell/karavi-metrics-powerflex/internal/k8s"
+)
 
 // MappedSDC is the summerized details of the SDCs volume is mapped to
 type MappedSDC struct {
@@ -33,6 +36,8 @@ type VolumeMeta struct {
 	Namespace                 string
 	StorageSystemID           string
 	MappedSDCs                []MappedSDC
+	// Attributes are the allowed kubernetes labels of the volume, keyed by attribute name
+	Attributes map[string]string
 }
 
 // VolumeMetaMetrics is the details of a volume in an SDC along with the metrics
@@ -45,6 +50,10 @@ type VolumeMetaMetrics struct {
 	Namespace                 string
 	StorageSystemID           string
 	MappedSDCs                []MappedSDC
+	Attributes                map[string]string
+	StorageClass              string
+	ProvisionedBytes          int64
+	SizeInKb                  int
 	ReadLatencyBwc            types.BWC
 	ReadBwc                   types.BWC
 	TrimBwc                   types.BWC
@@ -59,6 +68,8 @@ type VolumeMetaMetrics struct {
 	HostWriteIOPS       float64
 	AvgHostReadLatency  float64
 	AvgHostWriteLatency float64
+	// CatalogMetrics are the values of the instruments that the metric catalog adds to the volume series
+	CatalogMetrics []CatalogMetricValue
 }
 
 // SDCMeta is meta data for a specific SDC
@@ -67,6 +78,8 @@ type SDCMeta struct {
 	Name    string
 	IP      string
 	SdcGUID string
+	// Attributes are the allowed labels of the node of the SDC, keyed by attribute name
+	Attributes map[string]string
 }
 
 // StorageClassInfo is meta data about a storage class and contains the associated PowerFlex storage pool names
@@ -76,6 +89,7 @@ type StorageClassInfo struct {
 	Driver          string
 	StorageSystemID string
 	StoragePools    []string
+	Attributes      map[string]string
 }
 
 // StorageClassMeta is the same as StorageClassInfo except it contains a map of PowerFlex storage pool IDs to goscaleio storage pool structs
@@ -85,6 +99,8 @@ type StorageClassMeta struct {
 	Driver          string
 	StorageSystemID string
 	StoragePools    map[string]StoragePoolMetricsRetriever
+	// Attributes are the allowed kubernetes labels of the storage class, keyed by attribute name
+	Attributes map[string]string
 }
 
 type TopologyMeta struct {
@@ -101,4 +117,52 @@ type TopologyMeta struct {
 	StorageSystem           string
 	Protocol                string
 	CreatedTime             string
+	// Attributes are the allowed kubernetes labels of the volume, keyed by attribute name
+	Attributes map[string]string
+	// Consumers are the pods that mount the volume, they are only exported if ConsumersResolved
+	Consumers         []k8s.VolumeConsumer
+	ConsumersResolved bool
+}
+
+// NamespaceAggregateMeta identifies the volumes of a namespace whose metrics are summed
+type NamespaceAggregateMeta struct {
+	StorageSystemID string
+	Namespace       string
+}
+
+// StorageClassAggregateMeta identifies the volumes of a storage class whose metrics are summed
+type StorageClassAggregateMeta struct {
+	StorageSystemID string
+	StorageClass    string
+}
+
+// VolumeAggregateRecord holds the totals of a group of volumes
+type VolumeAggregateRecord struct {
+	ReadBW, WriteBW     float64
+	ReadIOPS, WriteIOPS float64
+	// ProvisionedGigabytes is the capacity requested by the persistent volumes, and VolumeSizeGigabytes the size of
+	// their storage system volumes, which the array rounds up. The size of a thin volume is not the capacity allocated
+	// to it, the array doesn't report that per volume.
+	ProvisionedGigabytes   float64
+	VolumeSizeGigabytes    float64
+	PersistentVolumeClaims int
+}
+
+// PoolEfficiencyRecord holds the data reduction and thin provisioning metrics of a storage pool
+type PoolEfficiencyRecord struct {
+	// CompressionRatio and DataReductionRatio are 0, and not published, if the storage pool doesn't report them.
+	// Gen1 storage pools never do: their statistics only hold the capacity after data reduction, and comparing
+	// CapacityInUseInKb with NetUserDataCapacityInKb would only measure the protection overhead.
+	CompressionRatio   float64
+	DataReductionRatio float64
+	// ThinOvercommit is the provisioned capacity divided by the usable capacity
+	ThinOvercommit            float64
+	SnapshotCapacityGigabytes float64
+}
+
+// NodeConditionsRecord holds the conditions of the node of an SDC
+type NodeConditionsRecord struct {
+	// Ready is false when the Ready condition of the node is False or Unknown
+	Ready    bool
+	Cordoned bool
 }
@@ -0,0 +1,96 @@
+/*
+ Copyright (c) 2025 Dell Inc. or its subsidiaries. All Rights Reserved.
+
+ Licensed under the Apache License, Version 2.0 (the "License");
+ you may not use this file except in compliance with the License.
+ You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package service
+
+import (
+	"context"
+	"sync"
+
+	"github.com/dell/karavi-metrics-powerflex/internal/telemetry"
+	"github.com/sirupsen/logrus"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
+)
+
+// UnmappedSDCs exports how many SDCs of each storage system couldn't be mapped to a node in the last collection of SDC
+// metrics as powerflex_unmapped_sdcs. The series of those SDCs have an empty node name. A nil *UnmappedSDCs discards the counts.
+type UnmappedSDCs struct {
+	Meter  metric.Meter
+	Logger *logrus.Logger
+
+	mu     sync.Mutex
+	counts map[string]int64
+	gauge  telemetry.Gauge
+}
+
+// Counts returns the last count of each storage system
+func (u *UnmappedSDCs) Counts() map[string]int64 {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	counts := make(map[string]int64, len(u.counts))
+	for storageSystemID, count := range u.counts {
+		counts[storageSystemID] = count
+	}
+	return counts
+}
+
+// set replaces the counts of the storage systems of one collection
+func (u *UnmappedSDCs) set(collected *unmappedSDCCounts) {
+	if u == nil {
+		return
+	}
+	u.gauge.RegisterInt64(u.Meter, u.Logger, "powerflex_unmapped_sdcs", u.observe)
+
+	collected.mu.Lock()
+	defer collected.mu.Unlock()
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	if u.counts == nil {
+		u.counts = make(map[string]int64)
+	}
+	for storageSystemID, count := range collected.counts {
+		u.counts[storageSystemID] = count
+	}
+}
+
+// observe observes the last count of each storage system
+func (u *UnmappedSDCs) observe(_ context.Context, obs metric.Int64Observer) error {
+	for storageSystemID, count := range u.Counts() {
+		obs.Observe(count, metric.WithAttributes(attribute.String("StorageSystemID", storageSystemID)))
+	}
+	return nil
+}
+
+// unmappedSDCCounts counts the unmapped SDCs of each storage system while the SDC metrics are gathered
+type unmappedSDCCounts struct {
+	mu     sync.Mutex
+	counts map[string]int64
+}
+
+func (c *unmappedSDCCounts) add(storageSystemID string, unmapped bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.counts == nil {
+		c.counts = make(map[string]int64)
+	}
+	// a storage system whose SDCs are all mapped is counted too, so that its last count is reset to 0
+	count := c.counts[storageSystemID]
+	if unmapped {
+		count++
+	}
+	c.counts[storageSystemID] = count
+}
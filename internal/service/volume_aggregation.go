@@ -0,0 +1,103 @@
+/*
+ Copyright (c) 2025 Dell Inc. or its subsidiaries. All Rights Reserved.
+
+ Licensed under the Apache License, Version 2.0 (the "License");
+ you may not use this file except in compliance with the License.
+ You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package service
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// VolumeAggregation selects the rollups of the volume metrics, which save summing thousands of per-volume series
+// at query time. The zero value exports only the per-volume series.
+type VolumeAggregation struct {
+	ByNamespace    bool
+	ByStorageClass bool
+	// AggregatesOnly drops the per-volume series and keeps the rollups, to cut cardinality on large clusters
+	AggregatesOnly bool
+}
+
+// Enabled returns true if the volume metrics are rolled up
+func (a VolumeAggregation) Enabled() bool {
+	return a.ByNamespace || a.ByStorageClass
+}
+
+// aggregateVolumeMetrics passes the volume metrics through, unless only the aggregates are exported, and records the
+// totals of each namespace and storage class once every volume was seen. Volumes without a persistent volume are not
+// counted, they don't belong to a namespace or a storage class.
+func (s *PowerFlexService) aggregateVolumeMetrics(ctx context.Context, volumeMetrics <-chan *VolumeMetricsRecord) <-chan *VolumeMetricsRecord {
+	start := time.Now()
+	defer s.timeSince(start, "aggregateVolumeMetrics")
+
+	aggregation := s.VolumeAggregation
+	ch := make(chan *VolumeMetricsRecord)
+	go func() {
+		namespaces := make(map[NamespaceAggregateMeta]*VolumeAggregateRecord)
+		storageClasses := make(map[StorageClassAggregateMeta]*VolumeAggregateRecord)
+		for metrics := range volumeMetrics {
+			if meta := metrics.volumeMeta; meta.PersistentVolumeName != "" {
+				if aggregation.ByNamespace {
+					key := NamespaceAggregateMeta{StorageSystemID: meta.StorageSystemID, Namespace: meta.Namespace}
+					if namespaces[key] == nil {
+						namespaces[key] = &VolumeAggregateRecord{}
+					}
+					namespaces[key].add(metrics)
+				}
+				if aggregation.ByStorageClass {
+					key := StorageClassAggregateMeta{StorageSystemID: meta.StorageSystemID, StorageClass: metrics.storageClass}
+					if storageClasses[key] == nil {
+						storageClasses[key] = &VolumeAggregateRecord{}
+					}
+					storageClasses[key].add(metrics)
+				}
+			}
+			if !aggregation.AggregatesOnly {
+				ch <- metrics
+			}
+		}
+
+		var wg sync.WaitGroup
+		record := func(meta interface{}, aggregate *VolumeAggregateRecord) {
+			defer wg.Done()
+			if err := s.MetricsWrapper.RecordVolumeAggregate(ctx, meta, aggregate); err != nil {
+				s.Logger.WithError(err).WithField("aggregate", meta).Error("recording volume aggregate")
+			}
+		}
+		for key, aggregate := range namespaces {
+			wg.Add(1)
+			go record(&key, aggregate)
+		}
+		for key, aggregate := range storageClasses {
+			wg.Add(1)
+			go record(&key, aggregate)
+		}
+		wg.Wait()
+		close(ch)
+	}()
+	return ch
+}
+
+// add counts the claim of a volume and adds its metrics to the totals
+func (a *VolumeAggregateRecord) add(metrics *VolumeMetricsRecord) {
+	a.ReadBW += metrics.readBW
+	a.WriteBW += metrics.writeBW
+	a.ReadIOPS += metrics.readIOPS
+	a.WriteIOPS += metrics.writeIOPS
+	a.ProvisionedGigabytes += float64(metrics.provisionedBytes) / (1024.0 * 1024.0 * 1024.0)
+	a.VolumeSizeGigabytes += float64(metrics.sizeInKb) / (1024.0 * 1024.0)
+	a.PersistentVolumeClaims++
+}
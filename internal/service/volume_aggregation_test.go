@@ -0,0 +1,146 @@
+/*
+ Copyright (c) 2025 Dell Inc. or its subsidiaries. All Rights Reserved.
+
+ Licensed under the Apache License, Version 2.0 (the "License");
+ you may not use this file except in compliance with the License.
+ You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package service_test
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+
+	types "github.com/dell/goscaleio/types/v1"
+	"github.com/dell/karavi-metrics-powerflex/internal/k8s"
+	"github.com/dell/karavi-metrics-powerflex/internal/service"
+	"github.com/dell/karavi-metrics-powerflex/internal/service/mocks"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/mock/gomock"
+)
+
+func Test_ExportVolumeStatistics_Aggregation(t *testing.T) {
+	newVolumes := func() []*service.VolumeMetaMetrics {
+		volume := func(id string, sizeInKb int, readBW float64, readIOPS float64) *service.VolumeMetaMetrics {
+			return &service.VolumeMetaMetrics{
+				ID:               id,
+				Name:             "k8s-" + id,
+				GenType:          types.GenTypeEC,
+				SizeInKb:         sizeInKb,
+				HostReadBandwith: readBW,
+				HostReadIOPS:     readIOPS,
+			}
+		}
+		return []*service.VolumeMetaMetrics{
+			volume("vol1", 8*1024*1024, 10, 100),
+			volume("vol2", 16*1024*1024, 5, 50),
+			volume("vol3", 8*1024*1024, 1, 10),
+			// a volume that isn't backing a persistent volume isn't in any namespace
+			volume("vol4", 8*1024*1024, 1000, 1000),
+		}
+	}
+	pvs := []k8s.VolumeInfo{
+		{StorageSystemVolumeName: "k8s-vol1", PersistentVolume: "pv-1", Namespace: "team-a", StorageClass: "vxflexos", StorageSystemID: "system-1", ProvisionedBytes: 8 << 30},
+		{StorageSystemVolumeName: "k8s-vol2", PersistentVolume: "pv-2", Namespace: "team-a", StorageClass: "vxflexos-xfs", StorageSystemID: "system-1", ProvisionedBytes: 10 << 30},
+		{StorageSystemVolumeName: "k8s-vol3", PersistentVolume: "pv-3", Namespace: "team-b", StorageClass: "vxflexos", StorageSystemID: "system-1", ProvisionedBytes: 4 << 30},
+	}
+
+	// aggregates collects the records by namespace or storage class
+	type aggregates struct {
+		sync.Mutex
+		records map[string]service.VolumeAggregateRecord
+	}
+	expectAggregates := func(metrics *mocks.MockMetricsRecorder, got *aggregates) {
+		got.records = make(map[string]service.VolumeAggregateRecord)
+		metrics.EXPECT().RecordVolumeAggregate(gomock.Any(), gomock.Any(), gomock.Any()).DoAndReturn(
+			func(_ context.Context, meta interface{}, aggregate *service.VolumeAggregateRecord) error {
+				got.Lock()
+				defer got.Unlock()
+				switch v := meta.(type) {
+				case *service.NamespaceAggregateMeta:
+					assert.Equal(t, "system-1", v.StorageSystemID)
+					got.records["namespace/"+v.Namespace] = *aggregate
+				case *service.StorageClassAggregateMeta:
+					assert.Equal(t, "system-1", v.StorageSystemID)
+					got.records["storageclass/"+v.StorageClass] = *aggregate
+				}
+				return nil
+			}).AnyTimes()
+	}
+
+	t.Run("rolls up by namespace and storage class", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		metrics := mocks.NewMockMetricsRecorder(ctrl)
+		volFinder := mocks.NewMockVolumeFinder(ctrl)
+		volFinder.EXPECT().GetPersistentVolumes().Return(pvs, nil)
+		metrics.EXPECT().Record(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Times(4)
+		got := &aggregates{}
+		expectAggregates(metrics, got)
+
+		svc := service.PowerFlexService{
+			MetricsWrapper:    metrics,
+			Logger:            logrus.New(),
+			VolumeAggregation: service.VolumeAggregation{ByNamespace: true, ByStorageClass: true},
+		}
+		svc.ExportVolumeStatistics(context.Background(), newVolumes(), volFinder)
+
+		assert.Equal(t, map[string]service.VolumeAggregateRecord{
+			"namespace/team-a": {ReadBW: 15, ReadIOPS: 150, ProvisionedGigabytes: 18, VolumeSizeGigabytes: 24, PersistentVolumeClaims: 2},
+			"namespace/team-b": {ReadBW: 1, ReadIOPS: 10, ProvisionedGigabytes: 4, VolumeSizeGigabytes: 8, PersistentVolumeClaims: 1},
+			"storageclass/vxflexos": {
+				ReadBW: 11, ReadIOPS: 110, ProvisionedGigabytes: 12, VolumeSizeGigabytes: 16, PersistentVolumeClaims: 2,
+			},
+			"storageclass/vxflexos-xfs": {
+				ReadBW: 5, ReadIOPS: 50, ProvisionedGigabytes: 10, VolumeSizeGigabytes: 16, PersistentVolumeClaims: 1,
+			},
+		}, got.records)
+	})
+
+	t.Run("exports only the aggregates", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		metrics := mocks.NewMockMetricsRecorder(ctrl)
+		volFinder := mocks.NewMockVolumeFinder(ctrl)
+		volFinder.EXPECT().GetPersistentVolumes().Return(pvs, nil)
+		metrics.EXPECT().Record(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Times(0)
+		got := &aggregates{}
+		expectAggregates(metrics, got)
+
+		svc := service.PowerFlexService{
+			MetricsWrapper:    metrics,
+			Logger:            logrus.New(),
+			VolumeAggregation: service.VolumeAggregation{ByNamespace: true, AggregatesOnly: true},
+		}
+		svc.ExportVolumeStatistics(context.Background(), newVolumes(), volFinder)
+
+		assert.Len(t, got.records, 2)
+		assert.Contains(t, got.records, "namespace/team-a")
+		assert.Contains(t, got.records, "namespace/team-b")
+	})
+
+	t.Run("errors recording an aggregate are logged", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		metrics := mocks.NewMockMetricsRecorder(ctrl)
+		volFinder := mocks.NewMockVolumeFinder(ctrl)
+		volFinder.EXPECT().GetPersistentVolumes().Return(pvs, nil)
+		metrics.EXPECT().RecordVolumeAggregate(gomock.Any(), gomock.Any(), gomock.Any()).Return(errors.New("error")).Times(2)
+
+		svc := service.PowerFlexService{
+			MetricsWrapper:    metrics,
+			Logger:            logrus.New(),
+			VolumeAggregation: service.VolumeAggregation{ByStorageClass: true, AggregatesOnly: true},
+		}
+		svc.ExportVolumeStatistics(context.Background(), newVolumes(), volFinder)
+	})
+}
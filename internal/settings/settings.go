@@ -0,0 +1,708 @@
+/*
+ Copyright (c) 2025 Dell Inc. or its subsidiaries. All Rights Reserved.
+
+ Licensed under the Apache License, Version 2.0 (the "License");
+ you may not use this file except in compliance with the License.
+ You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package settings
+
+import (
+	"errors"
+	"fmt"
+	"maps"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/dell/karavi-metrics-powerflex/internal/domain"
+	"github.com/dell/karavi-metrics-powerflex/internal/entrypoint"
+	"github.com/dell/karavi-metrics-powerflex/internal/k8s"
+	"github.com/dell/karavi-metrics-powerflex/internal/service"
+	otlexporters "github.com/dell/karavi-metrics-powerflex/opentelemetry/exporters"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/util/validation"
+)
+
+// Keys read from the karavi-metrics-powerflex configuration file
+const (
+	CollectorAddressKey               = "COLLECTOR_ADDR"
+	ProvisionerNamesKey               = "provisioner_names"
+	LogLevelKey                       = "LOG_LEVEL"
+	LogFormatKey                      = "LOG_FORMAT"
+	SDCMetricsEnabledKey              = "POWERFLEX_SDC_METRICS_ENABLED"
+	VolumeMetricsEnabledKey           = "POWERFLEX_VOLUME_METRICS_ENABLED"
+	StoragePoolMetricsEnabledKey      = "POWERFLEX_STORAGE_POOL_METRICS_ENABLED"
+	TopologyMetricsEnabledKey         = "POWERFLEX_TOPOLOGY_METRICS_ENABLED"
+	SDCPollFrequencyKey               = "POWERFLEX_SDC_IO_POLL_FREQUENCY"
+	VolumePollFrequencyKey            = "POWERFLEX_VOLUME_IO_POLL_FREQUENCY"
+	StoragePoolPollFrequencyKey       = "POWERFLEX_STORAGE_POOL_POLL_FREQUENCY"
+	TopologyMetricsPollFrequencyKey   = "POWERFLEX_TOPOLOGY_METRICS_POLL_FREQUENCY"
+	MaxConcurrentQueriesKey           = "POWERFLEX_MAX_CONCURRENT_QUERIES"
+	MetricsBatchSizeKey               = "POWERFLEX_METRICS_BATCH_SIZE"
+	MetricCatalogFileKey              = "POWERFLEX_METRIC_CATALOG_FILE"
+	InventoryRefreshIntervalKey       = "POWERFLEX_INVENTORY_REFRESH_INTERVAL"
+	CircuitBreakerFailureThresholdKey = "POWERFLEX_CIRCUIT_BREAKER_FAILURE_THRESHOLD"
+	RateLimitRequestsPerSecondKey     = "POWERFLEX_RATE_LIMIT_REQUESTS_PER_SECOND"
+	RateLimitBurstKey                 = "POWERFLEX_RATE_LIMIT_BURST"
+	LeaderElectionEnabledKey          = "POWERFLEX_LEADER_ELECTION_ENABLED"
+	LeaseDurationKey                  = "POWERFLEX_LEADER_ELECTION_LEASE_DURATION"
+	RenewDeadlineKey                  = "POWERFLEX_LEADER_ELECTION_RENEW_DEADLINE"
+	RetryPeriodKey                    = "POWERFLEX_LEADER_ELECTION_RETRY_PERIOD"
+	ShardingEnabledKey                = "POWERFLEX_SHARDING_ENABLED"
+	StorageSystemOverridesKey         = "storage_system_overrides"
+
+	VolumeIncludeNamespacesKey        = "POWERFLEX_VOLUME_INCLUDE_NAMESPACES"
+	VolumeExcludeNamespacesKey        = "POWERFLEX_VOLUME_EXCLUDE_NAMESPACES"
+	VolumeIncludeNamespaceSelectorKey = "POWERFLEX_VOLUME_INCLUDE_NAMESPACE_SELECTOR"
+	VolumeExcludeNamespaceSelectorKey = "POWERFLEX_VOLUME_EXCLUDE_NAMESPACE_SELECTOR"
+	VolumeIncludeStorageClassesKey    = "POWERFLEX_VOLUME_INCLUDE_STORAGE_CLASSES"
+	VolumeExcludeStorageClassesKey    = "POWERFLEX_VOLUME_EXCLUDE_STORAGE_CLASSES"
+	PersistentVolumeSelectorKey       = "POWERFLEX_VOLUME_PV_SELECTOR"
+	PersistentVolumeClaimSelectorKey  = "POWERFLEX_VOLUME_PVC_SELECTOR"
+
+	PersistentVolumeClaimLabelsKey = "POWERFLEX_VOLUME_PVC_LABELS"
+	PersistentVolumeLabelsKey      = "POWERFLEX_VOLUME_PV_LABELS"
+	NamespaceLabelsKey             = "POWERFLEX_VOLUME_NAMESPACE_LABELS"
+	StorageClassLabelsKey          = "POWERFLEX_STORAGE_CLASS_LABELS"
+	NodeLabelsKey                  = "POWERFLEX_SDC_NODE_LABELS"
+	MaxLabelValuesKey              = "POWERFLEX_MAX_LABEL_VALUES"
+
+	VolumeConsumersEnabledKey = "POWERFLEX_VOLUME_CONSUMERS_ENABLED"
+	VolumeAggregateByKey      = "POWERFLEX_VOLUME_AGGREGATE_BY"
+	VolumeAggregatesOnlyKey   = "POWERFLEX_VOLUME_AGGREGATES_ONLY"
+	NodeConditionsEnabledKey  = "POWERFLEX_SDC_NODE_CONDITIONS_ENABLED"
+)
+
+// Keys read from the environment of the pod
+const (
+	TLSEnabledKey         = "TLS_ENABLED"
+	CollectorCertPathKey  = "COLLECTOR_CERT_PATH"
+	MetricsEndpointKey    = "POWERFLEX_METRICS_ENDPOINT"
+	MetricsNamespaceKey   = "POWERFLEX_METRICS_NAMESPACE"
+	CollectionModeKey     = "POWERFLEX_COLLECTION_MODE"
+	NodeNameKey           = "NODE_NAME"
+	SDCGUIDKey            = "POWERFLEX_SDC_GUID"
+	HealthProbeAddressKey = "POWERFLEX_HEALTH_PROBE_ADDRESS"
+
+	CredentialsSourceKey          = "POWERFLEX_CREDENTIALS_SOURCE"
+	CredentialsSecretNamespaceKey = "POWERFLEX_CREDENTIALS_SECRET_NAMESPACE"
+	CredentialsSecretNameKey      = "POWERFLEX_CREDENTIALS_SECRET_NAME"
+	CredentialsSecretKeyKey       = "POWERFLEX_CREDENTIALS_SECRET_KEY"
+)
+
+const (
+	// CollectionModeCluster collects every SDC from one replica, or from the replicas that share the storage systems
+	CollectionModeCluster = "cluster"
+	// CollectionModeNode runs on every node and collects only the local SDC and its volumes
+	CollectionModeNode = "node"
+
+	// AggregateByNamespace rolls the volume metrics up by namespace
+	AggregateByNamespace = "namespace"
+	// AggregateByStorageClass rolls the volume metrics up by storage class
+	AggregateByStorageClass = "storage_class"
+
+	// CredentialsSourceFile reads the storage systems from the mounted vxflexos-config secret
+	CredentialsSourceFile = "file"
+	// CredentialsSourceSecret reads and watches the storage systems through the kubernetes API
+	CredentialsSourceSecret = "secret"
+
+	// DefaultCredentialsSecretName and DefaultCredentialsSecretKey select the secret of the CSI driver
+	DefaultCredentialsSecretName = "vxflexos-config"
+	DefaultCredentialsSecretKey  = "config"
+
+	// DefaultPollFrequency is how often each group of metrics is collected
+	DefaultPollFrequency = 5 * time.Second
+
+	// DefaultHealthProbeAddress is where the liveness and readiness probes are served
+	DefaultHealthProbeAddress = ":8081"
+)
+
+// overridableKeys can be set for one storage system in a block of StorageSystemOverridesKey
+var overridableKeys = []string{
+	SDCMetricsEnabledKey,
+	VolumeMetricsEnabledKey,
+	StoragePoolMetricsEnabledKey,
+	SDCPollFrequencyKey,
+	VolumePollFrequencyKey,
+	StoragePoolPollFrequencyKey,
+	MaxConcurrentQueriesKey,
+	RateLimitRequestsPerSecondKey,
+	RateLimitBurstKey,
+}
+
+// Getter returns the raw value of a key, or "" if it isn't set
+type Getter func(key string) string
+
+// File is the configuration file, e.g. a *viper.Viper
+type File interface {
+	GetString(key string) string
+	GetStringMap(key string) map[string]interface{}
+}
+
+// StorageSystemSettings are the collection settings of one storage system
+type StorageSystemSettings struct {
+	SDCMetricsEnabled         bool
+	VolumeMetricsEnabled      bool
+	StoragePoolMetricsEnabled bool
+	SDCPollFrequency          time.Duration
+	VolumePollFrequency       time.Duration
+	StoragePoolPollFrequency  time.Duration
+	MaxConcurrentQueries      int
+	// RateLimit is nil unless it is overridden. Arrays behind the same gateway still share one limiter.
+	RateLimit *domain.RateLimit
+}
+
+// Settings is the typed configuration of the service. Values come from the configuration file,
+// which can change at runtime, and from the environment of the pod.
+type Settings struct {
+	CollectorAddress string
+	ProvisionerNames []string
+	LogLevel         string
+	LogFormat        string
+
+	SDCMetricsEnabled         bool
+	VolumeMetricsEnabled      bool
+	StoragePoolMetricsEnabled bool
+	TopologyMetricsEnabled    bool
+
+	SDCPollFrequency             time.Duration
+	VolumePollFrequency          time.Duration
+	StoragePoolPollFrequency     time.Duration
+	TopologyMetricsPollFrequency time.Duration
+
+	MaxConcurrentQueries           int
+	MetricsBatchSize               int
+	MetricCatalogFile              string
+	InventoryRefreshInterval       time.Duration
+	CircuitBreakerFailureThreshold int
+	RateLimit                      domain.RateLimit
+	// MetricCatalog is the built in metric catalog with the entries of MetricCatalogFile, nil if it isn't set
+	MetricCatalog *service.MetricCatalog
+
+	LeaderElectionEnabled bool
+	LeaseDuration         time.Duration
+	RenewDeadline         time.Duration
+	RetryPeriod           time.Duration
+	ShardingEnabled       bool
+
+	// StorageSystems holds the settings of the storage systems that have overrides, keyed by lower case systemID
+	StorageSystems map[string]StorageSystemSettings
+
+	// VolumeFilter selects the persistent volumes and storage classes whose metrics are exported
+	VolumeFilter k8s.VolumeFilter
+	// LabelKeys are the kubernetes labels exported as metric attributes, each with at most MaxLabelValues values
+	LabelKeys      k8s.LabelKeys
+	MaxLabelValues int
+	// VolumeConsumersEnabled exports the pods and workloads that mount each volume, which requires watching pods
+	VolumeConsumersEnabled bool
+	// VolumeAggregation rolls the volume metrics up by namespace and storage class
+	VolumeAggregation service.VolumeAggregation
+	// NodeConditionsEnabled exports whether the node of each SDC is ready and cordoned
+	NodeConditionsEnabled bool
+
+	TLSEnabled        bool
+	CollectorCertPath string
+	MetricsEndpoint   string
+	MetricsNamespace  string
+	CollectionMode    string
+	NodeName          string
+	SDCGUID           string
+	// HealthProbeAddress serves /healthz and /readyz, which is ready once the kubernetes informer caches synced
+	HealthProbeAddress string
+
+	CredentialsSource          string
+	CredentialsSecretNamespace string
+	CredentialsSecretName      string
+	CredentialsSecretKey       string
+}
+
+// Defaults returns the settings used for every key that isn't set
+func Defaults() *Settings {
+	return &Settings{
+		LogLevel:                       "info",
+		LogFormat:                      "text",
+		SDCMetricsEnabled:              true,
+		VolumeMetricsEnabled:           true,
+		StoragePoolMetricsEnabled:      true,
+		TopologyMetricsEnabled:         true,
+		SDCPollFrequency:               DefaultPollFrequency,
+		VolumePollFrequency:            DefaultPollFrequency,
+		StoragePoolPollFrequency:       DefaultPollFrequency,
+		TopologyMetricsPollFrequency:   DefaultPollFrequency,
+		MaxConcurrentQueries:           service.DefaultMaxPowerFlexConnections,
+		MetricsBatchSize:               service.DefaultMetricsBatchSize,
+		MaxLabelValues:                 k8s.DefaultMaxLabelValues,
+		InventoryRefreshInterval:       service.DefaultInventoryRefreshInterval,
+		CircuitBreakerFailureThreshold: service.DefaultCircuitBreakerFailureThreshold,
+		LeaderElectionEnabled:          true,
+		LeaseDuration:                  k8s.DefaultLeaseDuration,
+		RenewDeadline:                  k8s.DefaultRenewDeadline,
+		RetryPeriod:                    k8s.DefaultRetryPeriod,
+		CollectorCertPath:              otlexporters.DefaultCollectorCertPath,
+		MetricsEndpoint:                entrypoint.DefaultEndPoint,
+		CollectionMode:                 CollectionModeCluster,
+		HealthProbeAddress:             DefaultHealthProbeAddress,
+		CredentialsSource:              CredentialsSourceFile,
+		CredentialsSecretName:          DefaultCredentialsSecretName,
+		CredentialsSecretKey:           DefaultCredentialsSecretKey,
+		StorageSystems:                 map[string]StorageSystemSettings{},
+	}
+}
+
+// Load reads the settings from the configuration file and the environment.
+// Every invalid value is reported in the returned error, along with the settings that could be read.
+func Load(file File, env Getter) (*Settings, error) {
+	s := Defaults()
+	p := &parser{}
+	get := file.GetString
+
+	s.CollectorAddress = p.required(get, CollectorAddressKey)
+	if provisionerNames := p.required(get, ProvisionerNamesKey); provisionerNames != "" {
+		s.ProvisionerNames = strings.Split(provisionerNames, ",")
+	}
+	s.LogLevel = p.string(get, LogLevelKey, s.LogLevel)
+	s.LogFormat = p.string(get, LogFormatKey, s.LogFormat)
+
+	s.SDCMetricsEnabled = p.bool(get, SDCMetricsEnabledKey, s.SDCMetricsEnabled)
+	s.VolumeMetricsEnabled = p.bool(get, VolumeMetricsEnabledKey, s.VolumeMetricsEnabled)
+	s.StoragePoolMetricsEnabled = p.bool(get, StoragePoolMetricsEnabledKey, s.StoragePoolMetricsEnabled)
+	s.TopologyMetricsEnabled = p.bool(get, TopologyMetricsEnabledKey, s.TopologyMetricsEnabled)
+
+	s.SDCPollFrequency = p.seconds(get, SDCPollFrequencyKey, s.SDCPollFrequency, entrypoint.MinimumTickInterval, entrypoint.MaximumTickInterval)
+	s.VolumePollFrequency = p.seconds(get, VolumePollFrequencyKey, s.VolumePollFrequency, entrypoint.MinimumTickInterval, entrypoint.MaximumTickInterval)
+	s.StoragePoolPollFrequency = p.seconds(get, StoragePoolPollFrequencyKey, s.StoragePoolPollFrequency, entrypoint.MinimumTickInterval, entrypoint.MaximumTickInterval)
+	s.TopologyMetricsPollFrequency = p.seconds(get, TopologyMetricsPollFrequencyKey, s.TopologyMetricsPollFrequency, entrypoint.MinimumTickInterval, entrypoint.MaximumTickInterval)
+
+	s.MaxConcurrentQueries = p.int(get, MaxConcurrentQueriesKey, s.MaxConcurrentQueries, 1)
+	s.MetricsBatchSize = p.int(get, MetricsBatchSizeKey, s.MetricsBatchSize, 1)
+	s.MetricCatalogFile = p.string(get, MetricCatalogFileKey, s.MetricCatalogFile)
+	if s.MetricCatalogFile != "" {
+		catalog, err := service.LoadMetricCatalog(s.MetricCatalogFile)
+		if err != nil {
+			p.errs = append(p.errs, fmt.Errorf("%s value %q is invalid: %w", MetricCatalogFileKey, s.MetricCatalogFile, err))
+		}
+		s.MetricCatalog = catalog
+	}
+	s.InventoryRefreshInterval = p.seconds(get, InventoryRefreshIntervalKey, s.InventoryRefreshInterval, 0, 0)
+	s.CircuitBreakerFailureThreshold = p.int(get, CircuitBreakerFailureThresholdKey, s.CircuitBreakerFailureThreshold, 1)
+	s.RateLimit.RequestsPerSecond = p.float(get, RateLimitRequestsPerSecondKey, s.RateLimit.RequestsPerSecond, 0)
+	s.RateLimit.Burst = p.int(get, RateLimitBurstKey, s.RateLimit.Burst, 0)
+
+	s.LeaderElectionEnabled = p.bool(get, LeaderElectionEnabledKey, s.LeaderElectionEnabled)
+	s.LeaseDuration = p.seconds(get, LeaseDurationKey, s.LeaseDuration, time.Second, 0)
+	s.RenewDeadline = p.seconds(get, RenewDeadlineKey, s.RenewDeadline, time.Second, 0)
+	s.RetryPeriod = p.seconds(get, RetryPeriodKey, s.RetryPeriod, time.Second, 0)
+	s.ShardingEnabled = p.bool(get, ShardingEnabledKey, s.ShardingEnabled)
+
+	s.VolumeFilter = k8s.VolumeFilter{
+		IncludeNamespaces:             p.list(get, VolumeIncludeNamespacesKey),
+		ExcludeNamespaces:             p.list(get, VolumeExcludeNamespacesKey),
+		IncludeNamespaceSelector:      p.selector(get, VolumeIncludeNamespaceSelectorKey),
+		ExcludeNamespaceSelector:      p.selector(get, VolumeExcludeNamespaceSelectorKey),
+		IncludeStorageClasses:         p.list(get, VolumeIncludeStorageClassesKey),
+		ExcludeStorageClasses:         p.list(get, VolumeExcludeStorageClassesKey),
+		PersistentVolumeSelector:      p.selector(get, PersistentVolumeSelectorKey),
+		PersistentVolumeClaimSelector: p.selector(get, PersistentVolumeClaimSelectorKey),
+	}
+
+	s.LabelKeys = k8s.LabelKeys{
+		PersistentVolumeClaim: p.labelKeys(get, PersistentVolumeClaimLabelsKey),
+		PersistentVolume:      p.labelKeys(get, PersistentVolumeLabelsKey),
+		Namespace:             p.labelKeys(get, NamespaceLabelsKey),
+		StorageClass:          p.labelKeys(get, StorageClassLabelsKey),
+		Node:                  p.labelKeys(get, NodeLabelsKey),
+	}
+	s.MaxLabelValues = p.int(get, MaxLabelValuesKey, s.MaxLabelValues, 1)
+	s.VolumeConsumersEnabled = p.bool(get, VolumeConsumersEnabledKey, s.VolumeConsumersEnabled)
+	for _, value := range p.list(get, VolumeAggregateByKey) {
+		switch value {
+		case AggregateByNamespace:
+			s.VolumeAggregation.ByNamespace = true
+		case AggregateByStorageClass:
+			s.VolumeAggregation.ByStorageClass = true
+		default:
+			p.errs = append(p.errs, fmt.Errorf("%s value %q is invalid, valid values are %s or %s", VolumeAggregateByKey, value, AggregateByNamespace, AggregateByStorageClass))
+		}
+	}
+	s.VolumeAggregation.AggregatesOnly = p.bool(get, VolumeAggregatesOnlyKey, s.VolumeAggregation.AggregatesOnly)
+	s.NodeConditionsEnabled = p.bool(get, NodeConditionsEnabledKey, s.NodeConditionsEnabled)
+
+	overrides := file.GetStringMap(StorageSystemOverridesKey)
+	for _, id := range slices.Sorted(maps.Keys(overrides)) {
+		s.StorageSystems[strings.ToLower(id)] = s.loadStorageSystem(p, id, overrides[id])
+	}
+
+	s.TLSEnabled = env(TLSEnabledKey) == "true"
+	if certPath := strings.TrimSpace(env(CollectorCertPathKey)); s.TLSEnabled && certPath != "" {
+		s.CollectorCertPath = certPath
+	}
+	s.MetricsEndpoint = p.string(env, MetricsEndpointKey, s.MetricsEndpoint)
+	s.MetricsNamespace = p.string(env, MetricsNamespaceKey, k8s.Namespace())
+	if s.MetricsNamespace == "" {
+		s.MetricsNamespace = entrypoint.DefaultNameSpace
+	}
+	s.CollectionMode = p.string(env, CollectionModeKey, s.CollectionMode)
+	s.NodeName = strings.TrimSpace(env(NodeNameKey))
+	s.SDCGUID = strings.TrimSpace(env(SDCGUIDKey))
+	s.HealthProbeAddress = p.string(env, HealthProbeAddressKey, s.HealthProbeAddress)
+	s.CredentialsSource = p.string(env, CredentialsSourceKey, s.CredentialsSource)
+	s.CredentialsSecretNamespace = p.string(env, CredentialsSecretNamespaceKey, k8s.Namespace())
+	s.CredentialsSecretName = p.string(env, CredentialsSecretNameKey, s.CredentialsSecretName)
+	s.CredentialsSecretKey = p.string(env, CredentialsSecretKeyKey, s.CredentialsSecretKey)
+
+	p.errs = append(p.errs, s.validate()...)
+	return s, errors.Join(p.errs...)
+}
+
+// loadStorageSystem applies one block of StorageSystemOverridesKey on top of the settings shared by every storage system
+func (s *Settings) loadStorageSystem(p *parser, id string, values interface{}) StorageSystemSettings {
+	storageSystem := s.StorageSystem(id)
+	prefix := StorageSystemOverridesKey + "." + id + "."
+	block, ok := values.(map[string]interface{})
+	if !ok {
+		p.errs = append(p.errs, fmt.Errorf("%s.%s must be a map of keys to values", StorageSystemOverridesKey, id))
+		return storageSystem
+	}
+
+	for key := range block {
+		if !slices.ContainsFunc(overridableKeys, func(k string) bool { return strings.EqualFold(k, key) }) {
+			p.errs = append(p.errs, fmt.Errorf("%s%s cannot be overridden per storage system", prefix, key))
+		}
+	}
+	get := func(key string) string {
+		key = strings.TrimPrefix(key, prefix)
+		for k, value := range block {
+			if strings.EqualFold(k, key) && value != nil {
+				return fmt.Sprint(value)
+			}
+		}
+		return ""
+	}
+
+	storageSystem.SDCMetricsEnabled = p.bool(get, prefix+SDCMetricsEnabledKey, storageSystem.SDCMetricsEnabled)
+	storageSystem.VolumeMetricsEnabled = p.bool(get, prefix+VolumeMetricsEnabledKey, storageSystem.VolumeMetricsEnabled)
+	storageSystem.StoragePoolMetricsEnabled = p.bool(get, prefix+StoragePoolMetricsEnabledKey, storageSystem.StoragePoolMetricsEnabled)
+	storageSystem.SDCPollFrequency = p.seconds(get, prefix+SDCPollFrequencyKey, storageSystem.SDCPollFrequency, entrypoint.MinimumTickInterval, entrypoint.MaximumTickInterval)
+	storageSystem.VolumePollFrequency = p.seconds(get, prefix+VolumePollFrequencyKey, storageSystem.VolumePollFrequency, entrypoint.MinimumTickInterval, entrypoint.MaximumTickInterval)
+	storageSystem.StoragePoolPollFrequency = p.seconds(get, prefix+StoragePoolPollFrequencyKey, storageSystem.StoragePoolPollFrequency, entrypoint.MinimumTickInterval, entrypoint.MaximumTickInterval)
+	storageSystem.MaxConcurrentQueries = p.int(get, prefix+MaxConcurrentQueriesKey, storageSystem.MaxConcurrentQueries, 1)
+
+	if get(RateLimitRequestsPerSecondKey) != "" || get(RateLimitBurstKey) != "" {
+		rateLimit := domain.RateLimit{
+			RequestsPerSecond: p.float(get, prefix+RateLimitRequestsPerSecondKey, s.RateLimit.RequestsPerSecond, 0),
+			Burst:             p.int(get, prefix+RateLimitBurstKey, s.RateLimit.Burst, 0),
+		}
+		if rateLimit.RequestsPerSecond <= 0 {
+			p.errs = append(p.errs, fmt.Errorf("%s must be greater than 0 when the rate limit is overridden", prefix+RateLimitRequestsPerSecondKey))
+		}
+		storageSystem.RateLimit = &rateLimit
+	}
+	return storageSystem
+}
+
+// StorageSystem returns the settings of a storage system, which are the shared settings unless it has overrides
+func (s *Settings) StorageSystem(id string) StorageSystemSettings {
+	if storageSystem, ok := s.StorageSystems[strings.ToLower(id)]; ok {
+		return storageSystem
+	}
+	return StorageSystemSettings{
+		SDCMetricsEnabled:         s.SDCMetricsEnabled,
+		VolumeMetricsEnabled:      s.VolumeMetricsEnabled,
+		StoragePoolMetricsEnabled: s.StoragePoolMetricsEnabled,
+		SDCPollFrequency:          s.SDCPollFrequency,
+		VolumePollFrequency:       s.VolumePollFrequency,
+		StoragePoolPollFrequency:  s.StoragePoolPollFrequency,
+		MaxConcurrentQueries:      s.MaxConcurrentQueries,
+	}
+}
+
+// validate checks the rules that involve more than one key
+func (s *Settings) validate() []error {
+	var errs []error
+	if s.LeaseDuration <= s.RenewDeadline {
+		errs = append(errs, fmt.Errorf("%s must be greater than %s", LeaseDurationKey, RenewDeadlineKey))
+	}
+	if s.RenewDeadline <= s.RetryPeriod {
+		errs = append(errs, fmt.Errorf("%s must be greater than %s", RenewDeadlineKey, RetryPeriodKey))
+	}
+	if s.ShardingEnabled && !s.LeaderElectionEnabled {
+		errs = append(errs, fmt.Errorf("%s requires %s to be true", ShardingEnabledKey, LeaderElectionEnabledKey))
+	}
+	if s.VolumeAggregation.AggregatesOnly && !s.VolumeAggregation.Enabled() {
+		errs = append(errs, fmt.Errorf("%s requires %s to be set", VolumeAggregatesOnlyKey, VolumeAggregateByKey))
+	}
+
+	switch s.CollectionMode {
+	case CollectionModeCluster:
+	case CollectionModeNode:
+		if s.NodeName == "" {
+			errs = append(errs, fmt.Errorf("%s must be set when %s is %s", NodeNameKey, CollectionModeKey, CollectionModeNode))
+		}
+		if s.ShardingEnabled {
+			errs = append(errs, fmt.Errorf("%s cannot be used when %s is %s", ShardingEnabledKey, CollectionModeKey, CollectionModeNode))
+		}
+	default:
+		errs = append(errs, fmt.Errorf("%s value %q is invalid, valid values are %s or %s", CollectionModeKey, s.CollectionMode, CollectionModeCluster, CollectionModeNode))
+	}
+
+	switch s.CredentialsSource {
+	case CredentialsSourceFile:
+	case CredentialsSourceSecret:
+		if s.CredentialsSecretNamespace == "" {
+			errs = append(errs, fmt.Errorf("%s must be set when %s is %s", CredentialsSecretNamespaceKey, CredentialsSourceKey, CredentialsSourceSecret))
+		}
+	default:
+		errs = append(errs, fmt.Errorf("%s value %q is invalid, valid values are %s or %s", CredentialsSourceKey, s.CredentialsSource, CredentialsSourceFile, CredentialsSourceSecret))
+	}
+	return errs
+}
+
+// CredentialsFromSecret returns true if the storage systems are read through the kubernetes API instead of the mounted file
+func (s *Settings) CredentialsFromSecret() bool {
+	return s.CredentialsSource == CredentialsSourceSecret
+}
+
+// NodeLocal returns true if the service only collects the SDC of the node it runs on
+func (s *Settings) NodeLocal() bool {
+	return s.CollectionMode == CollectionModeNode
+}
+
+// Values returns the effective value of every key, in the format it is configured in
+func (s *Settings) Values() (file map[string]string, env map[string]string) {
+	file = map[string]string{
+		CollectorAddressKey:               s.CollectorAddress,
+		ProvisionerNamesKey:               strings.Join(s.ProvisionerNames, ","),
+		LogLevelKey:                       s.LogLevel,
+		LogFormatKey:                      s.LogFormat,
+		SDCMetricsEnabledKey:              strconv.FormatBool(s.SDCMetricsEnabled),
+		VolumeMetricsEnabledKey:           strconv.FormatBool(s.VolumeMetricsEnabled),
+		StoragePoolMetricsEnabledKey:      strconv.FormatBool(s.StoragePoolMetricsEnabled),
+		TopologyMetricsEnabledKey:         strconv.FormatBool(s.TopologyMetricsEnabled),
+		SDCPollFrequencyKey:               formatSeconds(s.SDCPollFrequency),
+		VolumePollFrequencyKey:            formatSeconds(s.VolumePollFrequency),
+		StoragePoolPollFrequencyKey:       formatSeconds(s.StoragePoolPollFrequency),
+		TopologyMetricsPollFrequencyKey:   formatSeconds(s.TopologyMetricsPollFrequency),
+		MaxConcurrentQueriesKey:           strconv.Itoa(s.MaxConcurrentQueries),
+		MetricsBatchSizeKey:               strconv.Itoa(s.MetricsBatchSize),
+		MetricCatalogFileKey:              s.MetricCatalogFile,
+		InventoryRefreshIntervalKey:       formatSeconds(s.InventoryRefreshInterval),
+		CircuitBreakerFailureThresholdKey: strconv.Itoa(s.CircuitBreakerFailureThreshold),
+		RateLimitRequestsPerSecondKey:     strconv.FormatFloat(s.RateLimit.RequestsPerSecond, 'f', -1, 64),
+		RateLimitBurstKey:                 strconv.Itoa(s.RateLimit.Burst),
+		LeaderElectionEnabledKey:          strconv.FormatBool(s.LeaderElectionEnabled),
+		LeaseDurationKey:                  formatSeconds(s.LeaseDuration),
+		RenewDeadlineKey:                  formatSeconds(s.RenewDeadline),
+		RetryPeriodKey:                    formatSeconds(s.RetryPeriod),
+		ShardingEnabledKey:                strconv.FormatBool(s.ShardingEnabled),
+
+		VolumeIncludeNamespacesKey:        strings.Join(s.VolumeFilter.IncludeNamespaces, ","),
+		VolumeExcludeNamespacesKey:        strings.Join(s.VolumeFilter.ExcludeNamespaces, ","),
+		VolumeIncludeNamespaceSelectorKey: formatSelector(s.VolumeFilter.IncludeNamespaceSelector),
+		VolumeExcludeNamespaceSelectorKey: formatSelector(s.VolumeFilter.ExcludeNamespaceSelector),
+		VolumeIncludeStorageClassesKey:    strings.Join(s.VolumeFilter.IncludeStorageClasses, ","),
+		VolumeExcludeStorageClassesKey:    strings.Join(s.VolumeFilter.ExcludeStorageClasses, ","),
+		PersistentVolumeSelectorKey:       formatSelector(s.VolumeFilter.PersistentVolumeSelector),
+		PersistentVolumeClaimSelectorKey:  formatSelector(s.VolumeFilter.PersistentVolumeClaimSelector),
+
+		PersistentVolumeClaimLabelsKey: strings.Join(s.LabelKeys.PersistentVolumeClaim, ","),
+		PersistentVolumeLabelsKey:      strings.Join(s.LabelKeys.PersistentVolume, ","),
+		NamespaceLabelsKey:             strings.Join(s.LabelKeys.Namespace, ","),
+		StorageClassLabelsKey:          strings.Join(s.LabelKeys.StorageClass, ","),
+		NodeLabelsKey:                  strings.Join(s.LabelKeys.Node, ","),
+		MaxLabelValuesKey:              strconv.Itoa(s.MaxLabelValues),
+		VolumeConsumersEnabledKey:      strconv.FormatBool(s.VolumeConsumersEnabled),
+		VolumeAggregateByKey:           formatAggregation(s.VolumeAggregation),
+		VolumeAggregatesOnlyKey:        strconv.FormatBool(s.VolumeAggregation.AggregatesOnly),
+		NodeConditionsEnabledKey:       strconv.FormatBool(s.NodeConditionsEnabled),
+	}
+	for id, storageSystem := range s.StorageSystems {
+		prefix := StorageSystemOverridesKey + "." + id + "."
+		file[prefix+SDCMetricsEnabledKey] = strconv.FormatBool(storageSystem.SDCMetricsEnabled)
+		file[prefix+VolumeMetricsEnabledKey] = strconv.FormatBool(storageSystem.VolumeMetricsEnabled)
+		file[prefix+StoragePoolMetricsEnabledKey] = strconv.FormatBool(storageSystem.StoragePoolMetricsEnabled)
+		file[prefix+SDCPollFrequencyKey] = formatSeconds(storageSystem.SDCPollFrequency)
+		file[prefix+VolumePollFrequencyKey] = formatSeconds(storageSystem.VolumePollFrequency)
+		file[prefix+StoragePoolPollFrequencyKey] = formatSeconds(storageSystem.StoragePoolPollFrequency)
+		file[prefix+MaxConcurrentQueriesKey] = strconv.Itoa(storageSystem.MaxConcurrentQueries)
+		if storageSystem.RateLimit != nil {
+			file[prefix+RateLimitRequestsPerSecondKey] = strconv.FormatFloat(storageSystem.RateLimit.RequestsPerSecond, 'f', -1, 64)
+			file[prefix+RateLimitBurstKey] = strconv.Itoa(storageSystem.RateLimit.Burst)
+		}
+	}
+	env = map[string]string{
+		TLSEnabledKey:         strconv.FormatBool(s.TLSEnabled),
+		CollectorCertPathKey:  s.CollectorCertPath,
+		MetricsEndpointKey:    s.MetricsEndpoint,
+		MetricsNamespaceKey:   s.MetricsNamespace,
+		CollectionModeKey:     s.CollectionMode,
+		NodeNameKey:           s.NodeName,
+		SDCGUIDKey:            s.SDCGUID,
+		HealthProbeAddressKey: s.HealthProbeAddress,
+
+		CredentialsSourceKey:          s.CredentialsSource,
+		CredentialsSecretNamespaceKey: s.CredentialsSecretNamespace,
+		CredentialsSecretNameKey:      s.CredentialsSecretName,
+		CredentialsSecretKeyKey:       s.CredentialsSecretKey,
+	}
+	return file, env
+}
+
+func formatSeconds(d time.Duration) string {
+	return strconv.FormatInt(int64(d/time.Second), 10)
+}
+
+func formatAggregation(aggregation service.VolumeAggregation) string {
+	var values []string
+	if aggregation.ByNamespace {
+		values = append(values, AggregateByNamespace)
+	}
+	if aggregation.ByStorageClass {
+		values = append(values, AggregateByStorageClass)
+	}
+	return strings.Join(values, ",")
+}
+
+func formatSelector(selector labels.Selector) string {
+	if selector == nil {
+		return ""
+	}
+	return selector.String()
+}
+
+// parser converts raw values and collects every error instead of stopping at the first one
+type parser struct {
+	errs []error
+}
+
+func (p *parser) required(get Getter, key string) string {
+	value := strings.TrimSpace(get(key))
+	if value == "" {
+		p.errs = append(p.errs, fmt.Errorf("%s is required", key))
+	}
+	return value
+}
+
+func (p *parser) string(get Getter, key string, defaultValue string) string {
+	if value := strings.TrimSpace(get(key)); value != "" {
+		return value
+	}
+	return defaultValue
+}
+
+// list reads comma separated values, ignoring empty ones
+func (p *parser) list(get Getter, key string) []string {
+	var values []string
+	for _, value := range strings.Split(get(key), ",") {
+		if value = strings.TrimSpace(value); value != "" {
+			values = append(values, value)
+		}
+	}
+	return values
+}
+
+// labelKeys reads comma separated label keys, e.g. "team,app.kubernetes.io/name"
+func (p *parser) labelKeys(get Getter, key string) []string {
+	keys := p.list(get, key)
+	for _, labelKey := range keys {
+		if problems := validation.IsQualifiedName(labelKey); len(problems) > 0 {
+			p.errs = append(p.errs, fmt.Errorf("%s value %q is not a valid label key: %s", key, labelKey, strings.Join(problems, "; ")))
+		}
+	}
+	return keys
+}
+
+// selector reads a label selector, e.g. "team=storage,tier!=test". It returns nil if the key isn't set.
+func (p *parser) selector(get Getter, key string) labels.Selector {
+	value := strings.TrimSpace(get(key))
+	if value == "" {
+		return nil
+	}
+	selector, err := labels.Parse(value)
+	if err != nil {
+		p.errs = append(p.errs, fmt.Errorf("%s value %q is not a valid label selector: %w", key, value, err))
+		return nil
+	}
+	return selector
+}
+
+func (p *parser) bool(get Getter, key string, defaultValue bool) bool {
+	switch value := strings.TrimSpace(get(key)); value {
+	case "":
+		return defaultValue
+	case "true":
+		return true
+	case "false":
+		return false
+	default:
+		p.errs = append(p.errs, fmt.Errorf("%s value %q is invalid, valid values are true or false", key, value))
+		return defaultValue
+	}
+}
+
+func (p *parser) int(get Getter, key string, defaultValue int, minimum int) int {
+	value := strings.TrimSpace(get(key))
+	if value == "" {
+		return defaultValue
+	}
+	n, err := strconv.Atoi(value)
+	if err != nil {
+		p.errs = append(p.errs, fmt.Errorf("%s value %q is not a valid number", key, value))
+		return defaultValue
+	}
+	if n < minimum {
+		p.errs = append(p.errs, fmt.Errorf("%s value %d is invalid (< %d)", key, n, minimum))
+		return defaultValue
+	}
+	return n
+}
+
+func (p *parser) float(get Getter, key string, defaultValue float64, minimum float64) float64 {
+	value := strings.TrimSpace(get(key))
+	if value == "" {
+		return defaultValue
+	}
+	f, err := strconv.ParseFloat(value, 64)
+	if err != nil {
+		p.errs = append(p.errs, fmt.Errorf("%s value %q is not a valid number", key, value))
+		return defaultValue
+	}
+	if f < minimum {
+		p.errs = append(p.errs, fmt.Errorf("%s value %v is invalid (< %v)", key, f, minimum))
+		return defaultValue
+	}
+	return f
+}
+
+// seconds reads a whole number of seconds. A maximum of zero means there is no upper bound.
+func (p *parser) seconds(get Getter, key string, defaultValue time.Duration, minimum time.Duration, maximum time.Duration) time.Duration {
+	value := strings.TrimSpace(get(key))
+	if value == "" {
+		return defaultValue
+	}
+	n, err := strconv.Atoi(value)
+	if err != nil {
+		p.errs = append(p.errs, fmt.Errorf("%s value %q is not a valid number of seconds", key, value))
+		return defaultValue
+	}
+	d := time.Duration(n) * time.Second
+	if d < minimum || (maximum > 0 && d > maximum) {
+		if maximum > 0 {
+			p.errs = append(p.errs, fmt.Errorf("%s value %v is not within the allowed range of %v and %v", key, d, minimum, maximum))
+		} else {
+			p.errs = append(p.errs, fmt.Errorf("%s value %v is invalid (< %v)", key, d, minimum))
+		}
+		return defaultValue
+	}
+	return d
+}
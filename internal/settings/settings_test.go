@@ -0,0 +1,507 @@
+/*
+ Copyright (c) 2025 Dell Inc. or its subsidiaries. All Rights Reserved.
+
+ Licensed under the Apache License, Version 2.0 (the "License");
+ you may not use this file except in compliance with the License.
+ You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package settings_test
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/dell/karavi-metrics-powerflex/internal/domain"
+	"github.com/dell/karavi-metrics-powerflex/internal/service"
+	"github.com/dell/karavi-metrics-powerflex/internal/settings"
+	otlexporters "github.com/dell/karavi-metrics-powerflex/opentelemetry/exporters"
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"k8s.io/apimachinery/pkg/labels"
+)
+
+func getter(values map[string]string) settings.Getter {
+	return func(key string) string {
+		return values[key]
+	}
+}
+
+func configFile(values map[string]string) *viper.Viper {
+	v := viper.New()
+	for key, value := range values {
+		v.Set(key, value)
+	}
+	return v
+}
+
+func requiredValues(values map[string]string) map[string]string {
+	merged := map[string]string{
+		settings.CollectorAddressKey: "otel-collector:55680",
+		settings.ProvisionerNamesKey: "csi-vxflexos.dellemc.com",
+	}
+	for key, value := range values {
+		merged[key] = value
+	}
+	return merged
+}
+
+func Test_Load_Defaults(t *testing.T) {
+	s, err := settings.Load(configFile(requiredValues(nil)), getter(map[string]string{settings.MetricsNamespaceKey: "powerflex"}))
+	require.NoError(t, err)
+
+	expected := settings.Defaults()
+	expected.CollectorAddress = "otel-collector:55680"
+	expected.ProvisionerNames = []string{"csi-vxflexos.dellemc.com"}
+	expected.MetricsNamespace = "powerflex"
+	assert.Equal(t, expected, s)
+	assert.False(t, s.NodeLocal())
+}
+
+func Test_Load(t *testing.T) {
+	tests := map[string]struct {
+		file     map[string]string
+		env      map[string]string
+		validate func(t *testing.T, s *settings.Settings)
+	}{
+		"metrics and poll frequencies": {
+			file: map[string]string{
+				settings.SDCMetricsEnabledKey:            "false",
+				settings.TopologyMetricsEnabledKey:       "false",
+				settings.VolumePollFrequencyKey:          "30",
+				settings.StoragePoolPollFrequencyKey:     "600",
+				settings.TopologyMetricsPollFrequencyKey: "5",
+				settings.ProvisionerNamesKey:             "csi-vxflexos.dellemc.com,csi-powerflex.dellemc.com",
+			},
+			validate: func(t *testing.T, s *settings.Settings) {
+				assert.False(t, s.SDCMetricsEnabled)
+				assert.True(t, s.VolumeMetricsEnabled)
+				assert.False(t, s.TopologyMetricsEnabled)
+				assert.Equal(t, settings.DefaultPollFrequency, s.SDCPollFrequency)
+				assert.Equal(t, 30*time.Second, s.VolumePollFrequency)
+				assert.Equal(t, 10*time.Minute, s.StoragePoolPollFrequency)
+				assert.Equal(t, []string{"csi-vxflexos.dellemc.com", "csi-powerflex.dellemc.com"}, s.ProvisionerNames)
+			},
+		},
+		"service tuning": {
+			file: map[string]string{
+				settings.MaxConcurrentQueriesKey:           "10",
+				settings.MetricsBatchSizeKey:               "25",
+				settings.InventoryRefreshIntervalKey:       "0",
+				settings.CircuitBreakerFailureThresholdKey: "3",
+				settings.RateLimitRequestsPerSecondKey:     "2.5",
+				settings.RateLimitBurstKey:                 "5",
+			},
+			validate: func(t *testing.T, s *settings.Settings) {
+				assert.Equal(t, 10, s.MaxConcurrentQueries)
+				assert.Equal(t, 25, s.MetricsBatchSize)
+				assert.Equal(t, time.Duration(0), s.InventoryRefreshInterval)
+				assert.Equal(t, 3, s.CircuitBreakerFailureThreshold)
+				assert.Equal(t, domain.RateLimit{RequestsPerSecond: 2.5, Burst: 5}, s.RateLimit)
+			},
+		},
+		"metric catalog": {
+			file: map[string]string{settings.MetricCatalogFileKey: "../service/testdata/metric-catalog.yaml"},
+			validate: func(t *testing.T, s *settings.Settings) {
+				require.NotNil(t, s.MetricCatalog)
+				assert.Len(t, s.MetricCatalog.Metrics, 23)
+			},
+		},
+		"leader election and sharding": {
+			file: map[string]string{
+				settings.LeaseDurationKey:   "60",
+				settings.RenewDeadlineKey:   "30",
+				settings.RetryPeriodKey:     "5",
+				settings.ShardingEnabledKey: "true",
+			},
+			validate: func(t *testing.T, s *settings.Settings) {
+				assert.True(t, s.LeaderElectionEnabled)
+				assert.True(t, s.ShardingEnabled)
+				assert.Equal(t, time.Minute, s.LeaseDuration)
+				assert.Equal(t, 30*time.Second, s.RenewDeadline)
+				assert.Equal(t, 5*time.Second, s.RetryPeriod)
+			},
+		},
+		"volume filter": {
+			file: map[string]string{
+				settings.VolumeIncludeNamespacesKey:        "team-a, team-b,",
+				settings.VolumeExcludeNamespaceSelectorKey: "tier=test",
+				settings.VolumeExcludeStorageClassesKey:    "scratch",
+				settings.PersistentVolumeClaimSelectorKey:  "backup notin (none)",
+			},
+			validate: func(t *testing.T, s *settings.Settings) {
+				filter := s.VolumeFilter
+				assert.Equal(t, []string{"team-a", "team-b"}, filter.IncludeNamespaces)
+				assert.Nil(t, filter.ExcludeNamespaces)
+				assert.Nil(t, filter.IncludeNamespaceSelector)
+				assert.Equal(t, "tier=test", filter.ExcludeNamespaceSelector.String())
+				assert.Equal(t, []string{"scratch"}, filter.ExcludeStorageClasses)
+				assert.Nil(t, filter.PersistentVolumeSelector)
+				assert.Equal(t, "backup notin (none)", filter.PersistentVolumeClaimSelector.String())
+				assert.False(t, filter.Empty())
+			},
+		},
+		"label allowlist": {
+			file: map[string]string{
+				settings.PersistentVolumeClaimLabelsKey: "team,cost-center",
+				settings.NamespaceLabelsKey:             "app.kubernetes.io/part-of",
+				settings.MaxLabelValuesKey:              "20",
+				settings.NodeLabelsKey:                  "topology.kubernetes.io/zone,rack",
+			},
+			validate: func(t *testing.T, s *settings.Settings) {
+				assert.False(t, s.VolumeConsumersEnabled)
+				assert.Equal(t, []string{"team", "cost-center"}, s.LabelKeys.PersistentVolumeClaim)
+				assert.Nil(t, s.LabelKeys.PersistentVolume)
+				assert.Equal(t, []string{"app.kubernetes.io/part-of"}, s.LabelKeys.Namespace)
+				assert.Equal(t, 20, s.MaxLabelValues)
+				assert.Equal(t, []string{"topology.kubernetes.io/zone", "rack"}, s.LabelKeys.Node)
+				assert.False(t, s.NodeConditionsEnabled)
+			},
+		},
+		"volume consumers": {
+			file: map[string]string{settings.VolumeConsumersEnabledKey: "true"},
+			validate: func(t *testing.T, s *settings.Settings) {
+				assert.True(t, s.VolumeConsumersEnabled)
+			},
+		},
+		"volume aggregation": {
+			file: map[string]string{
+				settings.VolumeAggregateByKey:    "namespace, storage_class",
+				settings.VolumeAggregatesOnlyKey: "true",
+			},
+			validate: func(t *testing.T, s *settings.Settings) {
+				assert.Equal(t, service.VolumeAggregation{ByNamespace: true, ByStorageClass: true, AggregatesOnly: true}, s.VolumeAggregation)
+			},
+		},
+		"tls enabled with a cert path": {
+			env: map[string]string{settings.TLSEnabledKey: "true", settings.CollectorCertPathKey: "/path/to/cert"},
+			validate: func(t *testing.T, s *settings.Settings) {
+				assert.True(t, s.TLSEnabled)
+				assert.Equal(t, "/path/to/cert", s.CollectorCertPath)
+			},
+		},
+		"tls enabled without a cert path": {
+			env: map[string]string{settings.TLSEnabledKey: "true"},
+			validate: func(t *testing.T, s *settings.Settings) {
+				assert.Equal(t, otlexporters.DefaultCollectorCertPath, s.CollectorCertPath)
+			},
+		},
+		"tls disabled ignores the cert path": {
+			env: map[string]string{settings.TLSEnabledKey: "false", settings.CollectorCertPathKey: "/path/to/cert"},
+			validate: func(t *testing.T, s *settings.Settings) {
+				assert.False(t, s.TLSEnabled)
+				assert.Equal(t, otlexporters.DefaultCollectorCertPath, s.CollectorCertPath)
+			},
+		},
+		"credentials from the secret API": {
+			env: map[string]string{
+				settings.CredentialsSourceKey:          "secret",
+				settings.CredentialsSecretNamespaceKey: "vxflexos",
+				settings.CredentialsSecretKeyKey:       "config.yaml",
+			},
+			validate: func(t *testing.T, s *settings.Settings) {
+				assert.True(t, s.CredentialsFromSecret())
+				assert.Equal(t, "vxflexos", s.CredentialsSecretNamespace)
+				assert.Equal(t, settings.DefaultCredentialsSecretName, s.CredentialsSecretName)
+				assert.Equal(t, "config.yaml", s.CredentialsSecretKey)
+			},
+		},
+		"node collection mode": {
+			env: map[string]string{
+				settings.CollectionModeKey:     "node",
+				settings.NodeNameKey:           "worker-1",
+				settings.SDCGUIDKey:            "guid-1",
+				settings.MetricsEndpointKey:    "custom-endpoint",
+				settings.MetricsNamespaceKey:   "custom-namespace",
+				settings.HealthProbeAddressKey: ":9090",
+			},
+			validate: func(t *testing.T, s *settings.Settings) {
+				assert.True(t, s.NodeLocal())
+				assert.Equal(t, ":9090", s.HealthProbeAddress)
+				assert.Equal(t, "worker-1", s.NodeName)
+				assert.Equal(t, "guid-1", s.SDCGUID)
+				assert.Equal(t, "custom-endpoint", s.MetricsEndpoint)
+				assert.Equal(t, "custom-namespace", s.MetricsNamespace)
+			},
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			s, err := settings.Load(configFile(requiredValues(tc.file)), getter(tc.env))
+			require.NoError(t, err)
+			tc.validate(t, s)
+		})
+	}
+}
+
+func Test_Load_Errors(t *testing.T) {
+	tests := map[string]struct {
+		file     map[string]string
+		env      map[string]string
+		problems []string
+	}{
+		"missing required keys": {
+			file:     map[string]string{settings.CollectorAddressKey: "", settings.ProvisionerNamesKey: " "},
+			problems: []string{"COLLECTOR_ADDR is required", "provisioner_names is required"},
+		},
+		"invalid booleans": {
+			file: map[string]string{
+				settings.SDCMetricsEnabledKey:      "yes",
+				settings.TopologyMetricsEnabledKey: "test",
+				settings.LeaderElectionEnabledKey:  "maybe",
+			},
+			problems: []string{
+				`POWERFLEX_SDC_METRICS_ENABLED value "yes" is invalid`,
+				`POWERFLEX_TOPOLOGY_METRICS_ENABLED value "test" is invalid`,
+				`POWERFLEX_LEADER_ELECTION_ENABLED value "maybe" is invalid`,
+			},
+		},
+		"poll frequencies out of range": {
+			file: map[string]string{
+				settings.SDCPollFrequencyKey:             "invalid",
+				settings.VolumePollFrequencyKey:          "-1",
+				settings.StoragePoolPollFrequencyKey:     "601",
+				settings.TopologyMetricsPollFrequencyKey: "4",
+			},
+			problems: []string{
+				`POWERFLEX_SDC_IO_POLL_FREQUENCY value "invalid" is not a valid number of seconds`,
+				"POWERFLEX_VOLUME_IO_POLL_FREQUENCY value -1s is not within the allowed range of 5s and 10m0s",
+				"POWERFLEX_STORAGE_POOL_POLL_FREQUENCY value 10m1s is not within the allowed range of 5s and 10m0s",
+				"POWERFLEX_TOPOLOGY_METRICS_POLL_FREQUENCY value 4s is not within the allowed range of 5s and 10m0s",
+			},
+		},
+		"invalid service tuning": {
+			file: map[string]string{
+				settings.MaxConcurrentQueriesKey:           "0",
+				settings.MetricsBatchSizeKey:               "0",
+				settings.InventoryRefreshIntervalKey:       "-1",
+				settings.CircuitBreakerFailureThresholdKey: "three",
+				settings.RateLimitRequestsPerSecondKey:     "-1",
+				settings.RateLimitBurstKey:                 "big",
+			},
+			problems: []string{
+				"POWERFLEX_MAX_CONCURRENT_QUERIES value 0 is invalid (< 1)",
+				"POWERFLEX_METRICS_BATCH_SIZE value 0 is invalid (< 1)",
+				"POWERFLEX_INVENTORY_REFRESH_INTERVAL value -1s is invalid (< 0s)",
+				`POWERFLEX_CIRCUIT_BREAKER_FAILURE_THRESHOLD value "three" is not a valid number`,
+				"POWERFLEX_RATE_LIMIT_REQUESTS_PER_SECOND value -1 is invalid (< 0)",
+				`POWERFLEX_RATE_LIMIT_BURST value "big" is not a valid number`,
+			},
+		},
+		"invalid metric catalog": {
+			file: map[string]string{settings.MetricCatalogFileKey: "../service/testdata/metric-catalog-invalid.yaml"},
+			problems: []string{
+				`POWERFLEX_METRIC_CATALOG_FILE value "../service/testdata/metric-catalog-invalid.yaml" is invalid: invalid metric catalog entry 0 in ../service/testdata/metric-catalog-invalid.yaml: instrument read_bw_megabytes_per_second of the sdc series can't have attributes`,
+			},
+		},
+		"leader election timings": {
+			file: map[string]string{
+				settings.LeaseDurationKey: "10",
+				settings.RenewDeadlineKey: "10",
+				settings.RetryPeriodKey:   "0",
+			},
+			problems: []string{
+				"POWERFLEX_LEADER_ELECTION_RETRY_PERIOD value 0s is invalid (< 1s)",
+				"POWERFLEX_LEADER_ELECTION_LEASE_DURATION must be greater than POWERFLEX_LEADER_ELECTION_RENEW_DEADLINE",
+			},
+		},
+		"sharding without leader election": {
+			file: map[string]string{settings.ShardingEnabledKey: "true", settings.LeaderElectionEnabledKey: "false"},
+			problems: []string{
+				"POWERFLEX_SHARDING_ENABLED requires POWERFLEX_LEADER_ELECTION_ENABLED to be true",
+			},
+		},
+		"node collection mode": {
+			file: map[string]string{settings.ShardingEnabledKey: "true"},
+			env:  map[string]string{settings.CollectionModeKey: "node"},
+			problems: []string{
+				"NODE_NAME must be set when POWERFLEX_COLLECTION_MODE is node",
+				"POWERFLEX_SHARDING_ENABLED cannot be used when POWERFLEX_COLLECTION_MODE is node",
+			},
+		},
+		"invalid collection mode": {
+			env:      map[string]string{settings.CollectionModeKey: "daemonset"},
+			problems: []string{`POWERFLEX_COLLECTION_MODE value "daemonset" is invalid`},
+		},
+		"invalid label selectors": {
+			file: map[string]string{
+				settings.VolumeIncludeNamespaceSelectorKey: "team in (a",
+				settings.PersistentVolumeSelectorKey:       "=value",
+			},
+			problems: []string{
+				`POWERFLEX_VOLUME_INCLUDE_NAMESPACE_SELECTOR value "team in (a" is not a valid label selector`,
+				`POWERFLEX_VOLUME_PV_SELECTOR value "=value" is not a valid label selector`,
+			},
+		},
+		"invalid label allowlist": {
+			file: map[string]string{
+				settings.StorageClassLabelsKey: "team,cost center",
+				settings.MaxLabelValuesKey:     "0",
+			},
+			problems: []string{
+				`POWERFLEX_STORAGE_CLASS_LABELS value "cost center" is not a valid label key`,
+				"POWERFLEX_MAX_LABEL_VALUES value 0 is invalid (< 1)",
+			},
+		},
+		"invalid volume aggregation": {
+			file: map[string]string{
+				settings.VolumeAggregateByKey: "namespace,node",
+			},
+			problems: []string{`POWERFLEX_VOLUME_AGGREGATE_BY value "node" is invalid, valid values are namespace or storage_class`},
+		},
+		"aggregates only without aggregates": {
+			file: map[string]string{
+				settings.VolumeAggregatesOnlyKey: "true",
+			},
+			problems: []string{"POWERFLEX_VOLUME_AGGREGATES_ONLY requires POWERFLEX_VOLUME_AGGREGATE_BY to be set"},
+		},
+		"invalid credentials source": {
+			env:      map[string]string{settings.CredentialsSourceKey: "vault"},
+			problems: []string{`POWERFLEX_CREDENTIALS_SOURCE value "vault" is invalid, valid values are file or secret`},
+		},
+		"credentials secret without a namespace": {
+			env:      map[string]string{settings.CredentialsSourceKey: "secret"},
+			problems: []string{"POWERFLEX_CREDENTIALS_SECRET_NAMESPACE must be set when POWERFLEX_CREDENTIALS_SOURCE is secret"},
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			s, err := settings.Load(configFile(requiredValues(tc.file)), getter(tc.env))
+			require.Error(t, err)
+			assert.NotNil(t, s)
+			for _, problem := range tc.problems {
+				assert.ErrorContains(t, err, problem)
+			}
+		})
+	}
+}
+
+func Test_Values(t *testing.T) {
+	s := settings.Defaults()
+	s.CollectorAddress = "otel-collector:55680"
+	s.ProvisionerNames = []string{"csi-vxflexos.dellemc.com", "csi-powerflex.dellemc.com"}
+	s.RateLimit = domain.RateLimit{RequestsPerSecond: 2.5, Burst: 5}
+	s.NodeName = "worker-1"
+	s.MetricsNamespace = "powerflex"
+	s.StorageSystems["lab"] = settings.StorageSystemSettings{
+		SDCMetricsEnabled:         true,
+		VolumeMetricsEnabled:      false,
+		StoragePoolMetricsEnabled: true,
+		SDCPollFrequency:          5 * time.Minute,
+		VolumePollFrequency:       settings.DefaultPollFrequency,
+		StoragePoolPollFrequency:  settings.DefaultPollFrequency,
+		MaxConcurrentQueries:      2,
+		RateLimit:                 &domain.RateLimit{RequestsPerSecond: 1, Burst: 2},
+	}
+	s.VolumeFilter.IncludeNamespaces = []string{"team-a", "team-b"}
+	s.VolumeFilter.ExcludeStorageClasses = []string{"scratch"}
+	s.VolumeFilter.PersistentVolumeSelector, _ = labels.Parse("app=database,tier!=test")
+	s.LabelKeys.PersistentVolumeClaim = []string{"team", "app"}
+	s.MaxLabelValues = 50
+	s.MetricsBatchSize = 25
+	s.MetricCatalogFile = "../service/testdata/metric-catalog.yaml"
+	s.MetricCatalog, _ = service.LoadMetricCatalog(s.MetricCatalogFile)
+	s.VolumeConsumersEnabled = true
+	s.LabelKeys.Node = []string{"topology.kubernetes.io/zone"}
+	s.NodeConditionsEnabled = true
+	s.VolumeAggregation = service.VolumeAggregation{ByStorageClass: true, AggregatesOnly: true}
+
+	file, env := s.Values()
+	assert.Equal(t, "otel-collector:55680", file[settings.CollectorAddressKey])
+	assert.Equal(t, "csi-vxflexos.dellemc.com,csi-powerflex.dellemc.com", file[settings.ProvisionerNamesKey])
+	assert.Equal(t, "true", file[settings.TopologyMetricsEnabledKey])
+	assert.Equal(t, "5", file[settings.SDCPollFrequencyKey])
+	assert.Equal(t, "2.5", file[settings.RateLimitRequestsPerSecondKey])
+	assert.Equal(t, "15", file[settings.LeaseDurationKey])
+	assert.Equal(t, "worker-1", env[settings.NodeNameKey])
+	assert.Equal(t, "cluster", env[settings.CollectionModeKey])
+	assert.Equal(t, "300", file[settings.StorageSystemOverridesKey+".lab."+settings.SDCPollFrequencyKey])
+	assert.Equal(t, "team-a,team-b", file[settings.VolumeIncludeNamespacesKey])
+	assert.Equal(t, "app=database,tier!=test", file[settings.PersistentVolumeSelectorKey])
+
+	// every value read by Load can be printed
+	loaded, err := settings.Load(configFile(file), getter(env))
+	require.NoError(t, err)
+	assert.Equal(t, s, loaded)
+}
+
+func yamlFile(t *testing.T, content string) *viper.Viper {
+	v := viper.New()
+	v.SetConfigType("yaml")
+	require.NoError(t, v.ReadConfig(strings.NewReader(content)))
+	return v
+}
+
+func Test_Load_StorageSystemOverrides(t *testing.T) {
+	s, err := settings.Load(yamlFile(t, `
+COLLECTOR_ADDR: otel-collector:55680
+provisioner_names: csi-vxflexos.dellemc.com
+POWERFLEX_VOLUME_IO_POLL_FREQUENCY: 30
+storage_system_overrides:
+  Production:
+    POWERFLEX_VOLUME_IO_POLL_FREQUENCY: 10
+  lab:
+    POWERFLEX_SDC_IO_POLL_FREQUENCY: 300
+    POWERFLEX_VOLUME_METRICS_ENABLED: false
+    POWERFLEX_MAX_CONCURRENT_QUERIES: 2
+    POWERFLEX_RATE_LIMIT_REQUESTS_PER_SECOND: 1.5
+`), getter(nil))
+	require.NoError(t, err)
+
+	production := s.StorageSystem("PRODUCTION")
+	assert.Equal(t, 10*time.Second, production.VolumePollFrequency)
+	assert.Equal(t, settings.DefaultPollFrequency, production.SDCPollFrequency)
+	assert.True(t, production.VolumeMetricsEnabled)
+	assert.Equal(t, s.MaxConcurrentQueries, production.MaxConcurrentQueries)
+	assert.Nil(t, production.RateLimit)
+
+	lab := s.StorageSystem("lab")
+	assert.Equal(t, 5*time.Minute, lab.SDCPollFrequency)
+	assert.Equal(t, 30*time.Second, lab.VolumePollFrequency)
+	assert.False(t, lab.VolumeMetricsEnabled)
+	assert.True(t, lab.SDCMetricsEnabled)
+	assert.Equal(t, 2, lab.MaxConcurrentQueries)
+	assert.Equal(t, &domain.RateLimit{RequestsPerSecond: 1.5}, lab.RateLimit)
+
+	// storage systems without overrides use the shared settings
+	other := s.StorageSystem("other")
+	assert.Equal(t, 30*time.Second, other.VolumePollFrequency)
+	assert.Nil(t, other.RateLimit)
+}
+
+func Test_Load_StorageSystemOverrides_Errors(t *testing.T) {
+	_, err := settings.Load(yamlFile(t, `
+COLLECTOR_ADDR: otel-collector:55680
+provisioner_names: csi-vxflexos.dellemc.com
+storage_system_overrides:
+  production:
+    POWERFLEX_TOPOLOGY_METRICS_ENABLED: false
+    POWERFLEX_VOLUME_IO_POLL_FREQUENCY: 1
+    POWERFLEX_SDC_METRICS_ENABLED: maybe
+  lab:
+    POWERFLEX_RATE_LIMIT_BURST: 5
+  test: 10
+`), getter(nil))
+	require.Error(t, err)
+	for _, problem := range []string{
+		"storage_system_overrides.production.powerflex_topology_metrics_enabled cannot be overridden per storage system",
+		"storage_system_overrides.production.POWERFLEX_VOLUME_IO_POLL_FREQUENCY value 1s is not within the allowed range of 5s and 10m0s",
+		`storage_system_overrides.production.POWERFLEX_SDC_METRICS_ENABLED value "maybe" is invalid`,
+		"storage_system_overrides.lab.POWERFLEX_RATE_LIMIT_REQUESTS_PER_SECOND must be greater than 0 when the rate limit is overridden",
+		"storage_system_overrides.test must be a map of keys to values",
+	} {
+		assert.ErrorContains(t, err, problem)
+	}
+}
@@ -0,0 +1,63 @@
+/*
+ Copyright (c) 2025 Dell Inc. or its subsidiaries. All Rights Reserved.
+
+ Licensed under the Apache License, Version 2.0 (the "License");
+ you may not use this file except in compliance with the License.
+ You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+// Package telemetry holds the helpers shared by the components that export their own gauges.
+package telemetry
+
+import (
+	"sync"
+
+	"github.com/sirupsen/logrus"
+	"go.opentelemetry.io/otel/metric"
+)
+
+// Logger returns logger, or the standard logger if it is nil
+func Logger(logger *logrus.Logger) *logrus.Logger {
+	if logger == nil {
+		return logrus.StandardLogger()
+	}
+	return logger
+}
+
+// Gauge creates an observable gauge the first time it is registered. The zero value is ready to use.
+// A gauge that can't be created is logged and left out, since it shouldn't stop the metrics collection.
+type Gauge struct {
+	once sync.Once
+}
+
+// RegisterInt64 creates the int64 gauge name, observed by callback on every collection. It does nothing if meter is nil.
+func (g *Gauge) RegisterInt64(meter metric.Meter, logger *logrus.Logger, name string, callback metric.Int64Callback) {
+	if meter == nil {
+		return
+	}
+	g.once.Do(func() {
+		if _, err := meter.Int64ObservableGauge(name, metric.WithInt64Callback(callback)); err != nil {
+			Logger(logger).WithError(err).WithField("gauge", name).Warn("registering gauge")
+		}
+	})
+}
+
+// RegisterFloat64 creates the float64 gauge name, observed by callback on every collection. It does nothing if meter is nil.
+func (g *Gauge) RegisterFloat64(meter metric.Meter, logger *logrus.Logger, name string, callback metric.Float64Callback) {
+	if meter == nil {
+		return
+	}
+	g.once.Do(func() {
+		if _, err := meter.Float64ObservableGauge(name, metric.WithFloat64Callback(callback)); err != nil {
+			Logger(logger).WithError(err).WithField("gauge", name).Warn("registering gauge")
+		}
+	})
+}
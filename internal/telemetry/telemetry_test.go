@@ -0,0 +1,76 @@
+/*
+ Copyright (c) 2025 Dell Inc. or its subsidiaries. All Rights Reserved.
+
+ Licensed under the Apache License, Version 2.0 (the "License");
+ you may not use this file except in compliance with the License.
+ You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package telemetry_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/dell/karavi-metrics-powerflex/internal/telemetry"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/metric"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata"
+)
+
+func Test_Logger(t *testing.T) {
+	assert.Equal(t, logrus.StandardLogger(), telemetry.Logger(nil))
+	logger := logrus.New()
+	assert.Equal(t, logger, telemetry.Logger(logger))
+}
+
+func Test_Gauge(t *testing.T) {
+	var gauge telemetry.Gauge
+	assert.NotPanics(t, func() {
+		gauge.RegisterInt64(nil, nil, "powerflex_test", func(context.Context, metric.Int64Observer) error { return nil })
+	})
+
+	reader := sdkmetric.NewManualReader()
+	meter := sdkmetric.NewMeterProvider(sdkmetric.WithReader(reader)).Meter("test")
+	calls := 0
+	for i := 0; i < 2; i++ {
+		gauge.RegisterInt64(meter, nil, "powerflex_test", func(_ context.Context, obs metric.Int64Observer) error {
+			calls++
+			obs.Observe(3)
+			return nil
+		})
+	}
+	var ratio telemetry.Gauge
+	ratio.RegisterFloat64(meter, nil, "powerflex_test_ratio", func(_ context.Context, obs metric.Float64Observer) error {
+		obs.Observe(1.5)
+		return nil
+	})
+
+	var rm metricdata.ResourceMetrics
+	require.NoError(t, reader.Collect(context.Background(), &rm))
+	require.Len(t, rm.ScopeMetrics, 1)
+	require.Len(t, rm.ScopeMetrics[0].Metrics, 2)
+	// the gauge is only registered once
+	assert.Equal(t, 1, calls)
+	values := map[string]float64{}
+	for _, m := range rm.ScopeMetrics[0].Metrics {
+		switch data := m.Data.(type) {
+		case metricdata.Gauge[int64]:
+			values[m.Name] = float64(data.DataPoints[0].Value)
+		case metricdata.Gauge[float64]:
+			values[m.Name] = data.DataPoints[0].Value
+		}
+	}
+	assert.Equal(t, map[string]float64{"powerflex_test": 3, "powerflex_test_ratio": 1.5}, values)
+}